@@ -0,0 +1,123 @@
+package benchmarking
+
+import (
+	log "github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"stellar/benchmarking/networking/benchhttp"
+	"stellar/setup"
+	"time"
+)
+
+// ServiceTimeCalibrationCachePath is the file persisting the busy-spin calibrations of the probed targets across runs
+var ServiceTimeCalibrationCachePath = "service-time-calibration.json"
+
+const (
+	// calibrationProbeMilliseconds is the service time the calibration probes aim at, long enough to dwarf the
+	// overhead of the handler and the resolution of the clocks
+	calibrationProbeMilliseconds = 100
+	// calibrationProbes is the number of probes measuring the busy-spin rate of every function
+	calibrationProbes = 3
+	// initialProbeIncrementLimit is the first increment limit probed, doubled until the function spins long enough
+	initialProbeIncrementLimit = int64(1e6)
+	maxProbeIncrementLimit     = int64(1e12)
+	// serviceTimeTolerance is the relative error of the achieved service time beyond which a function is reported
+	serviceTimeTolerance = 0.05
+)
+
+// calibrateServiceTimes runs the calibration probe phase of the sub-experiment before its bursts. In the probe mode, the
+// busy-spin rate of every deployed function is measured, unless the target was already calibrated in the cache. In the
+// wall and CPU modes, every function is checked to spin for a target service time itself.
+func calibrateServiceTimes(experiment *setup.SubExperiment, cache *setup.ServiceTimeCalibrationCache) {
+	switch experiment.ServiceTimeMode {
+	case setup.ProbeServiceTime:
+		target := experiment.CalibrationTarget()
+		if calibration, ok := cache.Get(target); ok {
+			log.Infof("[sub-experiment %d] Using busy-spin rate of %.0f increments/ms calibrated for %s at %v.",
+				experiment.ID, calibration.BusySpinIncrementsPerMillisecond, target, calibration.CalibratedAt.Format(time.RFC3339))
+			for index := range experiment.Endpoints {
+				experiment.Endpoints[index].BusySpinIncrementsPerMillisecond = calibration.BusySpinIncrementsPerMillisecond
+			}
+			return
+		}
+
+		rates := make([]float64, len(experiment.Endpoints))
+		for index := range experiment.Endpoints {
+			rates[index] = probeBusySpinRate(experiment, index)
+			experiment.Endpoints[index].BusySpinIncrementsPerMillisecond = rates[index]
+		}
+		calibration := setup.ServiceTimeCalibration{BusySpinIncrementsPerMillisecond: median(rates), CalibratedAt: time.Now().UTC()}
+		if err := cache.Set(target, calibration); err != nil {
+			log.Errorf("[sub-experiment %d] Could not persist busy-spin calibration of %s: %s", experiment.ID, target, err.Error())
+		}
+	case setup.WallServiceTime, setup.CPUServiceTime:
+		for index := range experiment.Endpoints {
+			probeTargetSideServiceTime(experiment, index)
+		}
+	}
+}
+
+// probeBusySpinRate measures the busy-spin increments per millisecond of the function behind the endpoint, doubling the
+// increment limit until the function spins for about calibrationProbeMilliseconds
+func probeBusySpinRate(experiment *setup.SubExperiment, endpointIndex int) float64 {
+	probeServiceTime(experiment, endpointIndex, 0, benchhttp.ServiceTimeTarget{}) // absorbs the cold start
+
+	incrementLimit := initialProbeIncrementLimit
+	serviceTimeMs := probeServiceTime(experiment, endpointIndex, incrementLimit, benchhttp.ServiceTimeTarget{})
+	for serviceTimeMs < calibrationProbeMilliseconds && incrementLimit < maxProbeIncrementLimit {
+		incrementLimit *= 2
+		serviceTimeMs = probeServiceTime(experiment, endpointIndex, incrementLimit, benchhttp.ServiceTimeTarget{})
+	}
+
+	rates := make([]float64, calibrationProbes)
+	for probe := range rates {
+		rates[probe] = float64(incrementLimit) / probeServiceTime(experiment, endpointIndex, incrementLimit, benchhttp.ServiceTimeTarget{})
+	}
+	rate := median(rates)
+	log.Infof("[sub-experiment %d] Calibrated busy-spin rate of function %s to %.0f increments/ms.",
+		experiment.ID, experiment.Endpoints[endpointIndex].ID, rate)
+	return rate
+}
+
+// probeTargetSideServiceTime checks the function behind the endpoint achieves a target service time on its own clock
+func probeTargetSideServiceTime(experiment *setup.SubExperiment, endpointIndex int) {
+	target := benchhttp.ServiceTimeTarget{Milliseconds: calibrationProbeMilliseconds, Clock: experiment.ServiceTimeMode}
+	probeServiceTime(experiment, endpointIndex, 0, target) // absorbs the cold start
+
+	serviceTimeMs := probeServiceTime(experiment, endpointIndex, 0, target)
+	if math.Abs(serviceTimeMs-target.Milliseconds) > serviceTimeTolerance*target.Milliseconds {
+		// Spinning on the CPU clock of a throttled function takes longer than the CPU time
+		log.Warnf("[sub-experiment %d] Function %s spun %.1fms for a target of %.0fms on its %s clock.",
+			experiment.ID, experiment.Endpoints[endpointIndex].ID, serviceTimeMs, target.Milliseconds, target.Clock)
+		return
+	}
+	log.Infof("[sub-experiment %d] Function %s spun %.1fms for a target of %.0fms on its %s clock.",
+		experiment.ID, experiment.Endpoints[endpointIndex].ID, serviceTimeMs, target.Milliseconds, target.Clock)
+}
+
+// probeServiceTime sends a probe request to the function behind the endpoint and returns the service time it reports
+func probeServiceTime(experiment *setup.SubExperiment, endpointIndex int, incrementLimit int64, target benchhttp.ServiceTimeTarget) float64 {
+	request := benchhttp.CreateRequestWithServiceTime(experiment.Provider, 0, experiment.Endpoints[endpointIndex], incrementLimit, target,
+		false, experiment.Routes[endpointIndex])
+
+	ok, respBody, _, _ := benchhttp.ExecuteRequest(*request)
+	if !ok {
+		log.Fatalf("[sub-experiment %d] Calibration probe of function %s failed.", experiment.ID, experiment.Endpoints[endpointIndex].ID)
+	}
+	response := benchhttp.ExtractProducerConsumerResponse(respBody)
+	if response.ServiceTimeMilliseconds <= 0 && (incrementLimit > 0 || target.Clock != "") {
+		log.Fatalf("[sub-experiment %d] Function %s does not report its service time, ServiceTimeMode %q cannot be used.",
+			experiment.ID, experiment.Endpoints[endpointIndex].ID, experiment.ServiceTimeMode)
+	}
+	return response.ServiceTimeMilliseconds
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,152 @@
+package benchmarking
+
+import (
+	"encoding/csv"
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"strconv"
+)
+
+// requestTimestamps are the client timestamps of a request, on the clock of the client, and the handler timestamps
+// reported by the function, on the clock of the function, all in microseconds
+type requestTimestamps struct {
+	sentAt       float64
+	handlerEntry float64
+	handlerExit  float64
+	receivedAt   float64
+}
+
+// overhead is the time the request spent outside of the handler, regardless of the clock offset
+func (r requestTimestamps) overhead() float64 {
+	return (r.receivedAt - r.sentAt) - (r.handlerExit - r.handlerEntry)
+}
+
+// estimateClockOffset estimates the offset in microseconds of the clock of the functions to the clock of the client
+// from the request with the lowest overhead, whose request and response paths are assumed to be the most symmetric
+// (as in Cristian's algorithm). The clocks of the hosts of the provider are assumed to be synchronized with each other.
+func estimateClockOffset(requests []requestTimestamps) float64 {
+	fastest := requests[0]
+	for _, request := range requests[1:] {
+		if request.overhead() < fastest.overhead() {
+			fastest = request
+		}
+	}
+	return ((fastest.handlerEntry - fastest.sentAt) + (fastest.handlerExit - fastest.receivedAt)) / 2
+}
+
+// decomposeLatencies splits the latency of every request reporting handler timestamps into its request-path overhead
+// (from being sent to reaching the handler), its execution and its response-path overhead (from leaving the handler
+// to being received). The components are added to the latencies as columns and written to the latency decomposition
+// file of the sub-experiment.
+func decomposeLatencies(experiment setup.SubExperiment, latenciesDF dataframe.DataFrame, experimentDirectoryPath string) dataframe.DataFrame {
+	if !hasColumn(latenciesDF, "Handler Entry (us)") {
+		return latenciesDF
+	}
+
+	sentAt := parseColumn(latenciesDF, "Sent At (us)")
+	handlerEntry := parseColumn(latenciesDF, "Handler Entry (us)")
+	handlerExit := parseColumn(latenciesDF, "Handler Exit (us)")
+	receivedAt := parseColumn(latenciesDF, "Received At (us)")
+	executionMs := parseColumn(latenciesDF, "Execution (ms)")
+
+	requests := make([]requestTimestamps, latenciesDF.Nrow())
+	var reported []requestTimestamps
+	for i := range requests {
+		requests[i] = requestTimestamps{sentAt: sentAt[i], handlerEntry: handlerEntry[i], handlerExit: handlerExit[i], receivedAt: receivedAt[i]}
+		if !math.IsNaN(sentAt[i]) && !math.IsNaN(handlerEntry[i]) && !math.IsNaN(handlerExit[i]) && !math.IsNaN(receivedAt[i]) {
+			reported = append(reported, requests[i])
+		}
+	}
+	if len(reported) == 0 {
+		log.Debugf("[sub-experiment %d] The functions did not report handler timestamps, skipping latency decomposition", experiment.ID)
+		return latenciesDF
+	}
+
+	clockOffset := estimateClockOffset(reported)
+	log.Infof("[sub-experiment %d] Estimated the clock offset of the functions to the client at %.3fms", experiment.ID, clockOffset/1000)
+
+	requestPathMs := make([]float64, len(requests))
+	responsePathMs := make([]float64, len(requests))
+	for i, request := range requests {
+		requestPathMs[i] = (request.handlerEntry - clockOffset - request.sentAt) / 1000
+		responsePathMs[i] = (request.receivedAt - (request.handlerExit - clockOffset)) / 1000
+		if math.IsNaN(executionMs[i]) {
+			executionMs[i] = (request.handlerExit - request.handlerEntry) / 1000
+		}
+	}
+
+	latenciesDF = latenciesDF.Mutate(series.New(requestPathMs, series.Float, "Request Path Overhead (ms)")).
+		Mutate(series.New(responsePathMs, series.Float, "Response Path Overhead (ms)"))
+	writeLatencyDecomposition(experiment, latenciesDF, requestPathMs, executionMs, responsePathMs, clockOffset,
+		filepath.Join(experimentDirectoryPath, "latency-decomposition.csv"))
+	return latenciesDF
+}
+
+func writeLatencyDecomposition(experiment setup.SubExperiment, latenciesDF dataframe.DataFrame, requestPathMs []float64,
+	executionMs []float64, responsePathMs []float64, clockOffset float64, path string) {
+	log.Debugf("[sub-experiment %d] Writing latency decomposition to `%s`", experiment.ID, path)
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not create latency decomposition file: %s", experiment.ID, err.Error())
+	}
+	defer file.Close()
+
+	requestIDs := latenciesDF.Col("Request ID").Records()
+	burstIDs := latenciesDF.Col("Burst ID").Records()
+	var instanceIDs []string
+	if hasColumn(latenciesDF, "Instance ID") {
+		instanceIDs = latenciesDF.Col("Instance ID").Records()
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"Request ID", "Burst ID", "Instance ID", "Request Path Overhead (ms)", "Execution (ms)",
+		"Response Path Overhead (ms)", "Clock Offset (ms)"}); err != nil {
+		log.Errorf("[sub-experiment %d] Could not write latency decomposition header to file: %s", experiment.ID, err.Error())
+	}
+	for i := range requestPathMs {
+		if math.IsNaN(requestPathMs[i]) || math.IsNaN(responsePathMs[i]) {
+			continue
+		}
+		instanceID := ""
+		if instanceIDs != nil {
+			instanceID = instanceIDs[i]
+		}
+		if err := writer.Write([]string{requestIDs[i], burstIDs[i], instanceID, formatMilliseconds(requestPathMs[i]),
+			formatMilliseconds(executionMs[i]), formatMilliseconds(responsePathMs[i]), formatMilliseconds(clockOffset / 1000)}); err != nil {
+			log.Errorf("[sub-experiment %d] Could not write latency decomposition to file: %s", experiment.ID, err.Error())
+		}
+	}
+	writer.Flush()
+}
+
+// parseColumn returns the values of the column, NaN where left empty
+func parseColumn(latenciesDF dataframe.DataFrame, name string) []float64 {
+	records := latenciesDF.Col(name).Records()
+	values := make([]float64, len(records))
+	for i, record := range records {
+		value, err := strconv.ParseFloat(record, 64)
+		if err != nil {
+			value = math.NaN()
+		}
+		values[i] = value
+	}
+	return values
+}
+
+func hasColumn(latenciesDF dataframe.DataFrame, name string) bool {
+	for _, column := range latenciesDF.Names() {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatMilliseconds(durationMs float64) string {
+	return strconv.FormatFloat(durationMs, 'f', 3, 64)
+}
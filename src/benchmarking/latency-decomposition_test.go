@@ -0,0 +1,103 @@
+package benchmarking
+
+import (
+	"github.com/go-gota/gota/dataframe"
+	"github.com/stretchr/testify/require"
+	"math"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"strings"
+	"testing"
+)
+
+// functionClockOffsetUs is the offset of the clock of the functions to the clock of the client in the synthetic requests
+const functionClockOffsetUs = 5000
+
+// syntheticRequest returns the timestamps of a request sent at the given time on the client clock, spending the given
+// durations on its request path, in the handler and on its response path
+func syntheticRequest(sentAt float64, requestPathUs float64, executionUs float64, responsePathUs float64) requestTimestamps {
+	handlerEntry := sentAt + requestPathUs + functionClockOffsetUs
+	return requestTimestamps{
+		sentAt:       sentAt,
+		handlerEntry: handlerEntry,
+		handlerExit:  handlerEntry + executionUs,
+		receivedAt:   sentAt + requestPathUs + executionUs + responsePathUs,
+	}
+}
+
+func TestEstimateClockOffset(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		requests []requestTimestamps
+		expected float64
+	}{
+		{
+			name:     "symmetric paths",
+			requests: []requestTimestamps{syntheticRequest(1000, 300, 2000, 300)},
+			expected: functionClockOffsetUs,
+		},
+		{
+			name: "fastest request is symmetric",
+			requests: []requestTimestamps{
+				syntheticRequest(1000, 1000, 2000, 400),
+				syntheticRequest(10000, 300, 5000, 300),
+				syntheticRequest(20000, 200, 100, 900),
+			},
+			expected: functionClockOffsetUs,
+		},
+		{
+			// The offset is off by half the asymmetry of the fastest request
+			name:     "fastest request is asymmetric",
+			requests: []requestTimestamps{syntheticRequest(1000, 400, 2000, 200), syntheticRequest(10000, 1000, 2000, 1000)},
+			expected: functionClockOffsetUs + 100,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.InDelta(t, test.expected, estimateClockOffset(test.requests), 1e-9)
+		})
+	}
+}
+
+func TestDecomposeLatencies(t *testing.T) {
+	directory := t.TempDir()
+	latenciesDF := dataframe.ReadCSV(strings.NewReader(`Request ID,Burst ID,Sent At (us),Handler Entry (us),Handler Exit (us),Received At (us),Execution (ms)
+symmetric,0,1000,6300,8300,3600,1.5
+asymmetric,0,10000,16000,18000,13400,
+unreported,1,20000,,,21000,
+`))
+
+	decomposed := decomposeLatencies(setup.SubExperiment{}, latenciesDF, directory)
+
+	requestPathMs := decomposed.Col("Request Path Overhead (ms)").Float()
+	responsePathMs := decomposed.Col("Response Path Overhead (ms)").Float()
+	require.InDeltaSlice(t, []float64{0.3, 1.0}, requestPathMs[:2], 1e-9)
+	require.InDeltaSlice(t, []float64{0.3, 0.4}, responsePathMs[:2], 1e-9)
+	require.True(t, math.IsNaN(requestPathMs[2]), "requests without handler timestamps should not be decomposed")
+	require.True(t, math.IsNaN(responsePathMs[2]), "requests without handler timestamps should not be decomposed")
+
+	// The execution reported by the function is kept, that of the others is measured between the handler timestamps
+	decomposition, err := os.ReadFile(filepath.Join(directory, "latency-decomposition.csv"))
+	require.NoError(t, err)
+	require.Equal(t, `Request ID,Burst ID,Instance ID,Request Path Overhead (ms),Execution (ms),Response Path Overhead (ms),Clock Offset (ms)
+symmetric,0,,0.300,1.500,0.300,5.000
+asymmetric,0,,1.000,2.000,0.400,5.000
+`, string(decomposition))
+}
+
+func TestDecomposeLatenciesWithoutHandlerTimestamps(t *testing.T) {
+	for name, latencies := range map[string]string{
+		"no handler timestamp columns": "Request ID,Burst ID,Sent At (us),Received At (us)\nfirst,0,1000,3600\n",
+		"no reported handler timestamps": "Request ID,Burst ID,Sent At (us),Handler Entry (us),Handler Exit (us),Received At (us),Execution (ms)\n" +
+			"first,0,1000,,,3600,\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			directory := t.TempDir()
+			latenciesDF := dataframe.ReadCSV(strings.NewReader(latencies))
+
+			decomposed := decomposeLatencies(setup.SubExperiment{}, latenciesDF, directory)
+			require.Equal(t, latenciesDF.Names(), decomposed.Names())
+			require.NoFileExists(t, filepath.Join(directory, "latency-decomposition.csv"))
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"stellar/benchmarking/networking/benchgrpc/proto_gen"
 	"stellar/setup"
 	"time"
@@ -35,10 +36,14 @@ import (
 
 const (
 	timeout = 3 * time.Minute // 15 minutes are not practical for vHive
+	// TelemetryMetadataKey is the response header in which the functions send the JSON telemetry of their instance and
+	// the timestamps of their handler
+	TelemetryMetadataKey = "stellar-telemetry"
 )
 
-// ExecuteRequest will send a gRPC request and return the timestamp chain (if any).
-func ExecuteRequest(payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo, incrementLimit int64, storageTransfer bool) (string, time.Time, time.Time) {
+// ExecuteRequest will send a gRPC request and return the timestamp chain (if any) and the JSON telemetry of the
+// instance that served it (if sent).
+func ExecuteRequest(payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo, incrementLimit int64, storageTransfer bool) (string, []byte, time.Time, time.Time) {
 	conn, err := grpc.Dial(gatewayEndpoint.ID, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
 	if err != nil {
 		log.Fatalf("Did not connect: %v", err)
@@ -62,13 +67,18 @@ func ExecuteRequest(payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo,
 	}
 
 	var reply *proto_gen.InvokeChainReply
+	var header metadata.MD
 
 	reqSentTime := time.Now()
-	reply, err = client.InvokeNext(ctx, input)
+	reply, err = client.InvokeNext(ctx, input, grpc.Header(&header))
 	if err != nil {
 		log.Fatalf("Could not invoke gRPC function: %v", err)
 	}
 	reqReceivedTime := time.Now()
 
-	return reply.GetTimestampChain(), reqSentTime, reqReceivedTime
+	var telemetry []byte
+	if values := header.Get(TelemetryMetadataKey); len(values) > 0 {
+		telemetry = []byte(values[0])
+	}
+	return reply.GetTimestampChain(), telemetry, reqSentTime, reqReceivedTime
 }
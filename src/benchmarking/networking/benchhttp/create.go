@@ -28,6 +28,7 @@ import (
 	"net/http"
 	"stellar/setup"
 	"stellar/setup/deployment/connection/amazon"
+	"stellar/setup/deployment/connection/openwhisk"
 	"strings"
 	"time"
 )
@@ -35,19 +36,31 @@ import (
 // CreateRequest will generate an HTTP request according to the provider passed in the sub-experiment
 // configuration object.
 func CreateRequest(provider string, payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo, assignedFunctionIncrementLimit int64, storageTransfer bool, route string) *http.Request {
+	return CreateRequestWithServiceTime(provider, payloadLengthBytes, gatewayEndpoint, assignedFunctionIncrementLimit, ServiceTimeTarget{}, storageTransfer, route)
+}
+
+// CreateRequestWithServiceTime will generate an HTTP request as CreateRequest, asking the function to spin for the
+// target service time on its own clock, if the target has a clock, instead of for the increment limit.
+func CreateRequestWithServiceTime(provider string, payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo, assignedFunctionIncrementLimit int64,
+	serviceTime ServiceTimeTarget, storageTransfer bool, route string) *http.Request {
 	var request *http.Request
 
+	region := gatewayEndpoint.Region
+	if region == "" {
+		region = setup.DefaultRegion(provider)
+	}
+
 	switch provider {
 	case "aws":
 		request = createGeneralHttpsRequest(
 			http.MethodGet,
-			fmt.Sprintf("%s.execute-api.%s.amazonaws.com", gatewayEndpoint.ID, amazon.AWSRegion),
+			fmt.Sprintf("%s.execute-api.%s.amazonaws.com", gatewayEndpoint.ID, region),
 		)
 
-		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit,
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime,
 			gatewayEndpoint, storageTransfer, route)
 
-		_, err := amazon.AWSSingletonInstance.RequestSigner.Sign(request, nil, "execute-api", amazon.AWSRegion, time.Now())
+		_, err := amazon.Instance(region).RequestSigner.Sign(request, nil, "execute-api", region, time.Now())
 		if err != nil {
 			log.Fatalf("Could not sign AWS HTTP request: %s", err.Error())
 		}
@@ -59,28 +72,64 @@ func CreateRequest(provider string, payloadLengthBytes int, gatewayEndpoint setu
 			fmt.Sprintf("%s.azurewebsites.net", gatewayEndpoint.ID),
 		)
 
-		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, gatewayEndpoint, storageTransfer, route)
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
 	case "google":
 		// Example Google Cloud Functions URL:
 		// us-west2-zinc-hour-315914.cloudfunctions.net/hellopy-1
 		request = createGeneralHttpsRequest(http.MethodGet, strings.Split(gatewayEndpoint.ID, "/")[0])
 
-		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, gatewayEndpoint, storageTransfer, route)
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
 	case "cloudflare":
 		fallthrough
+	case "fermyon":
+		fallthrough
 	case "gcr":
 		request = createGeneralHttpsRequest(http.MethodGet, gatewayEndpoint.ID)
 
-		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, gatewayEndpoint, storageTransfer, route)
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
 	case "aliyun":
 		// Example Alibaba Cloud URL:
 		// http://5cfeb440ed6d4ad69ae29d8408aa606e-ap-southeast-1.alicloudapi.com/foo
 		request = createGeneralHttpRequest(
 			http.MethodGet,
-			fmt.Sprintf("%s-us-west-1.alicloudapi.com", gatewayEndpoint.ID),
+			fmt.Sprintf("%s-%s.alicloudapi.com", gatewayEndpoint.ID, region),
 		)
 
-		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, gatewayEndpoint, storageTransfer, route)
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
+	case "openwhisk":
+		// Example OpenWhisk web action URL:
+		// https://openwhisk.example.com/api/v1/web/guest/default/stellar-abcde-hellopy-0-0.json
+		var err error
+		request, err = http.NewRequest(http.MethodGet, gatewayEndpoint.ID, nil)
+		if err != nil {
+			log.Fatalf("Could not create OpenWhisk request: %s", err.Error())
+		}
+
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
+
+		client, err := openwhisk.Instance()
+		if err != nil {
+			log.Fatalf("Could not authenticate OpenWhisk request: %s", err.Error())
+		}
+		client.Authenticate(request)
+	case "spin":
+		// Local Spin runtimes listen on plain HTTP, e.g., 127.0.0.1:3000
+		fallthrough
+	case "docker-local":
+		// Local functions are served on plain HTTP as well, e.g., 127.0.0.1:41235
+		request = createGeneralHttpRequest(http.MethodGet, gatewayEndpoint.ID)
+
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
+	case "openfaas":
+		// Example OpenFaaS function URL:
+		// http://127.0.0.1:8080/function/stellar-abcde-hellopy-0-0
+		var err error
+		request, err = http.NewRequest(http.MethodGet, gatewayEndpoint.ID, nil)
+		if err != nil {
+			log.Fatalf("Could not create OpenFaaS request: %s", err.Error())
+		}
+
+		appendProducerConsumerParameters(provider, request, payloadLengthBytes, assignedFunctionIncrementLimit, serviceTime, gatewayEndpoint, storageTransfer, route)
 	default:
 		return createGeneralHttpsRequest(http.MethodGet, provider)
 	}
@@ -29,6 +29,8 @@ import (
 	"stellar/setup"
 	"stellar/setup/deployment/connection"
 	"stellar/setup/deployment/connection/amazon"
+	"stellar/setup/deployment/connection/openfaas"
+	"stellar/setup/deployment/connection/openwhisk"
 	"testing"
 )
 
@@ -41,12 +43,12 @@ func TestCreateAWSRequest(t *testing.T) {
 		DataTransferChainIDs: []string{},
 	}
 
-	connection.Initialize("aws", "", "../../../setup/deployment/raw-code/functions/producer-consumer/api-template.json")
+	connection.Initialize([]string{"aws"}, "", "../../../setup/deployment/raw-code/functions/producer-consumer/api-template.json")
 
 	randomAssignedIncrement := int64(1482911482)
 	req := CreateRequest("aws", randomPayloadLength, randomEndpoint, randomAssignedIncrement, false, "route1")
 
-	expectedHostname := fmt.Sprintf("%s.execute-api.%s.amazonaws.com", randomEndpoint.ID, amazon.AWSRegion)
+	expectedHostname := fmt.Sprintf("%s.execute-api.%s.amazonaws.com", randomEndpoint.ID, amazon.DefaultAWSRegion)
 	require.Equal(t, expectedHostname, req.Host)
 	require.Equal(t, expectedHostname, req.URL.Host)
 	require.Equal(t, http.MethodGet, req.Method)
@@ -63,3 +65,131 @@ func TestCreateExternalRequest(t *testing.T) {
 	require.Equal(t, http.MethodGet, req.Method)
 	require.Equal(t, "https", req.URL.Scheme)
 }
+
+func TestCreateAlibabaRequestRegion(t *testing.T) {
+	randomPayloadLength := 7
+	randomEndpoint := setup.EndpointInfo{
+		ID:     randomGatewayID,
+		Region: "ap-southeast-1",
+	}
+
+	randomAssignedIncrement := int64(1482911482)
+	req := CreateRequest("aliyun", randomPayloadLength, randomEndpoint, randomAssignedIncrement, false, "route1")
+
+	expectedHostname := fmt.Sprintf("%s-ap-southeast-1.alicloudapi.com", randomGatewayID)
+	require.Equal(t, expectedHostname, req.URL.Host)
+	require.Equal(t, "/route1", req.URL.Path)
+	require.Equal(t, "http", req.URL.Scheme)
+}
+
+func TestCreateOpenWhiskRequest(t *testing.T) {
+	client, err := openwhisk.NewClient("https://openwhisk.example.com", "guest", "user:password")
+	require.NoError(t, err)
+	openwhisk.SetInstance(client)
+	defer openwhisk.SetInstance(nil)
+
+	randomEndpoint := setup.EndpointInfo{ID: client.WebActionURL("stellar-hellopy-0-0")}
+	req := CreateRequest("openwhisk", 7, randomEndpoint, int64(1482911482), false, "")
+
+	require.Equal(t, "openwhisk.example.com", req.URL.Host)
+	require.Equal(t, "/api/v1/web/guest/default/stellar-hellopy-0-0.json", req.URL.Path)
+	require.Equal(t, "1482911482", req.URL.Query().Get("IncrementLimit"))
+	require.Equal(t, "https", req.URL.Scheme)
+
+	username, password, ok := req.BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", username)
+	require.Equal(t, "password", password)
+}
+
+func TestCreateOpenFaaSRequest(t *testing.T) {
+	randomEndpoint := setup.EndpointInfo{ID: "http://127.0.0.1:8080/function/stellar-abcde-hellopy-0-0"}
+	req := CreateRequest("openfaas", 7, randomEndpoint, int64(1482911482), false, "")
+
+	require.Equal(t, "127.0.0.1:8080", req.URL.Host)
+	require.Equal(t, "/function/stellar-abcde-hellopy-0-0", req.URL.Path)
+	require.Equal(t, "1482911482", req.URL.Query().Get("IncrementLimit"))
+	require.Equal(t, "http", req.URL.Scheme)
+}
+
+func TestResponseRequestID(t *testing.T) {
+	header := http.Header{}
+	header.Set(openwhisk.ActivationIDHeader, "e1f6d5a4c3b2a1f0e9d8c7b6a5f4e3d2")
+	header.Set(openfaas.CallIDHeader, "0b6e4b2c-1d7e-4c8a-9f35-2c7a1e5d9b42")
+	response := ExtractProducerConsumerResponse([]byte(`{"RequestID": "fallback", "TimestampChain": ["1"]}`))
+
+	require.Equal(t, "e1f6d5a4c3b2a1f0e9d8c7b6a5f4e3d2", ResponseRequestID("openwhisk", header, response))
+	require.Equal(t, "0b6e4b2c-1d7e-4c8a-9f35-2c7a1e5d9b42", ResponseRequestID("openfaas", header, response))
+	require.Equal(t, "fallback", ResponseRequestID("openwhisk", http.Header{}, response))
+	require.Equal(t, "fallback", ResponseRequestID("aws", header, response))
+}
+
+func TestExtractProducerConsumerResponseDurations(t *testing.T) {
+	response := ExtractProducerConsumerResponse([]byte(`{"RequestID": "a", "TimestampChain": ["1"], "InitDurationMilliseconds": 152.25, "ServiceTimeMilliseconds": 48.5}`))
+	require.Equal(t, 152.25, response.InitDurationMilliseconds)
+	require.Equal(t, 48.5, response.ServiceTimeMilliseconds)
+
+	response = ExtractProducerConsumerResponse([]byte(`{"RequestID": "a", "TimestampChain": ["1"]}`))
+	require.Zero(t, response.InitDurationMilliseconds)
+	require.Zero(t, response.ServiceTimeMilliseconds)
+	require.Empty(t, response.WorkloadPhases)
+
+	response = ExtractProducerConsumerResponse([]byte(`{"RequestID": "a", "TimestampChain": ["1"], "WorkloadPhases": {"write": 12.5, "fsync": 3}}`))
+	require.Equal(t, map[string]float64{"write": 12.5, "fsync": 3}, response.WorkloadPhases)
+}
+
+func TestExtractProducerConsumerResponseInstanceTelemetry(t *testing.T) {
+	response := ExtractProducerConsumerResponse([]byte(`{"RequestID": "a", "TimestampChain": ["1"], "InstanceID": "3f2a",
+		"InstanceBootTimestamp": 1700000000123, "InstanceRequestCount": 1, "RuntimeVersion": "python 3.12.3",
+		"CPUModel": "Intel(R) Xeon(R) Processor @ 2.50GHz", "MemoryLimitMB": 128}`))
+	require.Equal(t, "3f2a", response.InstanceID)
+	require.Equal(t, int64(1700000000123), response.InstanceBootTimestamp)
+	require.Equal(t, int64(1), response.InstanceRequestCount)
+	require.Equal(t, "python 3.12.3", response.RuntimeVersion)
+	require.Equal(t, "Intel(R) Xeon(R) Processor @ 2.50GHz", response.CPUModel)
+	require.Equal(t, int64(128), response.MemoryLimitMB)
+
+	response = ExtractProducerConsumerResponse([]byte(`{"RequestID": "a", "TimestampChain": ["1"]}`))
+	require.Empty(t, response.InstanceID)
+	require.Zero(t, response.InstanceRequestCount)
+}
+
+func TestExtractProducerConsumerResponseHandlerTimestamps(t *testing.T) {
+	response := ExtractProducerConsumerResponse([]byte(`{"RequestID": "a", "TimestampChain": ["1"], "ExecutionMilliseconds": 10.25,
+		"HandlerEntryTimestampMicroseconds": 1700000000123456, "HandlerExitTimestampMicroseconds": 1700000000133706}`))
+	require.Equal(t, 10.25, response.ExecutionMilliseconds)
+	require.Equal(t, int64(1700000000123456), response.HandlerEntryTimestampMicroseconds)
+	require.Equal(t, int64(1700000000133706), response.HandlerExitTimestampMicroseconds)
+}
+
+func TestCreateSpinRequest(t *testing.T) {
+	req := CreateRequest("spin", 7, setup.EndpointInfo{ID: "127.0.0.1:3000"}, int64(1482911482), false, "")
+
+	require.Equal(t, "127.0.0.1:3000", req.URL.Host)
+	require.Equal(t, "http", req.URL.Scheme)
+	require.Equal(t, "1482911482", req.URL.Query().Get("IncrementLimit"))
+
+	req = CreateRequest("fermyon", 7, setup.EndpointInfo{ID: "abcde-hellorust-0-0-x1y2z3.fermyon.app"}, int64(1482911482), false, "")
+	require.Equal(t, "abcde-hellorust-0-0-x1y2z3.fermyon.app", req.URL.Host)
+	require.Equal(t, "https", req.URL.Scheme)
+}
+
+func TestCreateDockerLocalRequest(t *testing.T) {
+	req := CreateRequest("docker-local", 7, setup.EndpointInfo{ID: "127.0.0.1:41235"}, int64(1482911482), false, "")
+
+	require.Equal(t, "127.0.0.1:41235", req.URL.Host)
+	require.Equal(t, "http", req.URL.Scheme)
+	require.Equal(t, "1482911482", req.URL.Query().Get("IncrementLimit"))
+}
+
+func TestCreateRequestWithServiceTime(t *testing.T) {
+	endpoint := setup.EndpointInfo{ID: "127.0.0.1:41235"}
+	req := CreateRequestWithServiceTime("docker-local", 7, endpoint, 0, ServiceTimeTarget{Milliseconds: 12.5, Clock: setup.CPUServiceTime}, false, "")
+
+	require.Equal(t, "0", req.URL.Query().Get("IncrementLimit"))
+	require.Equal(t, "12.5", req.URL.Query().Get("ServiceTimeMilliseconds"))
+	require.Equal(t, "cpu", req.URL.Query().Get("ServiceTimeClock"))
+
+	req = CreateRequest("docker-local", 7, endpoint, int64(1482911482), false, "")
+	require.False(t, req.URL.Query().Has("ServiceTimeClock"))
+}
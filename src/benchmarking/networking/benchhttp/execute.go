@@ -37,6 +37,12 @@ const (
 
 // ExecuteRequest will send an HTTP request, check its status code and return the response body.
 func ExecuteRequest(req http.Request) (bool, []byte, time.Time, time.Time) {
+	ok, bodyBytes, _, reqSentTime, reqReceivedTime := ExecuteRequestWithHeader(req)
+	return ok, bodyBytes, reqSentTime, reqReceivedTime
+}
+
+// ExecuteRequestWithHeader will send an HTTP request, check its status code and return the response body and header.
+func ExecuteRequestWithHeader(req http.Request) (bool, []byte, http.Header, time.Time, time.Time) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	ok := true
 	defer cancel()
@@ -45,8 +51,9 @@ func ExecuteRequest(req http.Request) (bool, []byte, time.Time, time.Time) {
 	if err != nil {
 		ok = false
 		log.Errorf("Could not send HTTP request: %s", err.Error())
-		return ok, nil, reqSentTime, reqReceivedTime
+		return ok, nil, nil, reqSentTime, reqReceivedTime
 	}
+	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -59,7 +66,7 @@ func ExecuteRequest(req http.Request) (bool, []byte, time.Time, time.Time) {
 		log.Errorf("Response from %s had status %s: %s", req.URL.Hostname(), resp.Status, string(bodyBytes))
 	}
 
-	return ok, bodyBytes, reqSentTime, reqReceivedTime
+	return ok, bodyBytes, resp.Header, reqSentTime, reqReceivedTime
 }
 
 // https://stackoverflow.com/questions/48077098/getting-ttfb-time-to-first-byte-value-in-golang/48077762#48077762
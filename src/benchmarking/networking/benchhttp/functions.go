@@ -29,6 +29,9 @@ import (
 	"net/http"
 	"stellar/setup"
 	"stellar/setup/deployment/connection/amazon"
+	"stellar/setup/deployment/connection/openfaas"
+	"stellar/setup/deployment/connection/openwhisk"
+	"strconv"
 	"strings"
 )
 
@@ -36,6 +39,40 @@ import (
 type ProducerConsumerResponse struct {
 	RequestID      string   `json:"RequestID"`
 	TimestampChain []string `json:"TimestampChain"`
+	// InitDurationMilliseconds is the duration of the init-time workload reported by the function, if any
+	InitDurationMilliseconds float64 `json:"InitDurationMilliseconds,omitempty"`
+	// ServiceTimeMilliseconds is the measured duration of the busy-spin workload reported by the function, if any
+	ServiceTimeMilliseconds float64 `json:"ServiceTimeMilliseconds,omitempty"`
+	// WorkloadPhases are the durations in milliseconds of the phases of the memory, disk or fetch workload, if any
+	WorkloadPhases map[string]float64 `json:"WorkloadPhases,omitempty"`
+	// InstanceID identifies the instance that served the request, generated by the function at init
+	InstanceID string `json:"InstanceID,omitempty"`
+	// InstanceBootTimestamp is the Unix time in milliseconds at which the instance was initialized
+	InstanceBootTimestamp int64 `json:"InstanceBootTimestamp,omitempty"`
+	// InstanceRequestCount is the number of requests served by the instance so far, 1 for a cold start
+	InstanceRequestCount int64 `json:"InstanceRequestCount,omitempty"`
+	// RuntimeVersion is the language runtime of the function, e.g., "python 3.11.7"
+	RuntimeVersion string `json:"RuntimeVersion,omitempty"`
+	// CPUModel is the model name of the CPU of the host as listed in /proc/cpuinfo
+	CPUModel string `json:"CPUModel,omitempty"`
+	// MemoryLimitMB is the memory limit of the instance, 0 if unlimited
+	MemoryLimitMB int64 `json:"MemoryLimitMB,omitempty"`
+	// ExecutionMilliseconds is the duration of the handler measured by the function on its monotonic clock
+	ExecutionMilliseconds float64 `json:"ExecutionMilliseconds,omitempty"`
+	// HandlerEntryTimestampMicroseconds is the Unix time in microseconds, on the clock of the function, at which the
+	// handler started serving the request
+	HandlerEntryTimestampMicroseconds int64 `json:"HandlerEntryTimestampMicroseconds,omitempty"`
+	// HandlerExitTimestampMicroseconds is the Unix time in microseconds, on the clock of the function, at which the
+	// handler returned the response
+	HandlerExitTimestampMicroseconds int64 `json:"HandlerExitTimestampMicroseconds,omitempty"`
+}
+
+// ServiceTimeTarget asks a function to spin for a service time on its own wall or CPU clock.
+type ServiceTimeTarget struct {
+	Milliseconds float64
+	// Clock is the clock the function spins on, setup.WallServiceTime or setup.CPUServiceTime, or empty to spin for the
+	// increment limit instead
+	Clock string
 }
 
 // ExtractProducerConsumerResponse will process an HTTP response body coming from a producer-consumer function
@@ -51,8 +88,25 @@ func ExtractProducerConsumerResponse(respBody []byte) ProducerConsumerResponse {
 	return response
 }
 
+// requestIDHeaders are the response headers holding the request ID for providers whose platform assigns it
+var requestIDHeaders = map[string]string{
+	"openwhisk": openwhisk.ActivationIDHeader,
+	"openfaas":  openfaas.CallIDHeader,
+}
+
+// ResponseRequestID returns the ID assigned to the request by the platform of the provider (e.g., the OpenWhisk
+// activation ID), falling back to the request ID reported by the function when the header is missing.
+func ResponseRequestID(provider string, header http.Header, response ProducerConsumerResponse) string {
+	if headerName, ok := requestIDHeaders[provider]; ok {
+		if requestID := header.Get(headerName); requestID != "" {
+			return requestID
+		}
+	}
+	return response.RequestID
+}
+
 func appendProducerConsumerParameters(provider string, request *http.Request, payloadLengthBytes int,
-	assignedFunctionIncrementLimit int64, gatewayEndpoint setup.EndpointInfo, storageTransfer bool, route string) *http.Request {
+	assignedFunctionIncrementLimit int64, serviceTime ServiceTimeTarget, gatewayEndpoint setup.EndpointInfo, storageTransfer bool, route string) *http.Request {
 	const (
 		googleBucket = "stellar-us-west-2"
 	)
@@ -62,13 +116,17 @@ func appendProducerConsumerParameters(provider string, request *http.Request, pa
 		payloadLengthBytes,
 		gatewayEndpoint.DataTransferChainIDs,
 	)
+	if serviceTime.Clock != "" {
+		request.URL.RawQuery += fmt.Sprintf("&ServiceTimeMilliseconds=%s&ServiceTimeClock=%s",
+			strconv.FormatFloat(serviceTime.Milliseconds, 'f', -1, 64), serviceTime.Clock)
+	}
 
 	switch provider {
 	case "aws":
 		request.URL.Path = fmt.Sprintf("/%s", route)
 
 		if storageTransfer {
-			request.URL.RawQuery += fmt.Sprintf("&Bucket=%v&StorageTransfer=true", amazon.AWSSingletonInstance.S3Bucket)
+			request.URL.RawQuery += fmt.Sprintf("&Bucket=%v&StorageTransfer=true", amazon.Instance(gatewayEndpoint.Region).S3Bucket)
 		}
 	case "azure":
 		request.URL.Path = fmt.Sprintf("/api/%s", route)
@@ -83,7 +141,12 @@ func appendProducerConsumerParameters(provider string, request *http.Request, pa
 		}
 	case "cloudflare":
 	case "gcr":
-		break // there is no raw query for GCR and Cloudflare
+	case "openwhisk":
+	case "openfaas":
+	case "fermyon":
+	case "spin":
+	case "docker-local":
+		break // the endpoint ID is the full URL for GCR, Cloudflare, OpenWhisk, OpenFaaS, Spin and docker-local
 	case "aliyun":
 		request.URL.Path = fmt.Sprintf("/%s", route)
 	default:
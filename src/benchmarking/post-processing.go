@@ -30,14 +30,16 @@ import (
 	"gonum.org/v1/gonum/stat"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 	"stellar/benchmarking/visualization"
 	"stellar/setup"
 )
 
-func postProcessing(experiment setup.SubExperiment, latenciesFile *os.File, burstDeltas []time.Duration, experimentDirectoryPath string, statisticsFile *os.File) {
+func postProcessing(experiment setup.SubExperiment, latenciesFile *os.File, burstDeltas []time.Duration, experimentDirectoryPath string, statisticsFile *os.File) []float64 {
 	log.Debugf("[sub-experiment %d] Reading written latencies from file %s", experiment.ID, latenciesFile.Name())
 
 	_, err := latenciesFile.Seek(0, io.SeekStart)
@@ -46,12 +48,20 @@ func postProcessing(experiment setup.SubExperiment, latenciesFile *os.File, burs
 	}
 
 	latenciesDF := dataframe.ReadCSV(latenciesFile)
+	latenciesDF = decomposeLatencies(experiment, latenciesDF, experimentDirectoryPath)
+	switch experiment.ExperimentType {
+	case setup.ScaleOutExperiment:
+		analyzeScaleOut(experiment, latenciesDF, experimentDirectoryPath)
+	case setup.PowerTuningExperiment:
+		analyzePowerTuning(experiment, latenciesDF, experimentDirectoryPath)
+	}
 
 	sortedLatencies := latenciesDF.Col("Client Latency (ms)").Float()
 	sort.Float64s(sortedLatencies)
 
 	visualization.Generate(experiment, burstDeltas, latenciesDF, sortedLatencies, experimentDirectoryPath)
 	generateStatistics(statisticsFile, experiment.ID, sortedLatencies)
+	return sortedLatencies
 }
 
 func generateStatistics(file *os.File, experimentID int, sortedLatencies []float64) {
@@ -59,12 +69,22 @@ func generateStatistics(file *os.File, experimentID int, sortedLatencies []float
 
 	statisticsWriter := csv.NewWriter(file)
 
-	if err := statisticsWriter.Write([]string{"Count", "Mean", "Standard Deviation", "Min", "25%ile", "50%ile",
-		"75%ile", "95%ile", "Max"}); err != nil {
+	if err := statisticsWriter.Write(statisticsHeader); err != nil {
 		log.Errorf("[sub-experiment %d] Could not write statistics header to file: %s", experimentID, err.Error())
 	}
 
-	if err := statisticsWriter.Write([]string{
+	if err := statisticsWriter.Write(statisticsRecord(sortedLatencies)); err != nil {
+		log.Errorf("[sub-experiment %d] Could not write statistics to file: %s", experimentID, err.Error())
+	}
+
+	statisticsWriter.Flush()
+}
+
+var statisticsHeader = []string{"Count", "Mean", "Standard Deviation", "Min", "25%ile", "50%ile",
+	"75%ile", "95%ile", "Max"}
+
+func statisticsRecord(sortedLatencies []float64) []string {
+	return []string{
 		strconv.Itoa(len(sortedLatencies)),
 		fmt.Sprintf("%.2f", stat.Mean(sortedLatencies, nil)),
 		fmt.Sprintf("%.2f", stat.StdDev(sortedLatencies, nil)),
@@ -74,9 +94,49 @@ func generateStatistics(file *os.File, experimentID int, sortedLatencies []float
 		fmt.Sprintf("%.2f", stat.Quantile(0.75, stat.Empirical, sortedLatencies, nil)),
 		fmt.Sprintf("%.2f", stat.Quantile(0.95, stat.Empirical, sortedLatencies, nil)),
 		fmt.Sprintf("%.2f", stat.Quantile(1, stat.Empirical, sortedLatencies, nil)),
-	}); err != nil {
-		log.Errorf("[sub-experiment %d] Could not write statistics to file: %s", experimentID, err.Error())
 	}
+}
 
-	statisticsWriter.Flush()
+// comparisonSummary gathers the latency statistics of all sub-experiments of a run, regardless of their provider
+type comparisonSummary struct {
+	mutex   sync.Mutex
+	records [][]string
+}
+
+func (c *comparisonSummary) add(experiment setup.SubExperiment, sortedLatencies []float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	record := []string{strconv.Itoa(experiment.ID), experiment.Title, experiment.Provider, experiment.Region,
+		experiment.Architecture, strconv.FormatInt(experiment.FunctionMemoryMB, 10), experiment.PackageType}
+	c.records = append(c.records, append(record, statisticsRecord(sortedLatencies)...))
+}
+
+func (c *comparisonSummary) write(outputDirectoryPath string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	summaryPath := filepath.Join(outputDirectoryPath, "summary.csv")
+	log.Infof("Writing comparison summary of %d sub-experiments to `%s`", len(c.records), summaryPath)
+	summaryFile, err := os.Create(summaryPath)
+	if err != nil {
+		log.Fatalf("Could not create comparison summary file: %s", err.Error())
+	}
+	defer summaryFile.Close()
+
+	// Rows are ordered by sub-experiment ID since concurrent sub-experiments may finish in any order
+	sort.Slice(c.records, func(i, j int) bool {
+		firstID, _ := strconv.Atoi(c.records[i][0])
+		secondID, _ := strconv.Atoi(c.records[j][0])
+		return firstID < secondID
+	})
+
+	summaryWriter := csv.NewWriter(summaryFile)
+	header := append([]string{"Sub-experiment", "Title", "Provider", "Region", "Architecture", "Memory (MB)", "Package Type"}, statisticsHeader...)
+	if err := summaryWriter.Write(header); err != nil {
+		log.Errorf("Could not write comparison summary header to file: %s", err.Error())
+	}
+	if err := summaryWriter.WriteAll(c.records); err != nil {
+		log.Errorf("Could not write comparison summary to file: %s", err.Error())
+	}
 }
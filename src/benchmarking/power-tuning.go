@@ -0,0 +1,305 @@
+package benchmarking
+
+import (
+	"encoding/csv"
+	"github.com/go-gota/gota/dataframe"
+	log "github.com/sirupsen/logrus"
+	"gonum.org/v1/gonum/stat"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"stellar/benchmarking/visualization"
+	"stellar/benchmarking/writers"
+	"stellar/setup"
+	"strconv"
+)
+
+// lambdaPrice is the on-demand price of Lambda in USD, per GB-second of billed duration and per request
+type lambdaPrice struct {
+	gbSecond float64
+	request  float64
+}
+
+// lambdaPricing lists the on-demand prices of Lambda by architecture, as in us-east-1 and most regions (first tier)
+var lambdaPricing = map[string]lambdaPrice{
+	"x86_64": {gbSecond: 0.0000166667, request: 0.0000002},
+	"arm64":  {gbSecond: 0.0000133334, request: 0.0000002},
+}
+
+// runPowerTuning sweeps the memory sizes of the power-tuning sub-experiment on its first function. Every round at a
+// memory size forces cold starts, by updating the memory of the function in the first round, and sends a cold burst,
+// then a warm burst to the instances that served it.
+func runPowerTuning(experiment setup.SubExperiment, provider string, latenciesWriter *writers.RTTLatencyWriter, dataTransferWriter *writers.DataTransferWriter) {
+	var serviceTimeSampler *setup.ServiceTimeSampler
+	if experiment.ServiceTimeDistribution != nil {
+		var err error
+		if serviceTimeSampler, err = experiment.ServiceTimeDistribution.NewSampler(); err != nil {
+			log.Fatalf("[sub-experiment %d] Could not sample service times: %s", experiment.ID, err.Error())
+		}
+	}
+	var incrementLimit int64
+	if len(experiment.BusySpinIncrements) > 0 {
+		incrementLimit = experiment.BusySpinIncrements[0]
+	}
+
+	errorThreshold := experiment.Bursts * experiment.BurstSizes[0] / 10
+	errorCount := ErrorCount{}
+	for burstID := 0; burstID < experiment.Bursts; burstID++ {
+		memoryMB, cold := experiment.PowerTuningBurst(burstID)
+		if burstID%(2*experiment.RoundsPerMemorySize) == 0 {
+			setup.UpdateFunctionMemory(experiment, memoryMB)
+		} else if cold {
+			setup.ForceColdStart(experiment, 0)
+		}
+
+		sendBurst(provider, experiment, burstID, experiment.BurstSizes[0], experiment.Endpoints[0], incrementLimit,
+			experiment.DesiredServiceTimeMilliseconds(0), serviceTimeSampler, latenciesWriter, dataTransferWriter, experiment.Routes[0],
+			cold, &errorCount)
+		if errs := errorCount.Read(); errs > errorThreshold {
+			log.Fatalf("Too many errors (%d) occurred, aborting experiment.", errs)
+		}
+
+		latenciesWriter.Writer.Flush()
+		if dataTransferWriter != nil {
+			dataTransferWriter.Writer.Flush()
+		}
+	}
+}
+
+// memorySizeSamples are the latencies and billed durations of the requests of the power-tuning experiment at one memory size
+type memorySizeSamples struct {
+	warmLatenciesMs       []float64
+	warmBilledDurationsMs []float64
+	coldLatenciesMs       []float64
+	coldBilledDurationsMs []float64
+}
+
+// analyzePowerTuning summarizes the warm and cold latencies of every memory size of the power-tuning sub-experiment
+// with their cost per invocation, and recommends the cheapest memory size on the Pareto frontier of warm cost and
+// 95th percentile latency meeting the latency target, or the fastest one if none does.
+func analyzePowerTuning(experiment setup.SubExperiment, latenciesDF dataframe.DataFrame, experimentDirectoryPath string) {
+	burstIDs := latenciesDF.Col("Burst ID").Records()
+	latenciesMs := parseColumn(latenciesDF, "Client Latency (ms)")
+	executionMs := optionalColumn(latenciesDF, "Execution (ms)")
+	initDurationsMs := optionalColumn(latenciesDF, "Init Duration (ms)")
+	var coldStarts []string
+	if hasColumn(latenciesDF, "Cold Start") {
+		coldStarts = latenciesDF.Col("Cold Start").Records()
+	}
+
+	samples := make(map[int64]*memorySizeSamples)
+	for _, memoryMB := range experiment.MemorySizesMB {
+		samples[memoryMB] = &memorySizeSamples{}
+	}
+	for i := range burstIDs {
+		burstID, err := strconv.Atoi(burstIDs[i])
+		if err != nil || math.IsNaN(latenciesMs[i]) {
+			continue
+		}
+		memoryMB, cold := experiment.PowerTuningBurst(burstID)
+		// The instances reported by the functions tell the requests of a cold burst served by warm instances apart
+		if coldStarts != nil && (coldStarts[i] == "true" || coldStarts[i] == "false") {
+			cold = coldStarts[i] == "true"
+		}
+
+		billedMs := billedDurationMs(executionMs[i], initDurationsMs[i], latenciesMs[i], cold)
+		if cold {
+			samples[memoryMB].coldLatenciesMs = append(samples[memoryMB].coldLatenciesMs, latenciesMs[i])
+			samples[memoryMB].coldBilledDurationsMs = append(samples[memoryMB].coldBilledDurationsMs, billedMs)
+		} else {
+			samples[memoryMB].warmLatenciesMs = append(samples[memoryMB].warmLatenciesMs, latenciesMs[i])
+			samples[memoryMB].warmBilledDurationsMs = append(samples[memoryMB].warmBilledDurationsMs, billedMs)
+		}
+	}
+
+	price := lambdaPricing[experiment.Architecture]
+	results := make([]visualization.PowerTuningResult, 0, len(samples))
+	for memoryMB, memorySamples := range samples {
+		results = append(results, visualization.PowerTuningResult{
+			MemoryMB:             memoryMB,
+			WarmRequests:         len(memorySamples.warmLatenciesMs),
+			WarmMedianMs:         quantile(0.5, memorySamples.warmLatenciesMs),
+			WarmP95Ms:            quantile(0.95, memorySamples.warmLatenciesMs),
+			WarmBilledDurationMs: mean(memorySamples.warmBilledDurationsMs),
+			WarmCostUSD:          costPerInvocation(memorySamples.warmBilledDurationsMs, memoryMB, price),
+			ColdRequests:         len(memorySamples.coldLatenciesMs),
+			ColdMedianMs:         quantile(0.5, memorySamples.coldLatenciesMs),
+			ColdP95Ms:            quantile(0.95, memorySamples.coldLatenciesMs),
+			ColdBilledDurationMs: mean(memorySamples.coldBilledDurationsMs),
+			ColdCostUSD:          costPerInvocation(memorySamples.coldBilledDurationsMs, memoryMB, price),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].MemoryMB < results[j].MemoryMB })
+
+	markParetoFrontier(results)
+	recommended, meetsTarget := recommendMemorySize(results, experiment.LatencyTargetMs)
+	if recommended < 0 {
+		log.Warnf("[sub-experiment %d] No warm request was served, skipping power-tuning recommendation", experiment.ID)
+	} else if !meetsTarget {
+		log.Warnf("[sub-experiment %d] No memory size meets the latency target of %vms, recommending the fastest one: %dMB.",
+			experiment.ID, experiment.LatencyTargetMs, results[recommended].MemoryMB)
+	} else {
+		log.Infof("[sub-experiment %d] Recommending %dMB (warm 95th percentile %sms, $%s per invocation).", experiment.ID,
+			results[recommended].MemoryMB, formatMilliseconds(results[recommended].WarmP95Ms), formatUSD(results[recommended].WarmCostUSD))
+	}
+
+	writePowerTuningResults(experiment, results, filepath.Join(experimentDirectoryPath, "power-tuning.csv"))
+	writePowerTuningRecommendation(experiment, results, recommended, meetsTarget,
+		filepath.Join(experimentDirectoryPath, "power-tuning-recommendation.csv"))
+
+	if experiment.Visualization != "none" {
+		visualization.GeneratePowerTuning(experiment, results, recommended, experimentDirectoryPath)
+	}
+}
+
+// billedDurationMs estimates the duration Lambda bills for a request from the execution of the handler reported by
+// the function, rounded up to the millisecond. Cold starts add the init-time workload reported by the function, but not
+// the init of the runtime, which only the REPORT line of Lambda logs, so their estimate is a lower bound. Requests to
+// functions not reporting their execution are billed their client latency.
+func billedDurationMs(executionMs float64, initDurationMs float64, clientLatencyMs float64, cold bool) float64 {
+	if math.IsNaN(executionMs) {
+		return math.Ceil(clientLatencyMs)
+	}
+	if cold && !math.IsNaN(initDurationMs) {
+		executionMs += initDurationMs
+	}
+	return math.Ceil(executionMs)
+}
+
+// costPerInvocation is the mean cost in USD of the invocations with the given billed durations, NaN without invocations
+func costPerInvocation(billedDurationsMs []float64, memoryMB int64, price lambdaPrice) float64 {
+	return mean(billedDurationsMs)/1000*float64(memoryMB)/1024*price.gbSecond + price.request
+}
+
+// markParetoFrontier marks the memory sizes whose warm requests no other memory size serves both as cheaply and as
+// fast (95th percentile), and strictly better in one of them
+func markParetoFrontier(results []visualization.PowerTuningResult) {
+	for i := range results {
+		if math.IsNaN(results[i].WarmCostUSD) {
+			continue
+		}
+		results[i].ParetoOptimal = true
+		for j := range results {
+			if j != i && !math.IsNaN(results[j].WarmCostUSD) && dominates(results[j], results[i]) {
+				results[i].ParetoOptimal = false
+				break
+			}
+		}
+	}
+}
+
+func dominates(a visualization.PowerTuningResult, b visualization.PowerTuningResult) bool {
+	return a.WarmCostUSD <= b.WarmCostUSD && a.WarmP95Ms <= b.WarmP95Ms && (a.WarmCostUSD < b.WarmCostUSD || a.WarmP95Ms < b.WarmP95Ms)
+}
+
+// recommendMemorySize returns the index of the cheapest memory size on the Pareto frontier meeting the latency target,
+// if any, or of the fastest one otherwise, and whether it meets the target. It returns -1 without warm requests.
+func recommendMemorySize(results []visualization.PowerTuningResult, latencyTargetMs float64) (int, bool) {
+	recommended := -1
+	for i, result := range results {
+		if !result.ParetoOptimal || (latencyTargetMs > 0 && result.WarmP95Ms > latencyTargetMs) {
+			continue
+		}
+		if recommended < 0 || result.WarmCostUSD < results[recommended].WarmCostUSD {
+			recommended = i
+		}
+	}
+	if recommended >= 0 {
+		return recommended, true
+	}
+
+	for i, result := range results {
+		if result.ParetoOptimal && (recommended < 0 || result.WarmP95Ms < results[recommended].WarmP95Ms) {
+			recommended = i
+		}
+	}
+	return recommended, false
+}
+
+func writePowerTuningResults(experiment setup.SubExperiment, results []visualization.PowerTuningResult, path string) {
+	rows := [][]string{{"Memory (MB)", "Warm Requests", "Warm Median (ms)", "Warm P95 (ms)", "Warm Billed Duration (ms)",
+		"Warm Cost per Invocation (USD)", "Cold Requests", "Cold Median (ms)", "Cold P95 (ms)",
+		"Cold Billed Duration Excluding Runtime Init (ms)", "Cold Cost per Invocation Excluding Runtime Init (USD)", "Pareto Optimal"}}
+	for _, result := range results {
+		rows = append(rows, []string{strconv.FormatInt(result.MemoryMB, 10), strconv.Itoa(result.WarmRequests),
+			formatOptionalMilliseconds(result.WarmMedianMs), formatOptionalMilliseconds(result.WarmP95Ms),
+			formatOptionalMilliseconds(result.WarmBilledDurationMs), formatUSD(result.WarmCostUSD), strconv.Itoa(result.ColdRequests),
+			formatOptionalMilliseconds(result.ColdMedianMs), formatOptionalMilliseconds(result.ColdP95Ms),
+			formatOptionalMilliseconds(result.ColdBilledDurationMs), formatUSD(result.ColdCostUSD), strconv.FormatBool(result.ParetoOptimal)})
+	}
+	writePowerTuningFile(experiment, rows, path)
+}
+
+func writePowerTuningRecommendation(experiment setup.SubExperiment, results []visualization.PowerTuningResult, recommended int,
+	meetsTarget bool, path string) {
+	rows := [][]string{{"Memory (MB)", "Warm P95 (ms)", "Warm Cost per Invocation (USD)", "Latency Target (ms)", "Meets Target"}}
+	if recommended >= 0 {
+		latencyTargetMs := ""
+		if experiment.LatencyTargetMs > 0 {
+			latencyTargetMs = formatMilliseconds(experiment.LatencyTargetMs)
+		}
+		rows = append(rows, []string{strconv.FormatInt(results[recommended].MemoryMB, 10), formatMilliseconds(results[recommended].WarmP95Ms),
+			formatUSD(results[recommended].WarmCostUSD), latencyTargetMs, strconv.FormatBool(meetsTarget)})
+	}
+	writePowerTuningFile(experiment, rows, path)
+}
+
+func writePowerTuningFile(experiment setup.SubExperiment, rows [][]string, path string) {
+	log.Debugf("[sub-experiment %d] Writing power-tuning results to `%s`", experiment.ID, path)
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not create power-tuning results file: %s", experiment.ID, err.Error())
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		log.Errorf("[sub-experiment %d] Could not write power-tuning results to file: %s", experiment.ID, err.Error())
+	}
+}
+
+// optionalColumn returns the values of the column, NaN where left empty or if the latencies file does not have it
+func optionalColumn(latenciesDF dataframe.DataFrame, name string) []float64 {
+	if hasColumn(latenciesDF, name) {
+		return parseColumn(latenciesDF, name)
+	}
+	values := make([]float64, latenciesDF.Nrow())
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// quantile returns the given quantile of the values, NaN without values
+func quantile(p float64, values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return stat.Quantile(p, stat.Empirical, sorted, nil)
+}
+
+// mean returns the mean of the values, NaN without values
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return stat.Mean(values, nil)
+}
+
+func formatOptionalMilliseconds(durationMs float64) string {
+	if math.IsNaN(durationMs) {
+		return ""
+	}
+	return formatMilliseconds(durationMs)
+}
+
+// formatUSD formats a cost in USD, left empty for NaN
+func formatUSD(cost float64) string {
+	if math.IsNaN(cost) {
+		return ""
+	}
+	return strconv.FormatFloat(cost, 'f', 10, 64)
+}
@@ -0,0 +1,137 @@
+package benchmarking
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"stellar/benchmarking/visualization"
+	"testing"
+)
+
+func warmResult(memoryMB int64, costUSD float64, p95Ms float64) visualization.PowerTuningResult {
+	return visualization.PowerTuningResult{MemoryMB: memoryMB, WarmCostUSD: costUSD, WarmP95Ms: p95Ms}
+}
+
+func TestBilledDurationMs(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		executionMs    float64
+		initDurationMs float64
+		latencyMs      float64
+		cold           bool
+		expected       float64
+	}{
+		{"warm", 10.2, 50, 30, false, 11},
+		{"cold with init", 10.2, 50, 80, true, 61},
+		{"cold without init", 10.2, math.NaN(), 80, true, 11},
+		{"no execution reported", math.NaN(), 50, 29.5, true, 30},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, billedDurationMs(test.executionMs, test.initDurationMs, test.latencyMs, test.cold))
+		})
+	}
+}
+
+func TestCostPerInvocation(t *testing.T) {
+	price := lambdaPrice{gbSecond: 0.00001, request: 0.0000002}
+	for _, test := range []struct {
+		name              string
+		billedDurationsMs []float64
+		memoryMB          int64
+		expected          float64
+	}{
+		{"one second at 1GB", []float64{1000}, 1024, 0.0000102},
+		{"mean of the durations", []float64{100, 300}, 512, 0.0000012},
+		{"no billed duration", []float64{0}, 2048, 0.0000002},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.InDelta(t, test.expected, costPerInvocation(test.billedDurationsMs, test.memoryMB, price), 1e-12)
+		})
+	}
+	require.True(t, math.IsNaN(costPerInvocation(nil, 128, price)), "the cost without invocations should be NaN")
+}
+
+func TestDominates(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		a, b     visualization.PowerTuningResult
+		expected bool
+	}{
+		{"cheaper and faster", warmResult(1024, 1, 10), warmResult(128, 2, 20), true},
+		{"cheaper only", warmResult(1024, 1, 20), warmResult(128, 2, 20), true},
+		{"faster only", warmResult(1024, 2, 10), warmResult(128, 2, 20), true},
+		{"equal", warmResult(1024, 2, 20), warmResult(128, 2, 20), false},
+		{"trade-off", warmResult(1024, 3, 10), warmResult(128, 2, 20), false},
+		{"worse", warmResult(1024, 3, 30), warmResult(128, 2, 20), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, dominates(test.a, test.b))
+		})
+	}
+}
+
+func TestMarkParetoFrontier(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		results  []visualization.PowerTuningResult
+		expected []bool
+	}{
+		{
+			name: "trade-off between cost and latency",
+			results: []visualization.PowerTuningResult{
+				warmResult(128, 1, 100), warmResult(256, 2, 50), warmResult(512, 3, 60), warmResult(1024, 4, 20),
+			},
+			expected: []bool{true, true, false, true},
+		},
+		{
+			name:     "equal results are both optimal",
+			results:  []visualization.PowerTuningResult{warmResult(128, 1, 10), warmResult(256, 1, 10)},
+			expected: []bool{true, true},
+		},
+		{
+			name:     "memory sizes without warm requests",
+			results:  []visualization.PowerTuningResult{warmResult(128, math.NaN(), math.NaN()), warmResult(256, 2, 50)},
+			expected: []bool{false, true},
+		},
+		{
+			name:     "no warm request",
+			results:  []visualization.PowerTuningResult{warmResult(128, math.NaN(), math.NaN())},
+			expected: []bool{false},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			markParetoFrontier(test.results)
+			for i, result := range test.results {
+				require.Equal(t, test.expected[i], result.ParetoOptimal, "%dMB", result.MemoryMB)
+			}
+		})
+	}
+}
+
+func TestRecommendMemorySize(t *testing.T) {
+	frontier := []visualization.PowerTuningResult{
+		warmResult(128, 1, 100), warmResult(256, 2, 50), warmResult(512, 3, 60), warmResult(1024, 4, 20),
+	}
+	markParetoFrontier(frontier)
+	withoutWarmRequests := []visualization.PowerTuningResult{warmResult(128, math.NaN(), math.NaN())}
+	markParetoFrontier(withoutWarmRequests)
+
+	for _, test := range []struct {
+		name                string
+		results             []visualization.PowerTuningResult
+		latencyTargetMs     float64
+		expected            int
+		expectedMeetsTarget bool
+	}{
+		{"cheapest without target", frontier, 0, 0, true},
+		{"cheapest meeting the target", frontier, 50, 1, true},
+		{"off-frontier sizes are not recommended", frontier, 60, 1, true},
+		{"fastest when none meets the target", frontier, 10, 3, false},
+		{"no warm request", withoutWarmRequests, 0, -1, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			recommended, meetsTarget := recommendMemorySize(test.results, test.latencyTargetMs)
+			require.Equal(t, test.expected, recommended)
+			require.Equal(t, test.expectedMeetsTarget, meetsTarget)
+		})
+	}
+}
@@ -23,7 +23,10 @@
 package benchmarking
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
 	"stellar/benchmarking/networking/benchgrpc"
 	"stellar/benchmarking/networking/benchhttp"
 	"stellar/benchmarking/writers"
@@ -59,15 +62,31 @@ func runSubExperiment(experiment setup.SubExperiment, burstDeltas []time.Duratio
 	deltaIndex := 0
 	errorThreshold := (experiment.Bursts) * (experiment.BurstSizes[util.IntegerMin(deltaIndex, len(experiment.BurstSizes)-1)]) / 10
 	errorCount := ErrorCount{}
+
+	var serviceTimeSampler *setup.ServiceTimeSampler
+	if experiment.ServiceTimeDistribution != nil {
+		var err error
+		if serviceTimeSampler, err = experiment.ServiceTimeDistribution.NewSampler(); err != nil {
+			log.Fatalf("[sub-experiment %d] Could not sample service times: %s", experiment.ID, err.Error())
+		}
+	}
+
 	for burstID < experiment.Bursts {
 		time.Sleep(burstDeltas[deltaIndex])
 		// Send one burst to each available gateway (the more gateways used, the faster the experiment)
 		for gatewayID := 0; gatewayID < len(experiment.Endpoints) && burstID < experiment.Bursts; gatewayID++ {
 			// Every refresh period, we cycle through burst sizes if they're dynamic i.e. more than 1 element
-			incrementLimit := experiment.BusySpinIncrements[util.IntegerMin(deltaIndex, len(experiment.BusySpinIncrements)-1)]
+			var incrementLimit int64
+			if len(experiment.BusySpinIncrements) > 0 {
+				incrementLimit = experiment.BusySpinIncrements[util.IntegerMin(deltaIndex, len(experiment.BusySpinIncrements)-1)]
+			}
 			burstSize := experiment.BurstSizes[deltaIndex%len(experiment.BurstSizes)]
 			log.Infof("%d", len(experiment.Routes))
-			sendBurst(provider, experiment, burstID, burstSize, experiment.Endpoints[gatewayID], incrementLimit, latenciesWriter, dataTransferWriter, experiment.Routes[gatewayID], &errorCount)
+			if experiment.ForceCold {
+				setup.ForceColdStart(experiment, gatewayID)
+			}
+			sendBurst(provider, experiment, burstID, burstSize, experiment.Endpoints[gatewayID], incrementLimit, experiment.DesiredServiceTimeMilliseconds(deltaIndex),
+				serviceTimeSampler, latenciesWriter, dataTransferWriter, experiment.Routes[gatewayID], experiment.ForceCold, &errorCount)
 			errs := errorCount.Read()
 			if errorCount.Read() > errorThreshold {
 				log.Fatalf("Too many errors (%d) occurred, aborting experiment.", errs)
@@ -84,47 +103,111 @@ func runSubExperiment(experiment setup.SubExperiment, burstDeltas []time.Duratio
 	}
 }
 
+// sendBurst sends the requests of a burst concurrently. With a service time sampler, every request busy-spins for its own
+// sampled service time instead of the desired service time of the burst. Outside of the client service time mode, the
+// service times are converted with the busy-spin rate probed for the function, or the function spins for them itself.
 func sendBurst(provider string, config setup.SubExperiment, burstID int, requests int, gatewayEndpoint setup.EndpointInfo,
-	incrementLimit int64, latenciesWriter *writers.RTTLatencyWriter, dataTransfersWriter *writers.DataTransferWriter, route string, errorCount *ErrorCount) {
-
-	log.Infof("[sub-experiment %d] Starting burst %d, making %d requests with increment limit %d to gateway with ID %q of provider %q.",
-		config.ID,
-		burstID,
-		requests,
-		incrementLimit,
-		gatewayEndpoint.ID,
-		provider,
-	)
+	incrementLimit int64, desiredServiceTimeMs float64, serviceTimeSampler *setup.ServiceTimeSampler, latenciesWriter *writers.RTTLatencyWriter,
+	dataTransfersWriter *writers.DataTransferWriter, route string, forcedCold bool, errorCount *ErrorCount) {
+
+	if serviceTimeSampler != nil {
+		log.Infof("[sub-experiment %d] Starting burst %d, making %d requests with %s service times to gateway with ID %q of provider %q.",
+			config.ID,
+			burstID,
+			requests,
+			config.ServiceTimeDistribution.Type,
+			gatewayEndpoint.ID,
+			provider,
+		)
+	} else if config.ServiceTimeMode != setup.ClientServiceTime {
+		log.Infof("[sub-experiment %d] Starting burst %d, making %d requests with service time %vms (%s) to gateway with ID %q of provider %q.",
+			config.ID,
+			burstID,
+			requests,
+			desiredServiceTimeMs,
+			config.ServiceTimeMode,
+			gatewayEndpoint.ID,
+			provider,
+		)
+	} else {
+		log.Infof("[sub-experiment %d] Starting burst %d, making %d requests with increment limit %d to gateway with ID %q of provider %q.",
+			config.ID,
+			burstID,
+			requests,
+			incrementLimit,
+			gatewayEndpoint.ID,
+			provider,
+		)
+	}
 
 	var requestsWaitGroup sync.WaitGroup
 	for i := 0; i < requests; i++ {
+		requestIncrementLimit, serviceTimeTarget, sampledServiceTimeMs := requestServiceTime(config, gatewayEndpoint, incrementLimit,
+			desiredServiceTimeMs, serviceTimeSampler)
+
 		requestsWaitGroup.Add(1)
-		go executeRequestAndWriteResults(&requestsWaitGroup, provider, incrementLimit, latenciesWriter, dataTransfersWriter, burstID,
-			config.PayloadLengthBytes, gatewayEndpoint, config.StorageTransfer, route, errorCount)
+		go executeRequestAndWriteResults(&requestsWaitGroup, provider, requestIncrementLimit, serviceTimeTarget, sampledServiceTimeMs, latenciesWriter,
+			dataTransfersWriter, burstID, config.PayloadLengthBytes, gatewayEndpoint, config.StorageTransfer, route, forcedCold, errorCount)
 	}
 
 	requestsWaitGroup.Wait()
 	log.Infof("[sub-experiment %d] Received all responses for burst %d.", config.ID, burstID)
 }
 
+// requestServiceTime returns the busy-spin increments or the target-side service time of the next request, and the
+// service time recorded for it, left empty when the request busy-spins for the increment limit of its burst
+func requestServiceTime(config setup.SubExperiment, gatewayEndpoint setup.EndpointInfo, incrementLimit int64, desiredServiceTimeMs float64,
+	serviceTimeSampler *setup.ServiceTimeSampler) (int64, benchhttp.ServiceTimeTarget, string) {
+	requestIncrementLimit, serviceTimeMs := incrementLimit, desiredServiceTimeMs
+	if serviceTimeSampler != nil {
+		serviceTimeMs = serviceTimeSampler.Sample()
+	}
+
+	var serviceTimeTarget benchhttp.ServiceTimeTarget
+	switch {
+	case config.TargetSideServiceTime():
+		requestIncrementLimit = 0
+		serviceTimeTarget = benchhttp.ServiceTimeTarget{Milliseconds: serviceTimeMs, Clock: config.ServiceTimeMode}
+	case config.ServiceTimeMode == setup.ProbeServiceTime:
+		requestIncrementLimit = int64(serviceTimeMs * gatewayEndpoint.BusySpinIncrementsPerMillisecond)
+	case serviceTimeSampler != nil:
+		requestIncrementLimit = config.BusySpinIncrement(serviceTimeMs)
+	}
+
+	sampledServiceTimeMs := ""
+	if serviceTimeSampler != nil || config.ServiceTimeMode != setup.ClientServiceTime {
+		sampledServiceTimeMs = strconv.FormatFloat(serviceTimeMs, 'f', 3, 64)
+	}
+	return requestIncrementLimit, serviceTimeTarget, sampledServiceTimeMs
+}
+
 func executeRequestAndWriteResults(requestsWaitGroup *sync.WaitGroup, provider string, incrementLimit int64,
+	serviceTimeTarget benchhttp.ServiceTimeTarget, sampledServiceTimeMs string,
 	latenciesWriter *writers.RTTLatencyWriter, dataTransfersWriter *writers.DataTransferWriter, burstID int,
-	payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo, storageTransfer bool, route string, errorCount *ErrorCount) {
+	payloadLengthBytes int, gatewayEndpoint setup.EndpointInfo, storageTransfer bool, route string, forcedCold bool, errorCount *ErrorCount) {
 	defer requestsWaitGroup.Done()
 
 	var reqSentTime, reqReceivedTime time.Time
-	var responseID, hostname string
+	var responseID, hostname, initDurationMs, serviceTimeMs, workloadPhasesMs string
+	var instance writers.InstanceTelemetry
+	var timing writers.RequestTiming
 	var timestampChain []string
 	var ok bool
 
 	switch provider {
 	case "vhive":
 		var stringArrayTimeStampChain string
-		stringArrayTimeStampChain, reqSentTime, reqReceivedTime = benchgrpc.ExecuteRequest(payloadLengthBytes, gatewayEndpoint, incrementLimit, storageTransfer)
+		var telemetry []byte
+		stringArrayTimeStampChain, telemetry, reqSentTime, reqReceivedTime = benchgrpc.ExecuteRequest(payloadLengthBytes, gatewayEndpoint, incrementLimit, storageTransfer)
 
 		timestampChain = stringArrayToArrayOfString(stringArrayTimeStampChain)
 		hostname = gatewayEndpoint.ID
 		responseID = "N/A"
+		if len(telemetry) > 0 {
+			response := benchhttp.ExtractProducerConsumerResponse(telemetry)
+			instance = instanceTelemetryColumns(response)
+			timing = handlerTimingColumns(response)
+		}
 	case "aws":
 		fallthrough
 	case "azure":
@@ -135,8 +218,14 @@ func executeRequestAndWriteResults(requestsWaitGroup *sync.WaitGroup, provider s
 		fallthrough
 	case "aliyun":
 		fallthrough
+	case "fermyon":
+		fallthrough
+	case "spin":
+		fallthrough
+	case "docker-local":
+		fallthrough
 	case "google":
-		request := benchhttp.CreateRequest(provider, payloadLengthBytes, gatewayEndpoint, incrementLimit, storageTransfer, route)
+		request := benchhttp.CreateRequestWithServiceTime(provider, payloadLengthBytes, gatewayEndpoint, incrementLimit, serviceTimeTarget, storageTransfer, route)
 		log.Debugf("Created HTTP request with URL (%q), Body (%q)", (*request).URL, (*request).Body)
 
 		var respBody []byte
@@ -151,10 +240,42 @@ func executeRequestAndWriteResults(requestsWaitGroup *sync.WaitGroup, provider s
 		timestampChain = response.TimestampChain
 		hostname = request.URL.Hostname()
 		responseID = response.RequestID
+		initDurationMs = formatReportedMilliseconds(response.InitDurationMilliseconds)
+		serviceTimeMs = formatReportedMilliseconds(response.ServiceTimeMilliseconds)
+		workloadPhasesMs = formatWorkloadPhases(response.WorkloadPhases)
+		instance = instanceTelemetryColumns(response)
+		timing = handlerTimingColumns(response)
+	case "openwhisk":
+		fallthrough
+	case "openfaas":
+		request := benchhttp.CreateRequestWithServiceTime(provider, payloadLengthBytes, gatewayEndpoint, incrementLimit, serviceTimeTarget, storageTransfer, route)
+		log.Debugf("Created HTTP request with URL (%q)", (*request).URL)
+
+		var respBody []byte
+		var respHeader http.Header
+		ok, respBody, respHeader, reqSentTime, reqReceivedTime = benchhttp.ExecuteRequestWithHeader(*request)
+		if !ok {
+			log.Errorf("Request failed, skipping...")
+			errorCount.Increment()
+			return
+		}
+		response := benchhttp.ExtractProducerConsumerResponse(respBody)
+
+		timestampChain = response.TimestampChain
+		hostname = request.URL.Hostname()
+		responseID = benchhttp.ResponseRequestID(provider, respHeader, response)
+		initDurationMs = formatReportedMilliseconds(response.InitDurationMilliseconds)
+		serviceTimeMs = formatReportedMilliseconds(response.ServiceTimeMilliseconds)
+		workloadPhasesMs = formatWorkloadPhases(response.WorkloadPhases)
+		instance = instanceTelemetryColumns(response)
+		timing = handlerTimingColumns(response)
 	default:
 		log.Fatalf("Unrecognized provider %q, benchmarking module cannot run.", provider)
 	}
 
+	timing.SentAtUs = strconv.FormatInt(reqSentTime.UnixMicro(), 10)
+	timing.ReceivedAtUs = strconv.FormatInt(reqReceivedTime.UnixMicro(), 10)
+
 	if dataTransfersWriter != nil {
 		dataTransfersWriter.WriteDataTransferRow(
 			responseID,
@@ -171,9 +292,64 @@ func executeRequestAndWriteResults(requestsWaitGroup *sync.WaitGroup, provider s
 		reqReceivedTime.Format(time.RFC3339),
 		strconv.FormatInt(reqReceivedTime.Sub(reqSentTime).Milliseconds(), 10),
 		strconv.Itoa(burstID),
+		initDurationMs,
+		sampledServiceTimeMs,
+		serviceTimeMs,
+		workloadPhasesMs,
+		instance,
+		timing,
+		strconv.FormatBool(forcedCold),
 	)
 }
 
+// formatReportedMilliseconds formats a duration reported by a function, left empty for functions not reporting it
+func formatReportedMilliseconds(durationMs float64) string {
+	if durationMs == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(durationMs, 'f', 3, 64)
+}
+
+// formatWorkloadPhases formats the phases of the workload reported by a function, e.g., "allocate=1.250 read=0.830 write=2.100"
+func formatWorkloadPhases(phasesMs map[string]float64) string {
+	phases := make([]string, 0, len(phasesMs))
+	for phase, durationMs := range phasesMs {
+		phases = append(phases, fmt.Sprintf("%s=%s", phase, strconv.FormatFloat(durationMs, 'f', 3, 64)))
+	}
+	sort.Strings(phases)
+	return strings.Join(phases, " ")
+}
+
+// instanceTelemetryColumns formats the telemetry of the instance that served the request, labelling the first request
+// served by an instance as its cold start
+func instanceTelemetryColumns(response benchhttp.ProducerConsumerResponse) writers.InstanceTelemetry {
+	if response.InstanceID == "" {
+		return writers.InstanceTelemetry{}
+	}
+	return writers.InstanceTelemetry{
+		ID:             response.InstanceID,
+		BootTimestamp:  strconv.FormatInt(response.InstanceBootTimestamp, 10),
+		RequestCount:   strconv.FormatInt(response.InstanceRequestCount, 10),
+		ColdStart:      strconv.FormatBool(response.InstanceRequestCount == 1),
+		RuntimeVersion: response.RuntimeVersion,
+		CPUModel:       response.CPUModel,
+		MemoryLimitMB:  strconv.FormatInt(response.MemoryLimitMB, 10),
+	}
+}
+
+// handlerTimingColumns formats the handler timestamps and execution duration reported by the function, left empty for
+// functions not reporting them
+func handlerTimingColumns(response benchhttp.ProducerConsumerResponse) writers.RequestTiming {
+	if response.HandlerEntryTimestampMicroseconds == 0 || response.HandlerExitTimestampMicroseconds == 0 {
+		return writers.RequestTiming{}
+	}
+	return writers.RequestTiming{
+		HandlerEntryUs: strconv.FormatInt(response.HandlerEntryTimestampMicroseconds, 10),
+		HandlerExitUs:  strconv.FormatInt(response.HandlerExitTimestampMicroseconds, 10),
+		ExecutionMs:    strconv.FormatFloat(response.ExecutionMilliseconds, 'f', 3, 64),
+	}
+}
+
 // stringArrayToArrayOfString will process, e.g., "[14 35 8]" into []string{14, 35, 8}
 func stringArrayToArrayOfString(str string) []string {
 	log.Debugf("stringArrayToArrayOfString argument was %q", str)
@@ -0,0 +1,312 @@
+package benchmarking
+
+import (
+	"encoding/csv"
+	"github.com/go-gota/gota/dataframe"
+	log "github.com/sirupsen/logrus"
+	"gonum.org/v1/gonum/stat"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"stellar/benchmarking/visualization"
+	"stellar/benchmarking/writers"
+	"stellar/setup"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// scaleOutTimeStep is the resolution of the time series of the scale-out experiment
+const scaleOutTimeStep = 100 * time.Millisecond
+
+// runScaleOut sends the requests of the scale-out sub-experiment to its first function at a constant arrival rate, without
+// waiting for the responses, so that the requests queue up while the function scales out. Every second of the run is
+// recorded as a burst.
+func runScaleOut(experiment setup.SubExperiment, provider string, latenciesWriter *writers.RTTLatencyWriter, dataTransferWriter *writers.DataTransferWriter) {
+	var serviceTimeSampler *setup.ServiceTimeSampler
+	if experiment.ServiceTimeDistribution != nil {
+		var err error
+		if serviceTimeSampler, err = experiment.ServiceTimeDistribution.NewSampler(); err != nil {
+			log.Fatalf("[sub-experiment %d] Could not sample service times: %s", experiment.ID, err.Error())
+		}
+	}
+	var incrementLimit int64
+	if len(experiment.BusySpinIncrements) > 0 {
+		incrementLimit = experiment.BusySpinIncrements[0]
+	}
+
+	requests := experiment.ScaleOutRequests()
+	interval := time.Duration(float64(time.Second) / experiment.ArrivalRate)
+	log.Infof("[sub-experiment %d] Starting scale-out, making %d requests at %v requests/s over %vs to gateway with ID %q of provider %q.",
+		experiment.ID, requests, experiment.ArrivalRate, experiment.DurationSeconds, experiment.Endpoints[0].ID, provider)
+
+	errorCount := ErrorCount{}
+	var requestsWaitGroup sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		sendAt := start.Add(time.Duration(i) * interval)
+		time.Sleep(time.Until(sendAt))
+
+		requestIncrementLimit, serviceTimeTarget, sampledServiceTimeMs := requestServiceTime(experiment, experiment.Endpoints[0],
+			incrementLimit, experiment.DesiredServiceTimeMilliseconds(0), serviceTimeSampler)
+
+		requestsWaitGroup.Add(1)
+		go executeRequestAndWriteResults(&requestsWaitGroup, provider, requestIncrementLimit, serviceTimeTarget, sampledServiceTimeMs,
+			latenciesWriter, dataTransferWriter, int(sendAt.Sub(start)/time.Second), experiment.PayloadLengthBytes, experiment.Endpoints[0],
+			experiment.StorageTransfer, experiment.Routes[0], experiment.ForceCold, &errorCount)
+	}
+
+	log.Infof("[sub-experiment %d] Sent all requests in %v, waiting for the responses.", experiment.ID, time.Since(start))
+	requestsWaitGroup.Wait()
+	if errs := errorCount.Read(); errs > 0 {
+		log.Warnf("[sub-experiment %d] %d of %d requests failed, e.g., throttled while the function scaled out.", experiment.ID, errs, requests)
+	}
+
+	latenciesWriter.Writer.Flush()
+	if dataTransferWriter != nil {
+		dataTransferWriter.Writer.Flush()
+	}
+}
+
+// servedRequest is a request of the scale-out experiment served by a reported instance, its times in microseconds on
+// the clock of the client
+type servedRequest struct {
+	requestID         string
+	instanceID        string
+	coldStart         string
+	sentAt            float64
+	handlerEntry      float64
+	handlerExit       float64
+	queueingDelayMs   float64
+	requestPathMs     float64
+	instanceFirstSeen bool
+}
+
+// analyzeScaleOut derives from the instances reported by the functions a time series of the distinct instances active
+// and seen so far, the time taken until TargetInstances serve concurrently and the queueing delay of every request,
+// i.e., its request-path overhead beyond the lowest one of the run, including the provisioning of new instances.
+func analyzeScaleOut(experiment setup.SubExperiment, latenciesDF dataframe.DataFrame, experimentDirectoryPath string) {
+	if !hasColumn(latenciesDF, "Request Path Overhead (ms)") || !hasColumn(latenciesDF, "Instance ID") {
+		log.Warnf("[sub-experiment %d] The functions did not report their instances and handler timestamps, skipping scale-out analysis", experiment.ID)
+		return
+	}
+
+	requestIDs := latenciesDF.Col("Request ID").Records()
+	instanceIDs := latenciesDF.Col("Instance ID").Records()
+	coldStarts := latenciesDF.Col("Cold Start").Records()
+	sentAt := parseColumn(latenciesDF, "Sent At (us)")
+	receivedAt := parseColumn(latenciesDF, "Received At (us)")
+	requestPathMs := parseColumn(latenciesDF, "Request Path Overhead (ms)")
+	responsePathMs := parseColumn(latenciesDF, "Response Path Overhead (ms)")
+
+	start := math.Inf(1)
+	for _, sent := range sentAt {
+		start = math.Min(start, sent)
+	}
+
+	var served []servedRequest
+	lowestRequestPathMs := math.Inf(1)
+	for i := range requestIDs {
+		if math.IsNaN(requestPathMs[i]) || math.IsNaN(responsePathMs[i]) || instanceIDs[i] == "" || instanceIDs[i] == "NaN" {
+			continue
+		}
+		served = append(served, servedRequest{
+			requestID:     requestIDs[i],
+			instanceID:    instanceIDs[i],
+			coldStart:     coldStarts[i],
+			sentAt:        sentAt[i] - start,
+			handlerEntry:  sentAt[i] + requestPathMs[i]*1000 - start,
+			handlerExit:   receivedAt[i] - responsePathMs[i]*1000 - start,
+			requestPathMs: requestPathMs[i],
+		})
+		lowestRequestPathMs = math.Min(lowestRequestPathMs, requestPathMs[i])
+	}
+	if len(served) == 0 {
+		log.Warnf("[sub-experiment %d] No request reported its instance, skipping scale-out analysis", experiment.ID)
+		return
+	}
+
+	sort.Slice(served, func(i, j int) bool { return served[i].handlerEntry < served[j].handlerEntry })
+	seenInstances := make(map[string]bool)
+	for i := range served {
+		served[i].queueingDelayMs = served[i].requestPathMs - lowestRequestPathMs
+		if !seenInstances[served[i].instanceID] {
+			seenInstances[served[i].instanceID] = true
+			served[i].instanceFirstSeen = true
+		}
+	}
+
+	series := scaleOutSeries(served)
+	peakActiveInstances, timeToTargetActive := concurrentInstances(served, experiment.TargetInstances)
+	timeToTargetUnique := math.NaN()
+	if experiment.TargetInstances > 0 {
+		for i, unique := 0, 0; i < len(served); i++ {
+			if served[i].instanceFirstSeen {
+				if unique++; unique == experiment.TargetInstances {
+					timeToTargetUnique = served[i].handlerEntry
+					break
+				}
+			}
+		}
+	}
+
+	log.Infof("[sub-experiment %d] %d requests were served by %d instances, at most %d of them concurrently.", experiment.ID,
+		len(served), len(seenInstances), peakActiveInstances)
+	if experiment.TargetInstances > 0 && math.IsNaN(timeToTargetActive) {
+		log.Warnf("[sub-experiment %d] The function never had %d instances serving concurrently.", experiment.ID, experiment.TargetInstances)
+	} else if experiment.TargetInstances > 0 {
+		log.Infof("[sub-experiment %d] %d instances were serving concurrently after %ss.", experiment.ID, experiment.TargetInstances,
+			formatSeconds(timeToTargetActive))
+	}
+
+	writeScaleOutRequests(experiment, served, filepath.Join(experimentDirectoryPath, "scale-out-requests.csv"))
+	writeScaleOutSeries(experiment, series, filepath.Join(experimentDirectoryPath, "scale-out.csv"))
+	writeScaleOutSummary(experiment, []string{
+		strconv.Itoa(len(requestIDs)),
+		strconv.Itoa(len(served)),
+		strconv.Itoa(len(seenInstances)),
+		strconv.Itoa(peakActiveInstances),
+		strconv.Itoa(experiment.TargetInstances),
+		formatSeconds(timeToTargetActive),
+		formatSeconds(timeToTargetUnique),
+	}, filepath.Join(experimentDirectoryPath, "scale-out-summary.csv"))
+
+	if experiment.Visualization != "none" {
+		visualization.GenerateScaleOut(experiment, series, experimentDirectoryPath)
+	}
+}
+
+// scaleOutSeries counts, for every time step of the run, the requests sent, the distinct instances serving a request
+// and the distinct instances seen so far, as well as the median queueing delay of the requests sent
+func scaleOutSeries(served []servedRequest) visualization.ScaleOutSeries {
+	step := float64(scaleOutTimeStep.Microseconds())
+	end := 0.
+	for _, request := range served {
+		end = math.Max(end, request.handlerExit)
+	}
+
+	var series visualization.ScaleOutSeries
+	for _, request := range served {
+		series.RequestSentAtSeconds = append(series.RequestSentAtSeconds, request.sentAt/1e6)
+		series.QueueingDelaysMs = append(series.QueueingDelaysMs, request.queueingDelayMs)
+	}
+	for stepStart := 0.; stepStart <= end; stepStart += step {
+		stepEnd := stepStart + step
+		activeInstances := make(map[string]bool)
+		uniqueInstances := 0
+		sent := 0
+		var queueingDelaysMs []float64
+		for _, request := range served {
+			if request.handlerEntry < stepEnd && request.handlerExit >= stepStart {
+				activeInstances[request.instanceID] = true
+			}
+			if request.instanceFirstSeen && request.handlerEntry < stepEnd {
+				uniqueInstances++
+			}
+			if request.sentAt >= stepStart && request.sentAt < stepEnd {
+				sent++
+				queueingDelaysMs = append(queueingDelaysMs, request.queueingDelayMs)
+			}
+		}
+
+		medianQueueingDelayMs := math.NaN()
+		if len(queueingDelaysMs) > 0 {
+			sort.Float64s(queueingDelaysMs)
+			medianQueueingDelayMs = stat.Quantile(0.5, stat.Empirical, queueingDelaysMs, nil)
+		}
+		series.TimeSeconds = append(series.TimeSeconds, stepStart/1e6)
+		series.SentRequests = append(series.SentRequests, float64(sent))
+		series.ActiveInstances = append(series.ActiveInstances, float64(len(activeInstances)))
+		series.UniqueInstances = append(series.UniqueInstances, float64(uniqueInstances))
+		series.MedianQueueingDelaysMs = append(series.MedianQueueingDelaysMs, medianQueueingDelayMs)
+	}
+	return series
+}
+
+// concurrentInstances sweeps the handler intervals of the requests and returns the highest number of distinct instances
+// serving concurrently and the time in microseconds at which the target number of them was first reached, NaN if never
+func concurrentInstances(served []servedRequest, targetInstances int) (int, float64) {
+	type event struct {
+		time       float64
+		instanceID string
+		delta      int
+	}
+	events := make([]event, 0, 2*len(served))
+	for _, request := range served {
+		events = append(events, event{request.handlerEntry, request.instanceID, 1}, event{request.handlerExit, request.instanceID, -1})
+	}
+	// Exits are counted before entries at the same time, so that instances are only concurrent when their requests overlap
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].time == events[j].time {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].time < events[j].time
+	})
+
+	openRequests := make(map[string]int)
+	peak, timeToTarget := 0, math.NaN()
+	for _, e := range events {
+		openRequests[e.instanceID] += e.delta
+		if openRequests[e.instanceID] == 0 {
+			delete(openRequests, e.instanceID)
+		}
+		if len(openRequests) > peak {
+			peak = len(openRequests)
+		}
+		if targetInstances > 0 && math.IsNaN(timeToTarget) && len(openRequests) >= targetInstances {
+			timeToTarget = e.time
+		}
+	}
+	return peak, timeToTarget
+}
+
+func writeScaleOutRequests(experiment setup.SubExperiment, served []servedRequest, path string) {
+	rows := [][]string{{"Request ID", "Instance ID", "Cold Start", "Sent At (s)", "Handler Entry (s)", "Handler Exit (s)", "Queueing Delay (ms)"}}
+	sort.Slice(served, func(i, j int) bool { return served[i].sentAt < served[j].sentAt })
+	for _, request := range served {
+		rows = append(rows, []string{request.requestID, request.instanceID, request.coldStart, formatSeconds(request.sentAt),
+			formatSeconds(request.handlerEntry), formatSeconds(request.handlerExit), formatMilliseconds(request.queueingDelayMs)})
+	}
+	writeScaleOutFile(experiment, rows, path)
+}
+
+func writeScaleOutSeries(experiment setup.SubExperiment, series visualization.ScaleOutSeries, path string) {
+	rows := [][]string{{"Time (s)", "Sent Requests", "Active Instances", "Unique Instances", "Median Queueing Delay (ms)"}}
+	for i := range series.TimeSeconds {
+		medianQueueingDelayMs := ""
+		if !math.IsNaN(series.MedianQueueingDelaysMs[i]) {
+			medianQueueingDelayMs = formatMilliseconds(series.MedianQueueingDelaysMs[i])
+		}
+		rows = append(rows, []string{strconv.FormatFloat(series.TimeSeconds[i], 'f', 1, 64), strconv.Itoa(int(series.SentRequests[i])),
+			strconv.Itoa(int(series.ActiveInstances[i])), strconv.Itoa(int(series.UniqueInstances[i])), medianQueueingDelayMs})
+	}
+	writeScaleOutFile(experiment, rows, path)
+}
+
+func writeScaleOutSummary(experiment setup.SubExperiment, record []string, path string) {
+	writeScaleOutFile(experiment, [][]string{{"Requests", "Served Requests", "Unique Instances", "Peak Active Instances",
+		"Target Instances", "Time To Target Active Instances (s)", "Time To Target Unique Instances (s)"}, record}, path)
+}
+
+func writeScaleOutFile(experiment setup.SubExperiment, rows [][]string, path string) {
+	log.Debugf("[sub-experiment %d] Writing scale-out results to `%s`", experiment.ID, path)
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not create scale-out results file: %s", experiment.ID, err.Error())
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		log.Errorf("[sub-experiment %d] Could not write scale-out results to file: %s", experiment.ID, err.Error())
+	}
+}
+
+// formatSeconds formats a time in microseconds since the start of the run in seconds, left empty for NaN
+func formatSeconds(timeUs float64) string {
+	if math.IsNaN(timeUs) {
+		return ""
+	}
+	return strconv.FormatFloat(timeUs/1e6, 'f', 3, 64)
+}
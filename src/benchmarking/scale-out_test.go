@@ -0,0 +1,119 @@
+package benchmarking
+
+import (
+	"github.com/go-gota/gota/dataframe"
+	"github.com/stretchr/testify/require"
+	"math"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"strings"
+	"testing"
+)
+
+// handlerInterval returns a request served by the instance between the given times in microseconds
+func handlerInterval(instanceID string, handlerEntry float64, handlerExit float64) servedRequest {
+	return servedRequest{instanceID: instanceID, handlerEntry: handlerEntry, handlerExit: handlerExit}
+}
+
+func TestConcurrentInstances(t *testing.T) {
+	for _, test := range []struct {
+		name                 string
+		served               []servedRequest
+		targetInstances      int
+		expectedPeak         int
+		expectedTimeToTarget float64
+	}{
+		{
+			name:                 "overlapping instances",
+			served:               []servedRequest{handlerInterval("a", 0, 100), handlerInterval("b", 50, 150), handlerInterval("c", 120, 200)},
+			targetInstances:      2,
+			expectedPeak:         2,
+			expectedTimeToTarget: 50,
+		},
+		{
+			name:                 "requests ending as others start are not concurrent",
+			served:               []servedRequest{handlerInterval("a", 0, 100), handlerInterval("b", 100, 200)},
+			targetInstances:      2,
+			expectedPeak:         1,
+			expectedTimeToTarget: math.NaN(),
+		},
+		{
+			name:                 "concurrent requests of one instance",
+			served:               []servedRequest{handlerInterval("a", 0, 100), handlerInterval("a", 10, 90), handlerInterval("b", 95, 120)},
+			targetInstances:      2,
+			expectedPeak:         2,
+			expectedTimeToTarget: 95,
+		},
+		{
+			name:                 "no target",
+			served:               []servedRequest{handlerInterval("a", 0, 100), handlerInterval("b", 50, 150)},
+			expectedPeak:         2,
+			expectedTimeToTarget: math.NaN(),
+		},
+		{
+			name:                 "no request",
+			targetInstances:      1,
+			expectedTimeToTarget: math.NaN(),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			peak, timeToTarget := concurrentInstances(test.served, test.targetInstances)
+			require.Equal(t, test.expectedPeak, peak)
+			if math.IsNaN(test.expectedTimeToTarget) {
+				require.True(t, math.IsNaN(timeToTarget), "the target should never be reached, got %v", timeToTarget)
+			} else {
+				require.Equal(t, test.expectedTimeToTarget, timeToTarget)
+			}
+		})
+	}
+}
+
+func TestAnalyzeScaleOut(t *testing.T) {
+	directory := t.TempDir()
+	// The second request waits 100ms longer than the others on its request path, while a new instance is provisioned,
+	// and the last one does not report its instance
+	latenciesDF := dataframe.ReadCSV(strings.NewReader(`Request ID,Instance ID,Cold Start,Sent At (us),Received At (us),Request Path Overhead (ms),Response Path Overhead (ms)
+first,a,true,1000000,1202000,1,1
+second,b,true,1050000,1352000,101,1
+third,a,false,1300000,1502000,1,1
+unreported,,,1400000,1500000,,
+`))
+
+	analyzeScaleOut(setup.SubExperiment{TargetInstances: 2, Visualization: "none"}, latenciesDF, directory)
+
+	summary, err := os.ReadFile(filepath.Join(directory, "scale-out-summary.csv"))
+	require.NoError(t, err)
+	require.Equal(t, `Requests,Served Requests,Unique Instances,Peak Active Instances,Target Instances,Time To Target Active Instances (s),Time To Target Unique Instances (s)
+4,3,2,2,2,0.151,0.151
+`, string(summary))
+
+	requests, err := os.ReadFile(filepath.Join(directory, "scale-out-requests.csv"))
+	require.NoError(t, err)
+	require.Equal(t, `Request ID,Instance ID,Cold Start,Sent At (s),Handler Entry (s),Handler Exit (s),Queueing Delay (ms)
+first,a,true,0.000,0.001,0.201,0.000
+second,b,true,0.050,0.151,0.351,100.000
+third,a,false,0.300,0.301,0.501,0.000
+`, string(requests))
+
+	series, err := os.ReadFile(filepath.Join(directory, "scale-out.csv"))
+	require.NoError(t, err)
+	require.Equal(t, `Time (s),Sent Requests,Active Instances,Unique Instances,Median Queueing Delay (ms)
+0.0,2,1,1,0.000
+0.1,0,2,2,
+0.2,0,2,2,
+0.3,1,2,2,0.000
+0.4,0,1,2,
+0.5,0,1,2,
+`, string(series))
+}
+
+func TestAnalyzeScaleOutWithoutInstances(t *testing.T) {
+	directory := t.TempDir()
+	latenciesDF := dataframe.ReadCSV(strings.NewReader(`Request ID,Instance ID,Cold Start,Sent At (us),Received At (us),Request Path Overhead (ms),Response Path Overhead (ms)
+first,,,1000000,1202000,,
+`))
+
+	analyzeScaleOut(setup.SubExperiment{TargetInstances: 2, Visualization: "none"}, latenciesDF, directory)
+	require.NoFileExists(t, filepath.Join(directory, "scale-out-summary.csv"))
+}
@@ -23,6 +23,7 @@
 package benchmarking
 
 import (
+	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"math"
@@ -31,20 +32,28 @@ import (
 	"path/filepath"
 	"stellar/benchmarking/writers"
 	"stellar/setup"
+	"strings"
 	"sync"
 	"time"
 )
 
 // TriggerSubExperiments will run the sub-experiments specified by the passed configuration object. It creates
-// a directory for each sub-experiment, as well as separate visualizations and latency files.
+// a directory for each sub-experiment, as well as separate visualizations and latency files. Once all sub-experiments
+// are done, a summary comparing them across providers and regions is written to the output directory.
 func TriggerSubExperiments(config setup.Configuration, outputDirectoryPath string, specificExperiment int) {
 	var experimentsWaitGroup sync.WaitGroup
+	summary := &comparisonSummary{}
+	mixedProviders := len(config.Providers()) > 1
+	calibrationCache, err := setup.LoadServiceTimeCalibrationCache(ServiceTimeCalibrationCachePath)
+	if err != nil {
+		log.Fatalf("Could not load service time calibration cache `%s`: %s", ServiceTimeCalibrationCachePath, err.Error())
+	}
 
 	switch specificExperiment {
 	case -1: // run all experiments
 		for experimentIndex := 0; experimentIndex < len(config.SubExperiments); experimentIndex++ {
 			experimentsWaitGroup.Add(1)
-			go triggerSubExperiment(&experimentsWaitGroup, config.Provider, config.SubExperiments[experimentIndex], outputDirectoryPath)
+			go triggerSubExperiment(&experimentsWaitGroup, config.SubExperiments[experimentIndex], outputDirectoryPath, mixedProviders, summary, calibrationCache)
 
 			if config.Sequential {
 				experimentsWaitGroup.Wait()
@@ -56,17 +65,22 @@ func TriggerSubExperiments(config setup.Configuration, outputDirectoryPath strin
 		}
 
 		experimentsWaitGroup.Add(1)
-		go triggerSubExperiment(&experimentsWaitGroup, config.Provider, config.SubExperiments[specificExperiment], outputDirectoryPath)
+		go triggerSubExperiment(&experimentsWaitGroup, config.SubExperiments[specificExperiment], outputDirectoryPath, mixedProviders, summary, calibrationCache)
 	}
 
 	experimentsWaitGroup.Wait()
+
+	summary.write(outputDirectoryPath)
 }
 
-func triggerSubExperiment(experimentsWaitGroup *sync.WaitGroup, provider string, experiment setup.SubExperiment, outputDirectoryPath string) {
+func triggerSubExperiment(experimentsWaitGroup *sync.WaitGroup, experiment setup.SubExperiment, outputDirectoryPath string, mixedProviders bool,
+	summary *comparisonSummary, calibrationCache *setup.ServiceTimeCalibrationCache) {
 	log.Infof("[sub-experiment %d] Starting...", experiment.ID)
 	defer experimentsWaitGroup.Done()
 
-	experimentDirectoryPath, latenciesFile, statisticsFile, dataTransfersFile := createSubExperimentOutput(outputDirectoryPath, experiment)
+	calibrateServiceTimes(&experiment, calibrationCache)
+
+	experimentDirectoryPath, latenciesFile, statisticsFile, dataTransfersFile := createSubExperimentOutput(outputDirectoryPath, experiment, mixedProviders)
 	defer latenciesFile.Close()
 	defer statisticsFile.Close()
 	if dataTransfersFile != nil {
@@ -82,17 +96,34 @@ func triggerSubExperiment(experimentsWaitGroup *sync.WaitGroup, provider string,
 	latenciesWriter := writers.NewRTTLatencyWriter(latenciesFile)
 	dataTransferWriter := writers.NewDataTransferWriter(dataTransfersFile, experiment.DataTransferChainLength)
 
-	runSubExperiment(experiment, burstDeltas, provider, latenciesWriter, dataTransferWriter)
+	switch experiment.ExperimentType {
+	case setup.ScaleOutExperiment:
+		runScaleOut(experiment, experiment.Provider, latenciesWriter, dataTransferWriter)
+	case setup.PowerTuningExperiment:
+		runPowerTuning(experiment, experiment.Provider, latenciesWriter, dataTransferWriter)
+	default:
+		runSubExperiment(experiment, burstDeltas, experiment.Provider, latenciesWriter, dataTransferWriter)
+	}
 
-	postProcessing(experiment, latenciesFile, burstDeltas, experimentDirectoryPath, statisticsFile)
+	sortedLatencies := postProcessing(experiment, latenciesFile, burstDeltas, experimentDirectoryPath, statisticsFile)
+	summary.add(experiment, sortedLatencies)
 
 	log.Infof("[sub-experiment %d] Successfully finished.", experiment.ID)
 }
 
-func createSubExperimentOutput(path string, experiment setup.SubExperiment) (string, *os.File, *os.File, *os.File) {
+func createSubExperimentOutput(path string, experiment setup.SubExperiment, mixedProviders bool) (string, *os.File, *os.File, *os.File) {
 	detailedTitle := fmt.Sprintf("%s-memory%dMB-img%dMB-IAT%vs-burst%d-st%s-payload%dKB", experiment.Title,
 		int(experiment.FunctionMemoryMB), int(experiment.FunctionImageSizeMB), experiment.IATSeconds, experiment.BurstSizes[0],
-		experiment.DesiredServiceTimes[0], experiment.PayloadLengthBytes/1024.0)
+		experiment.ServiceTimeLabel(), experiment.PayloadLengthBytes/1024.0)
+	if experiment.Region != "" {
+		detailedTitle = fmt.Sprintf("%s-%s", detailedTitle, strings.ReplaceAll(experiment.Region, " ", "-"))
+	}
+	if experiment.Architecture != "" && experiment.Architecture != "x86_64" {
+		detailedTitle = fmt.Sprintf("%s-%s", detailedTitle, experiment.Architecture)
+	}
+	if mixedProviders {
+		detailedTitle = fmt.Sprintf("%s-%s", experiment.Provider, detailedTitle)
+	}
 
 	directoryPath := filepath.Join(path, detailedTitle)
 	log.Infof("[sub-experiment %d] Creating directory at `%s`", experiment.ID, directoryPath)
@@ -100,6 +131,8 @@ func createSubExperimentOutput(path string, experiment setup.SubExperiment) (str
 		log.Fatal(err)
 	}
 
+	writeSubExperimentConfiguration(directoryPath, experiment)
+
 	latenciesPath := filepath.Join(directoryPath, "latencies.csv")
 	log.Infof("[sub-experiment %d] Creating latencies file at `%s`", experiment.ID, latenciesPath)
 	latenciesFile, err := os.Create(latenciesPath)
@@ -127,6 +160,22 @@ func createSubExperimentOutput(path string, experiment setup.SubExperiment) (str
 	return directoryPath, latenciesFile, statisticsFile, nil
 }
 
+// writeSubExperimentConfiguration records the sub-experiment, including the settings applied by the provider,
+// next to its results so that runs on different providers can be compared
+func writeSubExperimentConfiguration(directoryPath string, experiment setup.SubExperiment) {
+	configurationPath := filepath.Join(directoryPath, "configuration.json")
+	log.Infof("[sub-experiment %d] Writing applied configuration to `%s`", experiment.ID, configurationPath)
+
+	configurationBytes, err := json.MarshalIndent(experiment, "", "  ")
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not serialize configuration: %s", experiment.ID, err.Error())
+	}
+
+	if err := os.WriteFile(configurationPath, configurationBytes, 0644); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not write configuration file: %s", experiment.ID, err.Error())
+	}
+}
+
 func generateIAT(experiment setup.SubExperiment) []time.Duration {
 	step := 1.0
 	maxStep := experiment.IATSeconds
@@ -47,9 +47,13 @@ func Generate(experiment setup.SubExperiment, deltas []time.Duration, latenciesD
 		generateCDFs(experiment, sortedLatencies, path)
 		generateHistograms(experiment, latenciesDF, path, deltas)
 		generateBarCharts(experiment, latenciesDF, defaultColdThreshold, path)
+		generateLatencyDecompositionCharts(experiment, latenciesDF, path)
 	case "bar":
 		log.Infof("[sub-experiment %d] Generating burst bar chart visualization", experiment.ID)
 		generateBarCharts(experiment, latenciesDF, defaultColdThreshold, path)
+	case "decomposition":
+		log.Infof("[sub-experiment %d] Generating latency decomposition bar chart visualization", experiment.ID)
+		generateLatencyDecompositionCharts(experiment, latenciesDF, path)
 	case "cdf":
 		log.Infof("[sub-experiment %d] Generating CDF visualization", experiment.ID)
 		generateCDFs(experiment, sortedLatencies, path)
@@ -82,6 +86,17 @@ func generateBarCharts(experiment setup.SubExperiment, latenciesDF dataframe.Dat
 	plotBurstsBarChart(filepath.Join(path, "bursts_characterization.png"), experiment, coldThreshold, latenciesDF)
 }
 
+func generateLatencyDecompositionCharts(experiment setup.SubExperiment, latenciesDF dataframe.DataFrame, path string) {
+	for _, name := range latenciesDF.Names() {
+		if name == "Request Path Overhead (ms)" {
+			log.Debugf("[sub-experiment %d] Plotting latency decomposition bar chart", experiment.ID)
+			plotBurstsLatencyDecomposition(filepath.Join(path, "bursts_latency_decomposition.png"), experiment, latenciesDF)
+			return
+		}
+	}
+	log.Warnf("[sub-experiment %d] The functions did not report handler timestamps, skipping latency decomposition bar chart", experiment.ID)
+}
+
 func generateHistograms(experiment setup.SubExperiment, latenciesDF dataframe.DataFrame, path string, deltas []time.Duration) {
 	histogramsDirectoryPath := filepath.Join(path, "histograms")
 	log.Infof("[sub-experiment %d] Creating directory for histograms at `%s`", experiment.ID, histogramsDirectoryPath)
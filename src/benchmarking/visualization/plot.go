@@ -32,6 +32,8 @@ import (
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
+	"math"
+	"sort"
 	"stellar/setup"
 	"strings"
 	"time"
@@ -55,10 +57,12 @@ func plotBurstsBarChart(plotPath string, experiment setup.SubExperiment, coldThr
 		// sort.Float64s(burstLatencies)
 		// coldThreshold := stat.Quantile(0.8, stat.Empirical, burstLatencies, nil)
 
+		burstColdStarts := reportedColdStarts(burstDF)
+
 		burstColdResponses := 0
 		burstWarmResponses := 0
-		for _, burst := range burstLatencies {
-			if burst >= coldThreshold {
+		for i, burst := range burstLatencies {
+			if isColdResponse(burstColdStarts, i, burst, coldThreshold) {
 				burstColdResponses++
 			} else {
 				burstWarmResponses++
@@ -105,6 +109,97 @@ func plotBurstsBarChart(plotPath string, experiment setup.SubExperiment, coldThr
 	}
 }
 
+// reportedColdStarts returns the "Cold Start" labels reported by the functions for each request, nil if the latencies
+// file does not have them
+func reportedColdStarts(latenciesDF dataframe.DataFrame) []string {
+	for _, name := range latenciesDF.Names() {
+		if name == "Cold Start" {
+			return latenciesDF.Col(name).Records()
+		}
+	}
+	return nil
+}
+
+// isColdResponse labels the request as cold from the instance telemetry reported by the function, falling back to the
+// latency threshold for functions not reporting it
+func isColdResponse(coldStarts []string, index int, latency float64, coldThreshold float64) bool {
+	if index < len(coldStarts) {
+		switch coldStarts[index] {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return latency >= coldThreshold
+}
+
+// plotBurstsLatencyDecomposition plots, for every burst, the median request-path overhead, execution and response-path
+// overhead of its requests stacked on each other
+func plotBurstsLatencyDecomposition(plotPath string, experiment setup.SubExperiment, latenciesDF dataframe.DataFrame) {
+	plotInstance := plot.New()
+
+	plotInstance.Title.Text = fmt.Sprintf("Bursts Latency Decomposition (medians, cooldown ~%vs)", experiment.IATSeconds)
+	plotInstance.X.Label.Text = "Burst Sizes (Sequential)"
+	plotInstance.Y.Label.Text = "Latency (ms)"
+
+	components := []string{"Request Path Overhead (ms)", "Execution (ms)", "Response Path Overhead (ms)"}
+	medians := make([]plotter.Values, len(components))
+	for burstIndex := 0; burstIndex < experiment.Bursts; burstIndex++ {
+		burstDF := latenciesDF.Filter(dataframe.F{Colname: "Burst ID", Comparator: series.Eq, Comparando: burstIndex})
+		for i, component := range components {
+			medians[i] = append(medians[i], math.Max(0, reportedMedian(burstDF.Col(component).Float())))
+		}
+	}
+
+	w := vg.Points(20)
+	var below *plotter.BarChart
+	for i, component := range components {
+		bars, err := plotter.NewBarChart(medians[i], w)
+		if err != nil {
+			log.Errorf("[sub-experiment %d] Could not plot %s bars in latency decomposition bar chart: %s", experiment.ID,
+				component, err.Error())
+			return
+		}
+		bars.LineStyle.Width = vg.Length(0)
+		bars.Color = plotutil.Color(i)
+		if below != nil {
+			bars.StackOn(below)
+		}
+		below = bars
+
+		plotInstance.Add(bars)
+		plotInstance.Legend.Add(strings.TrimSuffix(component, " (ms)"), bars)
+	}
+	plotInstance.Legend.Left = true
+	plotInstance.Legend.Top = true
+
+	augmentedBurstSizes := experiment.BurstSizes
+	for i := experiment.Bursts - len(experiment.BurstSizes); i > 0; i-- {
+		augmentedBurstSizes = append(augmentedBurstSizes, experiment.BurstSizes[len(experiment.BurstSizes)-1])
+	}
+	plotInstance.NominalX(strings.Split(strings.Trim(fmt.Sprint(augmentedBurstSizes), "[]"), " ")...)
+
+	if err := plotInstance.Save(10*vg.Inch, 5*vg.Inch, plotPath); err != nil {
+		log.Errorf("[sub-experiment %d] Could not save latency decomposition bar chart: %s", experiment.ID, err.Error())
+	}
+}
+
+// reportedMedian returns the median of the values reported by the functions, 0 if none were
+func reportedMedian(values []float64) float64 {
+	reported := make([]float64, 0, len(values))
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			reported = append(reported, value)
+		}
+	}
+	if len(reported) == 0 {
+		return 0
+	}
+	sort.Float64s(reported)
+	return stat.Quantile(0.5, stat.Empirical, reported, nil)
+}
+
 func plotBurstLatenciesHistogram(plotPath string, burstLatencies []float64, burstIndex int, duration time.Duration) {
 	plotInstance := plot.New()
 
@@ -0,0 +1,140 @@
+package visualization
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"math"
+	"path/filepath"
+	"sort"
+	"stellar/setup"
+)
+
+// PowerTuningResult summarizes the latencies and costs of the requests of a power-tuning sub-experiment at one memory size
+type PowerTuningResult struct {
+	MemoryMB             int64
+	WarmRequests         int
+	WarmMedianMs         float64
+	WarmP95Ms            float64
+	WarmBilledDurationMs float64
+	WarmCostUSD          float64
+	ColdRequests         int
+	ColdMedianMs         float64
+	ColdP95Ms            float64
+	ColdBilledDurationMs float64
+	ColdCostUSD          float64
+	ParetoOptimal        bool
+}
+
+// GeneratePowerTuning plots the warm cost and latency of every memory size of the power-tuning sub-experiment with their
+// Pareto frontier and recommended setting, and the warm and cold latencies by memory size
+func GeneratePowerTuning(experiment setup.SubExperiment, results []PowerTuningResult, recommended int, path string) {
+	log.Infof("[sub-experiment %d] Generating power-tuning visualizations", experiment.ID)
+	plotPowerTuningFrontier(filepath.Join(path, "power_tuning_frontier.png"), experiment, results, recommended)
+	plotPowerTuningLatencies(filepath.Join(path, "power_tuning_latencies.png"), experiment, results)
+}
+
+func plotPowerTuningFrontier(plotPath string, experiment setup.SubExperiment, results []PowerTuningResult, recommended int) {
+	plotInstance := plot.New()
+	plotInstance.Title.Text = fmt.Sprintf("%v\nCost/performance of memory sizes (warm requests)", experiment.Title)
+	plotInstance.X.Label.Text = "Cost per 1M Invocations (USD)"
+	plotInstance.Y.Label.Text = "95th Percentile Latency (ms)"
+	plotInstance.Y.Min = 0.
+
+	var points, frontier plotter.XYs
+	var labels []string
+	for _, result := range results {
+		if math.IsNaN(result.WarmCostUSD) {
+			continue
+		}
+		point := plotter.XY{X: result.WarmCostUSD * 1e6, Y: result.WarmP95Ms}
+		points = append(points, point)
+		labels = append(labels, fmt.Sprintf("%dMB", result.MemoryMB))
+		if result.ParetoOptimal {
+			frontier = append(frontier, point)
+		}
+	}
+	if len(points) == 0 {
+		log.Warnf("[sub-experiment %d] No warm requests to plot the power-tuning frontier", experiment.ID)
+		return
+	}
+	sort.Slice(frontier, func(i, j int) bool { return frontier[i].X < frontier[j].X })
+
+	scatter, err := plotter.NewScatter(points)
+	if err != nil {
+		log.Errorf("[sub-experiment %d] Could not plot memory sizes: %s", experiment.ID, err.Error())
+		return
+	}
+	memoryLabels, err := plotter.NewLabels(plotter.XYLabels{XYs: points, Labels: labels})
+	if err != nil {
+		log.Errorf("[sub-experiment %d] Could not label memory sizes: %s", experiment.ID, err.Error())
+		return
+	}
+	plotInstance.Add(scatter, memoryLabels)
+	plotInstance.Legend.Add("Memory Sizes", scatter)
+
+	lines := []interface{}{"Pareto Frontier", frontier}
+	if experiment.LatencyTargetMs > 0 {
+		minCost, maxCost := math.Inf(1), math.Inf(-1)
+		for _, point := range points {
+			minCost, maxCost = math.Min(minCost, point.X), math.Max(maxCost, point.X)
+		}
+		lines = append(lines, "Latency Target", plotter.XYs{{X: minCost, Y: experiment.LatencyTargetMs}, {X: maxCost, Y: experiment.LatencyTargetMs}})
+	}
+	if err := plotutil.AddLines(plotInstance, lines...); err != nil {
+		log.Errorf("[sub-experiment %d] Could not add lines to power-tuning frontier plot: %s", experiment.ID, err.Error())
+		return
+	}
+
+	if recommended >= 0 {
+		recommendedScatter, err := plotter.NewScatter(plotter.XYs{{X: results[recommended].WarmCostUSD * 1e6, Y: results[recommended].WarmP95Ms}})
+		if err != nil {
+			log.Errorf("[sub-experiment %d] Could not plot recommended memory size: %s", experiment.ID, err.Error())
+			return
+		}
+		recommendedScatter.GlyphStyle.Radius = vg.Points(5)
+		recommendedScatter.GlyphStyle.Color = plotutil.Color(3)
+		plotInstance.Add(recommendedScatter)
+		plotInstance.Legend.Add(fmt.Sprintf("Recommended (%dMB)", results[recommended].MemoryMB), recommendedScatter)
+	}
+	plotInstance.Legend.Top = true
+
+	if err := plotInstance.Save(10*vg.Inch, 5*vg.Inch, plotPath); err != nil {
+		log.Errorf("[sub-experiment %d] Could not save power-tuning frontier plot: %s", experiment.ID, err.Error())
+	}
+}
+
+func plotPowerTuningLatencies(plotPath string, experiment setup.SubExperiment, results []PowerTuningResult) {
+	plotInstance := plot.New()
+	plotInstance.Title.Text = fmt.Sprintf("%v\nLatencies by memory size", experiment.Title)
+	plotInstance.X.Label.Text = "Memory (MB)"
+	plotInstance.Y.Label.Text = "Latency (ms)"
+	plotInstance.Y.Min = 0.
+
+	memorySizes := make([]float64, len(results))
+	warmMedians, warmP95s := make([]float64, len(results)), make([]float64, len(results))
+	coldMedians, coldP95s := make([]float64, len(results)), make([]float64, len(results))
+	for i, result := range results {
+		memorySizes[i] = float64(result.MemoryMB)
+		warmMedians[i], warmP95s[i] = result.WarmMedianMs, result.WarmP95Ms
+		coldMedians[i], coldP95s[i] = result.ColdMedianMs, result.ColdP95Ms
+	}
+
+	if err := plotutil.AddLinePoints(plotInstance,
+		"Warm Median", timeSeries(memorySizes, warmMedians),
+		"Warm 95th Percentile", timeSeries(memorySizes, warmP95s),
+		"Cold Median", timeSeries(memorySizes, coldMedians),
+		"Cold 95th Percentile", timeSeries(memorySizes, coldP95s),
+	); err != nil {
+		log.Errorf("[sub-experiment %d] Could not add lines to power-tuning latencies plot: %s", experiment.ID, err.Error())
+		return
+	}
+	plotInstance.Legend.Top = true
+
+	if err := plotInstance.Save(10*vg.Inch, 5*vg.Inch, plotPath); err != nil {
+		log.Errorf("[sub-experiment %d] Could not save power-tuning latencies plot: %s", experiment.ID, err.Error())
+	}
+}
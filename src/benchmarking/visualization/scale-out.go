@@ -0,0 +1,97 @@
+package visualization
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"math"
+	"path/filepath"
+	"stellar/setup"
+)
+
+// ScaleOutSeries is the time series of a scale-out sub-experiment, together with the queueing delay of every request
+type ScaleOutSeries struct {
+	TimeSeconds            []float64
+	SentRequests           []float64
+	ActiveInstances        []float64
+	UniqueInstances        []float64
+	MedianQueueingDelaysMs []float64
+	RequestSentAtSeconds   []float64
+	QueueingDelaysMs       []float64
+}
+
+// GenerateScaleOut plots the distinct instances active and seen so far over the scale-out sub-experiment, and the
+// queueing delays of its requests by the time they were sent
+func GenerateScaleOut(experiment setup.SubExperiment, series ScaleOutSeries, path string) {
+	log.Infof("[sub-experiment %d] Generating scale-out visualizations", experiment.ID)
+	plotScaleOutInstances(filepath.Join(path, "scale_out_instances.png"), experiment, series)
+	plotScaleOutQueueingDelays(filepath.Join(path, "scale_out_queueing_delays.png"), experiment, series)
+}
+
+func plotScaleOutInstances(plotPath string, experiment setup.SubExperiment, series ScaleOutSeries) {
+	plotInstance := plot.New()
+	plotInstance.Title.Text = fmt.Sprintf("%v\nScale-out at %v requests/s", experiment.Title, experiment.ArrivalRate)
+	plotInstance.X.Label.Text = "Time (s)"
+	plotInstance.Y.Label.Text = "Instances"
+	plotInstance.Y.Min = 0.
+
+	lines := []interface{}{
+		"Active Instances", timeSeries(series.TimeSeconds, series.ActiveInstances),
+		"Unique Instances", timeSeries(series.TimeSeconds, series.UniqueInstances),
+	}
+	if experiment.TargetInstances > 0 && len(series.TimeSeconds) > 0 {
+		target := float64(experiment.TargetInstances)
+		lines = append(lines, "Target Instances", plotter.XYs{{X: 0, Y: target}, {X: series.TimeSeconds[len(series.TimeSeconds)-1], Y: target}})
+	}
+	if err := plotutil.AddLines(plotInstance, lines...); err != nil {
+		log.Errorf("[sub-experiment %d] Could not add lines to scale-out instances plot: %s", experiment.ID, err.Error())
+		return
+	}
+	plotInstance.Legend.Left = true
+	plotInstance.Legend.Top = true
+
+	if err := plotInstance.Save(10*vg.Inch, 5*vg.Inch, plotPath); err != nil {
+		log.Errorf("[sub-experiment %d] Could not save scale-out instances plot: %s", experiment.ID, err.Error())
+	}
+}
+
+func plotScaleOutQueueingDelays(plotPath string, experiment setup.SubExperiment, series ScaleOutSeries) {
+	plotInstance := plot.New()
+	plotInstance.Title.Text = fmt.Sprintf("%v\nQueueing delays at %v requests/s", experiment.Title, experiment.ArrivalRate)
+	plotInstance.X.Label.Text = "Sent At (s)"
+	plotInstance.Y.Label.Text = "Queueing Delay (ms)"
+
+	scatter, err := plotter.NewScatter(timeSeries(series.RequestSentAtSeconds, series.QueueingDelaysMs))
+	if err != nil {
+		log.Errorf("[sub-experiment %d] Could not plot queueing delays: %s", experiment.ID, err.Error())
+		return
+	}
+	scatter.GlyphStyle.Radius = vg.Points(1)
+	plotInstance.Add(scatter)
+	plotInstance.Legend.Add("Requests", scatter)
+
+	if err := plotutil.AddLines(plotInstance, "Median", timeSeries(series.TimeSeconds, series.MedianQueueingDelaysMs)); err != nil {
+		log.Errorf("[sub-experiment %d] Could not add median line to queueing delays plot: %s", experiment.ID, err.Error())
+		return
+	}
+	plotInstance.Legend.Left = true
+	plotInstance.Legend.Top = true
+
+	if err := plotInstance.Save(10*vg.Inch, 5*vg.Inch, plotPath); err != nil {
+		log.Errorf("[sub-experiment %d] Could not save queueing delays plot: %s", experiment.ID, err.Error())
+	}
+}
+
+// timeSeries pairs the times with their values, skipping the times without a value
+func timeSeries(times []float64, values []float64) plotter.XYs {
+	points := make(plotter.XYs, 0, len(times))
+	for i := range times {
+		if !math.IsNaN(values[i]) {
+			points = append(points, plotter.XY{X: times[i], Y: values[i]})
+		}
+	}
+	return points
+}
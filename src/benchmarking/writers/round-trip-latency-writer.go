@@ -29,6 +29,28 @@ import (
 	"sync"
 )
 
+//InstanceTelemetry holds the columns describing the function instance that served a request, left empty for
+//functions not reporting it.
+type InstanceTelemetry struct {
+	ID             string
+	BootTimestamp  string
+	RequestCount   string
+	ColdStart      string
+	RuntimeVersion string
+	CPUModel       string
+	MemoryLimitMB  string
+}
+
+//RequestTiming holds the client timestamps of a request and the handler timestamps reported by the function, used to
+//decompose its latency. The handler columns are left empty for functions not reporting them.
+type RequestTiming struct {
+	SentAtUs       string
+	ReceivedAtUs   string
+	HandlerEntryUs string
+	HandlerExitUs  string
+	ExecutionMs    string
+}
+
 //RTTLatencyWriter records serverless RTT latencies. It is safe for concurrent use as it uses a mutual exclusion lock.
 type RTTLatencyWriter struct {
 	Writer *csv.Writer
@@ -40,22 +62,48 @@ func NewRTTLatencyWriter(file *os.File) *RTTLatencyWriter {
 	log.Debugf("Creating latency writer to file `%s`.", file.Name())
 	safeExperimentWriter := &RTTLatencyWriter{Writer: csv.NewWriter(file)}
 
-	safeExperimentWriter.WriteRTTLatencyRow(
+	safeExperimentWriter.writeRow([]string{
 		"Request ID",
 		"Host",
 		"Sent At",
 		"Received At",
 		"Client Latency (ms)",
 		"Burst ID",
-	)
+		"Init Duration (ms)",
+		"Sampled Service Time (ms)",
+		"Service Time (ms)",
+		"Workload Phases (ms)",
+		"Instance ID",
+		"Instance Boot Timestamp",
+		"Instance Request Count",
+		"Cold Start",
+		"Runtime Version",
+		"CPU Model",
+		"Memory Limit (MB)",
+		"Sent At (us)",
+		"Received At (us)",
+		"Handler Entry (us)",
+		"Handler Exit (us)",
+		"Execution (ms)",
+		"Forced Cold",
+	})
 
 	return safeExperimentWriter
 }
 
 //WriteRTTLatencyRow records round-trip time information of a request to disk.
-func (writer *RTTLatencyWriter) WriteRTTLatencyRow(awsRequestID string, host string, sentAt string, receivedAt string, clientLatencyMs string, burstID string) {
+func (writer *RTTLatencyWriter) WriteRTTLatencyRow(awsRequestID string, host string, sentAt string, receivedAt string, clientLatencyMs string, burstID string,
+	initDurationMs string, sampledServiceTimeMs string, serviceTimeMs string, workloadPhasesMs string, instance InstanceTelemetry,
+	timing RequestTiming, forcedCold string) {
+	writer.writeRow([]string{awsRequestID, host, sentAt, receivedAt, clientLatencyMs, burstID, initDurationMs,
+		sampledServiceTimeMs, serviceTimeMs, workloadPhasesMs, instance.ID, instance.BootTimestamp, instance.RequestCount,
+		instance.ColdStart, instance.RuntimeVersion, instance.CPUModel, instance.MemoryLimitMB, timing.SentAtUs,
+		timing.ReceivedAtUs, timing.HandlerEntryUs, timing.HandlerExitUs, timing.ExecutionMs, forcedCold})
+}
+
+func (writer *RTTLatencyWriter) writeRow(row []string) {
 	writer.mux.Lock()
-	if err := writer.Writer.Write([]string{awsRequestID, host, sentAt, receivedAt, clientLatencyMs, burstID}); err != nil {
+	if err := writer.Writer.Write(row); err != nil {
 		log.Fatal(err)
 	}
 	writer.mux.Unlock()
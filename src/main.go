@@ -45,6 +45,7 @@ var endpointsDirectoryPathFlag = flag.String("g", "endpoints", "Directory contai
 var specificExperimentFlag = flag.Int("r", -1, "Only run this particular experiment.")
 var logLevelFlag = flag.String("l", "info", "Select logging level.")
 var serverlessDeployment = flag.Bool("s", true, "Use serverless.com framework for deployment. ")
+var serviceTimeCalibrationPathFlag = flag.String("t", "service-time-calibration.json", "File caching the busy-spin rates probed for the functions.")
 
 func main() {
 	startTime := time.Now()
@@ -74,20 +75,28 @@ func main() {
 	config := setup.ExtractConfiguration(*configPathFlag)
 
 	amazon.UserARNNumber = *awsUserArnNumber
+	benchmarking.ServiceTimeCalibrationCachePath = *serviceTimeCalibrationPathFlag
 
-	// We find the busy-spinning time based on the host where the tool is run, i.e., not AWS or other providers
+	// We find the busy-spinning time based on the host where the tool is run, i.e., not AWS or other providers. Sub-experiments
+	// in another ServiceTimeMode calibrate the functions themselves before benchmarking.
 	setup.FindBusySpinIncrements(&config)
 
 	// Pick between deployment methods
-	connection.Initialize(config.Provider, *endpointsDirectoryPathFlag, "./setup/deployment/raw-code/functions/producer-consumer/api-template.json")
+	connection.Initialize(config.Providers(), *endpointsDirectoryPathFlag, "./setup/deployment/raw-code/functions/producer-consumer/api-template.json")
 	if *serverlessDeployment {
-		serverlessDirPath := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/", config.Provider)
-		setup.ProvisionFunctionsServerless(&config, serverlessDirPath)
+		for _, provider := range config.Providers() {
+			providerConfig := config.ForProvider(provider)
+			setup.ProvisionFunctionsServerless(&providerConfig, serverlessDirPath(provider))
+			config.MergeSubExperiments(providerConfig)
+		}
 		log.Infof("number of routes %d, numebr of endpoints %d", len(config.SubExperiments[0].Routes), len(config.SubExperiments[0].Endpoints))
 		benchmarking.TriggerSubExperiments(config, outputDirectoryPath, *specificExperimentFlag)
 
 		log.Info("Starting functions removal from cloud.")
-		setup.RemoveService(&config, serverlessDirPath)
+		for _, provider := range config.Providers() {
+			providerConfig := config.ForProvider(provider)
+			setup.RemoveService(&providerConfig, serverlessDirPath(provider))
+		}
 	} else {
 		setup.ProvisionFunctions(config)
 		benchmarking.TriggerSubExperiments(config, outputDirectoryPath, *specificExperimentFlag)
@@ -96,6 +105,10 @@ func main() {
 	log.Infof("Done in %v, exiting...", time.Since(startTime))
 }
 
+func serverlessDirPath(provider string) string {
+	return fmt.Sprintf("setup/deployment/raw-code/serverless/%s/", provider)
+}
+
 func setupLogging(path string) *os.File {
 	loggingPath := filepath.Join(path, "run_logs.txt")
 	log.Debugf("Creating log file for this run at `%s`", loggingPath)
@@ -24,7 +24,6 @@ package setup
 
 import (
 	"fmt"
-	"github.com/aws/aws-sdk-go/service/lambda"
 	log "github.com/sirupsen/logrus"
 	"math"
 	"stellar/setup/deployment"
@@ -32,19 +31,21 @@ import (
 	"stellar/util"
 )
 
-func assignEndpoints(availableEndpoints []connection.Endpoint, experiment *SubExperiment, provider string) []connection.Endpoint {
+func assignEndpoints(availableEndpoints []connection.Endpoint, experiment *SubExperiment) []connection.Endpoint {
 	log.Infof("[sub-experiment %d] Setting up deployment...", experiment.ID)
-	log.Infof("[sub-experiment %d] Experiment configuration: %vMB memory, %vMB image size, %vs IAT, %q package.",
+	log.Infof("[sub-experiment %d] Experiment configuration: %vMB memory, %vMB image size, %vs IAT, %q package, provider %q, region %q.",
 		experiment.ID, experiment.FunctionMemoryMB, experiment.FunctionImageSizeMB, experiment.IATSeconds,
-		experiment.PackageType)
+		experiment.PackageType, experiment.Provider, experiment.Region)
 	var assignedHandler string
 
-	if provider == "aws" { // deployment has only been automated for AWS so far
-		experiment.FunctionImageSizeMB, assignedHandler = deployment.SetupDeployment(
-			fmt.Sprintf("setup/deployment/raw-code/functions/%s/%s", experiment.Function, provider),
-			provider,
+	if experiment.Provider == "aws" { // deployment has only been automated for AWS so far
+		experiment.FunctionImageSizeMB, assignedHandler, experiment.ImageSize = deployment.SetupDeployment(
+			fmt.Sprintf("setup/deployment/raw-code/functions/%s/%s", experiment.Function, experiment.Provider),
+			experiment.Provider,
+			experiment.Region,
 			util.MebibyteToBytes(experiment.FunctionImageSizeMB),
 			experiment.PackageType,
+			experiment.Architecture,
 			experiment.ID,
 			experiment.Function,
 		)
@@ -54,7 +55,7 @@ func assignEndpoints(availableEndpoints []connection.Endpoint, experiment *SubEx
 	for i := 0; i < experiment.Parallelism; i++ {
 		foundEndpointID := findEndpointToAssign(&availableEndpoints, experiment, assignedHandler)
 
-		gatewayEndpoint := EndpointInfo{ID: foundEndpointID}
+		gatewayEndpoint := EndpointInfo{ID: foundEndpointID, Region: experiment.Region}
 
 		for j := experiment.DataTransferChainLength; j > 1; j-- {
 			gatewayEndpoint.DataTransferChainIDs = append(
@@ -79,12 +80,13 @@ func findEndpointToAssign(availableEndpoints *[]connection.Endpoint, experiment
 		}
 	}
 
-	log.Infof("[sub-experiment %d] Searched %d endpoints, could not find a function to assign with: memory %dMB, image size %vMB, package type %q.",
+	log.Infof("[sub-experiment %d] Searched %d endpoints, could not find a function to assign with: memory %dMB, image size %vMB, package type %q, architecture %s.",
 		experiment.ID,
 		len(*availableEndpoints),
 		experiment.FunctionMemoryMB,
 		experiment.FunctionImageSizeMB,
 		experiment.PackageType,
+		experiment.Architecture,
 	)
 
 	// Only attempt repurposing functions if they are ZIP-packaged:
@@ -93,7 +95,7 @@ func findEndpointToAssign(availableEndpoints *[]connection.Endpoint, experiment
 		for index, endpoint := range *availableEndpoints {
 			if endpoint.PackageType == "Zip" {
 				log.Infof("[sub-experiment %d] Repurposing an existing function...", experiment.ID)
-				connection.Singleton.UpdateFunction(experiment.PackageType, endpoint.GatewayID, experiment.FunctionMemoryMB)
+				connection.Get(experiment.Provider).UpdateFunction(experiment.Region, experiment.PackageType, endpoint.GatewayID, experiment.Architecture, experiment.FunctionMemoryMB)
 
 				*availableEndpoints = removeEndpointFromSlice(*availableEndpoints, index)
 
@@ -111,7 +113,7 @@ func findEndpointToAssign(availableEndpoints *[]connection.Endpoint, experiment
 	}
 
 	log.Infof("[sub-experiment %d] Could not find an existing function to repurpose, creating a new function...", experiment.ID)
-	return connection.Singleton.DeployFunction(assignedHandler, experiment.PackageType, experiment.Function, experiment.FunctionMemoryMB)
+	return connection.Get(experiment.Provider).DeployFunction(experiment.Region, assignedHandler, experiment.PackageType, experiment.Function, experiment.Architecture, experiment.FunctionMemoryMB)
 }
 
 func removeEndpointFromSlice(s []connection.Endpoint, i int) []connection.Endpoint {
@@ -128,9 +130,12 @@ func specsMatch(endpoint connection.Endpoint, experiment *SubExperiment) bool {
 		return false
 	}
 
-	// Image sizes are ignored for PackageTypeImage because AWS does not reveal this information
-	if experiment.PackageType == lambda.PackageTypeImage {
-		return true
+	endpointArchitecture := endpoint.Architecture
+	if endpointArchitecture == "" {
+		endpointArchitecture = defaultArchitecture
+	}
+	if endpointArchitecture != experiment.Architecture {
+		return false
 	}
 
 	return math.Abs(endpoint.ImageSizeMB-experiment.FunctionImageSizeMB) <= 5
@@ -142,7 +147,7 @@ func (s *SubExperiment) AssignEndpointIDs(endpointID string) {
 		s.Endpoints = []EndpointInfo{}
 	}
 	for i := 0; i < s.Parallelism; i++ {
-		s.Endpoints = append(s.Endpoints, EndpointInfo{ID: endpointID})
+		s.Endpoints = append(s.Endpoints, EndpointInfo{ID: endpointID, Region: s.Region})
 	}
 }
 
@@ -5,6 +5,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"stellar/setup/runtimes"
 	"stellar/util"
 )
 
@@ -13,17 +15,41 @@ type Builder struct {
 	functionsBuilt map[string]bool
 }
 
-func (b *Builder) BuildFunction(provider string, functionName string, runtime string) string {
-	artifactDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s", provider, functionName)
+// goArchitectures maps the instruction set architectures of the sub-experiments to Go architectures
+var goArchitectures = map[string]string{
+	"x86_64": "amd64",
+	"arm64":  "arm64",
+}
+
+// dotnetRuntimes maps the instruction set architectures of the sub-experiments to .NET runtime identifiers
+var dotnetRuntimes = map[string]string{
+	"x86_64": "linux-x64",
+	"arm64":  "linux-arm64",
+}
+
+// ArtifactName returns the name under which the artifacts of the function built for the given architecture are stored.
+// Functions built for the default x86_64 architecture keep the name of the function.
+func ArtifactName(functionName string, architecture string) string {
+	if architecture == "" || architecture == "x86_64" {
+		return functionName
+	}
+	return fmt.Sprintf("%s-%s", functionName, architecture)
+}
+
+// BuildFunction builds the function for the given runtime and instruction set architecture (x86_64 or arm64)
+// and returns the path of its ZIP artifact relative to the serverless.yml file.
+func (b *Builder) BuildFunction(provider string, functionName string, runtime string, architecture string) string {
+	artifactName := ArtifactName(functionName, architecture)
+	artifactDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s", provider, artifactName)
 
 	// First we check whether the function has not been built already
 	if b.functionsBuilt == nil {
 		b.functionsBuilt = make(map[string]bool)
 	}
 
-	if b.functionsBuilt[functionName] {
-		log.Warnf("Function %s already built. Skipping.", functionName)
-		return fmt.Sprintf("artifacts/%s/%s.zip", functionName, functionName)
+	if b.functionsBuilt[artifactName] {
+		log.Warnf("Function %s already built. Skipping.", artifactName)
+		return fmt.Sprintf("artifacts/%s/%s.zip", artifactName, artifactName)
 	}
 
 	// Create folder in artifacts for the function
@@ -33,68 +59,86 @@ func (b *Builder) BuildFunction(provider string, functionName string, runtime st
 
 	functionDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/%s", provider, functionName)
 
-	switch runtime {
-	case "java11":
-		buildJava(functionName, functionDir, artifactDir)
-	case "go1.x":
-		buildGolang(functionName, functionDir, artifactDir)
-	case "nodejs18":
-		fallthrough
-	case "nodejs18.x":
-		copyNodeJSFile(functionName, functionDir, artifactDir)
-	case "ruby3.2":
-		copyRubyFile(functionName, functionDir, artifactDir)
-	case "python3.8":
-		fallthrough
-	case "python3.9":
-		copyPythonFile(functionName, functionDir, artifactDir)
-	default:
+	functionRuntime, ok := runtimes.Lookup(runtime)
+	if !ok {
 		log.Warnf("Building runtime %s is not necessary, or not supported. Continuing without building.", runtime)
+		b.functionsBuilt[artifactName] = true
+		return fmt.Sprintf("artifacts/%s/%s.zip", artifactName, artifactName)
+	}
+
+	switch functionRuntime.Build {
+	case runtimes.BuildGradle:
+		buildJava(functionName, artifactName, functionDir, artifactDir)
+	case runtimes.BuildDotnet:
+		buildDotnet(functionName, architecture, functionDir, artifactDir)
+	case runtimes.BuildBootstrap:
+		if _, err := os.Stat(filepath.Join(functionDir, "Cargo.toml")); err == nil {
+			buildRust(functionName, architecture, functionDir, artifactDir)
+		} else {
+			buildGolang(functionName, architecture, functionDir, artifactDir)
+		}
+	case runtimes.BuildCopy:
+		copyEntryFile(functionRuntime.Language, functionRuntime.EntryPath(functionName), functionDir, artifactDir)
 	}
-	b.functionsBuilt[functionName] = true
-	return fmt.Sprintf("artifacts/%s/%s.zip", functionName, functionName)
+	b.functionsBuilt[artifactName] = true
+	return fmt.Sprintf("artifacts/%s/%s.zip", artifactName, artifactName)
 }
 
 // buildJava builds the java zip artifact for serverless deployment using Gradle
-func buildJava(functionName string, functionDir string, artifactDir string) string {
+func buildJava(functionName string, artifactName string, functionDir string, artifactDir string) string {
 	log.Infof("Building Java from the source code at %s directory", functionDir)
-	artifactPath := fmt.Sprintf("%s/%s.zip", artifactDir, functionName)
+	artifactPath := fmt.Sprintf("%s/%s.zip", artifactDir, artifactName)
 	util.RunCommandAndLog(exec.Command("gradle", "buildZip", "-p", functionDir))
 	util.RunCommandAndLog(exec.Command("mv", fmt.Sprintf("%s/build/distributions/%s.zip", functionDir, functionName), artifactPath))
 	return artifactPath
 }
 
-// buildGolang builds the Golang binary for serverless deployment
-func buildGolang(functionName string, functionDir string, artifactDir string) string {
-	log.Infof("Building Go from the source code at %s directory", functionDir)
+// buildGolang cross-compiles the Golang binary for serverless deployment on the given architecture
+func buildGolang(functionName string, architecture string, functionDir string, artifactDir string) string {
+	goArchitecture, ok := goArchitectures[architecture]
+	if !ok {
+		goArchitecture = goArchitectures["x86_64"]
+	}
+
+	log.Infof("Building Go for %s from the source code at %s directory", goArchitecture, functionDir)
 	artifactPath := fmt.Sprintf("%s/bootstrap", artifactDir)
-	util.RunCommandAndLog(exec.Command("env", "GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0", "go", "build", "-C", functionDir, "-o", "bootstrap"))
+	util.RunCommandAndLog(exec.Command("env", "GOOS=linux", fmt.Sprintf("GOARCH=%s", goArchitecture), "CGO_ENABLED=0", "go", "build", "-C", functionDir, "-o", "bootstrap"))
 	util.RunCommandAndLog(exec.Command("mv", fmt.Sprintf("%s/bootstrap", functionDir), artifactPath))
 	return artifactPath
 }
 
-// copyPythonFile copies the main Python file into the artifacts directory
-func copyPythonFile(functionName string, functionDir string, artifactDir string) string {
-	log.Infof("Copying Python source code from the %s directory", functionDir)
-	functionPath := fmt.Sprintf("%s/main.py", functionDir)
-	artifactPath := fmt.Sprintf("%s/main.py", artifactDir)
-	util.RunCommandAndLog(exec.Command("cp", functionPath, artifactPath))
+// buildDotnet publishes the .NET assemblies of the function and their dependencies into the artifacts directory
+func buildDotnet(functionName string, architecture string, functionDir string, artifactDir string) string {
+	dotnetRuntime, ok := dotnetRuntimes[architecture]
+	if !ok {
+		dotnetRuntime = dotnetRuntimes["x86_64"]
+	}
+
+	log.Infof("Building .NET for %s from the source code at %s directory", dotnetRuntime, functionDir)
+	artifactPath := fmt.Sprintf("%s/publish", artifactDir)
+	util.RunCommandAndLog(exec.Command("dotnet", "publish", functionDir, "-c", "Release", "-r", dotnetRuntime,
+		"--self-contained", "false", "-o", artifactPath))
 	return artifactPath
 }
 
-// copyNodeJSFile copies the main NodeJS file into the artifacts directory
-func copyNodeJSFile(functioName string, functionDir string, artifactDir string) string {
-	log.Infof("Copying Node source code from the %s directory", functionDir)
-	functionPath := fmt.Sprintf("%s/index.js", functionDir)
-	artifactPath := fmt.Sprintf("%s/index.js", artifactDir)
-	util.RunCommandAndLog(exec.Command("cp", functionPath, artifactPath))
+// buildRust compiles the Rust function into a bootstrap executable with Cargo Lambda
+func buildRust(functionName string, architecture string, functionDir string, artifactDir string) string {
+	log.Infof("Building Rust for %s from the source code at %s directory", architecture, functionDir)
+	arguments := []string{"lambda", "build", "--release", "--manifest-path", fmt.Sprintf("%s/Cargo.toml", functionDir)}
+	if architecture == "arm64" {
+		arguments = append(arguments, "--arm64")
+	}
+	artifactPath := fmt.Sprintf("%s/bootstrap", artifactDir)
+	util.RunCommandAndLog(exec.Command("cargo", arguments...))
+	util.RunCommandAndLog(exec.Command("mv", fmt.Sprintf("%s/target/lambda/bootstrap/bootstrap", functionDir), artifactPath))
 	return artifactPath
 }
 
-func copyRubyFile(functionName string, functionDir string, artifactDir string) string {
-	log.Infof("Copying Ruby source code from the %s directory", functionDir)
-	functionPath := fmt.Sprintf("%s/function.rb", functionDir)
-	artifactPath := fmt.Sprintf("%s/function.rb", artifactDir)
+// copyEntryFile copies the source file of a function in an interpreted language, e.g., main.py, to its artifact directory
+func copyEntryFile(language string, entryFile string, functionDir string, artifactDir string) string {
+	log.Infof("Copying %s source code from the %s directory", language, functionDir)
+	functionPath := fmt.Sprintf("%s/%s", functionDir, entryFile)
+	artifactPath := fmt.Sprintf("%s/%s", artifactDir, entryFile)
 	util.RunCommandAndLog(exec.Command("cp", functionPath, artifactPath))
 	return artifactPath
 }
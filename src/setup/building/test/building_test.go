@@ -22,19 +22,32 @@ func (s *BuildingTestSuite) SetupSuite() {
 
 func (s *BuildingTestSuite) TestBuildFunctionJava() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "hellojava", "java11")
+	b.BuildFunction("aws", "hellojava", "java11", "x86_64")
 	assert.FileExists(s.T(), "setup/deployment/raw-code/serverless/aws/artifacts/hellojava/hellojava.zip")
 }
 
 func (s *BuildingTestSuite) TestBuildFunctionGolang() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "hellogo", "go1.x")
+	b.BuildFunction("aws", "hellogo", "go1.x", "x86_64")
 	assert.FileExists(s.T(), "setup/deployment/raw-code/serverless/aws/artifacts/hellogo/bootstrap")
 }
 
+func (s *BuildingTestSuite) TestBuildFunctionGolangArm64() {
+	b := &building.Builder{}
+	artifactPath := b.BuildFunction("aws", "hellogo", "go1.x", "arm64")
+	assert.Equal(s.T(), "artifacts/hellogo-arm64/hellogo-arm64.zip", artifactPath)
+	assert.FileExists(s.T(), "setup/deployment/raw-code/serverless/aws/artifacts/hellogo-arm64/bootstrap")
+}
+
+func (s *BuildingTestSuite) TestArtifactName() {
+	assert.Equal(s.T(), "hellogo", building.ArtifactName("hellogo", "x86_64"))
+	assert.Equal(s.T(), "hellogo", building.ArtifactName("hellogo", ""))
+	assert.Equal(s.T(), "hellogo-arm64", building.ArtifactName("hellogo", "arm64"))
+}
+
 func (s *BuildingTestSuite) TestBuildFunctionUnsupported() {
 	b := &building.Builder{}
-	b.BuildFunction("mockProvider", "mockFunctionName", "unsupported")
+	b.BuildFunction("mockProvider", "mockFunctionName", "unsupported", "x86_64")
 }
 
 func TestBuildingTestSuite(t *testing.T) {
@@ -1,20 +1,184 @@
 package code_generation
 
-import log "github.com/sirupsen/logrus"
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"stellar/setup/runtimes"
+	"strings"
+	"text/template"
+)
 
-// GenerateCode generates source code of the given function for the given provider
-func GenerateCode(functionName string, provider string) {
+// templates holds the function templates, under <provider>/<language>/ for the files of a function and under
+// common/<language>.tmpl for the snippets shared by the providers
+//
+//go:embed all:templates
+var templates embed.FS
 
-	//TODO: Implement function.
+// SpecsDirectory holds the specifications of the functions generated from the templates, named <function>.json
+const SpecsDirectory = "setup/code-generation/specs"
 
-	switch provider {
-	case "aws":
-		fallthrough
-	case "azure":
-		fallthrough
-	case "google":
-		fallthrough
-	default:
-		log.Warnf("Code generation of %s function for %s is not supported.", functionName, provider)
+// functionPathPlaceholder is replaced by the name of the function in the paths of the templates, e.g., for Java packages
+const functionPathPlaceholder = "_function_"
+
+var functionNameRegex = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// FunctionSpec describes a benchmark function generated from the templates.
+type FunctionSpec struct {
+	Name     string       `json:"Name"`
+	Workload WorkloadSpec `json:"Workload"`
+	Response ResponseSpec `json:"Response"`
+	// Chain makes the function invoke the next function of its data transfer chain over HTTP, the endpoint IDs of the
+	// DataTransferChainIDs parameter being used as URLs (or hosts), and return the timestamp chain of the whole chain.
+	// The requests are not signed, so STeLLAR rejects sub-experiments chaining generated functions on its providers.
+	Chain bool `json:"Chain"`
+}
+
+// WorkloadSpec is the work done by the function for every request.
+type WorkloadSpec struct {
+	// BusySpin keeps the CPU busy for the IncrementLimit parameter, calibrated by the client from the DesiredServiceTimes
+	// or the ServiceTimeDistribution, and reports the measured ServiceTimeMilliseconds
+	BusySpin bool `json:"BusySpin"`
+	// SleepMilliseconds is a fixed wait, e.g., imitating a call to a remote service
+	SleepMilliseconds int `json:"SleepMilliseconds"`
+}
+
+// ResponseSpec is the content of the JSON response of the function, besides its RequestID and TimestampChain.
+type ResponseSpec struct {
+	// Region adds the region the function runs in
+	Region bool `json:"Region"`
+	// Payload adds a random string of the length given by the PayloadLengthBytes parameter
+	Payload bool `json:"Payload"`
+}
+
+// GenerateCode generates source code of the given function for the given provider and runtime into the
+// raw-code/serverless/<provider>/<function> directory, if the function has a specification in SpecsDirectory.
+// Functions without a specification keep their hand-written source code.
+func GenerateCode(functionName string, provider string, runtime string) {
+	if !HasSpecification(functionName) {
+		log.Debugf("Function %s has no specification, using its hand-written source code.", functionName)
+		return
+	}
+	specPath := specificationPath(functionName)
+
+	spec, err := ReadFunctionSpec(specPath)
+	if err != nil {
+		log.Fatalf("Could not read specification of function %s: %s", functionName, err.Error())
+	}
+
+	functionDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/%s", provider, functionName)
+	log.Infof("Generating %s source code of function %s for %s into %s...", runtime, functionName, provider, functionDir)
+	if err := RenderFunction(spec, provider, runtime, functionDir); err != nil {
+		log.Fatalf("Code generation of %s function for %s failed: %s", functionName, provider, err.Error())
+	}
+}
+
+// HasSpecification returns whether the function has a specification in SpecsDirectory, its source code being generated.
+func HasSpecification(functionName string) bool {
+	_, err := os.Stat(specificationPath(functionName))
+	return err == nil
+}
+
+func specificationPath(functionName string) string {
+	return filepath.Join(SpecsDirectory, fmt.Sprintf("%s.json", functionName))
+}
+
+// ReadFunctionSpec reads and validates the function specification at the given path.
+func ReadFunctionSpec(specPath string) (FunctionSpec, error) {
+	content, err := os.ReadFile(specPath)
+	if err != nil {
+		return FunctionSpec{}, err
+	}
+
+	var spec FunctionSpec
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return FunctionSpec{}, err
 	}
+	if spec.Name == "" {
+		spec.Name = strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath))
+	}
+	if !functionNameRegex.MatchString(spec.Name) {
+		// The name is used as Java package and Go module, and in the handlers of the runtimes
+		return FunctionSpec{}, fmt.Errorf("function name %q must be lowercase letters and digits, starting with a letter", spec.Name)
+	}
+	if spec.Workload.SleepMilliseconds < 0 {
+		return FunctionSpec{}, fmt.Errorf("SleepMilliseconds of function %s is negative", spec.Name)
+	}
+	return spec, nil
+}
+
+// Language returns the language of the templates used for the runtime, e.g., python for python3.12.
+func Language(runtime string) (string, error) {
+	registered, ok := runtimes.Lookup(runtime)
+	if !ok {
+		return "", fmt.Errorf("runtime %s is not registered", runtime)
+	}
+	if registered.Language == "native" {
+		return "go", nil // native executables are generated in Go
+	}
+	return registered.Language, nil
+}
+
+// Supported returns whether templates exist for the provider and the language of the runtime.
+func Supported(provider string, runtime string) bool {
+	language, err := Language(runtime)
+	if err != nil {
+		return false
+	}
+	_, err = fs.Stat(templates, path.Join("templates", provider, language))
+	return err == nil
+}
+
+// RenderFunction renders the templates of the provider and the language of the runtime into the output directory.
+func RenderFunction(spec FunctionSpec, provider string, runtime string, outputDir string) error {
+	language, err := Language(runtime)
+	if err != nil {
+		return err
+	}
+	if !Supported(provider, runtime) {
+		return fmt.Errorf("no %s templates for provider %s", language, provider)
+	}
+
+	common, err := template.ParseFS(templates, path.Join("templates", "common", language+".tmpl"))
+	if err != nil {
+		return err
+	}
+
+	providerDir := path.Join("templates", provider, language)
+	return fs.WalkDir(templates, providerDir, func(templatePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relativePath := strings.TrimSuffix(strings.TrimPrefix(templatePath, providerDir+"/"), ".tmpl")
+		outputPath := filepath.Join(outputDir, filepath.FromSlash(strings.ReplaceAll(relativePath, functionPathPlaceholder, spec.Name)))
+
+		fileTemplate, err := common.Clone()
+		if err != nil {
+			return err
+		}
+		content, err := fs.ReadFile(templates, templatePath)
+		if err != nil {
+			return err
+		}
+		if _, err := fileTemplate.New(relativePath).Parse(string(content)); err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+			return err
+		}
+		outputFile, err := os.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+		return fileTemplate.ExecuteTemplate(outputFile, relativePath, spec)
+	})
 }
@@ -1,10 +1,172 @@
 package code_generation
 
 import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
 	code_generation "stellar/setup/code-generation"
+	"strings"
 	"testing"
 )
 
-func TestAddFunctionConfig(t *testing.T) {
-	code_generation.GenerateCode("hellopy", "aws")
+func TestGenerateCodeWithoutSpecification(t *testing.T) {
+	code_generation.GenerateCode("hellopy", "aws", "python3.9")
+}
+
+func TestReadFunctionSpec(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "hellogen.json")
+	require.NoError(t, os.WriteFile(specPath, []byte(`{"Workload": {"BusySpin": true, "SleepMilliseconds": 10}, "Chain": true}`), 0644))
+
+	spec, err := code_generation.ReadFunctionSpec(specPath)
+	require.NoError(t, err)
+	require.Equal(t, "hellogen", spec.Name)
+	require.True(t, spec.Workload.BusySpin)
+	require.Equal(t, 10, spec.Workload.SleepMilliseconds)
+	require.True(t, spec.Chain)
+
+	require.NoError(t, os.WriteFile(specPath, []byte(`{"Name": "hello-gen"}`), 0644))
+	_, err = code_generation.ReadFunctionSpec(specPath)
+	require.Error(t, err)
+}
+
+func TestRenderFunctionFiles(t *testing.T) {
+	spec := code_generation.FunctionSpec{Name: "hellogen", Workload: code_generation.WorkloadSpec{BusySpin: true}}
+	for _, testCase := range []struct {
+		provider string
+		runtime  string
+		files    []string
+	}{
+		{"aws", "python3.12", []string{"main.py"}},
+		{"aws", "nodejs22.x", []string{"index.js"}},
+		{"aws", "ruby3.3", []string{"function.rb"}},
+		{"aws", "provided.al2023", []string{"main.go", "go.mod", "go.sum"}},
+		{"aws", "java21", []string{"build.gradle", "src/main/java/org/hellogen/Handler.java"}},
+		{"azure", "python3.11", []string{"main.py"}},
+		{"azure", "nodejs18", []string{"index.js"}},
+		{"aliyun", "python3.9", []string{"main.py"}},
+	} {
+		outputDir := t.TempDir()
+		require.NoError(t, code_generation.RenderFunction(spec, testCase.provider, testCase.runtime, outputDir), testCase.runtime)
+		for _, file := range testCase.files {
+			require.FileExists(t, filepath.Join(outputDir, filepath.FromSlash(file)), testCase.runtime)
+		}
+	}
+}
+
+func TestRenderFunctionKnobs(t *testing.T) {
+	outputDir := t.TempDir()
+	spec := code_generation.FunctionSpec{Name: "hellogen"}
+	require.NoError(t, code_generation.RenderFunction(spec, "aws", "python3.12", outputDir))
+	source := readSource(t, filepath.Join(outputDir, "main.py"))
+	require.NotContains(t, source, "simulate_work")
+	require.NotContains(t, source, "ServiceTimeMilliseconds")
+	require.NotContains(t, source, "spin_for")
+	require.NotContains(t, source, "invoke_next")
+	require.NotContains(t, source, "Payload")
+
+	spec = code_generation.FunctionSpec{
+		Name:     "hellogen",
+		Workload: code_generation.WorkloadSpec{BusySpin: true, SleepMilliseconds: 25},
+		Response: code_generation.ResponseSpec{Region: true, Payload: true},
+		Chain:    true,
+	}
+	require.NoError(t, code_generation.RenderFunction(spec, "aws", "python3.12", outputDir))
+	source = readSource(t, filepath.Join(outputDir, "main.py"))
+	require.Contains(t, source, "simulate_work(int(parameters.get('IncrementLimit') or 0))")
+	require.Contains(t, source, `body["ServiceTimeMilliseconds"] = service_time_ms`)
+	require.Contains(t, source, "spin_for(float(parameters.get('ServiceTimeMilliseconds') or 0), parameters['ServiceTimeClock'])")
+	require.Contains(t, source, "time.sleep(25 / 1000)")
+	require.Contains(t, source, "invoke_next(chain_ids, parameters, timestamp_chain)")
+	require.Contains(t, source, `body["Region"] = region`)
+	require.Contains(t, source, `body["Payload"]`)
+}
+
+func TestRenderFunctionReportsInitDuration(t *testing.T) {
+	spec := code_generation.FunctionSpec{Name: "hellogen"}
+	for runtime, entryFile := range map[string]string{
+		"python3.12":      "main.py",
+		"nodejs22.x":      "index.js",
+		"ruby3.3":         "function.rb",
+		"provided.al2023": "main.go",
+		"java21":          "src/main/java/org/hellogen/Handler.java",
+	} {
+		outputDir := t.TempDir()
+		require.NoError(t, code_generation.RenderFunction(spec, "aws", runtime, outputDir))
+		source := readSource(t, filepath.Join(outputDir, filepath.FromSlash(entryFile)))
+		require.Contains(t, source, "InitDurationMilliseconds", runtime)
+		require.Contains(t, source, "STELLAR_INIT_SLEEP_MS", runtime)
+	}
+}
+
+func TestRenderFunctionReportsWorkloadPhases(t *testing.T) {
+	spec := code_generation.FunctionSpec{Name: "hellogen"}
+	for runtime, entryFile := range map[string]string{
+		"python3.12":      "main.py",
+		"nodejs22.x":      "index.js",
+		"provided.al2023": "main.go",
+	} {
+		outputDir := t.TempDir()
+		require.NoError(t, code_generation.RenderFunction(spec, "aws", runtime, outputDir))
+		source := readSource(t, filepath.Join(outputDir, entryFile))
+		require.Contains(t, source, "WorkloadPhases", runtime)
+		require.Contains(t, source, "STELLAR_WORKLOAD_FSYNC", runtime)
+	}
+}
+
+func TestRenderFunctionReportsInstanceTelemetry(t *testing.T) {
+	spec := code_generation.FunctionSpec{Name: "hellogen"}
+	for runtime, entryFile := range map[string]string{
+		"python3.12":      "main.py",
+		"nodejs22.x":      "index.js",
+		"ruby3.3":         "function.rb",
+		"provided.al2023": "main.go",
+		"java21":          "src/main/java/org/hellogen/Handler.java",
+	} {
+		outputDir := t.TempDir()
+		require.NoError(t, code_generation.RenderFunction(spec, "aws", runtime, outputDir))
+		source := readSource(t, filepath.Join(outputDir, filepath.FromSlash(entryFile)))
+		require.Contains(t, source, "InstanceRequestCount", runtime)
+		require.Contains(t, source, "MemoryLimitMB", runtime)
+	}
+}
+
+func TestRenderFunctionReportsHandlerTimestamps(t *testing.T) {
+	spec := code_generation.FunctionSpec{Name: "hellogen"}
+	for runtime, entryFile := range map[string]string{
+		"python3.12":      "main.py",
+		"nodejs22.x":      "index.js",
+		"ruby3.3":         "function.rb",
+		"provided.al2023": "main.go",
+		"java21":          "src/main/java/org/hellogen/Handler.java",
+	} {
+		outputDir := t.TempDir()
+		require.NoError(t, code_generation.RenderFunction(spec, "aws", runtime, outputDir))
+		source := readSource(t, filepath.Join(outputDir, filepath.FromSlash(entryFile)))
+		require.Contains(t, source, "HandlerEntryTimestampMicroseconds", runtime)
+		require.Contains(t, source, "HandlerExitTimestampMicroseconds", runtime)
+		require.Contains(t, source, "ExecutionMilliseconds", runtime)
+	}
+}
+
+func TestRenderJavaFunctionPackage(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, code_generation.RenderFunction(code_generation.FunctionSpec{Name: "hellogen"}, "aws", "java17", outputDir))
+
+	source := readSource(t, filepath.Join(outputDir, "src", "main", "java", "org", "hellogen", "Handler.java"))
+	require.True(t, strings.HasPrefix(source, "package org.hellogen;"))
+	require.Contains(t, readSource(t, filepath.Join(outputDir, "build.gradle")), "archiveFileName = 'hellogen.zip'")
+}
+
+func TestRenderFunctionUnsupported(t *testing.T) {
+	spec := code_generation.FunctionSpec{Name: "hellogen"}
+	require.Error(t, code_generation.RenderFunction(spec, "azure", "java21", t.TempDir()))
+	require.Error(t, code_generation.RenderFunction(spec, "aws", "cobol", t.TempDir()))
+	require.False(t, code_generation.Supported("cloudflare", "nodejs20.x"))
+	require.True(t, code_generation.Supported("aws", "go1.x"))
+}
+
+func readSource(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
 }
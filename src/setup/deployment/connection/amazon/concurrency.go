@@ -0,0 +1,113 @@
+// MIT License
+//
+// Copyright (c) 2020 Theodor Amariucai and EASE Lab
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package amazon
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+const (
+	// ProvisionedConcurrencyAlias is the alias the Serverless framework creates, pointing to the version published
+	// for functions with provisioned concurrency. Their HTTP events are routed to this alias.
+	ProvisionedConcurrencyAlias = "provisioned"
+
+	provisionedConcurrencyPollInterval = 15 * time.Second
+	provisionedConcurrencyTimeout      = 20 * time.Minute
+)
+
+//WaitForProvisionedConcurrency blocks until the provisioned concurrency of the given function alias is allocated,
+//so that benchmarking only starts once the pre-initialized execution environments are ready.
+func (instance awsSingleton) WaitForProvisionedConcurrency(functionName string, alias string) {
+	log.Infof("Waiting for provisioned concurrency of lambda function %s:%s to be ready...", functionName, alias)
+
+	args := &lambda.GetProvisionedConcurrencyConfigInput{
+		FunctionName: aws.String(functionName),
+		Qualifier:    aws.String(alias),
+	}
+
+	deadline := time.Now().Add(provisionedConcurrencyTimeout)
+	for time.Now().Before(deadline) {
+		result, err := instance.lambdaSvc.GetProvisionedConcurrencyConfig(args)
+		if err != nil {
+			if strings.Contains(err.Error(), "TooManyRequestsException") {
+				log.Warnf("Facing AWS rate-limiting error, retrying...")
+				time.Sleep(provisionedConcurrencyPollInterval)
+				continue
+			}
+
+			log.Fatalf("Cannot get provisioned concurrency configuration: %s", err.Error())
+		}
+
+		switch aws.StringValue(result.Status) {
+		case lambda.ProvisionedConcurrencyStatusEnumReady:
+			log.Infof("Provisioned concurrency of lambda function %s:%s is ready (%d environments allocated).",
+				functionName, alias, aws.Int64Value(result.AllocatedProvisionedConcurrentExecutions))
+			return
+		case lambda.ProvisionedConcurrencyStatusEnumFailed:
+			log.Fatalf("Provisioned concurrency allocation of lambda function %s:%s failed: %s",
+				functionName, alias, aws.StringValue(result.StatusReason))
+		}
+
+		log.Debugf("Provisioned concurrency of lambda function %s:%s is %s (%d/%d environments allocated).",
+			functionName, alias, aws.StringValue(result.Status),
+			aws.Int64Value(result.AllocatedProvisionedConcurrentExecutions),
+			aws.Int64Value(result.RequestedProvisionedConcurrentExecutions))
+		time.Sleep(provisionedConcurrencyPollInterval)
+	}
+
+	log.Fatalf("Provisioned concurrency of lambda function %s:%s was not ready after %v.", functionName, alias, provisionedConcurrencyTimeout)
+}
+
+//RemoveProvisionedConcurrency resets the provisioned concurrency of the given function alias, releasing its pre-initialized
+//execution environments. Functions without such a configuration are left untouched.
+func (instance awsSingleton) RemoveProvisionedConcurrency(functionName string, alias string) {
+	log.Infof("Removing provisioned concurrency of lambda function %s:%s", functionName, alias)
+
+	args := &lambda.DeleteProvisionedConcurrencyConfigInput{
+		FunctionName: aws.String(functionName),
+		Qualifier:    aws.String(alias),
+	}
+
+	result, err := instance.lambdaSvc.DeleteProvisionedConcurrencyConfig(args)
+	if err != nil {
+		if strings.Contains(err.Error(), "TooManyRequestsException") {
+			log.Warnf("Facing AWS rate-limiting error, retrying...")
+			instance.RemoveProvisionedConcurrency(functionName, alias)
+			return
+		}
+
+		if strings.Contains(err.Error(), lambda.ErrCodeProvisionedConcurrencyConfigNotFoundException) ||
+			strings.Contains(err.Error(), lambda.ErrCodeResourceNotFoundException) {
+			log.Warnf("Lambda function %s:%s has no provisioned concurrency to remove.", functionName, alias)
+			return
+		}
+
+		log.Errorf("Cannot remove provisioned concurrency: %s", err.Error())
+		return
+	}
+	log.Debugf("Remove provisioned concurrency result: %s", result.String())
+}
@@ -31,11 +31,32 @@ import (
 	"strings"
 )
 
-func (instance awsSingleton) DeployFunction(binaryPath string, packageType string, language string, memoryAssigned int64) string {
+//functionDescription is the description of the functions created by vHive-bench
+const functionDescription = "Benchmarking function managed and used by vHive-bench."
+
+//imageSizeDescriptionFormat records the compressed image size in the description of Image functions, as Lambda does
+//not report the code size of container images
+const imageSizeDescriptionFormat = " Image size: %d bytes."
+
+//ImageFunctionDescription returns the description of an Image function created from an image of the given compressed size.
+func ImageFunctionDescription(imageSizeBytes int64) string {
+	return functionDescription + fmt.Sprintf(imageSizeDescriptionFormat, imageSizeBytes)
+}
+
+//ImageSizeFromDescription returns the compressed image size recorded in the description of an Image function, or 0.
+func ImageSizeFromDescription(description string) int64 {
+	var imageSizeBytes int64
+	if _, err := fmt.Sscanf(strings.TrimPrefix(description, functionDescription), imageSizeDescriptionFormat, &imageSizeBytes); err != nil {
+		return 0
+	}
+	return imageSizeBytes
+}
+
+func (instance awsSingleton) DeployFunction(binaryPath string, packageType string, language string, architecture string, memoryAssigned int64) string {
 	apiConfig := instance.createRESTAPI()
 
 	functionName := fmt.Sprintf("%s%s", namingPrefix, *apiConfig.Id)
-	functionConfig := instance.createFunction(binaryPath, packageType, functionName, language, memoryAssigned)
+	functionConfig := instance.createFunction(binaryPath, packageType, functionName, language, architecture, memoryAssigned)
 
 	resourceID := instance.getResourceID(*apiConfig.Name, *apiConfig.Id)
 	instance.createAPIFunctionIntegration(*apiConfig.Name, functionName, *apiConfig.Id, resourceID, *functionConfig.FunctionArn)
@@ -116,7 +137,7 @@ func (instance awsSingleton) getResourceID(APIName string, apiID string) string
 	return ""
 }
 
-func (instance awsSingleton) createFunction(binaryPath string, packageType string, functionName string, language string, memoryAssigned int64) *lambda.FunctionConfiguration {
+func (instance awsSingleton) createFunction(binaryPath string, packageType string, functionName string, language string, architecture string, memoryAssigned int64) *lambda.FunctionConfiguration {
 	var lambdaExecutionRole = fmt.Sprintf("arn:aws:iam::%s:role/LambdaProducerConsumer", UserARNNumber)
 	log.Infof("Creating producer function %s with role ARN %s", functionName, lambdaExecutionRole)
 
@@ -126,7 +147,7 @@ func (instance awsSingleton) createFunction(binaryPath string, packageType strin
 		var createCode *lambda.FunctionCode
 		if instance.S3Key != "" {
 			createCode = &lambda.FunctionCode{
-				S3Bucket: aws.String(AWSSingletonInstance.S3Bucket),
+				S3Bucket: aws.String(instance.S3Bucket),
 				S3Key:    aws.String(instance.S3Key),
 			}
 		} else {
@@ -138,7 +159,7 @@ func (instance awsSingleton) createFunction(binaryPath string, packageType strin
 		createArgs = &lambda.CreateFunctionInput{
 			PackageType:   aws.String(lambda.PackageTypeZip),
 			Code:          createCode,
-			Description:   aws.String("Benchmarking function managed and used by vHive-bench."),
+			Description:   aws.String(functionDescription),
 			Role:          aws.String(lambdaExecutionRole),
 			FunctionName:  aws.String(functionName),
 			Handler:       aws.String(binaryPath),
@@ -146,6 +167,7 @@ func (instance awsSingleton) createFunction(binaryPath string, packageType strin
 			TracingConfig: &lambda.TracingConfig{Mode: aws.String("PassThrough")},
 			Timeout:       aws.Int64(maxFunctionTimeout),
 			MemorySize:    aws.Int64(memoryAssigned),
+			Architectures: aws.StringSlice([]string{architecture}),
 		}
 	case "Image":
 		createArgs = &lambda.CreateFunctionInput{
@@ -153,12 +175,13 @@ func (instance awsSingleton) createFunction(binaryPath string, packageType strin
 			Code: &lambda.FunctionCode{
 				ImageUri: aws.String(instance.ImageURI),
 			},
-			Description:   aws.String("Benchmarking function managed and used by vHive-bench."),
+			Description:   aws.String(ImageFunctionDescription(instance.ImageSizeBytes)),
 			Role:          aws.String(lambdaExecutionRole),
 			FunctionName:  aws.String(functionName),
 			TracingConfig: &lambda.TracingConfig{Mode: aws.String("PassThrough")},
 			Timeout:       aws.Int64(maxFunctionTimeout),
 			MemorySize:    aws.Int64(memoryAssigned),
+			Architectures: aws.StringSlice([]string{architecture}),
 		}
 	default:
 		log.Fatalf("Package type %s not supported for function creation.", packageType)
@@ -168,7 +191,7 @@ func (instance awsSingleton) createFunction(binaryPath string, packageType strin
 	if err != nil {
 		if strings.Contains(err.Error(), "TooManyRequestsException") {
 			log.Warnf("Facing AWS rate-limiting error, retrying...")
-			return instance.createFunction(binaryPath, packageType, functionName, language, memoryAssigned)
+			return instance.createFunction(binaryPath, packageType, functionName, language, architecture, memoryAssigned)
 		}
 
 		log.Fatalf("Cannot create function: %s", err.Error())
@@ -191,7 +214,7 @@ func (instance awsSingleton) createAPIFunctionIntegration(APIName string, functi
 		RestApiId:  aws.String(apiID),
 		Type:       aws.String("AWS_PROXY"),
 		Uri: aws.String(fmt.Sprintf("arn:aws:apigateway:%s:lambda:path/2015-03-31/functions/%s/invocations",
-			AWSRegion, arn)),
+			instance.Region, arn)),
 	}
 
 	result, err := instance.apiGatewaySvc.PutIntegration(args)
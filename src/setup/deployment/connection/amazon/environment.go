@@ -0,0 +1,72 @@
+package amazon
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+const (
+	functionUpdatePollInterval = 2 * time.Second
+	functionUpdateTimeout      = 5 * time.Minute
+)
+
+// SetFunctionEnvironmentVariable sets an environment variable of the given function, keeping its other variables, and
+// blocks until the update is complete. Lambda serves the requests following the update from new execution environments.
+func (instance awsSingleton) SetFunctionEnvironmentVariable(functionName string, name string, value string) {
+	log.Debugf("Setting environment variable %s=%s of lambda function %s", name, value, functionName)
+
+	variables := map[string]*string{}
+	if environment := instance.getFunctionConfiguration(functionName).Environment; environment != nil {
+		for variable, variableValue := range environment.Variables {
+			variables[variable] = variableValue
+		}
+	}
+	variables[name] = aws.String(value)
+
+	instance.updateFunctionConfiguration(&lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(functionName),
+		Environment:  &lambda.Environment{Variables: variables},
+	})
+	instance.WaitForFunctionUpdate(functionName)
+}
+
+// WaitForFunctionUpdate blocks until the last update of the configuration of the given function is complete.
+func (instance awsSingleton) WaitForFunctionUpdate(functionName string) {
+	deadline := time.Now().Add(functionUpdateTimeout)
+	for time.Now().Before(deadline) {
+		configuration := instance.getFunctionConfiguration(functionName)
+
+		switch aws.StringValue(configuration.LastUpdateStatus) {
+		case lambda.LastUpdateStatusSuccessful:
+			return
+		case lambda.LastUpdateStatusFailed:
+			log.Fatalf("Update of lambda function %s failed: %s", functionName, aws.StringValue(configuration.LastUpdateStatusReason))
+		}
+
+		log.Debugf("Update of lambda function %s is %s...", functionName, aws.StringValue(configuration.LastUpdateStatus))
+		time.Sleep(functionUpdatePollInterval)
+	}
+
+	log.Fatalf("Update of lambda function %s was not complete after %v.", functionName, functionUpdateTimeout)
+}
+
+func (instance awsSingleton) getFunctionConfiguration(functionName string) *lambda.FunctionConfiguration {
+	args := &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(functionName),
+	}
+
+	result, err := instance.lambdaSvc.GetFunctionConfiguration(args)
+	if err != nil {
+		if strings.Contains(err.Error(), "TooManyRequestsException") {
+			log.Warnf("Facing AWS rate-limiting error, retrying...")
+			time.Sleep(functionUpdatePollInterval)
+			return instance.getFunctionConfiguration(functionName)
+		}
+
+		log.Fatalf("Cannot get function configuration: %s", err.Error())
+	}
+	return result
+}
@@ -37,13 +37,14 @@ import (
 	log "github.com/sirupsen/logrus"
 	"io"
 	"os"
-	"strings"
 	"stellar/util"
+	"strings"
+	"sync"
 )
 
 const (
-	//AWSRegion is the region that AWS operates in
-	AWSRegion = endpoints.UsWest1RegionID
+	//DefaultAWSRegion is the region that AWS operates in when a sub-experiment does not specify one
+	DefaultAWSRegion = endpoints.UsWest1RegionID
 	//AWSBucketName is the name of the bucket where the client operates
 	AWSBucketName      = "stellar"
 	deploymentStage    = "prod"
@@ -51,13 +52,19 @@ const (
 	namingPrefix       = "vHive-bench_"
 )
 
-//AWSSingletonInstance is an object used to interact with AWS through the methods it exports.
+//AWSSingletonInstance is an object used to interact with AWS (in the default region) through the methods it exports.
 var AWSSingletonInstance *awsSingleton
 
+// regionalInstances holds one singleton per AWS region, created lazily by Instance.
+var regionalInstances = make(map[string]*awsSingleton)
+var regionalInstancesMutex sync.Mutex
+
 //UserARNNumber is used in AWS benchmarking for client authentication
 var UserARNNumber string
 
 type awsSingleton struct {
+	// Region is the AWS region this instance operates in
+	Region string
 	// RequestSigner is the AWS object used for signing HTTP requests
 	RequestSigner *v4.Signer
 	// S3Key is the bucket location in which this specific deployment will be uploaded
@@ -65,7 +72,9 @@ type awsSingleton struct {
 	// S3Bucket is the bucket in which this specific deployment will be uploaded
 	S3Bucket string
 	// ImageURI is the location where the docker image is located
-	ImageURI                string
+	ImageURI string
+	// ImageSizeBytes is the compressed size of the docker image, recorded in the description of the functions
+	ImageSizeBytes          int64
 	s3Uploader              *s3manager.Uploader
 	s3Svc                   *s3.S3
 	lambdaSvc               *lambda.Lambda
@@ -77,39 +86,103 @@ type awsSingleton struct {
 
 //InitializeSingleton will create a new Amazon awsSingleton to interact with different AWS services.
 func InitializeSingleton(apiTemplatePath string) {
-	sessionInstance := session.Must(session.NewSession(&aws.Config{
-		Region:                         aws.String(AWSRegion),
-		CredentialsChainVerboseErrors:  aws.Bool(true),
-		DisableRestProtocolURICleaning: aws.Bool(true),
-	}))
-
 	apiTemplateByteValue, err := io.ReadAll(util.ReadFile(apiTemplatePath))
 	if err != nil {
 		log.Fatalf("Could not read API template JSON when initializing AWS connection: %s", err.Error())
 	}
 
-	AWSSingletonInstance = &awsSingleton{
+	regionalInstancesMutex.Lock()
+	defer regionalInstancesMutex.Unlock()
+
+	regionalInstances = make(map[string]*awsSingleton)
+	AWSSingletonInstance = newAWSSingleton(DefaultAWSRegion, apiTemplateByteValue)
+	regionalInstances[DefaultAWSRegion] = AWSSingletonInstance
+}
+
+//Instance returns the singleton used to interact with AWS services in the given region, creating it on first use.
+//An empty region selects the default region.
+func Instance(region string) *awsSingleton {
+	if region == "" {
+		region = DefaultAWSRegion
+	}
+
+	regionalInstancesMutex.Lock()
+	defer regionalInstancesMutex.Unlock()
+
+	if instance, ok := regionalInstances[region]; ok {
+		return instance
+	}
+
+	if AWSSingletonInstance == nil {
+		log.Fatalf("AWS connection must be initialized before requesting an instance for region %s.", region)
+	}
+
+	log.Infof("Creating AWS connection for region %s.", region)
+	instance := newAWSSingleton(region, AWSSingletonInstance.apiTemplateFileContents)
+	regionalInstances[region] = instance
+	return instance
+}
+
+//Regions returns the AWS regions for which a connection has been created.
+func Regions() []string {
+	regionalInstancesMutex.Lock()
+	defer regionalInstancesMutex.Unlock()
+
+	regions := make([]string, 0, len(regionalInstances))
+	for region := range regionalInstances {
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+func newAWSSingleton(region string, apiTemplateFileContents []byte) *awsSingleton {
+	sessionInstance := session.Must(session.NewSession(&aws.Config{
+		Region:                         aws.String(region),
+		CredentialsChainVerboseErrors:  aws.Bool(true),
+		DisableRestProtocolURICleaning: aws.Bool(true),
+	}))
+
+	return &awsSingleton{
+		Region:                  region,
 		RequestSigner:           v4.NewSigner(sessionInstance.Config.Credentials),
 		lambdaSvc:               lambda.New(sessionInstance),
 		apiGatewaySvc:           apigateway.New(sessionInstance),
 		s3Svc:                   s3.New(sessionInstance),
 		s3Uploader:              s3manager.NewUploader(sessionInstance),
 		ecrSvc:                  ecr.New(sessionInstance),
-		apiTemplateFileContents: apiTemplateByteValue,
-		S3Bucket:                AWSBucketName,
+		apiTemplateFileContents: apiTemplateFileContents,
+		S3Bucket:                BucketName(region),
 	}
 }
 
-//UploadZIPToS3 helps get around the 50MB image size limit for AWS functions.
-func UploadZIPToS3(localZipPath string, sizeMB float64) {
-	log.Infof(`Deploying to AWS and package size (~%vMB) > 50 MB, will now attempt to upload to Amazon S3.`, sizeMB)
-	AWSSingletonInstance.S3Key = fmt.Sprintf("benchmarking%vMB.zip", sizeMB)
+//BucketName returns the S3 bucket used in the given region. Lambda requires code stored in S3 to be in the
+//same region as the function, so regions other than the default use a bucket suffixed with the region name.
+func BucketName(region string) string {
+	if region == "" || region == DefaultAWSRegion {
+		return AWSBucketName
+	}
+	return fmt.Sprintf("%s-%s", AWSBucketName, region)
+}
 
-	if _, err := AWSSingletonInstance.s3Svc.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(AWSSingletonInstance.S3Bucket),
-		Key:    aws.String(AWSSingletonInstance.S3Key),
+//ECRRegistryURI returns the private ECR registry of the user in the given region.
+func ECRRegistryURI(region string) string {
+	if region == "" {
+		region = DefaultAWSRegion
+	}
+	return fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", UserARNNumber, region)
+}
+
+//UploadZIPToS3 helps get around the 50MB image size limit for AWS functions.
+func UploadZIPToS3(region string, localZipPath string, sizeMB float64) {
+	instance := Instance(region)
+	log.Infof(`Deploying to AWS (%s) and package size (~%vMB) > 50 MB, will now attempt to upload to Amazon S3.`, instance.Region, sizeMB)
+	instance.S3Key = fmt.Sprintf("benchmarking%vMB.zip", sizeMB)
+
+	if _, err := instance.s3Svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(instance.S3Bucket),
+		Key:    aws.String(instance.S3Key),
 	}); err == nil {
-		log.Infof("Object %q was already found in S3 bucket %q, skipping upload.", AWSSingletonInstance.S3Key, AWSSingletonInstance.S3Bucket)
+		log.Infof("Object %q was already found in S3 bucket %q, skipping upload.", instance.S3Key, instance.S3Bucket)
 		return
 	}
 
@@ -118,36 +191,36 @@ func UploadZIPToS3(localZipPath string, sizeMB float64) {
 		log.Fatalf("Failed to open zip file %q: %v", localZipPath, err)
 	}
 
-	uploadOutput, err := AWSSingletonInstance.s3Uploader.Upload(&s3manager.UploadInput{
-		Bucket: aws.String(AWSSingletonInstance.S3Bucket),
-		Key:    aws.String(AWSSingletonInstance.S3Key),
+	uploadOutput, err := instance.s3Uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(instance.S3Bucket),
+		Key:    aws.String(instance.S3Key),
 		Body:   zipFile,
 	})
 	if err != nil {
-		log.Fatalf("Unable to upload %q to %q, %v", AWSSingletonInstance.S3Key, AWSSingletonInstance.S3Bucket, err.Error())
+		log.Fatalf("Unable to upload %q to %q, %v", instance.S3Key, instance.S3Bucket, err.Error())
 	}
 
-	log.Infof("Successfully uploaded %q to bucket %q (%s)", AWSSingletonInstance.S3Key, AWSSingletonInstance.S3Bucket, uploadOutput.Location)
+	log.Infof("Successfully uploaded %q to bucket %q (%s)", instance.S3Key, instance.S3Bucket, uploadOutput.Location)
 }
 
-//SetLocalZip sets the location of the zipped binary file for the function to be deployed.
-func SetLocalZip(path string) {
+//SetLocalZip sets the location of the zipped binary file for the function to be deployed in the given region.
+func SetLocalZip(region string, path string) {
 	zipBytes, err := os.ReadFile(path)
 	if err != nil {
 		log.Fatalf("Could not read local zipped binary: %s", err.Error())
 	}
-	AWSSingletonInstance.localZipFileContents = zipBytes
+	Instance(region).localZipFileContents = zipBytes
 }
 
-//GetECRAuthorizationToken helps the client get authorization for container AWS deployment.
-func GetECRAuthorizationToken() string {
-	log.Info("Requesting ECR authorization token.")
+//GetECRAuthorizationToken helps the client get authorization for container AWS deployment in the given region.
+func GetECRAuthorizationToken(region string) string {
+	log.Infof("Requesting ECR authorization token for region %s.", region)
 
-	result, err := AWSSingletonInstance.ecrSvc.GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
+	result, err := Instance(region).ecrSvc.GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
 	if err != nil {
 		if strings.Contains(err.Error(), "TooManyRequestsException") {
 			log.Warnf("Facing AWS rate-limiting error, retrying...")
-			return GetECRAuthorizationToken()
+			return GetECRAuthorizationToken(region)
 		}
 
 		log.Fatalf("Cannot obtain ECR authorization token: %s", err.Error())
@@ -30,7 +30,7 @@ import (
 	"strings"
 )
 
-func (instance awsSingleton) UpdateFunction(packageType string, uniqueID string) *lambda.FunctionConfiguration {
+func (instance awsSingleton) UpdateFunction(packageType string, uniqueID string, architecture string) *lambda.FunctionConfiguration {
 	functionName := fmt.Sprintf("%s%s", namingPrefix, uniqueID)
 	log.Infof("Updating producer lambda code %s", functionName)
 
@@ -40,7 +40,7 @@ func (instance awsSingleton) UpdateFunction(packageType string, uniqueID string)
 		if instance.S3Key != "" {
 			args = &lambda.UpdateFunctionCodeInput{
 				FunctionName: aws.String(functionName),
-				S3Bucket:     aws.String(AWSSingletonInstance.S3Bucket),
+				S3Bucket:     aws.String(instance.S3Bucket),
 				S3Key:        aws.String(instance.S3Key),
 			}
 		} else {
@@ -57,12 +57,14 @@ func (instance awsSingleton) UpdateFunction(packageType string, uniqueID string)
 	default:
 		log.Fatalf("Package type %s not supported for function update.", packageType)
 	}
+	// The architecture is set with the code, so that repurposed functions run the code built for it
+	args.Architectures = aws.StringSlice([]string{architecture})
 
 	result, err := instance.lambdaSvc.UpdateFunctionCode(args)
 	if err != nil {
 		if strings.Contains(err.Error(), "TooManyRequestsException") {
 			log.Warnf("Facing AWS rate-limiting error, retrying...")
-			return instance.UpdateFunction(packageType, uniqueID)
+			return instance.UpdateFunction(packageType, uniqueID, architecture)
 		}
 
 		log.Fatalf("Cannot update function code: %s", err.Error())
@@ -77,22 +79,38 @@ func (instance awsSingleton) UpdateFunctionConfiguration(uniqueID string, assign
 	functionName := fmt.Sprintf("%s%s", namingPrefix, uniqueID)
 	log.Infof("Updating producer lambda configuration %s", functionName)
 
-	args := &lambda.UpdateFunctionConfigurationInput{
+	instance.updateFunctionConfiguration(&lambda.UpdateFunctionConfigurationInput{
 		FunctionName: aws.String(functionName),
 		MemorySize:   aws.Int64(assignedMemory),
 		Timeout:      aws.Int64(600),
-	}
+	})
+}
+
+//UpdateFunctionMemory will update the memory of the serverless function with the given name, e.g., between the steps of
+//a memory sweep, and wait until the update is complete.
+func (instance awsSingleton) UpdateFunctionMemory(functionName string, assignedMemory int64) {
+	log.Infof("Updating lambda memory %s to %dMB", functionName, assignedMemory)
+
+	instance.updateFunctionConfiguration(&lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(functionName),
+		MemorySize:   aws.Int64(assignedMemory),
+	})
+	instance.WaitForFunctionUpdate(functionName)
+}
 
+func (instance awsSingleton) updateFunctionConfiguration(args *lambda.UpdateFunctionConfigurationInput) {
 	result, err := instance.lambdaSvc.UpdateFunctionConfiguration(args)
 	if err != nil {
 		if strings.Contains(err.Error(), "TooManyRequestsException") {
 			log.Warnf("Facing AWS rate-limiting error, retrying...")
-			instance.UpdateFunctionConfiguration(uniqueID, assignedMemory)
+			instance.updateFunctionConfiguration(args)
+			return
 		}
 
 		if strings.Contains(err.Error(), "ResourceConflictException") {
 			log.Warnf("Facing AWS resource conflict error, retrying...")
-			instance.UpdateFunctionConfiguration(uniqueID, assignedMemory)
+			instance.updateFunctionConfiguration(args)
+			return
 		}
 
 		log.Fatalf("Cannot update function configuration: %s", err.Error())
@@ -26,10 +26,13 @@ package connection
 
 import (
 	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"path"
 	"stellar/setup/deployment/connection/amazon"
+	"stellar/setup/runtimes"
 	"stellar/util"
 	"strings"
 	"time"
@@ -41,55 +44,81 @@ type Endpoint struct {
 	FunctionMemoryMB int64   `json:"FunctionMemoryMB"`
 	ImageSizeMB      float64 `json:"ImageSizeMB"`
 	PackageType      string  `json:"PackageType"`
+	Region           string  `json:"Region"`
+	// Architecture is the instruction set architecture of the function, x86_64 if not set
+	Architecture string `json:"Architecture"`
 }
 
 // ServerlessInterface creates an interface through which to interact with various providers
 type ServerlessInterface struct {
-	//ListAPIs will list all endpoints corresponding to all serverless functions.
-	ListAPIs func() []Endpoint
+	//ListAPIs will list all endpoints in the given region corresponding to all serverless functions.
+	//Endpoints without a region are considered available in every region.
+	ListAPIs func(region string) []Endpoint
 
-	//DeployFunction will create a new serverless function in the specified language, with the specified amount of
-	//memory. An API to access it will then be created, as well as corresponding permissions and integrations.
-	DeployFunction func(binaryPath string, packageType string, language string, memoryAssigned int64) string
+	//DeployFunction will create a new serverless function in the specified region, language and architecture, with the specified
+	//amount of memory. An API to access it will then be created, as well as corresponding permissions and integrations.
+	DeployFunction func(region string, binaryPath string, packageType string, language string, architecture string, memoryAssigned int64) string
 
-	//RemoveFunction will remove the serverless function with given ID and its corresponding API.
-	RemoveFunction func(uniqueID string)
+	//RemoveFunction will remove the serverless function with given ID and region and its corresponding API.
+	RemoveFunction func(region string, uniqueID string)
 
-	//UpdateFunction will update the source code of the serverless function with given ID to the specified
-	//memory and to the most recently set code deployment settings (e.g., S3 key).
-	UpdateFunction func(packageType string, uniqueID string, memoryAssigned int64)
+	//UpdateFunction will update the source code of the serverless function with given ID and region to the specified
+	//architecture and memory and to the most recently set code deployment settings (e.g., S3 key).
+	UpdateFunction func(region string, packageType string, uniqueID string, architecture string, memoryAssigned int64)
 }
 
-// Singleton allows the client to interact with various serverless actions
-var Singleton *ServerlessInterface
+// providerConnections holds the connection to every provider targeted by the experiments
+var providerConnections = make(map[string]*ServerlessInterface)
 
-// Initialize will create a new provider connection to interact with
-func Initialize(provider string, endpointsDirectoryPath string, apiTemplatePath string) {
+// Initialize will create a new connection to interact with for each of the given providers
+func Initialize(providers []string, endpointsDirectoryPath string, apiTemplatePath string) {
+	for _, provider := range providers {
+		providerConnections[provider] = initializeProvider(provider, endpointsDirectoryPath, apiTemplatePath)
+	}
+}
+
+// Get returns the connection previously initialized for the given provider
+func Get(provider string) *ServerlessInterface {
+	providerConnection, ok := providerConnections[provider]
+	if !ok {
+		log.Fatalf("Connection to provider %s was not initialized.", provider)
+	}
+	return providerConnection
+}
+
+func initializeProvider(provider string, endpointsDirectoryPath string, apiTemplatePath string) *ServerlessInterface {
 	switch strings.ToLower(provider) {
 	case "aws":
-		setupAWSConnection(apiTemplatePath)
+		return setupAWSConnection(apiTemplatePath)
 	case "vhive":
-		setupFileConnection(path.Join(endpointsDirectoryPath, "vHive.json"))
+		return setupFileConnection(path.Join(endpointsDirectoryPath, "vHive.json"))
 	case "azure":
-		setupFileConnection(path.Join(endpointsDirectoryPath, "azure.json"))
+		return setupFileConnection(path.Join(endpointsDirectoryPath, "azure.json"))
 	case "google":
-		setupFileConnection(path.Join(endpointsDirectoryPath, "google.json"))
+		return setupFileConnection(path.Join(endpointsDirectoryPath, "google.json"))
 	default:
-		setupExternalConnection()
 		log.Warnf("Provider %s does not support initialization with the client, setting to external URL.", provider)
+		return setupExternalConnection()
 	}
 }
 
-func setupAWSConnection(apiTemplatePath string) {
+// functionRuntimes are the runtimes of the functions deployed through the AWS APIs
+var functionRuntimes = map[string]string{
+	"producer-consumer": "go1.x",
+	"hellopy":           "python3.8",
+}
+
+func setupAWSConnection(apiTemplatePath string) *ServerlessInterface {
 	amazon.InitializeSingleton(apiTemplatePath)
 
-	Singleton = &ServerlessInterface{
-		ListAPIs: func() []Endpoint {
+	return &ServerlessInterface{
+		ListAPIs: func(region string) []Endpoint {
+			instance := amazon.Instance(region)
 			mustRepeatListRequest := true
 			for mustRepeatListRequest {
 				mustRepeatListRequest = false
-				result := amazon.AWSSingletonInstance.ListFunctions(nil)
-				log.Infof("Found %d Lambda functions.", len(result))
+				result := instance.ListFunctions(nil)
+				log.Infof("Found %d Lambda functions in region %s.", len(result), instance.Region)
 
 				functions := make([]Endpoint, 0)
 				for _, function := range result {
@@ -99,11 +128,18 @@ func setupAWSConnection(apiTemplatePath string) {
 							break
 						}
 
+						codeSizeBytes := *function.CodeSize
+						if *function.PackageType == lambda.PackageTypeImage {
+							codeSizeBytes = amazon.ImageSizeFromDescription(aws.StringValue(function.Description))
+						}
+
 						functions = append(functions, Endpoint{
 							GatewayID:        strings.Split(*function.FunctionName, "_")[1],
 							FunctionMemoryMB: *function.MemorySize,
 							PackageType:      *function.PackageType,
-							ImageSizeMB:      util.BytesToMebibyte(*function.CodeSize),
+							ImageSizeMB:      util.BytesToMebibyte(codeSizeBytes),
+							Region:           instance.Region,
+							Architecture:     functionArchitecture(function),
 						})
 					}
 				}
@@ -113,41 +149,42 @@ func setupAWSConnection(apiTemplatePath string) {
 			}
 			return make([]Endpoint, 0)
 		},
-		DeployFunction: func(binaryPath string, packageType string, function string, memoryAssigned int64) string {
-			const (
-				golangRuntime = "go1.x"
-				pythonRuntime = "python3.8"
-			)
-
-			var language string
-			switch function {
-			case "producer-consumer":
-				language = golangRuntime
-			case "hellopy":
-				language = pythonRuntime
-			default:
+		DeployFunction: func(region string, binaryPath string, packageType string, function string, architecture string, memoryAssigned int64) string {
+			runtime, ok := functionRuntimes[function]
+			if !ok {
 				log.Fatalf("DeployFunction could not recognize function image %s", function)
 			}
+			language := runtimes.ProviderRuntime(runtime, "aws")
 
-			return amazon.AWSSingletonInstance.DeployFunction(binaryPath, packageType, language, memoryAssigned)
+			return amazon.Instance(region).DeployFunction(binaryPath, packageType, language, architecture, memoryAssigned)
 		},
-		RemoveFunction: func(uniqueID string) {
-			amazon.AWSSingletonInstance.RemoveFunction(uniqueID)
-			amazon.AWSSingletonInstance.RemoveAPIGateway(uniqueID)
+		RemoveFunction: func(region string, uniqueID string) {
+			instance := amazon.Instance(region)
+			instance.RemoveFunction(uniqueID)
+			instance.RemoveAPIGateway(uniqueID)
 		},
-		UpdateFunction: func(packageType string, uniqueID string, memoryAssigned int64) {
-			amazon.AWSSingletonInstance.UpdateFunction(packageType, uniqueID)
+		UpdateFunction: func(region string, packageType string, uniqueID string, architecture string, memoryAssigned int64) {
+			instance := amazon.Instance(region)
+			instance.UpdateFunction(packageType, uniqueID, architecture)
 
 			time.Sleep(time.Second * 5) // https://aws.amazon.com/de/blogs/compute/coming-soon-expansion-of-aws-lambda-states-to-all-functions/
 
-			amazon.AWSSingletonInstance.UpdateFunctionConfiguration(uniqueID, memoryAssigned)
+			instance.UpdateFunctionConfiguration(uniqueID, memoryAssigned)
 		},
 	}
 }
 
-func setupFileConnection(filePath string) {
-	Singleton = &ServerlessInterface{
-		ListAPIs: func() []Endpoint {
+// functionArchitecture returns the architecture of the Lambda function, x86_64 unless listed otherwise
+func functionArchitecture(function *lambda.FunctionConfiguration) string {
+	if len(function.Architectures) == 0 {
+		return lambda.ArchitectureX8664
+	}
+	return aws.StringValue(function.Architectures[0])
+}
+
+func setupFileConnection(filePath string) *ServerlessInterface {
+	return &ServerlessInterface{
+		ListAPIs: func(region string) []Endpoint {
 			endpointsFile := util.ReadFile(filePath)
 			configByteValue, _ := io.ReadAll(endpointsFile)
 
@@ -156,15 +193,30 @@ func setupFileConnection(filePath string) {
 				log.Fatalf("Could not extract endpoints configuration from file: %s", err.Error())
 			}
 
-			return parsedEndpoints
+			return filterEndpointsByRegion(parsedEndpoints, region)
 		},
 	}
 }
 
-func setupExternalConnection() {
-	Singleton = &ServerlessInterface{
-		ListAPIs: func() []Endpoint {
+func setupExternalConnection() *ServerlessInterface {
+	return &ServerlessInterface{
+		ListAPIs: func(region string) []Endpoint {
 			return nil
 		},
 	}
 }
+
+// filterEndpointsByRegion keeps the endpoints deployed in the given region, as well as those not specifying a region.
+func filterEndpointsByRegion(endpoints []Endpoint, region string) []Endpoint {
+	if region == "" {
+		return endpoints
+	}
+
+	filteredEndpoints := make([]Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Region == "" || endpoint.Region == region {
+			filteredEndpoints = append(filteredEndpoints, endpoint)
+		}
+	}
+	return filteredEndpoints
+}
@@ -0,0 +1,357 @@
+// Package docker runs benchmarked functions in local containers through the Docker Engine API, emulating the
+// scaling behaviour of serverless platforms (cold starts, scale-out and scale-to-zero) for offline experiments.
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultHost is the socket of the Docker Engine used when DOCKER_HOST is not set
+	DefaultHost = "unix:///var/run/docker.sock"
+
+	// apiBaseURL is the base of the Engine API URLs, the actual connection going through the socket
+	apiBaseURL = "http://docker"
+)
+
+// Engine talks to the Docker Engine API over its unix socket.
+type Engine struct {
+	socketPath string
+	httpClient *http.Client
+}
+
+var (
+	engineInstance *Engine
+	engineLock     sync.Mutex
+)
+
+// NewEngine creates a client for the Docker Engine listening on the given host, e.g., unix:///var/run/docker.sock.
+func NewEngine(host string) (*Engine, error) {
+	if host == "" {
+		host = DefaultHost
+	}
+	socketPath := strings.TrimPrefix(host, "unix://")
+	if socketPath == host && strings.Contains(host, "://") {
+		return nil, fmt.Errorf("only unix sockets are supported for the Docker Engine, got %s", host)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Engine{socketPath: socketPath, httpClient: &http.Client{Transport: transport}}, nil
+}
+
+// EngineInstance returns the Docker Engine client, connecting to DOCKER_HOST (or the default socket) if needed.
+func EngineInstance() (*Engine, error) {
+	engineLock.Lock()
+	defer engineLock.Unlock()
+
+	if engineInstance == nil {
+		engine, err := NewEngine(os.Getenv("DOCKER_HOST"))
+		if err != nil {
+			return nil, err
+		}
+		engineInstance = engine
+	}
+	return engineInstance, nil
+}
+
+// SetEngineInstance replaces the client returned by EngineInstance, e.g., with one for a stub of the Engine API in tests.
+func SetEngineInstance(engine *Engine) {
+	engineLock.Lock()
+	defer engineLock.Unlock()
+	engineInstance = engine
+}
+
+// ContainerSpec describes a container to be created for a function instance.
+type ContainerSpec struct {
+	Name  string
+	Image string
+	Cmd   []string
+	Env   []string
+	// Binds are the volumes mounted in the container, e.g., /path/on/host:/var/task:ro
+	Binds []string
+	// ContainerPort is the TCP port the function listens on in the container, published on a random host port
+	ContainerPort int
+	// MemoryMB and CPU limit the resources of the container, and are unlimited when 0
+	MemoryMB int64
+	CPU      float64
+}
+
+type portBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type hostConfig struct {
+	Binds        []string                 `json:"Binds,omitempty"`
+	PortBindings map[string][]portBinding `json:"PortBindings"`
+	Memory       int64                    `json:"Memory,omitempty"`
+	NanoCPUs     int64                    `json:"NanoCpus,omitempty"`
+}
+
+type containerConfig struct {
+	Image        string              `json:"Image"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	Labels       map[string]string   `json:"Labels"`
+	HostConfig   hostConfig          `json:"HostConfig"`
+}
+
+// ManagedByLabel marks the containers created by STeLLAR
+const ManagedByLabel = "stellar.managed-by"
+
+// CreateContainer creates a container for the given specification and returns its ID.
+func (e *Engine) CreateContainer(spec ContainerSpec) (string, error) {
+	port := fmt.Sprintf("%d/tcp", spec.ContainerPort)
+	config := containerConfig{
+		Image:        spec.Image,
+		Cmd:          spec.Cmd,
+		Env:          spec.Env,
+		ExposedPorts: map[string]struct{}{port: {}},
+		Labels:       map[string]string{ManagedByLabel: "stellar"},
+		HostConfig: hostConfig{
+			Binds:        spec.Binds,
+			PortBindings: map[string][]portBinding{port: {{HostIP: "127.0.0.1"}}},
+			Memory:       spec.MemoryMB * 1024 * 1024,
+			NanoCPUs:     int64(spec.CPU * 1e9),
+		},
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	path := "/containers/create?name=" + url.QueryEscape(spec.Name)
+	if err := e.do(http.MethodPost, path, config, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// StartContainer starts the container with the given ID.
+func (e *Engine) StartContainer(id string) error {
+	return e.do(http.MethodPost, "/containers/"+url.PathEscape(id)+"/start", nil, nil)
+}
+
+// StopContainer stops the container with the given ID, killing it after the given grace period.
+func (e *Engine) StopContainer(id string, graceSeconds int) error {
+	return e.do(http.MethodPost, fmt.Sprintf("/containers/%s/stop?t=%d", url.PathEscape(id), graceSeconds), nil, nil)
+}
+
+// RemoveContainer removes the container with the given ID, stopping it if needed.
+func (e *Engine) RemoveContainer(id string) error {
+	return e.do(http.MethodDelete, "/containers/"+url.PathEscape(id)+"?force=true", nil, nil)
+}
+
+// ContainerHostPort returns the host port on which the given container port is published.
+func (e *Engine) ContainerHostPort(id string, containerPort int) (string, error) {
+	var inspected struct {
+		NetworkSettings struct {
+			Ports map[string][]portBinding `json:"Ports"`
+		} `json:"NetworkSettings"`
+	}
+	if err := e.do(http.MethodGet, "/containers/"+url.PathEscape(id)+"/json", nil, &inspected); err != nil {
+		return "", err
+	}
+
+	bindings := inspected.NetworkSettings.Ports[fmt.Sprintf("%d/tcp", containerPort)]
+	if len(bindings) == 0 || bindings[0].HostPort == "" {
+		return "", fmt.Errorf("port %d of container %s is not published", containerPort, id)
+	}
+	return bindings[0].HostPort, nil
+}
+
+// PullImage pulls the given image, e.g., public.ecr.aws/lambda/python:3.9.
+func (e *Engine) PullImage(image string) error {
+	repository, tag := image, "latest"
+	if separator := strings.LastIndex(image, ":"); separator > strings.LastIndex(image, "/") {
+		repository, tag = image[:separator], image[separator+1:]
+	}
+	query := url.Values{"fromImage": {repository}, "tag": {tag}}
+	return e.doStream(http.MethodPost, "/images/create?"+query.Encode(), "", nil)
+}
+
+// BuildImage builds the Dockerfile at the root of the given directory into an image with the given tag.
+func (e *Engine) BuildImage(contextDir string, tag string) error {
+	// Closing the build context stops the archiving if the Engine fails before reading all of it
+	buildContext := tarDirectory(contextDir)
+	defer buildContext.Close()
+	query := url.Values{"t": {tag}, "rm": {"1"}}
+	return e.doStream(http.MethodPost, "/build?"+query.Encode(), "application/x-tar", buildContext)
+}
+
+// SaveImage writes the given image as a tarball to output, as with docker save.
+func (e *Engine) SaveImage(image string, output io.Writer) error {
+	response, err := e.httpClient.Get(apiBaseURL + "/images/" + url.PathEscape(image) + "/get")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(response.Body)
+		return &APIError{StatusCode: response.StatusCode, Body: string(responseBody)}
+	}
+	_, err = io.Copy(output, response.Body)
+	return err
+}
+
+// LoadImage loads the images of the given tarball, as with docker load.
+func (e *Engine) LoadImage(input io.Reader) error {
+	return e.doStream(http.MethodPost, "/images/load", "application/x-tar", input)
+}
+
+// do sends a request to the Engine API and decodes the JSON response into result, unless nil.
+func (e *Engine) do(method string, path string, body interface{}, result interface{}) error {
+	var requestBody io.Reader
+	if body != nil {
+		encodedBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(encodedBody)
+	}
+
+	request, err := http.NewRequest(method, apiBaseURL+path, requestBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := e.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	// 304 is returned when starting or stopping containers which already are in that state
+	if (response.StatusCode < 200 || response.StatusCode >= 300) && response.StatusCode != http.StatusNotModified {
+		return &APIError{StatusCode: response.StatusCode, Body: string(responseBody)}
+	}
+	if result != nil {
+		return json.Unmarshal(responseBody, result)
+	}
+	return nil
+}
+
+// doStream sends a request to an Engine API endpoint streaming JSON progress messages, e.g., image pulls and builds,
+// and returns the first error reported in the stream.
+func (e *Engine) doStream(method string, path string, contentType string, body io.Reader) error {
+	request, err := http.NewRequest(method, apiBaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := e.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(response.Body)
+		return &APIError{StatusCode: response.StatusCode, Body: string(responseBody)}
+	}
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var message struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &message) == nil && message.Error != "" {
+			return fmt.Errorf("docker engine: %s", message.Error)
+		}
+	}
+	return scanner.Err()
+}
+
+// APIError is returned when the Engine API answers with an unsuccessful status code.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Docker Engine API responded with status %d: %s", e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+// tarDirectory archives the files of the given directory, used as the build context of images. The archive is
+// streamed as it is read, so that large build contexts (e.g., with filler files) are not held in memory.
+func tarDirectory(directory string) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTarDirectory(writer, directory))
+	}()
+	return reader
+}
+
+func writeTarDirectory(output io.Writer, directory string) error {
+	archive := tar.NewWriter(output)
+
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
@@ -0,0 +1,364 @@
+package docker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lambdaInvocationPath is the path on which the Lambda Runtime Interface Emulator accepts invocations
+	lambdaInvocationPath = "/2015-03-31/functions/function/invocations"
+
+	// readinessPollInterval is how often a starting instance refusing connections is tried again
+	readinessPollInterval = time.Millisecond
+)
+
+// FunctionConfig describes a function emulated with local containers.
+type FunctionConfig struct {
+	Name string
+	// Container is the template of the containers of the function instances, each named after the function
+	Container ContainerSpec
+	// Lambda invokes the instances through the Lambda Runtime Interface Emulator instead of forwarding HTTP requests
+	Lambda bool
+	// IdleTimeout is how long an instance stays running without requests before it is stopped (scale-to-zero)
+	IdleTimeout time.Duration
+	// MinInstances are kept running, and at most MaxInstances run at once (0 for no limit)
+	MinInstances int
+	MaxInstances int
+	// ReadinessTimeout bounds how long a starting instance may refuse connections
+	ReadinessTimeout time.Duration
+}
+
+type instance struct {
+	containerID string
+	address     string
+	running     bool
+	busy        bool
+	lastUsed    time.Time
+}
+
+// Function is an HTTP endpoint serving each request with an idle instance of the function, starting a container
+// (i.e., a cold start) when none is available, and stopping the instances which stay idle for too long.
+type Function struct {
+	config     FunctionConfig
+	engine     *Engine
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	available *sync.Cond
+	instances []*instance
+	created   int
+
+	listener   net.Listener
+	server     *http.Server
+	stopReaper chan struct{}
+}
+
+// StartFunction starts the minimum instances of the function and serves its endpoint on a local port.
+func StartFunction(engine *Engine, config FunctionConfig) (*Function, error) {
+	function := &Function{
+		config:     config,
+		engine:     engine,
+		httpClient: &http.Client{},
+		stopReaper: make(chan struct{}),
+	}
+	function.available = sync.NewCond(&function.mu)
+
+	for i := 0; i < config.MinInstances; i++ {
+		warmInstance := &instance{busy: true}
+		function.instances = append(function.instances, warmInstance)
+		if err := function.startInstance(warmInstance); err != nil {
+			_ = function.Stop()
+			return nil, err
+		}
+		function.release(warmInstance)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = function.Stop()
+		return nil, err
+	}
+	function.listener = listener
+	function.server = &http.Server{Handler: function}
+	go func() {
+		if err := function.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Endpoint of function %s stopped: %s", config.Name, err.Error())
+		}
+	}()
+	go function.reapIdleInstances()
+	return function, nil
+}
+
+// Address returns the host and port of the function endpoint.
+func (f *Function) Address() string {
+	return f.listener.Addr().String()
+}
+
+// RunningInstances returns how many instances of the function are running.
+func (f *Function) RunningInstances() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	running := 0
+	for _, functionInstance := range f.instances {
+		if functionInstance.running {
+			running++
+		}
+	}
+	return running
+}
+
+// Stop closes the endpoint of the function and removes the containers of its instances.
+func (f *Function) Stop() error {
+	if f.server != nil {
+		_ = f.server.Close()
+		close(f.stopReaper)
+	}
+
+	f.mu.Lock()
+	instances := f.instances
+	f.instances = nil
+	f.mu.Unlock()
+
+	var errs []string
+	for _, functionInstance := range instances {
+		if functionInstance.containerID == "" {
+			continue
+		}
+		if err := f.engine.RemoveContainer(functionInstance.containerID); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not remove the containers of function %s: %s", f.config.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ServeHTTP serves a request with an instance of the function.
+func (f *Function) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	functionInstance, err := f.acquire()
+	if err != nil {
+		log.Errorf("Could not start an instance of function %s: %s", f.config.Name, err.Error())
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer f.release(functionInstance)
+
+	var statusCode int
+	var header http.Header
+	var body []byte
+	if f.config.Lambda {
+		statusCode, header, body, err = f.invokeLambda(functionInstance, r)
+	} else {
+		statusCode, header, body, err = f.forward(functionInstance, r)
+	}
+	if err != nil {
+		log.Errorf("Could not invoke function %s: %s", f.config.Name, err.Error())
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	for key, values := range header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
+
+// acquire returns an idle running instance, or starts one if there is none and the maximum is not reached.
+func (f *Function) acquire() (*instance, error) {
+	f.mu.Lock()
+	for {
+		for _, functionInstance := range f.instances {
+			if functionInstance.running && !functionInstance.busy {
+				functionInstance.busy = true
+				f.mu.Unlock()
+				return functionInstance, nil
+			}
+		}
+
+		var coldInstance *instance
+		for _, functionInstance := range f.instances {
+			if !functionInstance.running && !functionInstance.busy {
+				coldInstance = functionInstance
+				break
+			}
+		}
+		if coldInstance == nil && (f.config.MaxInstances == 0 || len(f.instances) < f.config.MaxInstances) {
+			coldInstance = &instance{}
+			f.instances = append(f.instances, coldInstance)
+		}
+
+		if coldInstance != nil {
+			coldInstance.busy = true
+			f.mu.Unlock()
+			if err := f.startInstance(coldInstance); err != nil {
+				f.release(coldInstance)
+				return nil, err
+			}
+			return coldInstance, nil
+		}
+		f.available.Wait()
+	}
+}
+
+// release makes the instance available for other requests
+func (f *Function) release(functionInstance *instance) {
+	f.mu.Lock()
+	functionInstance.busy = false
+	functionInstance.lastUsed = time.Now()
+	f.mu.Unlock()
+	f.available.Broadcast()
+}
+
+// startInstance creates the container of the instance if needed and starts it. The instance must be reserved (busy).
+func (f *Function) startInstance(functionInstance *instance) error {
+	if functionInstance.containerID == "" {
+		f.mu.Lock()
+		spec := f.config.Container
+		spec.Name = fmt.Sprintf("%s-%d", f.config.Name, f.created)
+		f.created++
+		f.mu.Unlock()
+
+		containerID, err := f.engine.CreateContainer(spec)
+		if err != nil {
+			return err
+		}
+		functionInstance.containerID = containerID
+	}
+
+	if err := f.engine.StartContainer(functionInstance.containerID); err != nil {
+		return err
+	}
+	// Containers get a new host port every time they are started
+	hostPort, err := f.engine.ContainerHostPort(functionInstance.containerID, f.config.Container.ContainerPort)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	functionInstance.address = net.JoinHostPort("127.0.0.1", hostPort)
+	functionInstance.running = true
+	f.mu.Unlock()
+	log.Debugf("Started instance %s of function %s at %s", functionInstance.containerID, f.config.Name, functionInstance.address)
+	return nil
+}
+
+// reapIdleInstances stops the instances which stayed idle for longer than the idle timeout, keeping the minimum instances
+func (f *Function) reapIdleInstances() {
+	interval := f.config.IdleTimeout / 2
+	if interval > time.Second {
+		interval = time.Second
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopReaper:
+			return
+		case <-ticker.C:
+		}
+
+		f.mu.Lock()
+		running := 0
+		for _, functionInstance := range f.instances {
+			if functionInstance.running {
+				running++
+			}
+		}
+		var idleInstances []*instance
+		for _, functionInstance := range f.instances {
+			if running <= f.config.MinInstances {
+				break
+			}
+			if functionInstance.running && !functionInstance.busy && time.Since(functionInstance.lastUsed) > f.config.IdleTimeout {
+				functionInstance.busy = true
+				idleInstances = append(idleInstances, functionInstance)
+				running--
+			}
+		}
+		f.mu.Unlock()
+
+		for _, functionInstance := range idleInstances {
+			log.Debugf("Stopping idle instance %s of function %s", functionInstance.containerID, f.config.Name)
+			if err := f.engine.StopContainer(functionInstance.containerID, 0); err != nil {
+				log.Errorf("Could not stop idle instance %s of function %s: %s", functionInstance.containerID, f.config.Name, err.Error())
+			}
+			f.mu.Lock()
+			functionInstance.running = false
+			f.mu.Unlock()
+			f.release(functionInstance)
+		}
+	}
+}
+
+// invokeLambda sends the request as an API Gateway event to the Lambda Runtime Interface Emulator of the instance
+func (f *Function) invokeLambda(functionInstance *instance, r *http.Request) (int, http.Header, []byte, error) {
+	event, err := LambdaEvent(r)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	_, _, payload, err := f.send(http.MethodPost, "http://"+functionInstance.address+lambdaInvocationPath, nil, event)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	statusCode, header, body := LambdaResponse(payload)
+	return statusCode, header, body, nil
+}
+
+// forward sends the request to the HTTP server of the instance
+func (f *Function) forward(functionInstance *instance, r *http.Request) (int, http.Header, []byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return f.send(r.Method, "http://"+functionInstance.address+r.URL.RequestURI(), r.Header, body)
+}
+
+// send delivers the request to an instance, retrying while the instance refuses connections as it is starting. Other
+// errors are returned as they are, as the instance may have received the request already and must not run it twice.
+func (f *Function) send(method string, url string, header http.Header, body []byte) (int, http.Header, []byte, error) {
+	deadline := time.Now().Add(f.config.ReadinessTimeout)
+	for {
+		request, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		for key, values := range header {
+			request.Header[key] = values
+		}
+
+		response, err := f.httpClient.Do(request)
+		if err == nil {
+			responseBody, err := io.ReadAll(response.Body)
+			response.Body.Close()
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			return response.StatusCode, response.Header, responseBody, nil
+		}
+
+		if !errors.Is(err, syscall.ECONNREFUSED) || time.Now().After(deadline) {
+			return 0, nil, nil, err
+		}
+		time.Sleep(readinessPollInterval)
+	}
+}
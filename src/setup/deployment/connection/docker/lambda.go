@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"stellar/setup/runtimes"
+)
+
+const (
+	// LambdaPort is the port on which the Lambda Runtime Interface Emulator of the AWS base images listens
+	LambdaPort = 8080
+	// LambdaTaskRoot is where the function code is mounted in the AWS base images
+	LambdaTaskRoot = "/var/task"
+)
+
+var lambdaRuntimeRegex = regexp.MustCompile(`^(python|nodejs|ruby|java|dotnet)(\d+(?:\.\d+)?)(?:\.x)?$`)
+
+// LambdaBaseImage returns the AWS Lambda base image, bundling the Runtime Interface Emulator, for the given Lambda
+// runtime identifier, e.g., public.ecr.aws/lambda/python:3.9 for python3.9.
+// Versions missing from the runtime registry are assumed to follow the naming of the base images of their language.
+func LambdaBaseImage(runtime string) (string, error) {
+	if registered, ok := runtimes.Lookup(runtime); ok && registered.LambdaBaseImage() != "" {
+		return registered.LambdaBaseImage(), nil
+	}
+	match := lambdaRuntimeRegex.FindStringSubmatch(runtime)
+	if match == nil {
+		return "", fmt.Errorf("no Lambda base image known for runtime %q", runtime)
+	}
+	return fmt.Sprintf("public.ecr.aws/lambda/%s:%s", match[1], match[2]), nil
+}
+
+type lambdaEvent struct {
+	HTTPMethod            string            `json:"httpMethod"`
+	Path                  string            `json:"path"`
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	Body                  string            `json:"body,omitempty"`
+}
+
+// LambdaEvent converts an HTTP request into the API Gateway event received by functions deployed to AWS.
+func LambdaEvent(r *http.Request) ([]byte, error) {
+	event := lambdaEvent{
+		HTTPMethod:            r.Method,
+		Path:                  r.URL.Path,
+		Headers:               map[string]string{},
+		QueryStringParameters: map[string]string{},
+	}
+	for key := range r.Header {
+		event.Headers[key] = r.Header.Get(key)
+	}
+	for key := range r.URL.Query() {
+		event.QueryStringParameters[key] = r.URL.Query().Get(key)
+	}
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		event.Body = string(body)
+	}
+	return json.Marshal(event)
+}
+
+type lambdaProxyResponse struct {
+	StatusCode *int              `json:"statusCode"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+	ErrorType  string            `json:"errorType"`
+}
+
+// LambdaResponse converts the payload returned by a function invocation into an HTTP response, unwrapping the
+// API Gateway proxy responses ({"statusCode": ..., "body": ...}) that functions deployed to AWS return.
+func LambdaResponse(payload []byte) (int, http.Header, []byte) {
+	// Some runtimes return the proxy response serialized into a JSON string
+	var serialized string
+	if json.Unmarshal(payload, &serialized) == nil && json.Valid([]byte(serialized)) {
+		payload = []byte(serialized)
+	}
+
+	var response lambdaProxyResponse
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return http.StatusOK, http.Header{}, payload
+	}
+	if response.ErrorType != "" {
+		return http.StatusBadGateway, http.Header{}, payload
+	}
+	if response.StatusCode == nil {
+		return http.StatusOK, http.Header{}, payload
+	}
+
+	header := http.Header{}
+	for key, value := range response.Headers {
+		header.Set(key, value)
+	}
+	body := []byte(response.Body)
+	var stringBody string
+	if json.Unmarshal(response.Body, &stringBody) == nil {
+		body = []byte(stringBody)
+	}
+	return *response.StatusCode, header, body
+}
@@ -0,0 +1,456 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/connection/docker"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stubContainer struct {
+	config  map[string]interface{}
+	server  *httptest.Server
+	starts  int
+	removed bool
+}
+
+// stubEngine imitates the parts of the Docker Engine API used to run functions, each started container being
+// backed by an HTTP server answering as the Lambda Runtime Interface Emulator or as the function itself.
+type stubEngine struct {
+	mu         sync.Mutex
+	containers map[string]*stubContainer
+	order      []string
+	// delay is the time each function invocation takes
+	delay time.Duration
+	// invocations counts the requests received by the functions, the first dropped of which are answered by closing
+	// the connection
+	invocations int
+	dropped     int
+	// images are the tarballs of the saved images by name, and loaded the last tarball loaded
+	images map[string][]byte
+	loaded []byte
+	// built are the files of the build context of the last image built
+	built []string
+}
+
+func newStubEngine(t *testing.T) (*stubEngine, *docker.Engine) {
+	stub := &stubEngine{containers: make(map[string]*stubContainer)}
+
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(stub.serve)}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() {
+		_ = server.Close()
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		for _, container := range stub.containers {
+			if container.server != nil {
+				container.server.Close()
+			}
+		}
+	})
+
+	engine, err := docker.NewEngine("unix://" + socketPath)
+	require.NoError(t, err)
+	return stub, engine
+}
+
+func (s *stubEngine) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/images/create":
+		if strings.Contains(r.URL.Query().Get("fromImage"), "missing") {
+			_, _ = fmt.Fprintln(w, `{"status":"Pulling from library"}`)
+			_, _ = fmt.Fprintln(w, `{"error":"manifest unknown"}`)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"status":"Downloaded newer image"}`)
+	case r.URL.Path == "/build" && r.Method == http.MethodPost:
+		s.built = nil
+		archive := tar.NewReader(r.Body)
+		for {
+			header, err := archive.Next()
+			if err != nil {
+				break
+			}
+			s.built = append(s.built, header.Name)
+		}
+		_, _ = fmt.Fprintf(w, "{\"stream\":\"Successfully tagged %s\"}\n", r.URL.Query().Get("t"))
+	case r.URL.Path == "/images/load" && r.Method == http.MethodPost:
+		s.loaded, _ = io.ReadAll(r.Body)
+		_, _ = fmt.Fprintln(w, `{"stream":"Loaded image"}`)
+	case len(parts) == 3 && parts[0] == "images" && parts[2] == "get":
+		image, ok := s.images[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(image)
+	case r.URL.Path == "/containers/create" && r.Method == http.MethodPost:
+		var config map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		id := fmt.Sprintf("container%d", len(s.order))
+		config["Name"] = r.URL.Query().Get("name")
+		s.containers[id] = &stubContainer{config: config}
+		s.order = append(s.order, id)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"Id": id})
+	case len(parts) >= 2 && parts[0] == "containers":
+		container, ok := s.containers[parts[1]]
+		if !ok || container.removed {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "start":
+			if container.server != nil {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			container.starts++
+			container.server = httptest.NewServer(s.functionHandler(parts[1], container.config["Image"].(string)))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "stop":
+			if container.server == nil {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			container.server.Close()
+			container.server = nil
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && len(parts) == 3 && parts[2] == "json":
+			ports := map[string]interface{}{}
+			if container.server != nil {
+				serverURL, _ := url.Parse(container.server.URL)
+				for port := range container.config["ExposedPorts"].(map[string]interface{}) {
+					ports[port] = []map[string]string{{"HostIp": "127.0.0.1", "HostPort": serverURL.Port()}}
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"NetworkSettings": map[string]interface{}{"Ports": ports}})
+		case r.Method == http.MethodDelete && len(parts) == 2:
+			if container.server != nil {
+				container.server.Close()
+				container.server = nil
+			}
+			container.removed = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *stubEngine) functionHandler(id string, image string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.invocations++
+		drop := s.invocations <= s.dropped
+		s.mu.Unlock()
+		if drop {
+			connection, _, _ := w.(http.Hijacker).Hijack()
+			_ = connection.Close()
+			return
+		}
+		time.Sleep(s.delay)
+		if !strings.HasPrefix(image, "public.ecr.aws/lambda/") {
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"RequestID":      id,
+				"IncrementLimit": r.URL.Query().Get("IncrementLimit"),
+			})
+			return
+		}
+
+		if r.URL.Path != "/2015-03-31/functions/function/invocations" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var event struct {
+			QueryStringParameters map[string]string `json:"queryStringParameters"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		body, _ := json.Marshal(map[string]string{
+			"RequestID":      id,
+			"IncrementLimit": event.QueryStringParameters["IncrementLimit"],
+		})
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"statusCode": 200, "body": string(body)})
+	})
+}
+
+func (s *stubEngine) counts() (created int, starts int, running int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, container := range s.containers {
+		starts += container.starts
+		if container.server != nil {
+			running++
+		}
+	}
+	return len(s.containers), starts, running
+}
+
+func invoke(t *testing.T, function *docker.Function, incrementLimit string) map[string]string {
+	response, err := http.Get(fmt.Sprintf("http://%s/?IncrementLimit=%s", function.Address(), incrementLimit))
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+	return body
+}
+
+func TestLambdaBaseImage(t *testing.T) {
+	for runtime, expected := range map[string]string{
+		"python3.9":       "public.ecr.aws/lambda/python:3.9",
+		"nodejs18.x":      "public.ecr.aws/lambda/nodejs:18",
+		"ruby3.2":         "public.ecr.aws/lambda/ruby:3.2",
+		"java11":          "public.ecr.aws/lambda/java:11",
+		"java21":          "public.ecr.aws/lambda/java:21",
+		"dotnet8":         "public.ecr.aws/lambda/dotnet:8",
+		"python3.7":       "public.ecr.aws/lambda/python:3.7",
+		"go1.x":           "public.ecr.aws/lambda/provided:al2023",
+		"provided.al2":    "public.ecr.aws/lambda/provided:al2",
+		"provided.al2023": "public.ecr.aws/lambda/provided:al2023",
+	} {
+		image, err := docker.LambdaBaseImage(runtime)
+		require.NoError(t, err)
+		require.Equal(t, expected, image, runtime)
+	}
+
+	_, err := docker.LambdaBaseImage("cobol")
+	require.Error(t, err)
+}
+
+func TestLambdaEvent(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/hellopy?IncrementLimit=42", nil)
+	event, err := docker.LambdaEvent(request)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(event, &decoded))
+	require.Equal(t, "GET", decoded["httpMethod"])
+	require.Equal(t, "/hellopy", decoded["path"])
+	require.Equal(t, map[string]interface{}{"IncrementLimit": "42"}, decoded["queryStringParameters"])
+	require.NotContains(t, decoded, "body")
+}
+
+func TestLambdaResponse(t *testing.T) {
+	statusCode, header, body := docker.LambdaResponse([]byte(`{"statusCode":201,"headers":{"Content-Type":"application/json"},"body":"{\"RequestID\":\"abc\"}"}`))
+	require.Equal(t, http.StatusCreated, statusCode)
+	require.Equal(t, "application/json", header.Get("Content-Type"))
+	require.JSONEq(t, `{"RequestID":"abc"}`, string(body))
+
+	// Node.js functions may return the proxy response serialized into a string
+	statusCode, _, body = docker.LambdaResponse([]byte(`"{\"statusCode\":200,\"body\":{\"RequestID\":\"abc\"}}"`))
+	require.Equal(t, http.StatusOK, statusCode)
+	require.JSONEq(t, `{"RequestID":"abc"}`, string(body))
+
+	statusCode, _, body = docker.LambdaResponse([]byte(`{"RequestID":"abc"}`))
+	require.Equal(t, http.StatusOK, statusCode)
+	require.JSONEq(t, `{"RequestID":"abc"}`, string(body))
+
+	statusCode, _, _ = docker.LambdaResponse([]byte(`{"errorMessage":"boom","errorType":"Exception"}`))
+	require.Equal(t, http.StatusBadGateway, statusCode)
+}
+
+func TestPullImage(t *testing.T) {
+	_, engine := newStubEngine(t)
+
+	require.NoError(t, engine.PullImage("public.ecr.aws/lambda/python:3.9"))
+	err := engine.PullImage("missing:latest")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "manifest unknown")
+}
+
+func TestBuildImage(t *testing.T) {
+	stub, engine := newStubEngine(t)
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(contextDir, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "app", "main.py"), []byte("print()\n"), 0644))
+
+	require.NoError(t, engine.BuildImage(contextDir, "stellar-hellopy:test"))
+	require.Equal(t, []string{"Dockerfile", "app", "app/main.py"}, stub.built)
+	require.Error(t, engine.BuildImage(filepath.Join(contextDir, "missing"), "stellar-hellopy:test"))
+}
+
+func TestSaveAndLoadImage(t *testing.T) {
+	stub, engine := newStubEngine(t)
+	stub.images = map[string][]byte{"stellar-hellopy:base": []byte("image tarball")}
+
+	var saved bytes.Buffer
+	require.NoError(t, engine.SaveImage("stellar-hellopy:base", &saved))
+	require.Equal(t, "image tarball", saved.String())
+	require.Error(t, engine.SaveImage("missing:latest", io.Discard))
+
+	require.NoError(t, engine.LoadImage(strings.NewReader("padded tarball")))
+	require.Equal(t, "padded tarball", string(stub.loaded))
+}
+
+func TestCreateContainer(t *testing.T) {
+	stub, engine := newStubEngine(t)
+
+	id, err := engine.CreateContainer(docker.ContainerSpec{
+		Name:          "stellar-abcde-hellopy-0-0-0",
+		Image:         "public.ecr.aws/lambda/python:3.9",
+		Cmd:           []string{"main.lambda_handler"},
+		Binds:         []string{"/tmp/task:/var/task:ro"},
+		ContainerPort: docker.LambdaPort,
+		MemoryMB:      128,
+		CPU:           0.5,
+	})
+	require.NoError(t, err)
+
+	config := stub.containers[id].config
+	require.Equal(t, "stellar-abcde-hellopy-0-0-0", config["Name"])
+	require.Equal(t, []interface{}{"main.lambda_handler"}, config["Cmd"])
+	require.Contains(t, config["Labels"], docker.ManagedByLabel)
+	require.Contains(t, config["ExposedPorts"], "8080/tcp")
+
+	hostConfig := config["HostConfig"].(map[string]interface{})
+	require.Equal(t, float64(128*1024*1024), hostConfig["Memory"])
+	require.Equal(t, float64(5e8), hostConfig["NanoCpus"])
+	require.Equal(t, []interface{}{"/tmp/task:/var/task:ro"}, hostConfig["Binds"])
+
+	_, err = engine.ContainerHostPort(id, docker.LambdaPort)
+	require.Error(t, err, "ports of stopped containers are not published")
+	require.NoError(t, engine.StartContainer(id))
+	hostPort, err := engine.ContainerHostPort(id, docker.LambdaPort)
+	require.NoError(t, err)
+	require.NotEmpty(t, hostPort)
+}
+
+func TestFunctionScalesToZero(t *testing.T) {
+	stub, engine := newStubEngine(t)
+
+	function, err := docker.StartFunction(engine, docker.FunctionConfig{
+		Name:             "stellar-abcde-hellopy-0-0",
+		Container:        docker.ContainerSpec{Image: "public.ecr.aws/lambda/python:3.9", ContainerPort: docker.LambdaPort},
+		Lambda:           true,
+		IdleTimeout:      100 * time.Millisecond,
+		ReadinessTimeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	created, _, _ := stub.counts()
+	require.Equal(t, 0, created, "no instance should run before the first request")
+
+	// The first request is a cold start, the second one reuses the warm instance
+	require.Equal(t, map[string]string{"RequestID": "container0", "IncrementLimit": "42"}, invoke(t, function, "42"))
+	require.Equal(t, "container0", invoke(t, function, "7")["RequestID"])
+	created, starts, running := stub.counts()
+	require.Equal(t, []int{1, 1, 1}, []int{created, starts, running})
+
+	require.Eventually(t, func() bool {
+		_, _, running := stub.counts()
+		return running == 0 && function.RunningInstances() == 0
+	}, 5*time.Second, 10*time.Millisecond, "idle instance should be stopped")
+
+	// After scaling to zero, the stopped container is started again
+	require.Equal(t, "container0", invoke(t, function, "1")["RequestID"])
+	created, starts, _ = stub.counts()
+	require.Equal(t, []int{1, 2}, []int{created, starts})
+
+	require.NoError(t, function.Stop())
+	require.True(t, stub.containers["container0"].removed)
+}
+
+func TestFunctionDoesNotResendRequests(t *testing.T) {
+	stub, engine := newStubEngine(t)
+	stub.dropped = 1
+
+	function, err := docker.StartFunction(engine, docker.FunctionConfig{
+		Name:             "stellar-abcde-hellopy-3-0",
+		Container:        docker.ContainerSpec{Image: "stellar-hellopy:abcde-3", ContainerPort: 8080},
+		IdleTimeout:      time.Minute,
+		ReadinessTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	defer function.Stop()
+
+	// A connection closed by the instance may follow the start of the request, so it is reported instead of retried
+	response, err := http.Get(fmt.Sprintf("http://%s/?IncrementLimit=1", function.Address()))
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusBadGateway, response.StatusCode)
+	require.Equal(t, "container0", invoke(t, function, "2")["RequestID"])
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	require.Equal(t, 2, stub.invocations)
+}
+
+func TestFunctionScalesOut(t *testing.T) {
+	stub, engine := newStubEngine(t)
+	stub.delay = 200 * time.Millisecond
+
+	function, err := docker.StartFunction(engine, docker.FunctionConfig{
+		Name:             "stellar-abcde-hellopy-1-0",
+		Container:        docker.ContainerSpec{Image: "stellar-hellopy:abcde-1", ContainerPort: 8080},
+		IdleTimeout:      time.Minute,
+		MaxInstances:     2,
+		ReadinessTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	defer function.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := http.Get(fmt.Sprintf("http://%s/", function.Address()))
+			require.NoError(t, err)
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			require.Equal(t, http.StatusOK, response.StatusCode)
+		}()
+	}
+	wg.Wait()
+
+	created, starts, running := stub.counts()
+	require.Equal(t, []int{2, 2, 2}, []int{created, starts, running})
+	require.Equal(t, 2, function.RunningInstances())
+}
+
+func TestFunctionKeepsMinInstancesWarm(t *testing.T) {
+	stub, engine := newStubEngine(t)
+
+	function, err := docker.StartFunction(engine, docker.FunctionConfig{
+		Name:             "stellar-abcde-hellopy-2-0",
+		Container:        docker.ContainerSpec{Image: "stellar-hellopy:abcde-2", ContainerPort: 8080},
+		IdleTimeout:      20 * time.Millisecond,
+		MinInstances:     1,
+		ReadinessTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	defer function.Stop()
+
+	time.Sleep(200 * time.Millisecond)
+	created, starts, running := stub.counts()
+	require.Equal(t, []int{1, 1, 1}, []int{created, starts, running})
+	require.Equal(t, "container0", invoke(t, function, "0")["RequestID"])
+}
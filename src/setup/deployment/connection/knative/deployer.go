@@ -0,0 +1,186 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultNamespace is the namespace the Knative Services are deployed to
+	DefaultNamespace = "default"
+
+	readinessPollInterval = 2 * time.Second
+)
+
+// Deployer applies Knative Services through the Kubernetes API.
+type Deployer struct {
+	client    dynamic.Interface
+	namespace string
+}
+
+// NewDeployer creates a deployer using the given Kubernetes client, e.g., a fake one in tests.
+func NewDeployer(client dynamic.Interface, namespace string) *Deployer {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &Deployer{client: client, namespace: namespace}
+}
+
+// NewDeployerFromKubeconfig creates a deployer for the cluster of the given kubeconfig file. An empty path
+// selects the file in the KUBECONFIG environment variable, falling back to ~/.kube/config.
+func NewDeployerFromKubeconfig(kubeconfigPath string, namespace string) *Deployer {
+	if kubeconfigPath == "" {
+		kubeconfigPath = os.Getenv("KUBECONFIG")
+	}
+	if kubeconfigPath == "" {
+		homeDirectory, err := os.UserHomeDir()
+		if err != nil {
+			log.Fatalf("Could not find home directory for the kubeconfig file: %s", err.Error())
+		}
+		kubeconfigPath = filepath.Join(homeDirectory, ".kube", "config")
+	}
+
+	log.Infof("Connecting to Kubernetes cluster using kubeconfig %s", kubeconfigPath)
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		log.Fatalf("Could not load kubeconfig %s: %s", kubeconfigPath, err.Error())
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		log.Fatalf("Could not create Kubernetes client: %s", err.Error())
+	}
+	return NewDeployer(client, namespace)
+}
+
+// Namespace returns the namespace the services are deployed to
+func (d *Deployer) Namespace() string {
+	return d.namespace
+}
+
+// Apply creates the Knative Service for the given specification, or updates it if it already exists.
+func (d *Deployer) Apply(ctx context.Context, spec ServiceSpec) error {
+	spec.Namespace = d.namespace
+	service := BuildService(spec)
+	services := d.client.Resource(ServiceResource).Namespace(d.namespace)
+
+	log.Infof("Applying Knative Service %s/%s with image %s", d.namespace, spec.Name, spec.Image)
+	_, err := services.Create(ctx, service, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		existing, getErr := services.Get(ctx, spec.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		service.SetResourceVersion(existing.GetResourceVersion())
+		_, err = services.Update(ctx, service, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// WaitForReady waits until the Knative Service with the given name is ready and returns its URL.
+func (d *Deployer) WaitForReady(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	return d.waitForGeneration(ctx, name, 0, timeout)
+}
+
+// ForceNewRevision rolls out a new revision of the Knative Service with the given name by changing an annotation of its
+// revision template, and waits until the revision is ready. The requests that follow are served by new instances.
+func (d *Deployer) ForceNewRevision(ctx context.Context, name string, nonce string, timeout time.Duration) error {
+	services := d.client.Resource(ServiceResource).Namespace(d.namespace)
+	service, err := services.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(service.Object, nonce, "spec", "template", "metadata", "annotations", RevisionNonceAnnotation); err != nil {
+		return err
+	}
+
+	log.Debugf("Rolling out a new revision of Knative Service %s/%s", d.namespace, name)
+	updated, err := services.Update(ctx, service, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	_, err = d.waitForGeneration(ctx, name, updated.GetGeneration(), timeout)
+	return err
+}
+
+// waitForGeneration waits until the Knative Service with the given name is ready, having reconciled at least the given
+// generation of its specification, and returns its URL.
+func (d *Deployer) waitForGeneration(ctx context.Context, name string, generation int64, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	services := d.client.Resource(ServiceResource).Namespace(d.namespace)
+	for {
+		service, err := services.Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+
+		if service != nil {
+			ready, message := readyCondition(service)
+			serviceURL, _, _ := unstructured.NestedString(service.Object, "status", "url")
+			observedGeneration, _, _ := unstructured.NestedInt64(service.Object, "status", "observedGeneration")
+			if ready && serviceURL != "" && observedGeneration >= generation {
+				log.Infof("Knative Service %s/%s is ready at %s", d.namespace, name, serviceURL)
+				return serviceURL, nil
+			}
+			log.Debugf("Knative Service %s/%s is not ready yet: %s", d.namespace, name, message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("knative service %s/%s was not ready after %v", d.namespace, name, timeout)
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
+
+// Remove deletes the Knative Service with the given name. Services that no longer exist are ignored.
+func (d *Deployer) Remove(ctx context.Context, name string) error {
+	log.Infof("Removing Knative Service %s/%s", d.namespace, name)
+	err := d.client.Resource(ServiceResource).Namespace(d.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		log.Warnf("Knative Service %s/%s was already removed.", d.namespace, name)
+		return nil
+	}
+	return err
+}
+
+// GRPCTarget transforms the URL of a Knative Service into the gRPC target used for benchmarking,
+// e.g., http://producer.default.192.168.1.240.nip.io becomes producer.default.192.168.1.240.nip.io:80.
+func GRPCTarget(serviceURL string) string {
+	parsedURL, err := url.Parse(serviceURL)
+	if err != nil || parsedURL.Host == "" {
+		log.Fatalf("Could not parse Knative Service URL %q", serviceURL)
+	}
+	if parsedURL.Port() != "" {
+		return parsedURL.Host
+	}
+	if parsedURL.Scheme == "https" {
+		return parsedURL.Host + ":443"
+	}
+	return parsedURL.Host + ":80"
+}
+
+func readyCondition(service *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(service.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok || conditionMap["type"] != "Ready" {
+			continue
+		}
+		message, _ := conditionMap["message"].(string)
+		return conditionMap["status"] == "True", message
+	}
+	return false, "no Ready condition reported"
+}
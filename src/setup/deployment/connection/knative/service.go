@@ -0,0 +1,104 @@
+// Package knative provides support for deploying benchmarked functions as Knative Services,
+// e.g., on a vHive cluster, through the Kubernetes API.
+package knative
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strconv"
+)
+
+// ServiceResource identifies Knative Services in the Kubernetes API
+var ServiceResource = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+
+const (
+	// stubImage is the image of the container standing in for the firecracker-containerd microVM in vHive,
+	// see https://github.com/vhive-serverless/vhive/issues/68
+	stubImage = "crccheck/hello-world:latest"
+	grpcPort  = 50051
+
+	// ManagedByLabel marks the Knative Services deployed by STeLLAR
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "stellar"
+
+	// RevisionNonceAnnotation is changed on the revision template of a Knative Service to roll out a new revision
+	RevisionNonceAnnotation = "stellar/revision-nonce"
+)
+
+// ServiceSpec describes a Knative Service to be deployed for a benchmarked function.
+type ServiceSpec struct {
+	Name      string
+	Namespace string
+	Image     string
+	// StockKnative deploys the image directly instead of running it as a vHive firecracker-containerd guest
+	StockKnative bool
+	MemoryMB     int64
+	MinScale     int
+	MaxScale     int
+	// ContainerConcurrency is the maximum number of concurrent requests per instance (0 lets Knative decide)
+	ContainerConcurrency int
+}
+
+// BuildService generates the Knative Service manifest for the given specification.
+func BuildService(spec ServiceSpec) *unstructured.Unstructured {
+	ports := []interface{}{
+		map[string]interface{}{
+			"name":          "h2c", // For GRPC support
+			"containerPort": int64(grpcPort),
+		},
+	}
+
+	container := map[string]interface{}{
+		"ports": ports,
+	}
+	if spec.StockKnative {
+		container["image"] = spec.Image
+	} else {
+		container["image"] = stubImage
+		container["env"] = []interface{}{
+			// Port on which the firecracker-containerd container is accepting requests
+			map[string]interface{}{"name": "GUEST_PORT", "value": strconv.Itoa(grpcPort)},
+			// Container image to use for firecracker-containerd container
+			map[string]interface{}{"name": "GUEST_IMAGE", "value": spec.Image},
+		}
+	}
+	if spec.MemoryMB > 0 {
+		memory := fmt.Sprintf("%dMi", spec.MemoryMB)
+		container["resources"] = map[string]interface{}{
+			"requests": map[string]interface{}{"memory": memory},
+			"limits":   map[string]interface{}{"memory": memory},
+		}
+	}
+
+	annotations := map[string]interface{}{}
+	if spec.MinScale > 0 {
+		annotations["autoscaling.knative.dev/minScale"] = strconv.Itoa(spec.MinScale)
+	}
+	if spec.MaxScale > 0 {
+		annotations["autoscaling.knative.dev/maxScale"] = strconv.Itoa(spec.MaxScale)
+	}
+
+	templateSpec := map[string]interface{}{
+		"containers": []interface{}{container},
+	}
+	if spec.ContainerConcurrency > 0 {
+		templateSpec["containerConcurrency"] = int64(spec.ContainerConcurrency)
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "serving.knative.dev/v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":      spec.Name,
+			"namespace": spec.Namespace,
+			"labels":    map[string]interface{}{ManagedByLabel: managedByValue},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": annotations},
+				"spec":     templateSpec,
+			},
+		},
+	}}
+}
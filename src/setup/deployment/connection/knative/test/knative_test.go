@@ -0,0 +1,138 @@
+package knative
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"stellar/setup/deployment/connection/knative"
+	"testing"
+	"time"
+)
+
+func newFakeClient(ready bool) *fake.FakeDynamicClient {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{knative.ServiceResource: "ServiceList"})
+	if ready {
+		// Knative reports the service as ready as soon as it is applied
+		setReady := func(action k8stesting.Action) (bool, runtime.Object, error) {
+			var service *unstructured.Unstructured
+			switch typedAction := action.(type) {
+			case k8stesting.CreateAction:
+				service = typedAction.GetObject().(*unstructured.Unstructured)
+			case k8stesting.UpdateAction:
+				service = typedAction.GetObject().(*unstructured.Unstructured)
+			}
+			_ = unstructured.SetNestedField(service.Object, "http://"+service.GetName()+".default.192.168.1.240.nip.io", "status", "url")
+			_ = unstructured.SetNestedSlice(service.Object, []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			}, "status", "conditions")
+			return false, nil, nil
+		}
+		client.PrependReactor("create", "services", setReady)
+		client.PrependReactor("update", "services", setReady)
+	}
+	return client
+}
+
+func TestBuildServiceVHive(t *testing.T) {
+	service := knative.BuildService(knative.ServiceSpec{
+		Name:      "producer",
+		Namespace: "default",
+		Image:     "vhiveease/stellar:prodcons",
+		MemoryMB:  256,
+		MinScale:  1,
+		MaxScale:  3,
+	})
+
+	require.Equal(t, "serving.knative.dev/v1", service.GetAPIVersion())
+	require.Equal(t, "Service", service.GetKind())
+	require.Equal(t, "producer", service.GetName())
+
+	annotations, _, _ := unstructured.NestedStringMap(service.Object, "spec", "template", "metadata", "annotations")
+	require.Equal(t, map[string]string{
+		"autoscaling.knative.dev/minScale": "1",
+		"autoscaling.knative.dev/maxScale": "3",
+	}, annotations)
+
+	containers, _, _ := unstructured.NestedSlice(service.Object, "spec", "template", "spec", "containers")
+	require.Len(t, containers, 1)
+	container := containers[0].(map[string]interface{})
+	require.Equal(t, "crccheck/hello-world:latest", container["image"])
+	require.Contains(t, container["env"], map[string]interface{}{"name": "GUEST_IMAGE", "value": "vhiveease/stellar:prodcons"})
+
+	memoryLimit, _, _ := unstructured.NestedString(container, "resources", "limits", "memory")
+	require.Equal(t, "256Mi", memoryLimit)
+}
+
+func TestBuildServiceStockKnative(t *testing.T) {
+	service := knative.BuildService(knative.ServiceSpec{Name: "producer", Image: "vhiveease/stellar:prodcons", StockKnative: true, ContainerConcurrency: 1})
+
+	containers, _, _ := unstructured.NestedSlice(service.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]interface{})
+	require.Equal(t, "vhiveease/stellar:prodcons", container["image"])
+	require.NotContains(t, container, "env")
+	require.NotContains(t, container, "resources")
+
+	concurrency, _, _ := unstructured.NestedInt64(service.Object, "spec", "template", "spec", "containerConcurrency")
+	require.Equal(t, int64(1), concurrency)
+}
+
+func TestDeployerLifecycle(t *testing.T) {
+	client := newFakeClient(true)
+	deployer := knative.NewDeployer(client, "")
+	ctx := context.Background()
+
+	require.NoError(t, deployer.Apply(ctx, knative.ServiceSpec{Name: "producer", Image: "vhiveease/stellar:prodcons"}))
+	// Applying again updates the existing service
+	require.NoError(t, deployer.Apply(ctx, knative.ServiceSpec{Name: "producer", Image: "vhiveease/stellar:prodcons", MemoryMB: 512}))
+
+	serviceURL, err := deployer.WaitForReady(ctx, "producer", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "http://producer.default.192.168.1.240.nip.io", serviceURL)
+
+	require.NoError(t, deployer.Remove(ctx, "producer"))
+	require.NoError(t, deployer.Remove(ctx, "producer"))
+
+	_, err = client.Resource(knative.ServiceResource).Namespace("default").Get(ctx, "producer", metav1.GetOptions{})
+	require.Error(t, err)
+}
+
+func TestDeployerForceNewRevision(t *testing.T) {
+	client := newFakeClient(true)
+	deployer := knative.NewDeployer(client, "default")
+	ctx := context.Background()
+
+	require.NoError(t, deployer.Apply(ctx, knative.ServiceSpec{Name: "producer", Image: "vhiveease/stellar:prodcons", MinScale: 1}))
+	require.NoError(t, deployer.ForceNewRevision(ctx, "producer", "1700000000", time.Second))
+
+	service, err := client.Resource(knative.ServiceResource).Namespace("default").Get(ctx, "producer", metav1.GetOptions{})
+	require.NoError(t, err)
+	annotations, _, _ := unstructured.NestedStringMap(service.Object, "spec", "template", "metadata", "annotations")
+	require.Equal(t, map[string]string{
+		"autoscaling.knative.dev/minScale": "1",
+		knative.RevisionNonceAnnotation:    "1700000000",
+	}, annotations)
+
+	require.Error(t, deployer.ForceNewRevision(ctx, "consumer", "1700000000", time.Second))
+}
+
+func TestDeployerWaitForReadyTimeout(t *testing.T) {
+	deployer := knative.NewDeployer(newFakeClient(false), "default")
+	ctx := context.Background()
+
+	require.NoError(t, deployer.Apply(ctx, knative.ServiceSpec{Name: "producer", Image: "vhiveease/stellar:prodcons"}))
+
+	_, err := deployer.WaitForReady(ctx, "producer", 100*time.Millisecond)
+	require.Error(t, err)
+}
+
+func TestGRPCTarget(t *testing.T) {
+	require.Equal(t, "producer.default.192.168.1.240.nip.io:80", knative.GRPCTarget("http://producer.default.192.168.1.240.nip.io"))
+	require.Equal(t, "producer.default.example.com:443", knative.GRPCTarget("https://producer.default.example.com"))
+	require.Equal(t, "producer.default.example.com:8080", knative.GRPCTarget("http://producer.default.example.com:8080"))
+}
@@ -0,0 +1,126 @@
+// Package openfaas provides support for deploying benchmarked functions to OpenFaaS through the REST API of its gateway.
+package openfaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGatewayURL is the gateway used when OPENFAAS_URL is not set, as done by faas-cli
+	DefaultGatewayURL = "http://127.0.0.1:8080"
+	defaultUsername   = "admin"
+
+	requestTimeout = 5 * time.Minute
+)
+
+// Client talks to the REST API of an OpenFaaS gateway.
+type Client struct {
+	// GatewayURL is the base URL of the gateway, e.g., http://127.0.0.1:8080
+	GatewayURL string
+	// Username and Password are the basic authentication credentials of the gateway
+	Username string
+	Password string
+
+	httpClient *http.Client
+}
+
+var (
+	instance     *Client
+	instanceLock sync.Mutex
+)
+
+// NewClient creates a client for the given gateway and basic authentication credentials.
+func NewClient(gatewayURL string, username string, password string) *Client {
+	if gatewayURL == "" {
+		gatewayURL = DefaultGatewayURL
+	}
+	if username == "" {
+		username = defaultUsername
+	}
+	return &Client{
+		GatewayURL: strings.TrimSuffix(gatewayURL, "/"),
+		Username:   username,
+		Password:   password,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewClientFromEnvironment creates a client using the OPENFAAS_URL, OPENFAAS_USERNAME and OPENFAAS_PASSWORD environment variables.
+func NewClientFromEnvironment() *Client {
+	return NewClient(os.Getenv("OPENFAAS_URL"), os.Getenv("OPENFAAS_USERNAME"), os.Getenv("OPENFAAS_PASSWORD"))
+}
+
+// Instance returns the client used for deploying functions, creating it from the environment if needed.
+func Instance() *Client {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+
+	if instance == nil {
+		instance = NewClientFromEnvironment()
+	}
+	return instance
+}
+
+// SetInstance replaces the client returned by Instance, e.g., with one for a local stub of the gateway in tests.
+func SetInstance(client *Client) {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+	instance = client
+}
+
+// do sends an authenticated request to the gateway and decodes the JSON response into result, unless nil.
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	var requestBody io.Reader
+	if body != nil {
+		encodedBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(encodedBody)
+	}
+
+	request, err := http.NewRequest(method, c.GatewayURL+path, requestBody)
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(c.Username, c.Password)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &APIError{StatusCode: response.StatusCode, Body: string(responseBody)}
+	}
+	if result != nil {
+		return json.Unmarshal(responseBody, result)
+	}
+	return nil
+}
+
+// APIError is returned when the gateway answers with an unsuccessful status code.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("OpenFaaS gateway responded with status %d: %s", e.StatusCode, strings.TrimSpace(e.Body))
+}
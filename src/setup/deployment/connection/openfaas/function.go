@@ -0,0 +1,151 @@
+package openfaas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// CallIDHeader is the response header through which the gateway returns the ID of the invocation
+	CallIDHeader = "X-Call-Id"
+
+	minScaleLabel         = "com.openfaas.scale.min"
+	maxScaleLabel         = "com.openfaas.scale.max"
+	readinessPollInterval = 2 * time.Second
+)
+
+// FunctionSpec describes a function to be deployed for a benchmarked function.
+type FunctionSpec struct {
+	Name  string
+	Image string
+	// MemoryMB and CPU limit the resources of each replica, and are left to the OpenFaaS defaults when 0
+	MemoryMB int64
+	CPU      float64
+	// MinScale and MaxScale bound the number of replicas, and are left to the OpenFaaS defaults when 0
+	MinScale int
+	MaxScale int
+	// Concurrency is the maximum number of concurrent requests per replica (0 lets the watchdog decide)
+	Concurrency int
+	// TimeoutSeconds is the request timeout of the watchdog (0 lets the watchdog decide)
+	TimeoutSeconds int
+	// Environment holds further environment variables of the function
+	Environment map[string]string
+}
+
+// Resources are the resources of each replica, in the Kubernetes format
+type Resources struct {
+	Memory string `json:"memory,omitempty"`
+	CPU    string `json:"cpu,omitempty"`
+}
+
+// Deployment is the body of deployment requests, as expected by /system/functions
+type Deployment struct {
+	Service  string            `json:"service"`
+	Image    string            `json:"image"`
+	EnvVars  map[string]string `json:"envVars,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Limits   *Resources        `json:"limits,omitempty"`
+	Requests *Resources        `json:"requests,omitempty"`
+}
+
+type functionStatus struct {
+	Name              string `json:"name"`
+	Replicas          int    `json:"replicas"`
+	AvailableReplicas int    `json:"availableReplicas"`
+}
+
+// BuildDeployment generates the body of the deployment request for the given specification.
+func BuildDeployment(spec FunctionSpec) Deployment {
+	deployment := Deployment{
+		Service: spec.Name,
+		Image:   spec.Image,
+		EnvVars: map[string]string{},
+		Labels:  map[string]string{},
+	}
+
+	for name, value := range spec.Environment {
+		deployment.EnvVars[name] = value
+	}
+	if spec.MinScale > 0 {
+		deployment.Labels[minScaleLabel] = strconv.Itoa(spec.MinScale)
+	}
+	if spec.MaxScale > 0 {
+		deployment.Labels[maxScaleLabel] = strconv.Itoa(spec.MaxScale)
+	}
+	if spec.Concurrency > 0 {
+		deployment.EnvVars["max_inflight"] = strconv.Itoa(spec.Concurrency)
+	}
+	if spec.TimeoutSeconds > 0 {
+		timeout := fmt.Sprintf("%ds", spec.TimeoutSeconds)
+		deployment.EnvVars["exec_timeout"] = timeout
+		deployment.EnvVars["read_timeout"] = timeout
+		deployment.EnvVars["write_timeout"] = timeout
+	}
+
+	resources := Resources{}
+	if spec.MemoryMB > 0 {
+		resources.Memory = fmt.Sprintf("%dMi", spec.MemoryMB)
+	}
+	if spec.CPU > 0 {
+		resources.CPU = fmt.Sprintf("%dm", int(spec.CPU*1000))
+	}
+	if resources != (Resources{}) {
+		deployment.Limits = &resources
+		deployment.Requests = &resources
+	}
+	return deployment
+}
+
+// Deploy creates the function for the given specification, or updates it if it already exists.
+func (c *Client) Deploy(spec FunctionSpec) error {
+	deployment := BuildDeployment(spec)
+
+	err := c.do(http.MethodPut, "/system/functions", deployment, nil)
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound {
+		err = c.do(http.MethodPost, "/system/functions", deployment, nil)
+	}
+	return err
+}
+
+// WaitForReady waits until the function with the given name has an available replica.
+func (c *Client) WaitForReady(name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var status functionStatus
+		err := c.do(http.MethodGet, "/system/function/"+url.PathEscape(name), nil, &status)
+
+		var apiError *APIError
+		if err != nil && !(errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound) {
+			return err
+		}
+		if err == nil && status.AvailableReplicas > 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("OpenFaaS function %s was not ready after %v", name, timeout)
+		}
+		time.Sleep(readinessPollInterval)
+	}
+}
+
+// Remove deletes the function with the given name. Functions that no longer exist are ignored.
+func (c *Client) Remove(name string) error {
+	err := c.do(http.MethodDelete, "/system/functions", map[string]string{"functionName": name}, nil)
+
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// FunctionURL returns the URL through which the function with the given name is invoked.
+func (c *Client) FunctionURL(name string) string {
+	return fmt.Sprintf("%s/function/%s", c.GatewayURL, url.PathEscape(name))
+}
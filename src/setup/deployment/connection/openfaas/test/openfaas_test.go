@@ -0,0 +1,138 @@
+package openfaas
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"stellar/setup/deployment/connection/openfaas"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubGateway imitates the parts of the OpenFaaS gateway REST API used to deploy functions
+type stubGateway struct {
+	mu        sync.Mutex
+	functions map[string]openfaas.Deployment
+}
+
+func newStubGateway(t *testing.T) (*stubGateway, *httptest.Server) {
+	gateway := &stubGateway{functions: make(map[string]openfaas.Deployment)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		gateway.mu.Lock()
+		defer gateway.mu.Unlock()
+		switch {
+		case r.URL.Path == "/system/functions" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+			var deployment openfaas.Deployment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deployment))
+			if _, exists := gateway.functions[deployment.Service]; exists != (r.Method == http.MethodPut) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			gateway.functions[deployment.Service] = deployment
+			w.WriteHeader(http.StatusAccepted)
+		case r.URL.Path == "/system/functions" && r.Method == http.MethodDelete:
+			var request map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			if _, exists := gateway.functions[request["functionName"]]; !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(gateway.functions, request["functionName"])
+		case path.Dir(r.URL.Path) == "/system/function" && r.Method == http.MethodGet:
+			name := path.Base(r.URL.Path)
+			if _, exists := gateway.functions[name]; !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "replicas": 1, "availableReplicas": 1})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return gateway, server
+}
+
+func TestNewClientDefaults(t *testing.T) {
+	client := openfaas.NewClient("", "", "secret")
+	require.Equal(t, openfaas.DefaultGatewayURL, client.GatewayURL)
+	require.Equal(t, "admin", client.Username)
+	require.Equal(t, "http://127.0.0.1:8080/function/hellopy", client.FunctionURL("hellopy"))
+}
+
+func TestBuildDeployment(t *testing.T) {
+	deployment := openfaas.BuildDeployment(openfaas.FunctionSpec{
+		Name:           "hellopy",
+		Image:          "stellar/hellopy_0_stellar:latest",
+		MemoryMB:       256,
+		CPU:            0.5,
+		MinScale:       1,
+		MaxScale:       3,
+		Concurrency:    4,
+		TimeoutSeconds: 30,
+		Environment:    map[string]string{"STELLAR_INIT_SLEEP_MS": "100"},
+	})
+
+	require.Equal(t, "hellopy", deployment.Service)
+	require.Equal(t, map[string]string{"com.openfaas.scale.min": "1", "com.openfaas.scale.max": "3"}, deployment.Labels)
+	require.Equal(t, &openfaas.Resources{Memory: "256Mi", CPU: "500m"}, deployment.Limits)
+	require.Equal(t, "4", deployment.EnvVars["max_inflight"])
+	require.Equal(t, "30s", deployment.EnvVars["exec_timeout"])
+	require.Equal(t, "100", deployment.EnvVars["STELLAR_INIT_SLEEP_MS"])
+}
+
+func TestBuildDeploymentDefaults(t *testing.T) {
+	deployment := openfaas.BuildDeployment(openfaas.FunctionSpec{Name: "hellopy", Image: "hellopy"})
+
+	require.Empty(t, deployment.Labels)
+	require.Empty(t, deployment.EnvVars)
+	require.Nil(t, deployment.Limits)
+	require.Nil(t, deployment.Requests)
+}
+
+func TestDeployAndRemove(t *testing.T) {
+	gateway, server := newStubGateway(t)
+	client := openfaas.NewClient(server.URL, "admin", "secret")
+
+	spec := openfaas.FunctionSpec{Name: "hellopy", Image: "hellopy:1", MaxScale: 2}
+	require.NoError(t, client.Deploy(spec))
+	require.Equal(t, "hellopy:1", gateway.functions["hellopy"].Image)
+
+	// Deploying an existing function updates it
+	spec.Image = "hellopy:2"
+	require.NoError(t, client.Deploy(spec))
+	require.Equal(t, "hellopy:2", gateway.functions["hellopy"].Image)
+
+	require.NoError(t, client.WaitForReady("hellopy", time.Second))
+
+	require.NoError(t, client.Remove("hellopy"))
+	require.Empty(t, gateway.functions)
+	// Functions that were already removed are ignored
+	require.NoError(t, client.Remove("hellopy"))
+}
+
+func TestDeployUnauthorized(t *testing.T) {
+	_, server := newStubGateway(t)
+	client := openfaas.NewClient(server.URL, "admin", "wrong")
+
+	err := client.Deploy(openfaas.FunctionSpec{Name: "hellopy", Image: "hellopy"})
+	var apiError *openfaas.APIError
+	require.ErrorAs(t, err, &apiError)
+	require.Equal(t, http.StatusUnauthorized, apiError.StatusCode)
+}
+
+func TestWaitForReadyTimeout(t *testing.T) {
+	_, server := newStubGateway(t)
+	client := openfaas.NewClient(server.URL, "admin", "secret")
+
+	require.Error(t, client.WaitForReady("missing", 0))
+}
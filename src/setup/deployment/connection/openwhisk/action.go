@@ -0,0 +1,112 @@
+package openwhisk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ActivationIDHeader is the response header through which web actions return the ID of their activation
+	ActivationIDHeader = "X-Openwhisk-Activation-Id"
+
+	// webActionPackage is the package holding actions deployed outside any package
+	webActionPackage = "default"
+)
+
+// sourceKinds maps the extensions of single-file action sources to the default OpenWhisk runtime of their language
+var sourceKinds = map[string]string{
+	".py": "python:default",
+	".js": "nodejs:default",
+	".go": "go:default",
+}
+
+// ActionSpec describes an action to be deployed for a benchmarked function.
+type ActionSpec struct {
+	Name string
+	// Kind is the OpenWhisk runtime of the action, e.g., python:3 or nodejs:18
+	Kind string
+	Code string
+	// MemoryMB, TimeoutSeconds and Concurrency are left to the OpenWhisk defaults when 0
+	MemoryMB       int64
+	TimeoutSeconds int
+	Concurrency    int
+}
+
+type actionExec struct {
+	Kind string `json:"kind"`
+	Code string `json:"code"`
+}
+
+type actionLimits struct {
+	Memory      int64 `json:"memory,omitempty"`
+	Timeout     int   `json:"timeout,omitempty"`
+	Concurrency int   `json:"concurrency,omitempty"`
+}
+
+type annotation struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type actionBody struct {
+	Exec        actionExec   `json:"exec"`
+	Limits      actionLimits `json:"limits"`
+	Annotations []annotation `json:"annotations"`
+}
+
+// KindForSource returns the OpenWhisk runtime for the given action source file. Runtimes given in the OpenWhisk
+// format (e.g., python:3.11) take precedence over the default runtime of the source language.
+func KindForSource(sourcePath string, runtime string) (string, error) {
+	if strings.Contains(runtime, ":") {
+		return runtime, nil
+	}
+	kind, ok := sourceKinds[filepath.Ext(sourcePath)]
+	if !ok {
+		return "", fmt.Errorf("no OpenWhisk runtime known for action source %s", sourcePath)
+	}
+	return kind, nil
+}
+
+// PutAction creates the action for the given specification as a web action, or updates it if it already exists.
+// Invoking the web action requires the credentials of the namespace owner.
+func (c *Client) PutAction(spec ActionSpec) error {
+	body := actionBody{
+		Exec: actionExec{Kind: spec.Kind, Code: spec.Code},
+		Limits: actionLimits{
+			Memory:      spec.MemoryMB,
+			Timeout:     spec.TimeoutSeconds * 1000, // OpenWhisk expects milliseconds
+			Concurrency: spec.Concurrency,
+		},
+		Annotations: []annotation{
+			{Key: "web-export", Value: true},
+			{Key: "raw-http", Value: false},
+			{Key: "final", Value: true},
+			{Key: "require-whisk-auth", Value: true},
+		},
+	}
+	return c.do(http.MethodPut, c.actionPath(spec.Name)+"?overwrite=true", body, nil)
+}
+
+// DeleteAction removes the action with the given name. Actions that no longer exist are ignored.
+func (c *Client) DeleteAction(name string) error {
+	err := c.do(http.MethodDelete, c.actionPath(name), nil, nil)
+
+	var apiError *APIError
+	if errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// WebActionURL returns the URL through which the web action with the given name is invoked, returning its result as JSON.
+func (c *Client) WebActionURL(name string) string {
+	return fmt.Sprintf("%s/api/v1/web/%s/%s/%s.json", c.APIHost, url.PathEscape(c.Namespace), webActionPackage, url.PathEscape(name))
+}
+
+func (c *Client) actionPath(name string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/actions/%s", url.PathEscape(c.Namespace), url.PathEscape(name))
+}
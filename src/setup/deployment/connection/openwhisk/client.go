@@ -0,0 +1,206 @@
+// Package openwhisk provides support for deploying benchmarked functions as Apache OpenWhisk web actions
+// through the OpenWhisk REST API.
+package openwhisk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNamespace is the namespace of the authenticated user, as understood by the OpenWhisk API
+	DefaultNamespace = "_"
+
+	requestTimeout = 5 * time.Minute
+)
+
+// Client talks to the REST API of an OpenWhisk deployment.
+type Client struct {
+	// APIHost is the base URL of the OpenWhisk API, e.g., https://openwhisk.example.com
+	APIHost string
+	// Namespace is the namespace the actions are deployed to
+	Namespace string
+	// Username and Password form the authorization key of the namespace owner
+	Username string
+	Password string
+
+	httpClient *http.Client
+}
+
+var (
+	instance     *Client
+	instanceLock sync.Mutex
+)
+
+// NewClient creates a client for the given API host, namespace and authorization key (in the "user:password" format used by wsk).
+// API hosts without a scheme are reached over HTTPS, as done by the wsk CLI.
+func NewClient(apiHost string, namespace string, authKey string) (*Client, error) {
+	if apiHost == "" {
+		return nil, fmt.Errorf("no OpenWhisk API host was configured")
+	}
+	username, password, found := strings.Cut(authKey, ":")
+	if !found {
+		return nil, fmt.Errorf("the OpenWhisk authorization key should have the format user:password")
+	}
+	if !strings.HasPrefix(apiHost, "http://") && !strings.HasPrefix(apiHost, "https://") {
+		apiHost = "https://" + apiHost
+	}
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	return &Client{
+		APIHost:    strings.TrimSuffix(apiHost, "/"),
+		Namespace:  namespace,
+		Username:   username,
+		Password:   password,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// NewClientFromEnvironment creates a client using the OPENWHISK_APIHOST, OPENWHISK_AUTH and OPENWHISK_NAMESPACE
+// environment variables, falling back to the APIHOST, AUTH and NAMESPACE properties of ~/.wskprops (or WSK_CONFIG_FILE).
+func NewClientFromEnvironment() (*Client, error) {
+	properties := readWskProperties()
+
+	setting := func(variable string, property string) string {
+		if value := os.Getenv(variable); value != "" {
+			return value
+		}
+		return properties[property]
+	}
+	return NewClient(setting("OPENWHISK_APIHOST", "APIHOST"), setting("OPENWHISK_NAMESPACE", "NAMESPACE"), setting("OPENWHISK_AUTH", "AUTH"))
+}
+
+// Instance returns the client used for deploying and invoking actions, creating it from the environment if needed.
+func Instance() (*Client, error) {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+
+	if instance == nil {
+		client, err := NewClientFromEnvironment()
+		if err != nil {
+			return nil, err
+		}
+		instance = client
+	}
+	return instance, nil
+}
+
+// SetInstance replaces the client returned by Instance, e.g., with one for a local stub of the API in tests.
+func SetInstance(client *Client) {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+	instance = client
+}
+
+// Authenticate adds the credentials of the namespace owner to the given request.
+func (c *Client) Authenticate(request *http.Request) {
+	request.SetBasicAuth(c.Username, c.Password)
+}
+
+// ResolveNamespace replaces the default namespace "_" by the actual namespace of the authenticated user,
+// which is needed to build web action URLs.
+func (c *Client) ResolveNamespace() error {
+	if c.Namespace != DefaultNamespace {
+		return nil
+	}
+
+	var namespaces []string
+	if err := c.do(http.MethodGet, "/api/v1/namespaces", nil, &namespaces); err != nil {
+		return err
+	}
+	if len(namespaces) == 0 {
+		return fmt.Errorf("the OpenWhisk API did not return any namespace for the configured authorization key")
+	}
+	c.Namespace = namespaces[0]
+	return nil
+}
+
+// do sends an authenticated request to the OpenWhisk API and decodes the JSON response into result, unless nil.
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	var requestBody io.Reader
+	if body != nil {
+		encodedBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(encodedBody)
+	}
+
+	request, err := http.NewRequest(method, c.APIHost+path, requestBody)
+	if err != nil {
+		return err
+	}
+	c.Authenticate(request)
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &APIError{StatusCode: response.StatusCode, Body: string(responseBody)}
+	}
+	if result != nil {
+		return json.Unmarshal(responseBody, result)
+	}
+	return nil
+}
+
+// APIError is returned when the OpenWhisk API answers with an unsuccessful status code.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("OpenWhisk API responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// readWskProperties parses the properties file of the wsk CLI, returning no properties if it cannot be read.
+func readWskProperties() map[string]string {
+	properties := make(map[string]string)
+
+	propertiesPath := os.Getenv("WSK_CONFIG_FILE")
+	if propertiesPath == "" {
+		homeDirectory, err := os.UserHomeDir()
+		if err != nil {
+			return properties
+		}
+		propertiesPath = filepath.Join(homeDirectory, ".wskprops")
+	}
+
+	file, err := os.Open(propertiesPath)
+	if err != nil {
+		return properties
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if found && !strings.HasPrefix(key, "#") {
+			properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return properties
+}
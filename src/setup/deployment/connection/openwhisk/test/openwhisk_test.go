@@ -0,0 +1,182 @@
+package openwhisk
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/connection/openwhisk"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	stubUsername = "23bc46b1-71f6-4ed5-8c54-816aa4f8c502"
+	stubPassword = "123zO3xZCLrMN6v2BKK1dXYFpXlPkccOFqm12CdAsMgRU4VrNZ9lyGVCGuMDGIwP"
+)
+
+// stubAPI imitates the parts of the OpenWhisk REST API used to deploy actions
+type stubAPI struct {
+	mu      sync.Mutex
+	actions map[string]map[string]interface{}
+}
+
+func newStubAPI(t *testing.T) (*stubAPI, *httptest.Server) {
+	api := &stubAPI{actions: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != stubUsername || password != stubPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces":
+			_ = json.NewEncoder(w).Encode([]string{"guest"})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/guest/actions/"):
+			require.Equal(t, "true", r.URL.Query().Get("overwrite"))
+			var action map[string]interface{}
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &action))
+			api.actions[filepath.Base(r.URL.Path)] = action
+			_ = json.NewEncoder(w).Encode(action)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/guest/actions/"):
+			name := filepath.Base(r.URL.Path)
+			if _, exists := api.actions[name]; !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(api.actions, name)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func newStubClient(t *testing.T, server *httptest.Server) *openwhisk.Client {
+	client, err := openwhisk.NewClient(server.URL, "", stubUsername+":"+stubPassword)
+	require.NoError(t, err)
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	client, err := openwhisk.NewClient("openwhisk.example.com/", "", "user:password")
+	require.NoError(t, err)
+	require.Equal(t, "https://openwhisk.example.com", client.APIHost)
+	require.Equal(t, openwhisk.DefaultNamespace, client.Namespace)
+	require.Equal(t, "user", client.Username)
+	require.Equal(t, "password", client.Password)
+
+	_, err = openwhisk.NewClient("openwhisk.example.com", "", "password")
+	require.Error(t, err)
+	_, err = openwhisk.NewClient("", "", "user:password")
+	require.Error(t, err)
+}
+
+func TestNewClientFromWskProperties(t *testing.T) {
+	propertiesPath := filepath.Join(t.TempDir(), ".wskprops")
+	require.NoError(t, os.WriteFile(propertiesPath, []byte("APIHOST=http://172.17.0.1:3233\nAUTH=user:password\nNAMESPACE=guest\n"), 0644))
+	t.Setenv("WSK_CONFIG_FILE", propertiesPath)
+	t.Setenv("OPENWHISK_APIHOST", "")
+	t.Setenv("OPENWHISK_AUTH", "")
+	t.Setenv("OPENWHISK_NAMESPACE", "bench")
+
+	client, err := openwhisk.NewClientFromEnvironment()
+	require.NoError(t, err)
+	require.Equal(t, "http://172.17.0.1:3233", client.APIHost)
+	require.Equal(t, "bench", client.Namespace)
+	require.Equal(t, "user", client.Username)
+}
+
+func TestResolveNamespace(t *testing.T) {
+	_, server := newStubAPI(t)
+	client := newStubClient(t, server)
+
+	require.NoError(t, client.ResolveNamespace())
+	require.Equal(t, "guest", client.Namespace)
+}
+
+func TestPutAction(t *testing.T) {
+	api, server := newStubAPI(t)
+	client := newStubClient(t, server)
+	client.Namespace = "guest"
+
+	require.NoError(t, client.PutAction(openwhisk.ActionSpec{
+		Name:           "stellar-hellopy-0-0",
+		Kind:           "python:3",
+		Code:           "def main(args):\n    return {}\n",
+		MemoryMB:       256,
+		TimeoutSeconds: 30,
+		Concurrency:    4,
+	}))
+
+	action := api.actions["stellar-hellopy-0-0"]
+	require.Equal(t, map[string]interface{}{"kind": "python:3", "code": "def main(args):\n    return {}\n"}, action["exec"])
+	require.Equal(t, map[string]interface{}{"memory": 256.0, "timeout": 30000.0, "concurrency": 4.0}, action["limits"])
+	require.Contains(t, action["annotations"], map[string]interface{}{"key": "web-export", "value": true})
+	require.Contains(t, action["annotations"], map[string]interface{}{"key": "require-whisk-auth", "value": true})
+}
+
+func TestPutActionDefaultLimits(t *testing.T) {
+	api, server := newStubAPI(t)
+	client := newStubClient(t, server)
+	client.Namespace = "guest"
+
+	require.NoError(t, client.PutAction(openwhisk.ActionSpec{Name: "hellonode", Kind: "nodejs:default", Code: "function main() {}"}))
+	require.Empty(t, api.actions["hellonode"]["limits"])
+}
+
+func TestPutActionUnauthorized(t *testing.T) {
+	_, server := newStubAPI(t)
+	client, err := openwhisk.NewClient(server.URL, "guest", "user:password")
+	require.NoError(t, err)
+
+	err = client.PutAction(openwhisk.ActionSpec{Name: "hellopy", Kind: "python:3"})
+	var apiError *openwhisk.APIError
+	require.ErrorAs(t, err, &apiError)
+	require.Equal(t, http.StatusUnauthorized, apiError.StatusCode)
+}
+
+func TestDeleteAction(t *testing.T) {
+	api, server := newStubAPI(t)
+	client := newStubClient(t, server)
+	client.Namespace = "guest"
+
+	require.NoError(t, client.PutAction(openwhisk.ActionSpec{Name: "hellopy", Kind: "python:3"}))
+	require.NoError(t, client.DeleteAction("hellopy"))
+	require.Empty(t, api.actions)
+
+	// Actions that were already removed are ignored
+	require.NoError(t, client.DeleteAction("hellopy"))
+}
+
+func TestWebActionURL(t *testing.T) {
+	client, err := openwhisk.NewClient("https://openwhisk.example.com", "guest", "user:password")
+	require.NoError(t, err)
+	require.Equal(t, "https://openwhisk.example.com/api/v1/web/guest/default/hellopy.json", client.WebActionURL("hellopy"))
+}
+
+func TestKindForSource(t *testing.T) {
+	kind, err := openwhisk.KindForSource("hellopy/main.py", "python3.9")
+	require.NoError(t, err)
+	require.Equal(t, "python:default", kind)
+
+	kind, err = openwhisk.KindForSource("hellogo/main.go", "go1.x")
+	require.NoError(t, err)
+	require.Equal(t, "go:default", kind)
+
+	kind, err = openwhisk.KindForSource("hellonode/index.js", "nodejs:20")
+	require.NoError(t, err)
+	require.Equal(t, "nodejs:20", kind)
+
+	_, err = openwhisk.KindForSource("hellojava/Main.java", "java11")
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"stellar/setup/runtimes"
+	"strings"
+)
+
+// dependencyBundleSeed seeds the content of binary dependency bundles, which must not depend on the filler settings
+const dependencyBundleSeed = 42
+
+// dependencyBundleLines generate the source code of the dependency bundles of interpreted languages, one small
+// function per line, so that loading the bundle costs parsing and compiling code as for a real dependency
+var dependencyBundleLines = map[string]func(index int) string{
+	"python": func(index int) string { return fmt.Sprintf("def f%d(x):\n    return x * %d + 1\n", index, index) },
+	"nodejs": func(index int) string { return fmt.Sprintf("exports.f%d = (x) => x * %d + 1;\n", index, index) },
+	"ruby":   func(index int) string { return fmt.Sprintf("def stellar_f%d(x); x * %d + 1; end\n", index, index) },
+}
+
+// DependencyBundleFileName returns the name of the dependency bundle loaded at init by the functions of the runtime:
+// a module imported by Python, Node.js and Ruby functions, or a binary file read by functions of other languages.
+func DependencyBundleFileName(runtime string) string {
+	language := ""
+	if registered, ok := runtimes.Lookup(runtime); ok {
+		language = registered.Language
+	}
+	switch language {
+	case "python":
+		return "stellar_bundle.py"
+	case "nodejs":
+		return "stellar_bundle.js"
+	case "ruby":
+		return "stellar_bundle.rb"
+	default:
+		return "stellar.bundle"
+	}
+}
+
+// DependencyBundleZIPSource adds a dependency bundle of exactly the given size for the functions of the runtime.
+func DependencyBundleZIPSource(runtime string, sizeBytes int64) ZIPSource {
+	return func(writer *zip.Writer) error {
+		header := &zip.FileHeader{Name: DependencyBundleFileName(runtime), Method: zip.Deflate, Modified: zipModified}
+		header.SetMode(0644)
+		entry, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return writeDependencyBundle(entry, runtime, sizeBytes)
+	}
+}
+
+// writeDependencyBundle writes the bundle of the runtime, padding source bundles with empty lines to the exact size
+func writeDependencyBundle(output io.Writer, runtime string, sizeBytes int64) error {
+	registered, _ := runtimes.Lookup(runtime)
+	line, isSource := dependencyBundleLines[registered.Language]
+	if !isSource {
+		source, err := NewFillerReader(FillerOptions{Seed: dependencyBundleSeed})
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(output, source, sizeBytes)
+		return err
+	}
+
+	buffered := bufio.NewWriter(output)
+	written := int64(0)
+	for index := 0; ; index++ {
+		next := line(index)
+		if written+int64(len(next)) > sizeBytes {
+			break
+		}
+		if _, err := buffered.WriteString(next); err != nil {
+			return err
+		}
+		written += int64(len(next))
+	}
+	if _, err := buffered.WriteString(strings.Repeat("\n", int(sizeBytes-written))); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
@@ -23,61 +23,229 @@
 package packaging
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
 	log "github.com/sirupsen/logrus"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"stellar/setup/deployment/connection/amazon"
 	"stellar/util"
+	"strings"
 )
 
-var builtImages = make(map[string]bool)
+// builtImage is an image pushed during this run, with its sizes
+type builtImage struct {
+	name string
+	size ImageSize
+}
+
+var builtImages = make(map[string]builtImage)
 var privateRepoURI string = ""
 var loggedIn bool = false
 
-// SetupContainerImageDeployment will package the function using container images and push to registry
-func SetupContainerImageDeployment(function string, provider string, compressedImageSizeMebibyte float64) string {
+// ImageRegistryEnvironmentVariable overrides the registry the images are pushed to, e.g., localhost:5000/stellar
+const ImageRegistryEnvironmentVariable = "STELLAR_IMAGE_REGISTRY"
+
+// SetupContainerImageDeployment will package the function using container images and push to registry.
+// The region selects the registry for providers with regional registries (e.g., Amazon ECR), while the
+// architecture (x86_64 or arm64) selects the platforms the image is built for.
+// The image of the function code is built once per version of the code and reused from the registry afterwards,
+// without a Docker daemon unless its Dockerfile has build steps (see buildBaseImage). The filler reaching the target
+// size is appended to it as its own layer without a Docker daemon.
+// It returns the name of the pushed image and its measured sizes.
+func SetupContainerImageDeployment(function string, provider string, region string, architecture string, compressedImageSizeMebibyte float64, fillerOptions FillerOptions) (string, ImageSize) {
 	functionDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/%s", provider, function)
+	return SetupContainerImageDeploymentFromDirectory(functionDir, function, provider, region, architecture, compressedImageSizeMebibyte, fillerOptions)
+}
+
+// SetupContainerImageDeploymentFromDirectory is SetupContainerImageDeployment for function code in the given directory.
+func SetupContainerImageDeploymentFromDirectory(functionDir string, function string, provider string, region string, architecture string, compressedImageSizeMebibyte float64, fillerOptions FillerOptions) (string, ImageSize) {
+	auth := setupImageRegistry(provider, region)
+
+	imageFunctionName := function
+	if architecture == "arm64" {
+		imageFunctionName = fmt.Sprintf("%s-%s", function, architecture)
+	}
+	taggedImage := fmt.Sprintf("%s_%v_stellar:latest", imageFunctionName, compressedImageSizeMebibyte)
+	imageName := fmt.Sprintf("%s/%s", privateRepoURI, taggedImage)
+	builtImageKey := fmt.Sprintf("%s %+v", imageName, fillerOptions)
+	if image, ok := builtImages[builtImageKey]; ok {
+		log.Infof("Container image for function %q is already built. Skipping...", taggedImage)
+		setAmazonImage(provider, region, image)
+		return image.name, image.size
+	}
+
+	// Filler files left in the function directory, e.g., by the docker-local provider, must not end up in the base image
+	RemoveStaleFillerFiles(0, filepath.Join(functionDir, "filler.file"))
+	// The base image is tagged in the repository of the image, as registries such as Amazon ECR do not create repositories on push
+	baseImageName := fmt.Sprintf("%s/%s_%v_stellar:base-%s", privateRepoURI, imageFunctionName, compressedImageSizeMebibyte, directoryDigest(functionDir))
+	if ImageExists(baseImageName, auth) {
+		log.Infof("Base image %q of function %q is already in the registry. Skipping build...", baseImageName, function)
+	} else {
+		buildBaseImage(functionDir, baseImageName, provider, region, architecture, auth)
+	}
+
+	var size ImageSize
+	var err error
+	if compressedImageSizeMebibyte == 0 {
+		imageName = baseImageName
+		size, err = RegistryImageSize(baseImageName, auth)
+	} else {
+		log.Infof("Appending filler layer to %q and pushing it to %q...", baseImageName, imageName)
+		size, err = PushImageWithFiller(baseImageName, imageName, util.MebibyteToBytes(compressedImageSizeMebibyte), fillerOptions, auth)
+	}
+	if err != nil {
+		log.Fatalf("Could not push container image %q: %s", imageName, err.Error())
+	}
+	log.Infof("Container image %q is %d bytes compressed and %d bytes uncompressed.", imageName, size.CompressedBytes, size.UncompressedBytes)
+
+	image := builtImage{name: imageName, size: size}
+	setAmazonImage(provider, region, image)
+	builtImages[builtImageKey] = image
+	return image.name, image.size
+}
+
+// buildBaseImage builds the image of the function code and pushes it as baseImageName. Dockerfiles copying the code
+// onto a base image are built without a Docker daemon, while those with build steps (e.g., RUN) are built with
+// docker buildx, which needs a Docker daemon with the buildx plugin.
+func buildBaseImage(functionDir string, baseImageName string, provider string, region string, architecture string, auth authn.Authenticator) {
+	platforms := ContainerPlatforms(provider, architecture)
+	recipe, err := ParseDockerfile(filepath.Join(functionDir, "Dockerfile"))
+	if err == nil {
+		log.Infof("Building base container image for platforms %s and pushing it to %q without a Docker daemon...", platforms, baseImageName)
+		if err := BuildImage(functionDir, recipe, platforms, baseImageName, auth); err != nil {
+			log.Fatalf("Could not build container image %q: %s", baseImageName, err.Error())
+		}
+		return
+	}
+	if !errors.Is(err, ErrDockerfileNeedsDaemon) {
+		log.Fatalf("Could not read the Dockerfile of %s: %s", functionDir, err.Error())
+	}
+
+	log.Infof("Building base container image for platforms %s with docker buildx (%s) and pushing it to %q...", platforms, err.Error(), baseImageName)
+	loginDockerCLI(provider, region)
+	// Provenance attestations are disabled as they turn single-platform images into manifest lists, which Lambda rejects
+	util.RunCommandAndLog(exec.Command("docker", "buildx", "build", "--platform", platforms, "--provenance=false",
+		"-t", baseImageName, "--push", functionDir))
+}
+
+// setAmazonImage sets the image the Lambda functions of the region are created from
+func setAmazonImage(provider string, region string, image builtImage) {
+	if provider == "aws" {
+		amazon.Instance(region).ImageURI = image.name
+		amazon.Instance(region).ImageSizeBytes = image.size.CompressedBytes
+	}
+}
+
+// setupImageRegistry selects the registry the images of the provider are pushed to and returns its credentials
+func setupImageRegistry(provider string, region string) authn.Authenticator {
+	if registry := os.Getenv(ImageRegistryEnvironmentVariable); registry != "" {
+		privateRepoURI = strings.TrimSuffix(registry, "/")
+		return keychainAuthenticator(privateRepoURI)
+	}
+
 	switch provider {
 	case "aws":
-		privateRepoURI = fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", amazon.UserARNNumber, amazon.AWSRegion)
-
-		log.Info("Authenticating Docker CLI to the Amazon ECR registry...")
-		util.RunCommandAndLog(exec.Command("docker", "login", "-u", "AWS", "-p",
-			amazon.GetECRAuthorizationToken(), privateRepoURI))
+		privateRepoURI = amazon.ECRRegistryURI(region)
+		return &authn.Basic{Username: "AWS", Password: amazon.GetECRAuthorizationToken(region)}
 	case "gcr":
 		fallthrough
 	case "vhive":
-		log.Info("Authenticating Docker CLI to the DockerHub registry...")
-
-		if !loggedIn {
-			privateRepoURI = os.Getenv("DOCKER_HUB_USERNAME")
-			privateRepoToken := os.Getenv("DOCKER_HUB_ACCESS_TOKEN")
-			util.RunCommandAndLog(exec.Command("docker", "login", "-u",
-				privateRepoURI, "-p", privateRepoToken))
-			loggedIn = true
+		fallthrough
+	case "openfaas":
+		privateRepoURI = os.Getenv("DOCKER_HUB_USERNAME")
+		if token := os.Getenv("DOCKER_HUB_ACCESS_TOKEN"); token != "" {
+			return &authn.Basic{Username: privateRepoURI, Password: token}
 		}
+		return keychainAuthenticator(name.DefaultRegistry)
 	default:
 		log.Fatalf("Provider %s does not support container image deployment.", provider)
+		return nil
 	}
+}
 
-	taggedImage := fmt.Sprintf("%s_%v_stellar:latest", function, compressedImageSizeMebibyte)
-	imageName := fmt.Sprintf("%s/%s", privateRepoURI, taggedImage)
-	if builtImages[taggedImage] {
-		log.Infof("Container image for function %q is already built. Skipping...", taggedImage)
-		return imageName
+// keychainAuthenticator returns the credentials of the Docker configuration for the registry, if any
+func keychainAuthenticator(repository string) authn.Authenticator {
+	registry, err := name.NewRegistry(strings.Split(repository, "/")[0])
+	if err != nil {
+		log.Fatalf("Could not parse image registry %q: %s", repository, err.Error())
+	}
+	auth, err := authn.DefaultKeychain.Resolve(registry)
+	if err != nil {
+		log.Fatalf("Could not find credentials for image registry %q: %s", repository, err.Error())
+	}
+	return auth
+}
+
+// loginDockerCLI authenticates the Docker CLI, used to build the base images with build steps, to the registry of the provider
+func loginDockerCLI(provider string, region string) {
+	if os.Getenv(ImageRegistryEnvironmentVariable) != "" {
+		return // the Docker CLI is expected to be logged in to custom registries already
 	}
 
-	util.RunCommandAndLog(exec.Command("docker", "build", "-t", taggedImage, functionDir))
+	switch provider {
+	case "aws":
+		log.Info("Authenticating Docker CLI to the Amazon ECR registry...")
+		loginCommand := exec.Command("docker", "login", "-u", "AWS", "--password-stdin", privateRepoURI)
+		loginCommand.Stdin = strings.NewReader(amazon.GetECRAuthorizationToken(region))
+		util.RunCommandAndLog(loginCommand)
+	default:
+		log.Info("Authenticating Docker CLI to the DockerHub registry...")
 
-	log.Infof("Pushing container image to %q...", imageName)
+		if !loggedIn {
+			// The token is passed on the standard input, so that it is neither logged nor listed with the processes
+			loginCommand := exec.Command("docker", "login", "-u", privateRepoURI, "--password-stdin")
+			loginCommand.Stdin = strings.NewReader(os.Getenv("DOCKER_HUB_ACCESS_TOKEN"))
+			util.RunCommandAndLog(loginCommand)
+			loggedIn = true
+		}
+	}
+}
 
-	util.RunCommandAndLog(exec.Command("docker", "tag", taggedImage, imageName))
-	util.RunCommandAndLog(exec.Command("docker", "push", imageName))
+// directoryDigest returns a short digest of the files of the directory, identifying the version of the function code
+func directoryDigest(directory string) string {
+	hash := sha256.New()
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(relativePath), len(content))
+		hash.Write(content)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Could not read function code at %s: %s", directory, err.Error())
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
 
-	if provider == "aws" {
-		amazon.AWSSingletonInstance.ImageURI = imageName
+// ContainerPlatforms returns the comma-separated Docker platforms to build the function image for.
+// Lambda and Cloud Run require a single-platform image matching the function, while images for self-hosted
+// clusters (vHive, OpenFaaS) are built for both architectures so that they can run on nodes of either kind.
+func ContainerPlatforms(provider string, architecture string) string {
+	switch provider {
+	case "gcr":
+		return "linux/amd64"
+	case "vhive", "openfaas":
+		return "linux/amd64,linux/arm64"
+	default:
+		if architecture == "arm64" {
+			return "linux/arm64"
+		}
+		return "linux/amd64"
 	}
-	builtImages[taggedImage] = true
-	return imageName
 }
@@ -0,0 +1,222 @@
+package packaging
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrDockerfileNeedsDaemon is returned for Dockerfiles with instructions only a Docker daemon can build, e.g., RUN
+var ErrDockerfileNeedsDaemon = errors.New("the Dockerfile needs a Docker daemon")
+
+// ImageRecipe is a Dockerfile that can be built without a Docker daemon: files of the build context copied onto a base
+// image, with the configuration of the image. It is built by BuildImage.
+type ImageRecipe struct {
+	Base         string
+	WorkingDir   string
+	Env          []string
+	Entrypoint   []string
+	Cmd          []string
+	ExposedPorts []string
+	User         string
+	StopSignal   string
+	Copies       []ImageCopy
+	// entrypointSet records an ENTRYPOINT instruction, which resets the command of the base image as in Docker
+	entrypointSet bool
+}
+
+// ImageCopy copies files of the build context to the image, as the COPY instruction
+type ImageCopy struct {
+	// Sources are paths of the build context, possibly with wildcards
+	Sources []string
+	// Destination is the absolute path in the image, a directory if it ends with a slash
+	Destination string
+}
+
+// ParseDockerfile reads the Dockerfile at the given path into an image recipe. Dockerfiles with build steps (RUN),
+// several stages, build arguments or instructions other than FROM, WORKDIR, COPY, ENV, EXPOSE, CMD, ENTRYPOINT, USER
+// and STOPSIGNAL return an error wrapping ErrDockerfileNeedsDaemon.
+func ParseDockerfile(dockerfilePath string) (ImageRecipe, error) {
+	file, err := os.Open(dockerfilePath)
+	if err != nil {
+		return ImageRecipe{}, err
+	}
+	defer file.Close()
+
+	var recipe ImageRecipe
+	workingDir := "/"
+	var instruction strings.Builder
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if instruction.Len() == 0 && (line == "" || strings.HasPrefix(line, "#")) {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			instruction.WriteString(strings.TrimSuffix(line, "\\") + " ")
+			continue
+		}
+		instruction.WriteString(line)
+		if err := recipe.addInstruction(instruction.String(), &workingDir); err != nil {
+			return ImageRecipe{}, err
+		}
+		instruction.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return ImageRecipe{}, err
+	}
+	if recipe.Base == "" {
+		return ImageRecipe{}, fmt.Errorf("the Dockerfile at %s has no FROM instruction", dockerfilePath)
+	}
+	return recipe, nil
+}
+
+func (r *ImageRecipe) addInstruction(instruction string, workingDir *string) error {
+	fields := strings.Fields(instruction)
+	keyword, arguments := strings.ToUpper(fields[0]), strings.TrimSpace(instruction[len(fields[0]):])
+	if keyword != "FROM" && r.Base == "" {
+		return fmt.Errorf("%w: %s before FROM", ErrDockerfileNeedsDaemon, keyword)
+	}
+
+	switch keyword {
+	case "FROM":
+		if r.Base != "" {
+			return fmt.Errorf("%w: multi-stage builds", ErrDockerfileNeedsDaemon)
+		}
+		if len(fields) != 2 && !(len(fields) == 4 && strings.EqualFold(fields[2], "AS")) {
+			return fmt.Errorf("%w: FROM %s", ErrDockerfileNeedsDaemon, arguments)
+		}
+		r.Base = fields[1]
+	case "WORKDIR":
+		*workingDir = resolveImagePath(*workingDir, arguments)
+		r.WorkingDir = *workingDir
+	case "COPY":
+		paths, err := instructionArguments(arguments)
+		if err != nil {
+			return err
+		}
+		if len(paths) < 2 || strings.HasPrefix(paths[0], "--") {
+			return fmt.Errorf("%w: COPY %s", ErrDockerfileNeedsDaemon, arguments)
+		}
+		destination := paths[len(paths)-1]
+		isDirectory := strings.HasSuffix(destination, "/") || destination == "." || len(paths) > 2
+		destination = resolveImagePath(*workingDir, destination)
+		if isDirectory {
+			destination = strings.TrimSuffix(destination, "/") + "/"
+		}
+		r.Copies = append(r.Copies, ImageCopy{Sources: paths[:len(paths)-1], Destination: destination})
+	case "ENV":
+		variables, err := environmentArguments(arguments)
+		if err != nil {
+			return err
+		}
+		r.Env = mergeEnvironment(r.Env, variables)
+	case "EXPOSE":
+		for _, port := range fields[1:] {
+			if !strings.Contains(port, "/") {
+				port += "/tcp"
+			}
+			r.ExposedPorts = append(r.ExposedPorts, port)
+		}
+	case "CMD", "ENTRYPOINT":
+		command := []string{"/bin/sh", "-c", arguments}
+		if strings.HasPrefix(arguments, "[") {
+			if err := json.Unmarshal([]byte(arguments), &command); err != nil {
+				return fmt.Errorf("could not parse %s %s: %w", keyword, arguments, err)
+			}
+		}
+		if keyword == "CMD" {
+			r.Cmd = command
+		} else {
+			r.Entrypoint, r.entrypointSet = command, true
+		}
+	case "USER":
+		r.User = arguments
+	case "STOPSIGNAL":
+		r.StopSignal = arguments
+	default:
+		return fmt.Errorf("%w: %s", ErrDockerfileNeedsDaemon, keyword)
+	}
+	return nil
+}
+
+// resolveImagePath resolves a path of the image relative to the working directory, unless absolute
+func resolveImagePath(workingDir string, imagePath string) string {
+	if path.IsAbs(imagePath) {
+		return path.Clean(imagePath)
+	}
+	return path.Join(workingDir, imagePath)
+}
+
+// instructionArguments returns the arguments of an instruction in the exec (JSON array) or the space-separated form
+func instructionArguments(arguments string) ([]string, error) {
+	if !strings.HasPrefix(arguments, "[") {
+		return strings.Fields(arguments), nil
+	}
+	var parsed []string
+	if err := json.Unmarshal([]byte(arguments), &parsed); err != nil {
+		return nil, fmt.Errorf("could not parse arguments %s: %w", arguments, err)
+	}
+	return parsed, nil
+}
+
+// environmentArguments returns the KEY=value assignments of an ENV instruction, in the "KEY=value ..." or "KEY value" form
+func environmentArguments(arguments string) ([]string, error) {
+	fields := strings.Fields(arguments)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("ENV without variables")
+	}
+	if !strings.Contains(fields[0], "=") {
+		return []string{fmt.Sprintf("%s=%s", fields[0], strings.TrimSpace(arguments[len(fields[0]):]))}, nil
+	}
+
+	var variables []string
+	var current strings.Builder
+	quote := rune(0)
+	for _, character := range arguments + " " {
+		switch {
+		case quote != 0 && character == quote:
+			quote = 0
+		case quote == 0 && (character == '"' || character == '\''):
+			quote = character
+		case quote == 0 && (character == ' ' || character == '\t'):
+			if current.Len() > 0 {
+				variables = append(variables, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(character)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in ENV %s", arguments)
+	}
+	for _, variable := range variables {
+		if !strings.Contains(variable, "=") {
+			return nil, fmt.Errorf("could not parse ENV %s", arguments)
+		}
+	}
+	return variables, nil
+}
+
+// mergeEnvironment sets the KEY=value assignments in the environment, replacing the previous values of their keys
+func mergeEnvironment(environment []string, variables []string) []string {
+	merged := append([]string{}, environment...)
+	for _, variable := range variables {
+		key := strings.SplitN(variable, "=", 2)[0]
+		replaced := false
+		for index, existing := range merged {
+			if strings.SplitN(existing, "=", 2)[0] == key {
+				merged[index], replaced = variable, true
+			}
+		}
+		if !replaced {
+			merged = append(merged, variable)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,243 @@
+package packaging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// FillerRandom fills the artifacts with incompressible random bytes (default)
+	FillerRandom = "random"
+	// FillerZeros fills the artifacts with zeros, which compress almost entirely
+	FillerZeros = "zeros"
+	// FillerText fills the artifacts with text-like data compressing at a target ratio
+	FillerText = "text"
+
+	defaultFillerCompressionRatio = 3.0
+	fillerChunkSizeBytes          = 1 << 20
+	// fillerCalibrationSampleBytes is the size of the text samples compressed to tune the text generator
+	fillerCalibrationSampleBytes = 256 << 10
+	fillerCalibrationSteps       = 12
+)
+
+// fillerVocabulary are the words repeated in text filler, interleaved with random words to reach the compression ratio
+var fillerVocabulary = strings.Fields("the quick brown fox jumps over the lazy dog while serverless functions start cold")
+
+// FillerOptions describes the content and layout of the filler files padding the artifacts to a target size.
+// The zero value generates a single file of random bytes from seed 0.
+type FillerOptions struct {
+	// Seed makes the generated content reproducible across runs
+	Seed int64
+	// Profile is FillerRandom, FillerZeros or FillerText
+	Profile string
+	// CompressionRatio is the uncompressed to DEFLATE-compressed size ratio of FillerText content (default 3)
+	CompressionRatio float64
+	// Files splits the filler into this many files of equal size (default 1)
+	Files int
+}
+
+// GenerateFillerFile writes a single filler file of random bytes of the given size.
+func GenerateFillerFile(experimentID int, fillerFilePath string, sizeBytes int64) {
+	GenerateFillerFiles(experimentID, fillerFilePath, sizeBytes, FillerOptions{})
+}
+
+// GenerateFillerFiles streams filler data of the given total size to disk, in one file at fillerFilePath or, when
+// options.Files is above 1, split across files named after it (e.g., filler-0.file, filler-1.file). It returns the
+// paths of the generated files.
+func GenerateFillerFiles(experimentID int, fillerFilePath string, sizeBytes int64, options FillerOptions) []string {
+	log.Infof("[sub-experiment %d] Generating %s filler of %d bytes to be included in deployment...", experimentID, fillerProfile(options), sizeBytes)
+
+	source, err := NewFillerReader(options)
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] %s", experimentID, err.Error())
+	}
+
+	RemoveStaleFillerFiles(experimentID, fillerFilePath)
+	paths := FillerFilePaths(fillerFilePath, options.Files)
+	fileSizes := fillerFileSizes(sizeBytes, options.Files)
+	for index, path := range paths {
+		fileSizeBytes := fileSizes[index]
+		if err := writeFillerFile(path, source, fileSizeBytes); err != nil {
+			log.Fatalf("[sub-experiment %d] Could not generate filler file %s with size %d bytes: %v", experimentID, path, fileSizeBytes, err)
+		}
+	}
+
+	log.Infof("[sub-experiment %d] Successfully generated %d filler file(s).", experimentID, len(paths))
+	return paths
+}
+
+// FillerFilePaths returns the paths of the filler files of a layout with the given number of files
+func FillerFilePaths(fillerFilePath string, files int) []string {
+	if files <= 1 {
+		return []string{fillerFilePath}
+	}
+	extension := filepath.Ext(fillerFilePath)
+	base := strings.TrimSuffix(fillerFilePath, extension)
+	paths := make([]string, files)
+	for index := range paths {
+		paths[index] = fmt.Sprintf("%s-%d%s", base, index, extension)
+	}
+	return paths
+}
+
+// fillerFileSizes splits the filler size evenly across the files of the layout
+func fillerFileSizes(sizeBytes int64, files int) []int64 {
+	if files < 1 {
+		files = 1
+	}
+	sizes := make([]int64, files)
+	for index := range sizes {
+		sizes[index] = sizeBytes / int64(files)
+		if int64(index) < sizeBytes%int64(files) {
+			sizes[index]++
+		}
+	}
+	return sizes
+}
+
+// RemoveStaleFillerFiles removes the filler files left by a previous layout, which would otherwise be packaged too
+func RemoveStaleFillerFiles(experimentID int, fillerFilePath string) {
+	extension := filepath.Ext(fillerFilePath)
+	stalePaths, _ := filepath.Glob(strings.TrimSuffix(fillerFilePath, extension) + "-*" + extension)
+	for _, path := range append(stalePaths, fillerFilePath) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("[sub-experiment %d] Could not remove stale filler file %s: %s", experimentID, path, err.Error())
+		}
+	}
+}
+
+func writeFillerFile(path string, source io.Reader, sizeBytes int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriterSize(file, fillerChunkSizeBytes)
+	if _, err := io.CopyN(writer, source, sizeBytes); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func fillerProfile(options FillerOptions) string {
+	if options.Profile == "" {
+		return FillerRandom
+	}
+	return options.Profile
+}
+
+// NewFillerReader returns an endless, reproducible stream of filler data for the given options.
+func NewFillerReader(options FillerOptions) (io.Reader, error) {
+	switch fillerProfile(options) {
+	case FillerRandom:
+		return rand.New(rand.NewSource(options.Seed)), nil
+	case FillerZeros:
+		return zeroReader{}, nil
+	case FillerText:
+		ratio := options.CompressionRatio
+		if ratio == 0 {
+			ratio = defaultFillerCompressionRatio
+		}
+		if ratio < 1 {
+			return nil, fmt.Errorf("filler compression ratio must be at least 1, got %v", ratio)
+		}
+		return newTextReader(options.Seed, calibrateTextFreshness(options.Seed, ratio)), nil
+	default:
+		return nil, fmt.Errorf("unknown filler profile %q, expected %s, %s or %s", options.Profile, FillerRandom, FillerZeros, FillerText)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(buffer []byte) (int, error) {
+	for index := range buffer {
+		buffer[index] = 0
+	}
+	return len(buffer), nil
+}
+
+// textReader generates words, each either the next word of a repeated phrase or, with probability freshness,
+// a word of random letters. The more fresh words, the less compressible the text.
+type textReader struct {
+	random    *rand.Rand
+	freshness float64
+	phrase    int
+	pending   []byte
+}
+
+func newTextReader(seed int64, freshness float64) *textReader {
+	return &textReader{random: rand.New(rand.NewSource(seed)), freshness: freshness}
+}
+
+func (r *textReader) Read(buffer []byte) (int, error) {
+	written := 0
+	for written < len(buffer) {
+		if len(r.pending) == 0 {
+			r.pending = r.nextWord()
+		}
+		copied := copy(buffer[written:], r.pending)
+		r.pending = r.pending[copied:]
+		written += copied
+	}
+	return written, nil
+}
+
+func (r *textReader) nextWord() []byte {
+	if r.random.Float64() < r.freshness {
+		word := make([]byte, 3+r.random.Intn(8), 12)
+		for index := range word {
+			word[index] = byte('a' + r.random.Intn(26))
+		}
+		return append(word, ' ')
+	}
+	word := fillerVocabulary[r.phrase%len(fillerVocabulary)]
+	r.phrase++
+	if r.phrase%len(fillerVocabulary) == 0 {
+		return []byte(word + ".\n")
+	}
+	return []byte(word + " ")
+}
+
+// calibrateTextFreshness searches the fraction of fresh words for which text samples compress at the target ratio.
+// Ratios beyond the reach of the generator are clamped, e.g., text of only random words compresses about 1.6 times.
+func calibrateTextFreshness(seed int64, targetRatio float64) float64 {
+	low, high := 0.0, 1.0
+	for step := 0; step < fillerCalibrationSteps; step++ {
+		freshness := (low + high) / 2
+		if CompressionRatio(newTextReader(seed, freshness), fillerCalibrationSampleBytes) > targetRatio {
+			low = freshness
+		} else {
+			high = freshness
+		}
+	}
+	freshness := (low + high) / 2
+	if achieved := CompressionRatio(newTextReader(seed, freshness), fillerCalibrationSampleBytes); achieved < 0.9*targetRatio || achieved > 1.1*targetRatio {
+		log.Warnf("Text filler compresses %.2f times instead of the requested %.2f.", achieved, targetRatio)
+	}
+	return freshness
+}
+
+// CompressionRatio returns the ratio of uncompressed to DEFLATE-compressed size of the first bytes of the source.
+func CompressionRatio(source io.Reader, sampleBytes int64) float64 {
+	var compressed bytes.Buffer
+	writer, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if _, err := io.CopyN(writer, source, sampleBytes); err != nil {
+		log.Fatalf("Could not compress filler sample: %s", err.Error())
+	}
+	if err := writer.Close(); err != nil {
+		log.Fatalf("Could not compress filler sample: %s", err.Error())
+	}
+	return float64(sampleBytes) / float64(compressed.Len())
+}
@@ -0,0 +1,260 @@
+package packaging
+
+import (
+	"archive/tar"
+	"fmt"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildImage builds the image of the recipe from the build context in contextDir and pushes it as targetImage, without
+// a Docker daemon. The copied files are appended as one layer onto the base image, pulled from its registry for every
+// platform of the comma-separated list, e.g., linux/amd64,linux/arm64. Several platforms are pushed as an image index.
+func BuildImage(contextDir string, recipe ImageRecipe, platforms string, targetImage string, auth authn.Authenticator) error {
+	baseReference, err := name.ParseReference(recipe.Base)
+	if err != nil {
+		return err
+	}
+	targetReference, err := name.ParseReference(targetImage)
+	if err != nil {
+		return err
+	}
+	files, err := resolveImageCopies(contextDir, recipe.Copies)
+	if err != nil {
+		return err
+	}
+	layer, err := functionLayer(files)
+	if err != nil {
+		return err
+	}
+
+	platformNames := strings.Split(platforms, ",")
+	index := mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+	for _, platformName := range platformNames {
+		platform, err := v1.ParsePlatform(platformName)
+		if err != nil {
+			return err
+		}
+		base, err := remote.Image(baseReference, remote.WithPlatform(*platform), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		if err != nil {
+			return fmt.Errorf("could not get base image %s: %w", recipe.Base, err)
+		}
+		image, err := recipe.appendTo(base, layer, *platform)
+		if err != nil {
+			return err
+		}
+		if len(platformNames) == 1 {
+			return remote.Write(targetReference, image, remote.WithAuth(auth))
+		}
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{Add: image, Descriptor: v1.Descriptor{Platform: platform}})
+	}
+	return remote.WriteIndex(targetReference, index, remote.WithAuth(auth))
+}
+
+// appendTo appends the layer of the copied files to the base image of the platform and applies the configuration of the recipe
+func (r ImageRecipe) appendTo(base v1.Image, layer v1.Layer, platform v1.Platform) (v1.Image, error) {
+	configFile, err := base.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if configFile.Architecture != "" && !configFile.Platform().Satisfies(platform) {
+		return nil, fmt.Errorf("base image %s is not available for platform %s", r.Base, platform.String())
+	}
+
+	layerMediaType := types.DockerLayer
+	if mediaType, err := base.MediaType(); err == nil && mediaType == types.OCIManifestSchema1 {
+		layerMediaType = types.OCILayer
+	}
+	image, err := mutate.Append(base, mutate.Addendum{
+		Layer:     layer,
+		MediaType: layerMediaType,
+		History:   v1.History{CreatedBy: "COPY (built by STeLLAR)", Created: v1.Time{Time: zipModified}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mutate.Config(image, r.config(configFile.Config))
+}
+
+// config applies the instructions of the recipe to the configuration of the base image
+func (r ImageRecipe) config(config v1.Config) v1.Config {
+	if r.WorkingDir != "" {
+		config.WorkingDir = r.WorkingDir
+	}
+	config.Env = mergeEnvironment(config.Env, r.Env)
+	if r.entrypointSet {
+		// As in Docker, an ENTRYPOINT resets the command inherited from the base image
+		config.Entrypoint, config.Cmd = r.Entrypoint, nil
+	}
+	if r.Cmd != nil {
+		config.Cmd = r.Cmd
+	}
+	if len(r.ExposedPorts) > 0 {
+		exposedPorts := map[string]struct{}{}
+		for port := range config.ExposedPorts {
+			exposedPorts[port] = struct{}{}
+		}
+		for _, port := range r.ExposedPorts {
+			exposedPorts[port] = struct{}{}
+		}
+		config.ExposedPorts = exposedPorts
+	}
+	if r.User != "" {
+		config.User = r.User
+	}
+	if r.StopSignal != "" {
+		config.StopSignal = r.StopSignal
+	}
+	return config
+}
+
+// imageFile is a file of the build context copied to the given path of the image
+type imageFile struct {
+	source    string
+	imagePath string
+}
+
+// resolveImageCopies lists the files of the build context copied by the COPY instructions, in the order of the
+// instructions and of the files within copied directories
+func resolveImageCopies(contextDir string, copies []ImageCopy) ([]imageFile, error) {
+	var files []imageFile
+	for _, imageCopy := range copies {
+		var matches []string
+		for _, source := range imageCopy.Sources {
+			sourceMatches, err := filepath.Glob(filepath.Join(contextDir, filepath.FromSlash(source)))
+			if err != nil {
+				return nil, err
+			}
+			if len(sourceMatches) == 0 {
+				return nil, fmt.Errorf("COPY source %s not found in %s", source, contextDir)
+			}
+			sort.Strings(sourceMatches)
+			matches = append(matches, sourceMatches...)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				imagePath := strings.TrimSuffix(imageCopy.Destination, "/")
+				if strings.HasSuffix(imageCopy.Destination, "/") || len(matches) > 1 {
+					imagePath = path.Join(imagePath, filepath.Base(match))
+				}
+				files = append(files, imageFile{source: match, imagePath: imagePath})
+				continue
+			}
+			// The contents of copied directories are copied, not the directories themselves
+			err = filepath.WalkDir(match, func(filePath string, entry fs.DirEntry, err error) error {
+				if err != nil || filePath == match {
+					return err
+				}
+				relativePath, err := filepath.Rel(match, filePath)
+				if err != nil {
+					return err
+				}
+				files = append(files, imageFile{source: filePath, imagePath: path.Join(imageCopy.Destination, filepath.ToSlash(relativePath))})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// functionLayer returns the layer of the copied files, written again whenever the layer is read
+func functionLayer(files []imageFile) (v1.Layer, error) {
+	opener := func() (io.ReadCloser, error) {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(writeFunctionTar(writer, files))
+		}()
+		return reader, nil
+	}
+	return tarball.LayerFromOpener(opener)
+}
+
+// writeFunctionTar writes the copied files with their parent directories, with fixed timestamps and owners, so that
+// the same build context gives the same layer
+func writeFunctionTar(output io.Writer, files []imageFile) error {
+	writer := tar.NewWriter(output)
+	writtenDirectories := map[string]bool{"/": true, ".": true}
+	var writeDirectory func(directory string) error
+	writeDirectory = func(directory string) error {
+		if writtenDirectories[directory] {
+			return nil
+		}
+		if err := writeDirectory(path.Dir(directory)); err != nil {
+			return err
+		}
+		writtenDirectories[directory] = true
+		return writer.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: strings.TrimPrefix(directory, "/") + "/", Mode: 0755, ModTime: zipModified})
+	}
+
+	for _, file := range files {
+		info, err := os.Lstat(file.source)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if err := writeDirectory(file.imagePath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeDirectory(path.Dir(file.imagePath)); err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file.source); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = strings.TrimPrefix(file.imagePath, "/")
+		header.ModTime, header.AccessTime, header.ChangeTime = zipModified, time.Time{}, time.Time{}
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err := copyFile(writer, file.source); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func copyFile(output io.Writer, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(output, file)
+	return err
+}
@@ -0,0 +1,437 @@
+package packaging
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"path"
+	"strings"
+)
+
+const (
+	// tarBlockSizeBytes is the granularity of tar archives, to which the filler files are padded
+	tarBlockSizeBytes = 512
+	// minLayerPaddingBytes is the size of the smallest gzip extra field, holding one empty subfield
+	minLayerPaddingBytes = 6
+	// maxLayerPaddingBytes is the size of the largest gzip extra field, which pads the filler layer to its exact size
+	maxLayerPaddingBytes = 2 + math.MaxUint16
+)
+
+// FillerLayer returns an image layer holding filler files of the given total size in the directory, laid out as in
+// GenerateFillerFiles, e.g., /app/filler.file. The layer is generated again from the seed whenever it is read, so that
+// it is never held in memory or on disk. Random filler is not compressed, so that the compressed size of the layer
+// matches the filler size.
+func FillerLayer(directory string, sizeBytes int64, options FillerOptions) (v1.Layer, error) {
+	return fillerLayer(directory, sizeBytes, options, 0)
+}
+
+// fillerLayer returns the filler layer with a gzip extra field taking up the given number of bytes, which pads the
+// compressed layer byte by byte where the tar blocks of the filler files cannot
+func fillerLayer(directory string, sizeBytes int64, options FillerOptions, paddingBytes int) (v1.Layer, error) {
+	if _, err := NewFillerReader(options); err != nil {
+		return nil, err
+	}
+
+	opener := func() (io.ReadCloser, error) {
+		source, err := NewFillerReader(options)
+		if err != nil {
+			return nil, err
+		}
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(writeFillerLayer(writer, directory, source, sizeBytes, options, paddingBytes))
+		}()
+		return reader, nil
+	}
+	return tarball.LayerFromOpener(opener)
+}
+
+// writeFillerLayer writes the compressed filler layer, with a gzip extra field of paddingBytes bytes, if any
+func writeFillerLayer(output io.Writer, directory string, source io.Reader, sizeBytes int64, options FillerOptions, paddingBytes int) error {
+	compressionLevel := gzip.DefaultCompression
+	if fillerProfile(options) == FillerRandom {
+		compressionLevel = gzip.NoCompression
+	}
+	writer, err := gzip.NewWriterLevel(output, compressionLevel)
+	if err != nil {
+		return err
+	}
+	if paddingBytes > 0 {
+		// A single subfield of zeros, identified as "ST", after the 2 bytes of the extra field length
+		writer.Extra = make([]byte, paddingBytes-2)
+		writer.Extra[0], writer.Extra[1] = 'S', 'T'
+		binary.LittleEndian.PutUint16(writer.Extra[2:], uint16(paddingBytes-minLayerPaddingBytes))
+	}
+	if err := writeFillerTar(writer, directory, source, sizeBytes, options); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// storedFillerLayerSize returns the compressed size of the random filler layer of the given filler size. As random
+// filler is stored uncompressed, the layer is as large as a layer of zeros of the same layout, which is faster to generate.
+func storedFillerLayerSize(directory string, sizeBytes int64, options FillerOptions) (int64, error) {
+	counter := &countingWriter{}
+	err := writeFillerLayer(counter, directory, zeroReader{}, sizeBytes, options, 0)
+	return counter.written, err
+}
+
+// fillerTarSize returns the uncompressed size of the filler layer, i.e., the filler files with their tar headers
+func fillerTarSize(directory string, sizeBytes int64, options FillerOptions) (int64, error) {
+	counter := &countingWriter{}
+	err := writeFillerTar(counter, directory, zeroReader{}, sizeBytes, options)
+	return counter.written, err
+}
+
+func writeFillerTar(output io.Writer, directory string, source io.Reader, sizeBytes int64, options FillerOptions) error {
+	writer := tar.NewWriter(output)
+	fileSizes := fillerFileSizes(sizeBytes, options.Files)
+	for index, filePath := range FillerFilePaths(path.Join(strings.TrimPrefix(directory, "/"), "filler.file"), options.Files) {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     filePath,
+			Mode:     0644,
+			Size:     fileSizes[index],
+			ModTime:  zipModified,
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(writer, source, fileSizes[index]); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// CompressedImageSize returns the size of the image as stored in registries, i.e., the sum of its compressed layers.
+func CompressedImageSize(image v1.Image) (int64, error) {
+	layers, err := image.Layers()
+	if err != nil {
+		return 0, err
+	}
+	var sizeBytes int64
+	for _, layer := range layers {
+		layerSize, err := layer.Size()
+		if err != nil {
+			return 0, err
+		}
+		sizeBytes += layerSize
+	}
+	return sizeBytes, nil
+}
+
+// ImageSize is the size of a container image as stored in registries (compressed) and as extracted on hosts (uncompressed).
+type ImageSize struct {
+	CompressedBytes   int64 `json:"CompressedBytes"`
+	UncompressedBytes int64 `json:"UncompressedBytes"`
+}
+
+// uncompressedSizes caches the uncompressed sizes of the layers by digest, as measuring them downloads the layers
+var uncompressedSizes = make(map[v1.Hash]int64)
+
+// UncompressedImageSize returns the size of the extracted layers of the image. Remote layers are downloaded to be measured.
+func UncompressedImageSize(image v1.Image) (int64, error) {
+	layers, err := image.Layers()
+	if err != nil {
+		return 0, err
+	}
+	var sizeBytes int64
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := uncompressedSizes[digest]; !ok {
+			reader, err := layer.Uncompressed()
+			if err != nil {
+				return 0, err
+			}
+			layerSize, err := io.Copy(io.Discard, reader)
+			reader.Close()
+			if err != nil {
+				return 0, err
+			}
+			uncompressedSizes[digest] = layerSize
+		}
+		sizeBytes += uncompressedSizes[digest]
+	}
+	return sizeBytes, nil
+}
+
+// RegistryImageSize returns the sizes of the image in its registry, for the default platform of multi-platform images.
+func RegistryImageSize(image string, auth authn.Authenticator) (ImageSize, error) {
+	reference, err := name.ParseReference(image)
+	if err != nil {
+		return ImageSize{}, err
+	}
+	remoteImage, err := remote.Image(reference, remote.WithAuth(auth))
+	if err != nil {
+		return ImageSize{}, err
+	}
+	var size ImageSize
+	if size.CompressedBytes, err = CompressedImageSize(remoteImage); err != nil {
+		return ImageSize{}, err
+	}
+	if size.UncompressedBytes, err = UncompressedImageSize(remoteImage); err != nil {
+		return ImageSize{}, err
+	}
+	return size, nil
+}
+
+// ImageExists returns whether the image reference can be found in its registry.
+func ImageExists(image string, auth authn.Authenticator) bool {
+	reference, err := name.ParseReference(image)
+	if err != nil {
+		return false
+	}
+	_, err = remote.Head(reference, remote.WithAuth(auth))
+	return err == nil
+}
+
+// PushImageWithFiller pushes targetImage as baseImage with a filler layer appended, sized so that the compressed image
+// reaches the target size. Random filler is sized exactly, taking off the tar and gzip overheads of the layer, while
+// compressible filler counts its uncompressed bytes. The image of every platform of a multi-platform base gets its own
+// filler layer. Both images are read from and written to their registries directly, without a Docker daemon.
+// The sizes of the pushed image are returned, for the first platform of multi-platform images.
+func PushImageWithFiller(baseImage string, targetImage string, targetSizeBytes int64, options FillerOptions, auth authn.Authenticator) (ImageSize, error) {
+	baseReference, err := name.ParseReference(baseImage)
+	if err != nil {
+		return ImageSize{}, err
+	}
+	targetReference, err := name.ParseReference(targetImage)
+	if err != nil {
+		return ImageSize{}, err
+	}
+
+	base, err := remote.Get(baseReference, remote.WithAuth(auth))
+	if err != nil {
+		return ImageSize{}, fmt.Errorf("could not get base image %s: %w", baseImage, err)
+	}
+
+	if !base.MediaType.IsIndex() {
+		image, err := base.Image()
+		if err != nil {
+			return ImageSize{}, err
+		}
+		paddedImage, size, err := padImage(image, targetSizeBytes, options)
+		if err != nil {
+			return ImageSize{}, err
+		}
+		if err := remote.Write(targetReference, paddedImage, remote.WithAuth(auth)); err != nil {
+			return ImageSize{}, err
+		}
+		return size, verifyPushedImageSize(targetReference, targetSizeBytes, options, auth)
+	}
+
+	index, err := base.ImageIndex()
+	if err != nil {
+		return ImageSize{}, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return ImageSize{}, err
+	}
+
+	var size ImageSize
+	paddedIndex := mutate.IndexMediaType(empty.Index, base.MediaType)
+	for _, descriptor := range manifest.Manifests {
+		if !descriptor.MediaType.IsImage() || (descriptor.Platform != nil && descriptor.Platform.OS == "unknown") {
+			continue // e.g., attestation manifests
+		}
+		image, err := index.Image(descriptor.Digest)
+		if err != nil {
+			return ImageSize{}, err
+		}
+		// Each platform is padded to the target size on its own, as their base images differ in size
+		paddedImage, platformSize, err := padImage(image, targetSizeBytes, options)
+		if err != nil {
+			return ImageSize{}, err
+		}
+		if size == (ImageSize{}) {
+			size = platformSize
+		}
+		paddedIndex = mutate.AppendManifests(paddedIndex, mutate.IndexAddendum{
+			Add:        paddedImage,
+			Descriptor: v1.Descriptor{Platform: descriptor.Platform},
+		})
+	}
+	if err := remote.WriteIndex(targetReference, paddedIndex, remote.WithAuth(auth)); err != nil {
+		return ImageSize{}, err
+	}
+	return size, verifyPushedImageSize(targetReference, targetSizeBytes, options, auth)
+}
+
+// PadImageTarball writes the image saved in the tarball at basePath, e.g., by docker save, with a filler layer appended
+// as in PushImageWithFiller to output, as a tarball of targetImage to be loaded by a Docker daemon. The compressed
+// size of the image is that of its layers as they would be pushed to a registry. The sizes of the padded image are returned.
+func PadImageTarball(basePath string, targetImage string, output io.Writer, targetSizeBytes int64, options FillerOptions) (ImageSize, error) {
+	targetTag, err := name.NewTag(targetImage)
+	if err != nil {
+		return ImageSize{}, err
+	}
+	image, err := tarball.ImageFromPath(basePath, nil)
+	if err != nil {
+		return ImageSize{}, fmt.Errorf("could not read base image from %s: %w", basePath, err)
+	}
+	paddedImage, size, err := padImage(image, targetSizeBytes, options)
+	if err != nil {
+		return ImageSize{}, err
+	}
+	return size, tarball.Write(targetTag, paddedImage, output)
+}
+
+// padImage appends the filler layer bringing the compressed size of the image to the target size, placing the filler
+// files in the working directory of the image where the functions read them, and returns the sizes of the padded image
+func padImage(image v1.Image, targetSizeBytes int64, options FillerOptions) (v1.Image, ImageSize, error) {
+	baseSizeBytes, err := CompressedImageSize(image)
+	if err != nil {
+		return nil, ImageSize{}, err
+	}
+	if targetSizeBytes < baseSizeBytes {
+		return nil, ImageSize{}, fmt.Errorf("target image size (%d bytes) cannot be smaller than the base image (%d bytes)", targetSizeBytes, baseSizeBytes)
+	}
+	configFile, err := image.ConfigFile()
+	if err != nil {
+		return nil, ImageSize{}, err
+	}
+	directory := configFile.Config.WorkingDir
+
+	fillerSizeBytes, paddingBytes := targetSizeBytes-baseSizeBytes, 0
+	if fillerProfile(options) == FillerRandom {
+		if fillerSizeBytes, paddingBytes, err = storedFillerLayerLayout(directory, targetSizeBytes-baseSizeBytes, options); err != nil {
+			return nil, ImageSize{}, err
+		}
+	}
+	log.Debugf("Padding image of %d bytes with %d bytes of filler.", baseSizeBytes, fillerSizeBytes)
+
+	layer, err := fillerLayer(directory, fillerSizeBytes, options, paddingBytes)
+	if err != nil {
+		return nil, ImageSize{}, err
+	}
+	paddedImage, err := mutate.AppendLayers(image, layer)
+	if err != nil {
+		return nil, ImageSize{}, err
+	}
+
+	var size ImageSize
+	if size.CompressedBytes, err = CompressedImageSize(paddedImage); err != nil {
+		return nil, ImageSize{}, err
+	}
+	baseUncompressedBytes, err := UncompressedImageSize(image)
+	if err != nil {
+		return nil, ImageSize{}, err
+	}
+	fillerUncompressedBytes, err := fillerTarSize(directory, fillerSizeBytes, options)
+	if err != nil {
+		return nil, ImageSize{}, err
+	}
+	size.UncompressedBytes = baseUncompressedBytes + fillerUncompressedBytes
+	return paddedImage, size, nil
+}
+
+// storedFillerLayerLayout returns the size of the random filler and the padding of its layer for which the compressed
+// layer is exactly of the given size. The filler files fill the layer up to the last tar blocks, and the gzip extra
+// field pads the rest.
+func storedFillerLayerLayout(directory string, layerSizeBytes int64, options FillerOptions) (int64, int, error) {
+	fillerSizeBytes := layerSizeBytes
+	for attempt := 0; attempt < 5; attempt++ {
+		sizeBytes, err := storedFillerLayerSize(directory, fillerSizeBytes, options)
+		if err != nil {
+			return 0, 0, err
+		}
+		slackBytes := layerSizeBytes - sizeBytes
+		switch {
+		case slackBytes == 0:
+			return fillerSizeBytes, 0, nil
+		case slackBytes >= minLayerPaddingBytes && slackBytes <= maxLayerPaddingBytes:
+			return fillerSizeBytes, int(slackBytes), nil
+		case fillerSizeBytes == 0 && slackBytes < 0:
+			return 0, 0, fmt.Errorf("target size leaves %d bytes for the filler layer, less than an empty layer (%d bytes)", layerSizeBytes, sizeBytes)
+		}
+		// Aims for a slack of two tar blocks per file, as the filler files grow and shrink by whole blocks
+		fillerSizeBytes += slackBytes - 2*tarBlockSizeBytes*int64(len(fillerFileSizes(0, options.Files)))
+		if fillerSizeBytes < 0 {
+			fillerSizeBytes = 0
+		}
+	}
+	return 0, 0, fmt.Errorf("could not size filler layer to %d bytes", layerSizeBytes)
+}
+
+// verifyPushedImageSize checks the compressed size of the pushed image, as listed in its manifests, against the target
+func verifyPushedImageSize(reference name.Reference, targetSizeBytes int64, options FillerOptions, auth authn.Authenticator) error {
+	sizes, err := PushedImageSizes(reference, auth)
+	if err != nil {
+		return fmt.Errorf("could not verify size of pushed image %s: %w", reference, err)
+	}
+	for platform, sizeBytes := range sizes {
+		switch {
+		case fillerProfile(options) == FillerRandom && sizeBytes != targetSizeBytes:
+			log.Warnf("Pushed image %s (%s) is %d bytes instead of the requested %d bytes.", reference, platform, sizeBytes, targetSizeBytes)
+		default:
+			log.Infof("Pushed image %s (%s) is %d bytes compressed.", reference, platform, sizeBytes)
+		}
+	}
+	return nil
+}
+
+// PushedImageSizes returns the compressed size of the image in the registry, per platform for multi-platform images,
+// summing the sizes of the layers listed in its manifests.
+func PushedImageSizes(reference name.Reference, auth authn.Authenticator) (map[string]int64, error) {
+	descriptor, err := remote.Get(reference, remote.WithAuth(auth))
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]v1.Image)
+	if descriptor.MediaType.IsIndex() {
+		index, err := descriptor.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+		for _, platformDescriptor := range manifest.Manifests {
+			image, err := index.Image(platformDescriptor.Digest)
+			if err != nil {
+				return nil, err
+			}
+			platform := platformDescriptor.Digest.String()
+			if platformDescriptor.Platform != nil {
+				platform = platformDescriptor.Platform.String()
+			}
+			images[platform] = image
+		}
+	} else {
+		image, err := descriptor.Image()
+		if err != nil {
+			return nil, err
+		}
+		images[""] = image
+	}
+
+	sizes := make(map[string]int64)
+	for platform, image := range images {
+		manifest, err := image.Manifest()
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range manifest.Layers {
+			sizes[platform] += layer.Size
+		}
+	}
+	return sizes, nil
+}
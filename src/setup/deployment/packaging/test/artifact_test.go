@@ -0,0 +1,129 @@
+package packaging
+
+import (
+	"archive/zip"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/packaging"
+	"testing"
+	"time"
+)
+
+func writeHandler(t *testing.T, directory string) string {
+	handlerPath := filepath.Join(directory, "bootstrap")
+	require.NoError(t, os.WriteFile(handlerPath, []byte("#!/bin/sh\necho hello\n"), 0755))
+	return handlerPath
+}
+
+func TestGenerateZIPArtifactReachesExactSize(t *testing.T) {
+	directory := t.TempDir()
+	zipPath := filepath.Join(directory, "function.zip")
+	targetSizeBytes := int64(5<<20 + 123)
+
+	packaging.GenerateZIPArtifact(1, zipPath, targetSizeBytes, packaging.FillerOptions{Seed: 3},
+		packaging.FileZIPSource(writeHandler(t, directory)))
+
+	info, err := os.Stat(zipPath)
+	require.NoError(t, err)
+	require.Equal(t, targetSizeBytes, info.Size())
+}
+
+func TestGenerateZIPArtifactIsDeterministic(t *testing.T) {
+	directory := t.TempDir()
+	handlerPath := writeHandler(t, directory)
+	firstPath := filepath.Join(directory, "first.zip")
+	secondPath := filepath.Join(directory, "second.zip")
+
+	options := packaging.FillerOptions{Seed: 3, Profile: packaging.FillerText, Files: 3}
+	packaging.GenerateZIPArtifact(1, firstPath, 2<<20, options, packaging.FileZIPSource(handlerPath))
+	require.NoError(t, os.Chtimes(handlerPath, time.Now(), time.Now().Add(time.Hour)))
+	packaging.GenerateZIPArtifact(1, secondPath, 2<<20, options, packaging.FileZIPSource(handlerPath))
+
+	require.Equal(t, readFile(t, firstPath), readFile(t, secondPath))
+}
+
+func TestGenerateZIPArtifactEntries(t *testing.T) {
+	directory := t.TempDir()
+	zipPath := filepath.Join(directory, "function.zip")
+
+	packaging.GenerateZIPArtifact(1, zipPath, 3<<20, packaging.FillerOptions{Files: 2},
+		packaging.FileZIPSource(writeHandler(t, directory)))
+
+	archive, err := zip.OpenReader(zipPath)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	require.Len(t, archive.File, 3)
+	require.Equal(t, "bootstrap", archive.File[0].Name)
+	require.Equal(t, os.FileMode(0755), archive.File[0].Mode().Perm(), "handlers must stay executable")
+	require.Equal(t, "filler-0.file", archive.File[1].Name)
+	require.Equal(t, "filler-1.file", archive.File[2].Name)
+	require.Equal(t, zip.Store, archive.File[1].Method, "random filler should be stored uncompressed")
+	require.Equal(t, 1980, archive.File[1].Modified.Year())
+}
+
+func TestDirectoryZIPSourceKeepsRelativePaths(t *testing.T) {
+	directory := t.TempDir()
+	publishDir := filepath.Join(directory, "publish")
+	require.NoError(t, os.MkdirAll(filepath.Join(publishDir, "runtimes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(publishDir, "hellodotnet.dll"), []byte("assembly"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(publishDir, "runtimes", "native.so"), []byte("library"), 0644))
+	zipPath := filepath.Join(directory, "function.zip")
+
+	packaging.GenerateZIPArtifact(1, zipPath, 1<<20, packaging.FillerOptions{}, packaging.DirectoryZIPSource(publishDir))
+
+	archive, err := zip.OpenReader(zipPath)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	require.Equal(t, "hellodotnet.dll", archive.File[0].Name)
+	require.Equal(t, "runtimes/native.so", archive.File[1].Name)
+	require.Equal(t, "filler.file", archive.File[2].Name)
+}
+
+func TestDependencyBundleZIPSource(t *testing.T) {
+	directory := t.TempDir()
+
+	for runtime, fileName := range map[string]string{
+		"python3.12": "stellar_bundle.py",
+		"nodejs22.x": "stellar_bundle.js",
+		"ruby3.3":    "stellar_bundle.rb",
+		"java21":     "stellar.bundle",
+	} {
+		require.Equal(t, fileName, packaging.DependencyBundleFileName(runtime))
+
+		zipPath := filepath.Join(directory, runtime+".zip")
+		packaging.GenerateZIPArtifact(1, zipPath, 2<<20, packaging.FillerOptions{},
+			packaging.FileZIPSource(writeHandler(t, directory)), packaging.DependencyBundleZIPSource(runtime, 300<<10+7))
+
+		archive, err := zip.OpenReader(zipPath)
+		require.NoError(t, err)
+		require.Equal(t, fileName, archive.File[1].Name, runtime)
+		require.Equal(t, uint64(300<<10+7), archive.File[1].UncompressedSize64, runtime)
+		require.NoError(t, archive.Close())
+	}
+}
+
+func TestArchiveZIPSourceSkipsPadding(t *testing.T) {
+	directory := t.TempDir()
+	paddedPath := filepath.Join(directory, "padded.zip")
+	packaging.GenerateZIPArtifact(1, paddedPath, 2<<20, packaging.FillerOptions{Files: 2},
+		packaging.FileZIPSource(writeHandler(t, directory)), packaging.DependencyBundleZIPSource("java21", 100<<10))
+
+	padded, err := zip.OpenReader(paddedPath)
+	require.NoError(t, err)
+	defer padded.Close()
+
+	repaddedPath := filepath.Join(directory, "repadded.zip")
+	packaging.GenerateZIPArtifact(1, repaddedPath, 3<<20, packaging.FillerOptions{},
+		packaging.ArchiveZIPSource(&padded.Reader))
+
+	repadded, err := zip.OpenReader(repaddedPath)
+	require.NoError(t, err)
+	defer repadded.Close()
+
+	require.Len(t, repadded.File, 2)
+	require.Equal(t, "bootstrap", repadded.File[0].Name)
+	require.Equal(t, "filler.file", repadded.File[1].Name)
+}
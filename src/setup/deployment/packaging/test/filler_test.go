@@ -0,0 +1,70 @@
+package packaging
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/packaging"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) []byte {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return content
+}
+
+func TestGenerateFillerFilesIsReproducible(t *testing.T) {
+	directory := t.TempDir()
+	first := packaging.GenerateFillerFiles(1, filepath.Join(directory, "first.file"), 3<<20, packaging.FillerOptions{Seed: 42})
+	second := packaging.GenerateFillerFiles(1, filepath.Join(directory, "second.file"), 3<<20, packaging.FillerOptions{Seed: 42})
+	other := packaging.GenerateFillerFiles(1, filepath.Join(directory, "other.file"), 3<<20, packaging.FillerOptions{Seed: 7})
+
+	require.Len(t, readFile(t, first[0]), 3<<20)
+	require.Equal(t, readFile(t, first[0]), readFile(t, second[0]))
+	require.NotEqual(t, readFile(t, first[0]), readFile(t, other[0]))
+	require.InDelta(t, 1, packaging.CompressionRatio(bytes.NewReader(readFile(t, first[0])), 3<<20), 0.01,
+		"random filler should not compress")
+}
+
+func TestGenerateFillerFilesProfiles(t *testing.T) {
+	directory := t.TempDir()
+
+	zeros := packaging.GenerateFillerFiles(1, filepath.Join(directory, "zeros.file"), 1<<20, packaging.FillerOptions{Profile: packaging.FillerZeros})
+	require.Equal(t, make([]byte, 1<<20), readFile(t, zeros[0]))
+
+	for _, ratio := range []float64{2, 4, 10} {
+		text := packaging.GenerateFillerFiles(1, filepath.Join(directory, "text.file"), 4<<20,
+			packaging.FillerOptions{Profile: packaging.FillerText, CompressionRatio: ratio, Seed: 3})
+		content := readFile(t, text[0])
+		require.Len(t, content, 4<<20)
+		require.InEpsilon(t, ratio, packaging.CompressionRatio(bytes.NewReader(content), int64(len(content))), 0.1)
+	}
+
+	_, err := packaging.NewFillerReader(packaging.FillerOptions{Profile: "lorem"})
+	require.Error(t, err)
+	_, err = packaging.NewFillerReader(packaging.FillerOptions{Profile: packaging.FillerText, CompressionRatio: 0.5})
+	require.Error(t, err)
+}
+
+func TestGenerateFillerFilesLayout(t *testing.T) {
+	fillerFilePath := filepath.Join(t.TempDir(), "filler.file")
+
+	packaging.GenerateFillerFile(1, fillerFilePath, 10)
+	paths := packaging.GenerateFillerFiles(1, fillerFilePath, 10, packaging.FillerOptions{Files: 3})
+	require.Equal(t, []string{
+		filepath.Join(filepath.Dir(fillerFilePath), "filler-0.file"),
+		filepath.Join(filepath.Dir(fillerFilePath), "filler-1.file"),
+		filepath.Join(filepath.Dir(fillerFilePath), "filler-2.file"),
+	}, paths)
+	for index, expectedSize := range []int{4, 3, 3} {
+		require.Len(t, readFile(t, paths[index]), expectedSize)
+	}
+	require.NoFileExists(t, fillerFilePath, "the filler of the previous layout should be removed")
+
+	require.Equal(t, []string{fillerFilePath}, packaging.GenerateFillerFiles(1, fillerFilePath, 10, packaging.FillerOptions{Files: 1}))
+	for _, path := range paths {
+		require.NoFileExists(t, path)
+	}
+}
@@ -0,0 +1,150 @@
+package packaging
+
+import (
+	"errors"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/packaging"
+	"testing"
+)
+
+// writeBuildContext writes a function with its Dockerfile to a new build context
+func writeBuildContext(t *testing.T, dockerfile string) string {
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte(dockerfile), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "app.py"), []byte("print('hello')\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(contextDir, "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "lib", "util.py"), []byte("\n"), 0644))
+	return contextDir
+}
+
+// platformBaseImage returns a random image configured for the given platform
+func platformBaseImage(t *testing.T, architecture string) v1.Image {
+	image, err := random.Image(1<<10, 1)
+	require.NoError(t, err)
+	configFile, err := image.ConfigFile()
+	require.NoError(t, err)
+	configFile.OS, configFile.Architecture = "linux", architecture
+	configFile.Config.Env = []string{"PATH=/usr/bin", "PORT=80"}
+	configFile.Config.Cmd = []string{"python3"}
+	image, err = mutate.ConfigFile(image, configFile)
+	require.NoError(t, err)
+	return image
+}
+
+func TestParseDockerfile(t *testing.T) {
+	contextDir := writeBuildContext(t, `# Function served by gunicorn
+FROM python:3.11-alpine AS runtime
+WORKDIR /app
+COPY app.py ./
+COPY lib lib/
+ENV PORT=8080 MODE="http server"
+ENV GREETING hello world
+EXPOSE 8080
+ENTRYPOINT ["gunicorn", \
+    "app:app"]
+`)
+
+	recipe, err := packaging.ParseDockerfile(filepath.Join(contextDir, "Dockerfile"))
+	require.NoError(t, err)
+	require.Equal(t, "python:3.11-alpine", recipe.Base)
+	require.Equal(t, "/app", recipe.WorkingDir)
+	require.Equal(t, []packaging.ImageCopy{
+		{Sources: []string{"app.py"}, Destination: "/app/"},
+		{Sources: []string{"lib"}, Destination: "/app/lib/"},
+	}, recipe.Copies)
+	require.Equal(t, []string{"PORT=8080", "MODE=http server", "GREETING=hello world"}, recipe.Env)
+	require.Equal(t, []string{"8080/tcp"}, recipe.ExposedPorts)
+	require.Equal(t, []string{"gunicorn", "app:app"}, recipe.Entrypoint)
+}
+
+func TestParseDockerfileNeedsDaemon(t *testing.T) {
+	for _, dockerfile := range []string{
+		"FROM python:3.11-alpine\nRUN pip install Flask\n",
+		"FROM golang:latest AS builder\nFROM alpine:3\nCOPY --from=builder /server /server\n",
+		"ARG VERSION=3\nFROM python:${VERSION}\n",
+	} {
+		_, err := packaging.ParseDockerfile(filepath.Join(writeBuildContext(t, dockerfile), "Dockerfile"))
+		require.True(t, errors.Is(err, packaging.ErrDockerfileNeedsDaemon), dockerfile)
+	}
+}
+
+func TestBuildImage(t *testing.T) {
+	host := startRegistry(t)
+	baseReference, err := name.ParseReference(host + "/python:base")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(baseReference, platformBaseImage(t, "amd64")))
+
+	contextDir := writeBuildContext(t, "FROM "+baseReference.String()+"\nWORKDIR /app\nCOPY . .\nENV PORT=8080\nCMD exec python3 app.py\n")
+	recipe, err := packaging.ParseDockerfile(filepath.Join(contextDir, "Dockerfile"))
+	require.NoError(t, err)
+
+	targetImage := host + "/hellopy_stellar:base"
+	require.NoError(t, packaging.BuildImage(contextDir, recipe, "linux/amd64", targetImage, authn.Anonymous))
+
+	built := getImage(t, targetImage)
+	layers, err := built.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 2, "the function code should be appended to the base image as one layer")
+	requireFillerLayer(t, built, "app/", "app/Dockerfile", "app/app.py", "app/lib/", "app/lib/util.py")
+
+	configFile, err := built.ConfigFile()
+	require.NoError(t, err)
+	require.Equal(t, "/app", configFile.Config.WorkingDir)
+	require.Equal(t, []string{"PATH=/usr/bin", "PORT=8080"}, configFile.Config.Env)
+	require.Equal(t, []string{"/bin/sh", "-c", "exec python3 app.py"}, configFile.Config.Cmd)
+
+	// The same build context gives the same image
+	require.NoError(t, packaging.BuildImage(contextDir, recipe, "linux/amd64", host+"/hellopy_stellar:again", authn.Anonymous))
+	builtDigest, err := built.Digest()
+	require.NoError(t, err)
+	againDigest, err := getImage(t, host+"/hellopy_stellar:again").Digest()
+	require.NoError(t, err)
+	require.Equal(t, builtDigest, againDigest)
+}
+
+func TestBuildImageIndex(t *testing.T) {
+	host := startRegistry(t)
+	baseReference, err := name.ParseReference(host + "/python:multi")
+	require.NoError(t, err)
+	baseIndex := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: platformBaseImage(t, "amd64"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: platformBaseImage(t, "arm64"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+	)
+	require.NoError(t, remote.WriteIndex(baseReference, baseIndex))
+
+	contextDir := writeBuildContext(t, "FROM "+baseReference.String()+"\nCOPY app.py /srv/\nENTRYPOINT [\"python3\", \"/srv/app.py\"]\n")
+	recipe, err := packaging.ParseDockerfile(filepath.Join(contextDir, "Dockerfile"))
+	require.NoError(t, err)
+	targetImage := host + "/hellopy_stellar:multi"
+	require.NoError(t, packaging.BuildImage(contextDir, recipe, "linux/amd64,linux/arm64", targetImage, authn.Anonymous))
+
+	targetReference, err := name.ParseReference(targetImage)
+	require.NoError(t, err)
+	index, err := remote.Index(targetReference)
+	require.NoError(t, err)
+	manifest, err := index.IndexManifest()
+	require.NoError(t, err)
+	require.Len(t, manifest.Manifests, 2)
+	for _, descriptor := range manifest.Manifests {
+		image, err := index.Image(descriptor.Digest)
+		require.NoError(t, err)
+		configFile, err := image.ConfigFile()
+		require.NoError(t, err)
+		require.Equal(t, descriptor.Platform.Architecture, configFile.Architecture)
+		require.Equal(t, []string{"python3", "/srv/app.py"}, configFile.Config.Entrypoint)
+		require.Nil(t, configFile.Config.Cmd, "an ENTRYPOINT should reset the command of the base image")
+		requireFillerLayer(t, image, "srv/", "srv/app.py")
+	}
+
+	// Base images missing a platform are rejected
+	require.Error(t, packaging.BuildImage(contextDir, recipe, "linux/s390x", host+"/hellopy_stellar:s390x", authn.Anonymous))
+}
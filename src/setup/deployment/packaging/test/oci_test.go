@@ -0,0 +1,208 @@
+package packaging
+
+import (
+	"archive/tar"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/packaging"
+	"testing"
+)
+
+// startRegistry serves an in-memory registry and returns its host
+func startRegistry(t *testing.T) string {
+	server := httptest.NewServer(registry.New())
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return serverURL.Host
+}
+
+func randomBaseImage(t *testing.T) v1.Image {
+	image, err := random.Image(1<<20, 2)
+	require.NoError(t, err)
+	image, err = mutate.Config(image, v1.Config{WorkingDir: "/app"})
+	require.NoError(t, err)
+	return image
+}
+
+func getImage(t *testing.T, image string) v1.Image {
+	reference, err := name.ParseReference(image)
+	require.NoError(t, err)
+	pulled, err := remote.Image(reference)
+	require.NoError(t, err)
+	return pulled
+}
+
+// requireFillerLayer checks that the last layer of the image holds the filler files in its working directory
+func requireFillerLayer(t *testing.T, image v1.Image, expectedFiles ...string) {
+	layers, err := image.Layers()
+	require.NoError(t, err)
+	reader, err := layers[len(layers)-1].Uncompressed()
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var files []string
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		files = append(files, header.Name)
+	}
+	require.Equal(t, expectedFiles, files)
+}
+
+func TestPushImageWithFiller(t *testing.T) {
+	host := startRegistry(t)
+	baseImage := host + "/hellopy_stellar:base"
+	targetImage := host + "/hellopy_20_stellar:latest"
+	targetSizeBytes := int64(20 << 20)
+
+	base := randomBaseImage(t)
+	reference, err := name.ParseReference(baseImage)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(reference, base))
+	require.True(t, packaging.ImageExists(baseImage, authn.Anonymous))
+	require.False(t, packaging.ImageExists(targetImage, authn.Anonymous))
+
+	size, err := packaging.PushImageWithFiller(baseImage, targetImage, targetSizeBytes, packaging.FillerOptions{Seed: 1, Files: 2}, authn.Anonymous)
+	require.NoError(t, err)
+
+	padded := getImage(t, targetImage)
+	layers, err := padded.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 3)
+	requireFillerLayer(t, padded, "app/filler-0.file", "app/filler-1.file")
+
+	targetReference, err := name.ParseReference(targetImage)
+	require.NoError(t, err)
+	sizes, err := packaging.PushedImageSizes(targetReference, authn.Anonymous)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"": targetSizeBytes}, sizes, "random filler should reach the target size exactly")
+	require.Equal(t, targetSizeBytes, size.CompressedBytes)
+
+	uncompressedBytes, err := packaging.UncompressedImageSize(padded)
+	require.NoError(t, err)
+	require.Equal(t, uncompressedBytes, size.UncompressedBytes)
+}
+
+func TestPadImageTarball(t *testing.T) {
+	directory := t.TempDir()
+	basePath := filepath.Join(directory, "base.tar")
+	baseTag, err := name.NewTag("stellar-hellopy:test-base")
+	require.NoError(t, err)
+	require.NoError(t, tarball.WriteToFile(basePath, baseTag, randomBaseImage(t)))
+
+	paddedPath := filepath.Join(directory, "padded.tar")
+	padded, err := os.Create(paddedPath)
+	require.NoError(t, err)
+	targetSizeBytes := int64(6<<20 + 77)
+	size, err := packaging.PadImageTarball(basePath, "stellar-hellopy:test", padded, targetSizeBytes, packaging.FillerOptions{Seed: 1})
+	require.NoError(t, err)
+	require.NoError(t, padded.Close())
+
+	paddedTag, err := name.NewTag("stellar-hellopy:test")
+	require.NoError(t, err)
+	image, err := tarball.ImageFromPath(paddedPath, &paddedTag)
+	require.NoError(t, err)
+	requireFillerLayer(t, image, "app/filler.file")
+	compressedBytes, err := packaging.CompressedImageSize(image)
+	require.NoError(t, err)
+	require.Equal(t, targetSizeBytes, compressedBytes, "random filler should reach the target size exactly")
+	require.Equal(t, targetSizeBytes, size.CompressedBytes)
+}
+
+func TestPushImageWithTextFiller(t *testing.T) {
+	host := startRegistry(t)
+	baseImage := host + "/hellopy_stellar:base"
+	reference, err := name.ParseReference(baseImage)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(reference, randomBaseImage(t)))
+
+	options := packaging.FillerOptions{Seed: 1, Profile: packaging.FillerText, CompressionRatio: 4}
+	size, err := packaging.PushImageWithFiller(baseImage, host+"/hellopy_8_stellar:latest", 8<<20, options, authn.Anonymous)
+	require.NoError(t, err)
+
+	// Compressible filler counts its uncompressed bytes towards the target size
+	require.InDelta(t, 8<<20, size.UncompressedBytes, 16<<10)
+	require.Less(t, size.CompressedBytes, int64(5<<20))
+}
+
+func TestPushImageWithFillerRejectsSmallTargets(t *testing.T) {
+	host := startRegistry(t)
+	reference, err := name.ParseReference(host + "/hellopy_stellar:base")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(reference, randomBaseImage(t)))
+
+	_, err = packaging.PushImageWithFiller(reference.String(), host+"/hellopy_1_stellar:latest", 1<<10, packaging.FillerOptions{}, authn.Anonymous)
+	require.Error(t, err)
+}
+
+func TestPushImageIndexWithFiller(t *testing.T) {
+	host := startRegistry(t)
+	baseImage := host + "/hellopy_stellar:base"
+	targetImage := host + "/hellopy_10_stellar:latest"
+
+	var index v1.ImageIndex = mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+	for _, platform := range []v1.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}, {OS: "unknown", Architecture: "unknown"}} {
+		platform := platform
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{
+			Add:        randomBaseImage(t),
+			Descriptor: v1.Descriptor{Platform: &platform},
+		})
+	}
+	reference, err := name.ParseReference(baseImage)
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(reference, index))
+
+	options := packaging.FillerOptions{Seed: 1, Profile: packaging.FillerZeros, Files: 2}
+	_, err = packaging.PushImageWithFiller(baseImage, targetImage, 10<<20, options, authn.Anonymous)
+	require.NoError(t, err)
+
+	targetReference, err := name.ParseReference(targetImage)
+	require.NoError(t, err)
+	padded, err := remote.Index(targetReference)
+	require.NoError(t, err)
+	manifest, err := padded.IndexManifest()
+	require.NoError(t, err)
+	require.Len(t, manifest.Manifests, 2, "attestation-like manifests of unknown platforms should be dropped")
+
+	for _, descriptor := range manifest.Manifests {
+		require.Equal(t, "linux", descriptor.Platform.OS)
+		image, err := padded.Image(descriptor.Digest)
+		require.NoError(t, err)
+		requireFillerLayer(t, image, "app/filler-0.file", "app/filler-1.file")
+	}
+}
+
+func TestPushImageWithFillerExactSizes(t *testing.T) {
+	host := startRegistry(t)
+	reference, err := name.ParseReference(host + "/hellopy_stellar:base")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(reference, randomBaseImage(t)))
+
+	for _, files := range []int{1, 5} {
+		for _, targetSizeBytes := range []int64{3<<20 + 1, 7<<20 + 333} {
+			size, err := packaging.PushImageWithFiller(reference.String(), host+"/hellopy_exact_stellar:latest", targetSizeBytes,
+				packaging.FillerOptions{Files: files}, authn.Anonymous)
+			require.NoError(t, err)
+			require.Equal(t, targetSizeBytes, size.CompressedBytes, "%d filler files", files)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"os"
+	"path/filepath"
 	"stellar/setup/building"
 	"stellar/setup/deployment/packaging"
 	"stellar/util"
@@ -41,20 +42,37 @@ func (s *ZipTestSuite) TestGenerateFillerFile() {
 
 func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsPython() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "hellopy", "python3.9")
-	packaging.GenerateServerlessZIPArtifacts(1, "aws", "python3.9", "hellopy", 50)
-	fileInfo, err := os.Stat("setup/deployment/raw-code/serverless/aws/artifacts/hellopy/hellopy.zip")
+	b.BuildFunction("aws", "hellopy", "python3.9", "x86_64")
+	zipPath := packaging.GenerateServerlessZIPArtifacts(1, "aws", "python3.9", "hellopy", 50, packaging.FillerOptions{})
+	fileInfo, err := os.Stat(filepath.Join("setup/deployment/raw-code/serverless/aws", zipPath))
 	if err != nil {
 		assert.Fail(s.T(), "Could not obtain file info of ZIP artifact")
 	}
 	assert.InDelta(s.T(), 50, util.BytesToMebibyte(fileInfo.Size()), 0.1)
 }
 
+func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsPerSubExperiment() {
+	b := &building.Builder{}
+	b.BuildFunction("aws", "hellopy", "python3.9", "x86_64")
+	smallZIPPath := packaging.GenerateServerlessZIPArtifacts(4, "aws", "python3.9", "hellopy", 10, packaging.FillerOptions{})
+	largeZIPPath := packaging.GenerateServerlessZIPArtifacts(5, "aws", "python3.9", "hellopy", 20, packaging.FillerOptions{})
+	assert.Equal(s.T(), "artifacts/hellopy/sub-experiment-4/hellopy.zip", smallZIPPath)
+	assert.Equal(s.T(), "artifacts/hellopy/sub-experiment-5/hellopy.zip", largeZIPPath)
+
+	for zipPath, expectedSizeMB := range map[string]float64{smallZIPPath: 10, largeZIPPath: 20} {
+		fileInfo, err := os.Stat(filepath.Join("setup/deployment/raw-code/serverless/aws", zipPath))
+		if err != nil {
+			assert.Fail(s.T(), "Could not obtain file info of ZIP artifact")
+		}
+		assert.InDelta(s.T(), expectedSizeMB, util.BytesToMebibyte(fileInfo.Size()), 0.1)
+	}
+}
+
 func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsGolang() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "hellogo", "go1.x")
-	packaging.GenerateServerlessZIPArtifacts(2, "aws", "go1.x", "hellogo", 50)
-	fileInfo, err := os.Stat("setup/deployment/raw-code/serverless/aws/artifacts/hellogo/hellogo.zip")
+	b.BuildFunction("aws", "hellogo", "go1.x", "x86_64")
+	zipPath := packaging.GenerateServerlessZIPArtifacts(2, "aws", "go1.x", "hellogo", 50, packaging.FillerOptions{})
+	fileInfo, err := os.Stat(filepath.Join("setup/deployment/raw-code/serverless/aws", zipPath))
 	if err != nil {
 		assert.Fail(s.T(), "Could not obtain file info of ZIP artifact")
 	}
@@ -63,9 +81,9 @@ func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsGolang() {
 
 func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsJava() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "hellojava", "java11")
-	packaging.GenerateServerlessZIPArtifacts(3, "aws", "java11", "hellojava", 50)
-	fileInfo, err := os.Stat("setup/deployment/raw-code/serverless/aws/artifacts/hellojava/hellojava.zip")
+	b.BuildFunction("aws", "hellojava", "java11", "x86_64")
+	zipPath := packaging.GenerateServerlessZIPArtifacts(3, "aws", "java11", "hellojava", 50, packaging.FillerOptions{})
+	fileInfo, err := os.Stat(filepath.Join("setup/deployment/raw-code/serverless/aws", zipPath))
 	if err != nil {
 		assert.Fail(s.T(), "Could not obtain file info of ZIP artifact")
 	}
@@ -74,9 +92,9 @@ func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsJava() {
 
 func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsNode() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "hellonode", "nodejs18.x")
-	packaging.GenerateServerlessZIPArtifacts(2, "aws", "nodejs18.x", "hellonode", 50)
-	fileInfo, err := os.Stat("setup/deployment/raw-code/serverless/aws/artifacts/hellonode/hellonode.zip")
+	b.BuildFunction("aws", "hellonode", "nodejs18.x", "x86_64")
+	zipPath := packaging.GenerateServerlessZIPArtifacts(2, "aws", "nodejs18.x", "hellonode", 50, packaging.FillerOptions{})
+	fileInfo, err := os.Stat(filepath.Join("setup/deployment/raw-code/serverless/aws", zipPath))
 	if err != nil {
 		assert.Fail(s.T(), "Could not obtain file info of ZIP artifact")
 	}
@@ -85,9 +103,9 @@ func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsNode() {
 
 func (s *ZipTestSuite) TestGenerateServerlessZipArtifactsRuby() {
 	b := &building.Builder{}
-	b.BuildFunction("aws", "helloruby", "ruby3.2")
-	packaging.GenerateServerlessZIPArtifacts(2, "aws", "ruby3.2", "helloruby", 50)
-	fileInfo, err := os.Stat("setup/deployment/raw-code/serverless/aws/artifacts/helloruby/helloruby.zip")
+	b.BuildFunction("aws", "helloruby", "ruby3.2", "x86_64")
+	zipPath := packaging.GenerateServerlessZIPArtifacts(2, "aws", "ruby3.2", "helloruby", 50, packaging.FillerOptions{})
+	fileInfo, err := os.Stat(filepath.Join("setup/deployment/raw-code/serverless/aws", zipPath))
 	if err != nil {
 		assert.Fail(s.T(), "Could not obtain file info of ZIP artifact")
 	}
@@ -23,139 +23,324 @@
 package packaging
 
 import (
+	"archive/zip"
 	"fmt"
 	log "github.com/sirupsen/logrus"
-	// "math/rand"
-	// 25.09 change for go linter syntax check errors 
-	// "math/rand"
-	"crypto/rand"
+	"io"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"stellar/setup/deployment/connection/amazon"
+	"stellar/setup/runtimes"
 	"stellar/util"
+	"time"
 )
 
-// SetupZIPDeployment will package the function using ZIP
-func SetupZIPDeployment(provider string, deploymentSizeBytes int64, zipPath string) {
+// zipModified is the modification time of all ZIP entries, so that artifacts with the same content are identical
+var zipModified = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// fillerEntryPattern matches the filler entries laid out by FillerFilePaths when several filler files are used
+var fillerEntryPattern = regexp.MustCompile(`^filler-[0-9]+\.file$`)
+
+// ZIPSource adds entries to a ZIP archive, e.g., the built function
+type ZIPSource func(writer *zip.Writer) error
+
+// SetupZIPDeployment will package the function using ZIP for deployment in the given region
+func SetupZIPDeployment(provider string, region string, deploymentSizeBytes int64, zipPath string) {
 	deploymentSizeMB := util.BytesToMebibyte(deploymentSizeBytes)
 	switch provider {
 	case "aws":
 		if deploymentSizeMB > 50. {
-			amazon.UploadZIPToS3(zipPath, deploymentSizeMB)
+			amazon.UploadZIPToS3(region, zipPath, deploymentSizeMB)
 		} else {
-			amazon.SetLocalZip(zipPath)
+			amazon.SetLocalZip(region, zipPath)
 		}
 	default:
 		log.Warnf("Provider %s does not support ZIP deployment, skipping ZIP generation...", provider)
 	}
 
 	log.Debugf("Cleaning up ZIP %q...", zipPath)
-	util.RunCommandAndLog(exec.Command("rm", "-r", zipPath))
+	if err := os.Remove(zipPath); err != nil {
+		log.Warnf("Could not remove ZIP %q: %s", zipPath, err.Error())
+	}
 }
 
-// GetZippedBinaryFileSize zips the binary and returns its size
+// GetZippedBinaryFileSize returns the size of a ZIP archive of the binary, without writing it to disk
 func GetZippedBinaryFileSize(experimentID int, binaryPath string) int64 {
 	log.Infof("[sub-experiment %d] Zipping binary file to find its size...", experimentID)
-	pathToTempArchive := filepath.Join(filepath.Dir(binaryPath), "zipped-binary.zip")
-
-	util.RunCommandAndLog(exec.Command("zip", pathToTempArchive, binaryPath))
 
-	fi, err := os.Stat(pathToTempArchive)
+	zippedBinarySizeBytes, err := zipSize(FileZIPSource(binaryPath))
 	if err != nil {
 		log.Fatalf("Could not get size of zipped binary file: %s", err.Error())
 	}
-	zippedBinarySizeBytes := fi.Size()
-
-	log.Debug("Cleaning up zipped binary...")
-	util.RunCommandAndLog(exec.Command("rm", "-r", pathToTempArchive))
-
 	return zippedBinarySizeBytes
 }
 
-func GenerateFillerFile(experimentID int, fillerFilePath string, sizeBytes int64) {
-	log.Infof("[sub-experiment %d] Generating filler file to be included in deployment...", experimentID)
+// GenerateZIP creates the zip file for deployment
+func GenerateZIP(experimentID int, fillerFilePath string, binaryPath string, zipName string) string {
+	log.Infof("[sub-experiment %d] Generating ZIP file to be deployed...", experimentID)
+
+	if err := writeZIPFile(zipName, FileZIPSource(binaryPath), FileZIPSource(fillerFilePath)); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not generate ZIP file %s: %s", experimentID, zipName, err.Error())
+	}
+	if err := os.Remove(fillerFilePath); err != nil {
+		log.Warnf("[sub-experiment %d] Could not remove filler file %s: %s", experimentID, fillerFilePath, err.Error())
+	}
+
+	log.Infof("[sub-experiment %d] Successfully generated ZIP file.", experimentID)
 
-	buffer := make([]byte, sizeBytes)
-	_, err := rand.Read(buffer) // The slice should now contain random bytes instead of only zeroes (prevents efficient archiving).
+	workingDirectory, err := filepath.Abs(filepath.Dir("."))
 	if err != nil {
-		log.Fatalf("[sub-experiment %d] Failed to fill buffer with random bytes: `%s`", experimentID, err.Error())
+		log.Fatal(err)
+	}
+	return filepath.Join(workingDirectory, zipName)
+}
+
+// GenerateServerlessZIPArtifacts pads the built function, along with the extra sources (e.g., a dependency bundle), to the
+// target size with the given filler and zips it. Every sub-experiment gets its own archive, as their sizes, filler and
+// sources may differ. It returns the path of the archive relative to the serverless.yml file, or an empty string if the
+// runtime is not registered.
+func GenerateServerlessZIPArtifacts(experimentID int, provider string, runtime string, functionName string, functionImageSizeMB float64, fillerOptions FillerOptions, extraSources ...ZIPSource) string {
+	functionRuntime, ok := runtimes.Lookup(runtime)
+	if !ok {
+		log.Warnf("[sub-experiment %d] Runtime %s is not registered, skipping generation of ZIP artifact.", experimentID, runtime)
+		return ""
 	}
 
-	if err := os.WriteFile(fillerFilePath, buffer, 0666); err != nil {
-		log.Fatalf("[sub-experiment %d] Could not generate random file with size %d bytes: %v", experimentID, sizeBytes, err)
+	serverlessDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s", provider)
+	artifactDir := fmt.Sprintf("%s/artifacts/%s", serverlessDir, functionName)
+	zipPathRelativeToServerlessConfigFile := ServerlessZIPArtifactPath(experimentID, functionName)
+	zipPath := fmt.Sprintf("%s/%s", serverlessDir, zipPathRelativeToServerlessConfigFile)
+	if err := os.MkdirAll(filepath.Dir(zipPath), os.ModePerm); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not create directory for ZIP artifact %s: %s", experimentID, zipPath, err.Error())
 	}
 
-	log.Infof("[sub-experiment %d] Successfully generated the filler file.", experimentID)
+	entryPath := fmt.Sprintf("%s/%s", artifactDir, functionRuntime.EntryPath(functionName))
+	switch functionRuntime.Build {
+	case runtimes.BuildGradle:
+		generateServerlessZIPArtifactsJava(experimentID, artifactDir, functionName, zipPath, functionImageSizeMB, fillerOptions, extraSources)
+	case runtimes.BuildDotnet:
+		GenerateZIPArtifact(experimentID, zipPath, util.MebibyteToBytes(functionImageSizeMB), fillerOptions, append([]ZIPSource{DirectoryZIPSource(entryPath)}, extraSources...)...)
+	default:
+		GenerateZIPArtifact(experimentID, zipPath, util.MebibyteToBytes(functionImageSizeMB), fillerOptions, append([]ZIPSource{FileZIPSource(entryPath)}, extraSources...)...)
+	}
+	return zipPathRelativeToServerlessConfigFile
 }
 
-// GenerateZIP creates the zip file for deployment
-func GenerateZIP(experimentID int, fillerFilePath string, binaryPath string, zipName string) string {
+// ServerlessZIPArtifactPath returns the path of the ZIP artifact of the sub-experiment relative to the serverless.yml file
+func ServerlessZIPArtifactPath(experimentID int, functionName string) string {
+	return fmt.Sprintf("artifacts/%s/sub-experiment-%d/%s.zip", functionName, experimentID, functionName)
+}
+
+// generateServerlessZIPArtifactsJava pads the archive built by Gradle, which is left untouched for the other sub-experiments
+func generateServerlessZIPArtifactsJava(experimentID int, artifactDir string, functionName string, zipPath string, functionImageSizeMB float64, fillerOptions FillerOptions, extraSources []ZIPSource) {
+	gradleArtifactPath := fmt.Sprintf("%s/%s.zip", artifactDir, functionName)
+	if _, err := os.Stat(gradleArtifactPath); err != nil {
+		log.Fatalf("Could not file size of Java artifact at %s", gradleArtifactPath)
+	}
+
+	// The entries of the Gradle archive are copied into a new archive, as it cannot be appended to in place
+	gradleArchive, err := zip.OpenReader(gradleArtifactPath)
+	if err != nil {
+		log.Fatalf("Could not read Java artifact at %s: %s", gradleArtifactPath, err.Error())
+	}
+	defer gradleArchive.Close()
+
+	GenerateZIPArtifact(experimentID, zipPath, util.MebibyteToBytes(functionImageSizeMB), fillerOptions, append([]ZIPSource{ArchiveZIPSource(&gradleArchive.Reader)}, extraSources...)...)
+}
+
+// GenerateZIPArtifact writes a ZIP archive of the given sources to zipPath, padded with filler entries so that the archive
+// is exactly the target size. Random filler is stored uncompressed, and its entry headers are accounted for. Compressible
+// filler is deflated, the target size then counting its uncompressed bytes.
+func GenerateZIPArtifact(experimentID int, zipPath string, targetSizeBytes int64, fillerOptions FillerOptions, sources ...ZIPSource) {
 	log.Infof("[sub-experiment %d] Generating ZIP file to be deployed...", experimentID)
 
-	util.RunCommandAndLog(exec.Command("zip", "-j", zipName, binaryPath, fillerFilePath))
+	baseSizeBytes, err := zipSize(sources...)
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not get size of ZIP file: %s", experimentID, err.Error())
+	}
+	fillerSizeBytes := CalculateFillerFileSizeInBytes(baseSizeBytes, targetSizeBytes)
 
-	util.RunCommandAndLog(exec.Command("rm", "-r", fillerFilePath))
+	if fillerSizeBytes > 0 {
+		if fillerProfile(fillerOptions) == FillerRandom {
+			fillerSizeBytes, err = storedFillerSize(baseSizeBytes+fillerSizeBytes, fillerSizeBytes, fillerOptions.Files, sources)
+			if err != nil {
+				log.Fatalf("[sub-experiment %d] Could not get size of ZIP file: %s", experimentID, err.Error())
+			}
+		}
+		source, err := NewFillerReader(fillerOptions)
+		if err != nil {
+			log.Fatalf("[sub-experiment %d] %s", experimentID, err.Error())
+		}
+		sources = append(sources, fillerZIPSource(source, fillerSizeBytes, fillerOptions))
+	}
 
+	if err := writeZIPFile(zipPath, sources...); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not generate ZIP file %s: %s", experimentID, zipPath, err.Error())
+	}
 	log.Infof("[sub-experiment %d] Successfully generated ZIP file.", experimentID)
+}
 
-	workingDirectory, err := filepath.Abs(filepath.Dir("."))
+// storedFillerSize returns the size of the stored filler entries for which the archive reaches the target size,
+// taking off the local and central directory headers of the entries
+func storedFillerSize(targetSizeBytes int64, fillerSizeBytes int64, files int, sources []ZIPSource) (int64, error) {
+	// Headers grow once sizes need ZIP64 fields, so the overhead is measured again for the reduced filler
+	for attempt := 0; attempt < 3; attempt++ {
+		sizeBytes, err := zipSize(append(sources, fillerZIPSource(zeroReader{}, fillerSizeBytes, FillerOptions{Files: files}))...)
+		if err != nil {
+			return 0, err
+		}
+		if sizeBytes == targetSizeBytes {
+			break
+		}
+		fillerSizeBytes -= sizeBytes - targetSizeBytes
+		if fillerSizeBytes < 0 {
+			return 0, nil
+		}
+	}
+	return fillerSizeBytes, nil
+}
+
+// FileZIPSource adds the file at the given path, at the root of the archive.
+func FileZIPSource(path string) ZIPSource {
+	return func(writer *zip.Writer) error {
+		return fileZIPEntry(writer, path, filepath.Base(path))
+	}
+}
+
+// fileZIPEntry adds the file at the given path under the given name, preserving its mode
+func fileZIPEntry(writer *zip.Writer, path string, name string) error {
+	info, err := os.Stat(path)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	return filepath.Join(workingDirectory, zipName)
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: zipModified}
+	header.SetMode(info.Mode())
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
 }
 
-func GenerateServerlessZIPArtifacts(experimentID int, provider string, runtime string, functionName string, functionImageSizeMB float64) {
-	switch runtime {
-	case "python3.9":
-		fallthrough
-	case "nodejs18.x":
-		fallthrough
-	case "ruby3.2":
-		fallthrough
-	case "go1.x":
-		generateServerlessZIPArtifactsGeneral(experimentID, provider, runtime, functionName, functionImageSizeMB)
-	case "java11":
-		generateServerlessZIPArtifactsJava(experimentID, provider, runtime, functionName, functionImageSizeMB)
+// DirectoryZIPSource adds the files of the directory, at the root of the archive, in lexical order.
+func DirectoryZIPSource(directory string) ZIPSource {
+	return func(writer *zip.Writer) error {
+		return filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			relativePath, err := filepath.Rel(directory, path)
+			if err != nil {
+				return err
+			}
+			return fileZIPEntry(writer, path, filepath.ToSlash(relativePath))
+		})
 	}
 }
 
-func generateServerlessZIPArtifactsGeneral(experimentID int, provider string, runtime string, functionName string, functionImageSizeMB float64) {
-	defaultBinaryName := map[string]string{
-		"python3.9":  "main.py",
-		"go1.x":      "bootstrap",
-		"nodejs18.x": "index.js",
-		"ruby3.2":    "function.rb",
+// ArchiveZIPSource copies the entries of an existing archive without recompressing them. The filler and dependency
+// bundle entries of an archive that was already padded are left out, so that it can be padded again to another size.
+func ArchiveZIPSource(archive *zip.Reader) ZIPSource {
+	return func(writer *zip.Writer) error {
+		for _, file := range archive.File {
+			if isPaddingEntry(file.Name) {
+				continue
+			}
+			if err := writer.Copy(file); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	binaryPath := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s/%s", provider, functionName, defaultBinaryName[runtime])
+}
+
+// isPaddingEntry returns whether the archive entry is a filler file or a dependency bundle added by STeLLAR
+func isPaddingEntry(name string) bool {
+	switch name {
+	case "filler.file", "stellar_bundle.py", "stellar_bundle.js", "stellar_bundle.rb", "stellar.bundle":
+		return true
+	}
+	return fillerEntryPattern.MatchString(name)
+}
 
-	currentSizeInBytes := GetZippedBinaryFileSize(experimentID, binaryPath)
-	targetSizeInBytes := util.MebibyteToBytes(functionImageSizeMB)
+// fillerZIPSource streams filler entries of the given total size from the source, laid out as in GenerateFillerFiles
+func fillerZIPSource(source io.Reader, sizeBytes int64, options FillerOptions) ZIPSource {
+	return func(writer *zip.Writer) error {
+		method := zip.Deflate
+		if fillerProfile(options) == FillerRandom {
+			method = zip.Store
+		}
 
-	fillerFileSize := CalculateFillerFileSizeInBytes(currentSizeInBytes, targetSizeInBytes)
-	fillerFilePath := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s/filler.file", provider, functionName)
-	GenerateFillerFile(experimentID, fillerFilePath, fillerFileSize)
+		fileSizes := fillerFileSizes(sizeBytes, options.Files)
+		for index, name := range FillerFilePaths("filler.file", options.Files) {
+			header := &zip.FileHeader{Name: name, Method: method, Modified: zipModified}
+			header.SetMode(0644)
+			entry, err := writer.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			if _, err := io.CopyN(entry, source, fileSizes[index]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
 
-	zipPath := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s/%s.zip", provider, functionName, functionName)
-	GenerateZIP(experimentID, fillerFilePath, binaryPath, zipPath)
+func writeZIP(output io.Writer, sources ...ZIPSource) error {
+	writer := zip.NewWriter(output)
+	for _, source := range sources {
+		if err := source(writer); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
 }
 
-func generateServerlessZIPArtifactsJava(experimentID int, provider string, runtime string, functionName string, functionImageSizeMB float64) {
-	gradleArtifactPath := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s/%s.zip", provider, functionName, functionName)
-	fileInfo, err := os.Stat(gradleArtifactPath)
+// writeZIPFile writes the archive next to its destination first, as the sources may read the archive it replaces
+func writeZIPFile(zipPath string, sources ...ZIPSource) error {
+	temporaryPath := zipPath + ".tmp"
+	file, err := os.Create(temporaryPath)
 	if err != nil {
-		log.Fatalf("Could not file size of Java artifact at %s", gradleArtifactPath)
+		return err
+	}
+	if err := writeZIP(file, sources...); err != nil {
+		file.Close()
+		os.Remove(temporaryPath)
+		return err
 	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, zipPath)
+}
 
-	currentSizeInBytes := fileInfo.Size()
-	targetSizeInBytes := util.MebibyteToBytes(functionImageSizeMB)
+type countingWriter struct {
+	written int64
+}
 
-	fillerFileSize := CalculateFillerFileSizeInBytes(currentSizeInBytes, targetSizeInBytes)
-	fillerFilePath := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/artifacts/%s/filler.file", provider, functionName)
-	GenerateFillerFile(experimentID, fillerFilePath, fillerFileSize)
+func (w *countingWriter) Write(data []byte) (int, error) {
+	w.written += int64(len(data))
+	return len(data), nil
+}
 
-	addFileToExistingZIPArchive(gradleArtifactPath, fillerFilePath)
+// zipSize returns the size of the archive of the given sources
+func zipSize(sources ...ZIPSource) (int64, error) {
+	counter := &countingWriter{}
+	if err := writeZIP(counter, sources...); err != nil {
+		return 0, err
+	}
+	return counter.written, nil
 }
 
 func CalculateFillerFileSizeInBytes(currentSizeInBytes int64, targetSizeInBytes int64) int64 {
@@ -173,9 +358,3 @@ func CalculateFillerFileSizeInBytes(currentSizeInBytes int64, targetSizeInBytes
 	}
 	return targetSizeInBytes - currentSizeInBytes
 }
-
-func addFileToExistingZIPArchive(archivePath string, filePath string) {
-	log.Infof("Adding %s to an existing archive at %s", filePath, archivePath)
-	util.RunCommandAndLog(exec.Command("zip", "-gj", archivePath, filePath))
-	util.RunCommandAndLog(exec.Command("rm", "-r", filePath))
-}
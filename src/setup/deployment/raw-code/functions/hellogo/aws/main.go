@@ -24,26 +24,59 @@ package main
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	log "github.com/sirupsen/logrus"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 //ProducerConsumerResponse is the structure that we expect a consumer-producer function response to follow
 type ProducerConsumerResponse struct {
 	RequestID      string   `json:"RequestID"`
 	TimestampChain []string `json:"TimestampChain"`
+	InstanceTelemetry
+	//ExecutionMilliseconds is the duration of the handler, from HandlerEntryTimestampMicroseconds to
+	//HandlerExitTimestampMicroseconds on the monotonic clock
+	ExecutionMilliseconds             float64 `json:"ExecutionMilliseconds"`
+	HandlerEntryTimestampMicroseconds int64   `json:"HandlerEntryTimestampMicroseconds"`
+	HandlerExitTimestampMicroseconds  int64   `json:"HandlerExitTimestampMicroseconds"`
 }
 
+//InstanceTelemetry identifies the instance serving the request and its host
+type InstanceTelemetry struct {
+	InstanceID            string `json:"InstanceID"`
+	InstanceBootTimestamp int64  `json:"InstanceBootTimestamp"`
+	InstanceRequestCount  int64  `json:"InstanceRequestCount"`
+	RuntimeVersion        string `json:"RuntimeVersion"`
+	CPUModel              string `json:"CPUModel"`
+	MemoryLimitMB         int64  `json:"MemoryLimitMB"`
+}
+
+//instanceID identifies the instance serving the requests, generated at init
+var instanceID = newInstanceID()
+var instanceBootTimestamp = time.Now().UnixNano() / int64(time.Millisecond)
+var instanceRequestCount int64
+var cpuModel = readCPUModel()
+var memoryLimitMB = readMemoryLimitMB()
+
 func main() {
 	lambda.Start(producerConsumer)
 }
 
 func producerConsumer(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	handlerStart := time.Now()
+
 	incrementLimit := extractIncrementLimit(&request)
 
 	simulateWork(incrementLimit)
@@ -58,8 +91,12 @@ func producerConsumer(ctx context.Context, request events.APIGatewayProxyRequest
 	}
 
 	httpOutput, err := json.Marshal(ProducerConsumerResponse{
-		RequestID:      reqId,
-		TimestampChain: []string{},
+		RequestID:                         reqId,
+		TimestampChain:                    []string{},
+		InstanceTelemetry:                 instanceTelemetry(),
+		ExecutionMilliseconds:             float64(time.Since(handlerStart).Microseconds()) / 1000,
+		HandlerEntryTimestampMicroseconds: handlerStart.UnixNano() / int64(time.Microsecond),
+		HandlerExitTimestampMicroseconds:  time.Now().UnixNano() / int64(time.Microsecond),
 	})
 	if err != nil {
 		log.Fatalf("Could not marshal function output: %s", err)
@@ -89,3 +126,53 @@ func simulateWork(incrementLimitString string) {
 	for i := 0; i < incrementLimit; i++ {
 	}
 }
+
+//instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+//its host
+func instanceTelemetry() InstanceTelemetry {
+	return InstanceTelemetry{
+		InstanceID:            instanceID,
+		InstanceBootTimestamp: instanceBootTimestamp,
+		InstanceRequestCount:  atomic.AddInt64(&instanceRequestCount, 1),
+		RuntimeVersion:        runtime.Version(),
+		CPUModel:              cpuModel,
+		MemoryLimitMB:         memoryLimitMB,
+	}
+}
+
+func newInstanceID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+//readCPUModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+func readCPUModel() string {
+	cpuinfo, err := ioutil.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if strings.HasPrefix(line, "model name") {
+			return strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
+		}
+	}
+	return ""
+}
+
+//readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+func readMemoryLimitMB() int64 {
+	if memorySize, err := strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64); err == nil {
+		return memorySize
+	}
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err == nil && limit > 0 && limit < 1<<50 {
+			return limit / (1024 * 1024)
+		}
+	}
+	return 0
+}
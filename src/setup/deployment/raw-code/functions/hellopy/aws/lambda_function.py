@@ -2,10 +2,14 @@ from __future__ import print_function
 
 import json
 import os
+import platform
 import time
+import uuid
 
 
 def lambda_handler(request, context):
+    handler_entry_us = time.time_ns() // 1000
+    handler_start = time.perf_counter()
 
     incr_limit = 0
 
@@ -26,7 +30,11 @@ def lambda_handler(request, context):
         "body": json.dumps({
             "Region ": json_region,
             "RequestID": context.aws_request_id,
-            "TimestampChain": [str(time.time_ns())]
+            "TimestampChain": [str(time.time_ns())],
+            **instance_telemetry(),
+            "ExecutionMilliseconds": (time.perf_counter() - handler_start) * 1000,
+            "HandlerEntryTimestampMicroseconds": handler_entry_us,
+            "HandlerExitTimestampMicroseconds": time.time_ns() // 1000
         },indent=4)
     }
 
@@ -37,4 +45,53 @@ def simulate_work(increment):
     # MAXNUM = 6103705
     num = 0
     while num < increment:
-        num += 1
\ No newline at end of file
+        num += 1
+
+
+def read_cpu_model():
+    """Returns the model name of the CPU of the host from /proc/cpuinfo, if listed"""
+    try:
+        with open('/proc/cpuinfo') as cpuinfo:
+            for line in cpuinfo:
+                if line.startswith('model name'):
+                    return line.split(':', 1)[1].strip()
+    except OSError:
+        pass
+    return ''
+
+
+def read_memory_limit_mb():
+    """Returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited"""
+    if os.environ.get('AWS_LAMBDA_FUNCTION_MEMORY_SIZE'):
+        return int(os.environ['AWS_LAMBDA_FUNCTION_MEMORY_SIZE'])
+    for path in ('/sys/fs/cgroup/memory.max', '/sys/fs/cgroup/memory/memory.limit_in_bytes'):
+        try:
+            with open(path) as limit_file:
+                limit = int(limit_file.read().strip())
+            if 0 < limit < 2 ** 50:
+                return limit // (1024 * 1024)
+        except (OSError, ValueError):
+            pass
+    return 0
+
+
+# INSTANCE_ID identifies the instance serving the requests, generated at init
+INSTANCE_ID = uuid.uuid4().hex
+INSTANCE_BOOT_TIMESTAMP = time.time_ns() // 1_000_000
+CPU_MODEL = read_cpu_model()
+MEMORY_LIMIT_MB = read_memory_limit_mb()
+instance_request_count = 0
+
+
+def instance_telemetry():
+    """Counts the request served by the instance and returns the telemetry identifying the instance and its host"""
+    global instance_request_count
+    instance_request_count += 1
+    return {
+        "InstanceID": INSTANCE_ID,
+        "InstanceBootTimestamp": INSTANCE_BOOT_TIMESTAMP,
+        "InstanceRequestCount": instance_request_count,
+        "RuntimeVersion": "python " + platform.python_version(),
+        "CPUModel": CPU_MODEL,
+        "MemoryLimitMB": MEMORY_LIMIT_MB,
+    }
@@ -1,5 +1,10 @@
 import grpc
+import json
 import logging
+import os
+import platform
+import time
+import uuid
 from concurrent import futures
 
 import chainfunction_pb2
@@ -9,9 +14,71 @@ import chainfunction_pb2_grpc
 class Greeter(chainfunction_pb2_grpc.ProducerConsumerServicer):
 
     def InvokeNext(self, _, context):
+        handler_entry_us = time.time_ns() // 1000
+        handler_start = time.perf_counter()
+
+        # The reply only holds the timestamp chain, the telemetry of the instance and the timestamps of the handler are
+        # sent in the response header
+        context.send_initial_metadata(((TELEMETRY_METADATA_KEY, json.dumps({
+            **instance_telemetry(),
+            "ExecutionMilliseconds": (time.perf_counter() - handler_start) * 1000,
+            "HandlerEntryTimestampMicroseconds": handler_entry_us,
+            "HandlerExitTimestampMicroseconds": time.time_ns() // 1000
+        })),))
         return chainfunction_pb2.InvokeChainReply(timestampChain='[0]')
 
 
+def read_cpu_model():
+    """Returns the model name of the CPU of the host from /proc/cpuinfo, if listed"""
+    try:
+        with open('/proc/cpuinfo') as cpuinfo:
+            for line in cpuinfo:
+                if line.startswith('model name'):
+                    return line.split(':', 1)[1].strip()
+    except OSError:
+        pass
+    return ''
+
+
+def read_memory_limit_mb():
+    """Returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited"""
+    if os.environ.get('AWS_LAMBDA_FUNCTION_MEMORY_SIZE'):
+        return int(os.environ['AWS_LAMBDA_FUNCTION_MEMORY_SIZE'])
+    for path in ('/sys/fs/cgroup/memory.max', '/sys/fs/cgroup/memory/memory.limit_in_bytes'):
+        try:
+            with open(path) as limit_file:
+                limit = int(limit_file.read().strip())
+            if 0 < limit < 2 ** 50:
+                return limit // (1024 * 1024)
+        except (OSError, ValueError):
+            pass
+    return 0
+
+
+# TELEMETRY_METADATA_KEY is the response header holding the telemetry of the instance, which the reply cannot hold
+TELEMETRY_METADATA_KEY = 'stellar-telemetry'
+# INSTANCE_ID identifies the instance serving the requests, generated at init
+INSTANCE_ID = uuid.uuid4().hex
+INSTANCE_BOOT_TIMESTAMP = time.time_ns() // 1_000_000
+CPU_MODEL = read_cpu_model()
+MEMORY_LIMIT_MB = read_memory_limit_mb()
+instance_request_count = 0
+
+
+def instance_telemetry():
+    """Counts the request served by the instance and returns the telemetry identifying the instance and its host"""
+    global instance_request_count
+    instance_request_count += 1
+    return {
+        "InstanceID": INSTANCE_ID,
+        "InstanceBootTimestamp": INSTANCE_BOOT_TIMESTAMP,
+        "InstanceRequestCount": instance_request_count,
+        "RuntimeVersion": "python " + platform.python_version(),
+        "CPUModel": CPU_MODEL,
+        "MemoryLimitMB": MEMORY_LIMIT_MB,
+    }
+
+
 def serve():
     server = grpc.server(futures.ThreadPoolExecutor(max_workers=1))
     chainfunction_pb2_grpc.add_ProducerConsumerServicer_to_server(Greeter(), server)
@@ -31,6 +31,7 @@ import (
 	protogen2 "github.com/vhive-serverless/stellar/src/setup/deployment/raw-code/functions/producer-consumer/proto_gen"
 	log "github.com/sirupsen/logrus"
 	"strconv"
+	"time"
 )
 
 //GlobalRandomPayload is a 1MB string used for quick random payload generation
@@ -40,10 +41,14 @@ var GlobalRandomPayload string
 type ProducerConsumerResponse struct {
 	RequestID      string   `json:"RequestID"`
 	TimestampChain []string `json:"TimestampChain"`
+	InstanceTelemetry
+	HandlerTiming
 }
 
 //GenerateResponse creates the HTTP or gRPC producer-consumer response payload
 func GenerateResponse(ctx context.Context, requestHTTP *events.APIGatewayProxyRequest, requestGRPC *protogen2.InvokeChainRequest) ([]byte, []string) {
+	handlerStart := time.Now()
+
 	dataTransferChainIDs, incrementLimit := extractChainIDsAndIncrementLimit(requestHTTP, requestGRPC)
 
 	var updatedTimestampChain []string
@@ -112,8 +117,10 @@ func GenerateResponse(ctx context.Context, requestHTTP *events.APIGatewayProxyRe
 		// (https://docs.aws.amazon.com/lambda/latest/dg/golang-context.html)
 		lc, _ := lambdacontext.FromContext(ctx)
 		httpOutput, err := json.Marshal(ProducerConsumerResponse{
-			RequestID:      lc.AwsRequestID,
-			TimestampChain: updatedTimestampChain,
+			RequestID:         lc.AwsRequestID,
+			TimestampChain:    updatedTimestampChain,
+			InstanceTelemetry: NewInstanceTelemetry(),
+			HandlerTiming:     NewHandlerTiming(handlerStart),
 		})
 		if err != nil {
 			log.Fatalf("Could not marshal function output: %s", err)
@@ -0,0 +1,97 @@
+package common
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// InstanceTelemetry identifies the instance serving the request and its host
+type InstanceTelemetry struct {
+	InstanceID            string `json:"InstanceID"`
+	InstanceBootTimestamp int64  `json:"InstanceBootTimestamp"`
+	InstanceRequestCount  int64  `json:"InstanceRequestCount"`
+	RuntimeVersion        string `json:"RuntimeVersion"`
+	CPUModel              string `json:"CPUModel"`
+	MemoryLimitMB         int64  `json:"MemoryLimitMB"`
+}
+
+// instanceID identifies the instance serving the requests, generated at init
+var instanceID = newInstanceID()
+var instanceBootTimestamp = time.Now().UnixMilli()
+var instanceRequestCount int64
+var cpuModel = readCPUModel()
+var memoryLimitMB = readMemoryLimitMB()
+
+// NewInstanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+func NewInstanceTelemetry() InstanceTelemetry {
+	return InstanceTelemetry{
+		InstanceID:            instanceID,
+		InstanceBootTimestamp: instanceBootTimestamp,
+		InstanceRequestCount:  atomic.AddInt64(&instanceRequestCount, 1),
+		RuntimeVersion:        runtime.Version(),
+		CPUModel:              cpuModel,
+		MemoryLimitMB:         memoryLimitMB,
+	}
+}
+
+// HandlerTiming records when the handler started and returned on the clock of the function
+type HandlerTiming struct {
+	// ExecutionMilliseconds is the duration of the handler, from HandlerEntryTimestampMicroseconds to
+	// HandlerExitTimestampMicroseconds on the monotonic clock
+	ExecutionMilliseconds             float64 `json:"ExecutionMilliseconds"`
+	HandlerEntryTimestampMicroseconds int64   `json:"HandlerEntryTimestampMicroseconds"`
+	HandlerExitTimestampMicroseconds  int64   `json:"HandlerExitTimestampMicroseconds"`
+}
+
+// NewHandlerTiming returns the timing of the handler started at handlerStart and returning now
+func NewHandlerTiming(handlerStart time.Time) HandlerTiming {
+	return HandlerTiming{
+		ExecutionMilliseconds:             float64(time.Since(handlerStart).Microseconds()) / 1000,
+		HandlerEntryTimestampMicroseconds: handlerStart.UnixMicro(),
+		HandlerExitTimestampMicroseconds:  time.Now().UnixMicro(),
+	}
+}
+
+func newInstanceID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// readCPUModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+func readCPUModel() string {
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if strings.HasPrefix(line, "model name") {
+			return strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
+		}
+	}
+	return ""
+}
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+func readMemoryLimitMB() int64 {
+	if memorySize, err := strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64); err == nil {
+		return memorySize
+	}
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err == nil && limit > 0 && limit < 1<<50 {
+			return limit / (1024 * 1024)
+		}
+	}
+	return 0
+}
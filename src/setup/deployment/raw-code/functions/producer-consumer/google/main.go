@@ -30,6 +30,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 //GlobalRandomPayload is a 1MB string used for quick random payload generation
@@ -39,10 +40,14 @@ var GlobalRandomPayload string
 type ProducerConsumerResponse struct {
 	RequestID      string   `json:"RequestID"`
 	TimestampChain []string `json:"TimestampChain"`
+	InstanceTelemetry
+	HandlerTiming
 }
 
 //GenerateResponse creates the HTTP or gRPC producer-consumer response payload
 func GenerateResponse(ctx context.Context, requestHTTP *http.Request, requestGRPC *InvokeChainRequest) ([]byte, []string) {
+	handlerStart := time.Now()
+
 	dataTransferChainIDs, incrementLimit := extractChainIDsAndIncrementLimit(requestHTTP, requestGRPC)
 
 	var updatedTimestampChain []string
@@ -118,8 +123,10 @@ func GenerateResponse(ctx context.Context, requestHTTP *http.Request, requestGRP
 		}
 
 		httpOutput, err := json.Marshal(ProducerConsumerResponse{
-			RequestID:      reqId,
-			TimestampChain: updatedTimestampChain,
+			RequestID:         reqId,
+			TimestampChain:    updatedTimestampChain,
+			InstanceTelemetry: NewInstanceTelemetry(),
+			HandlerTiming:     NewHandlerTiming(handlerStart),
 		})
 		if err != nil {
 			log.Fatalf("Could not marshal function output: %s", err)
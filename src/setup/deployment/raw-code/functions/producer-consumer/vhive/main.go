@@ -24,16 +24,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	common2 "github.com/vhive-serverless/stellar/src/setup/deployment/raw-code/functions/producer-consumer/common"
 	protogen2 "github.com/vhive-serverless/stellar/src/setup/deployment/raw-code/functions/producer-consumer/proto_gen"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"log"
 	"net"
+	"time"
 )
 
 const (
 	port = ":50051"
+	// telemetryMetadataKey is the response header holding the telemetry of the instance and the timing of the handler,
+	// which the reply cannot hold
+	telemetryMetadataKey = "stellar-telemetry"
 )
 
 type server struct {
@@ -61,8 +67,18 @@ func main() {
 }
 
 func (s *server) InvokeNext(ctx context.Context, request *protogen2.InvokeChainRequest) (*protogen2.InvokeChainReply, error) {
+	handlerStart := time.Now()
 	_, grpcOutput := common2.GenerateResponse(ctx, nil, request)
 
+	if telemetry, err := json.Marshal(struct {
+		common2.InstanceTelemetry
+		common2.HandlerTiming
+	}{common2.NewInstanceTelemetry(), common2.NewHandlerTiming(handlerStart)}); err == nil {
+		if err := grpc.SetHeader(ctx, metadata.Pairs(telemetryMetadataKey, string(telemetry))); err != nil {
+			log.Printf("Could not set telemetry header: %v", err)
+		}
+	}
+
 	return &protogen2.InvokeChainReply{
 		TimestampChain: fmt.Sprintf("%v", grpcOutput),
 	}, nil
@@ -0,0 +1,126 @@
+using System.Diagnostics;
+using System.Text.Json;
+using Amazon.Lambda.APIGatewayEvents;
+using Amazon.Lambda.Core;
+
+[assembly: LambdaSerializer(typeof(Amazon.Lambda.Serialization.SystemTextJson.DefaultLambdaJsonSerializer))]
+
+namespace hellodotnet;
+
+public class Function
+{
+    public APIGatewayProxyResponse FunctionHandler(APIGatewayProxyRequest request, ILambdaContext context)
+    {
+        var handlerEntryMicroseconds = EpochMicroseconds();
+        var handlerStart = Stopwatch.GetTimestamp();
+
+        long incrementLimit = 0;
+        if (request.QueryStringParameters != null &&
+            request.QueryStringParameters.TryGetValue("IncrementLimit", out var limit))
+        {
+            long.TryParse(limit, out incrementLimit);
+        }
+
+        SimulateWork(incrementLimit);
+
+        var response = new Dictionary<string, object>
+        {
+            ["RequestID"] = context.AwsRequestId,
+            ["TimestampChain"] = new[] { DateTimeOffset.UtcNow.ToUnixTimeMilliseconds().ToString() },
+        };
+        foreach (var (name, value) in InstanceTelemetry())
+        {
+            response[name] = value;
+        }
+        response["ExecutionMilliseconds"] = Stopwatch.GetElapsedTime(handlerStart).TotalMilliseconds;
+        response["HandlerEntryTimestampMicroseconds"] = handlerEntryMicroseconds;
+        response["HandlerExitTimestampMicroseconds"] = EpochMicroseconds();
+        var body = JsonSerializer.Serialize(response);
+
+        return new APIGatewayProxyResponse
+        {
+            StatusCode = 200,
+            Body = body,
+            Headers = new Dictionary<string, string> { ["Content-Type"] = "application/json" },
+        };
+    }
+
+    private static void SimulateWork(long incrementLimit)
+    {
+        for (long i = 0; i < incrementLimit; i++)
+        {
+        }
+    }
+
+    // EpochMicroseconds returns the Unix time in microseconds
+    private static long EpochMicroseconds()
+    {
+        return (DateTimeOffset.UtcNow - DateTimeOffset.UnixEpoch).Ticks / TimeSpan.TicksPerMicrosecond;
+    }
+
+    // InstanceId identifies the instance serving the requests, generated at init
+    private static readonly string InstanceId = Guid.NewGuid().ToString("N");
+    private static readonly long InstanceBootTimestamp = DateTimeOffset.UtcNow.ToUnixTimeMilliseconds();
+    private static readonly string CpuModel = ReadCpuModel();
+    private static readonly long MemoryLimitMB = ReadMemoryLimitMB();
+    private static long instanceRequestCount;
+
+    // InstanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance
+    // and its host
+    private static Dictionary<string, object> InstanceTelemetry()
+    {
+        return new Dictionary<string, object>
+        {
+            ["InstanceID"] = InstanceId,
+            ["InstanceBootTimestamp"] = InstanceBootTimestamp,
+            ["InstanceRequestCount"] = Interlocked.Increment(ref instanceRequestCount),
+            ["RuntimeVersion"] = "dotnet " + Environment.Version,
+            ["CPUModel"] = CpuModel,
+            ["MemoryLimitMB"] = MemoryLimitMB,
+        };
+    }
+
+    // ReadCpuModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+    private static string ReadCpuModel()
+    {
+        try
+        {
+            foreach (var line in File.ReadLines("/proc/cpuinfo"))
+            {
+                if (line.StartsWith("model name"))
+                {
+                    return line[(line.IndexOf(':') + 1)..].Trim();
+                }
+            }
+        }
+        catch (IOException)
+        {
+            // the model is left empty
+        }
+        return "";
+    }
+
+    // ReadMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+    private static long ReadMemoryLimitMB()
+    {
+        if (long.TryParse(Environment.GetEnvironmentVariable("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), out var memorySize))
+        {
+            return memorySize;
+        }
+        foreach (var path in new[] { "/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes" })
+        {
+            try
+            {
+                if (long.TryParse(File.ReadAllText(path).Trim(), out var limit) && limit > 0 && limit < (1L << 50))
+                {
+                    return limit / (1024 * 1024);
+                }
+            }
+            catch (IOException)
+            {
+                // the next cgroup version is tried
+            }
+        }
+        return 0;
+    }
+}
@@ -1,19 +1,50 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 type HelloGoResponse struct {
-	RequestID      string   `json:"RequestID"`
-	TimestampChain []string `json:"TimestampChain"`
+	RequestID                string   `json:"RequestID"`
+	TimestampChain           []string `json:"TimestampChain"`
+	InitDurationMilliseconds float64  `json:"InitDurationMilliseconds"`
+	ServiceTimeMilliseconds  float64  `json:"ServiceTimeMilliseconds"`
+	// WorkloadPhases are the durations in milliseconds of the phases of the memory, disk or fetch workload, if any
+	WorkloadPhases map[string]float64 `json:"WorkloadPhases"`
+	InstanceTelemetry
+	// ExecutionMilliseconds is the duration of the handler, from HandlerEntryTimestampMicroseconds to
+	// HandlerExitTimestampMicroseconds on the monotonic clock
+	ExecutionMilliseconds             float64 `json:"ExecutionMilliseconds"`
+	HandlerEntryTimestampMicroseconds int64   `json:"HandlerEntryTimestampMicroseconds"`
+	HandlerExitTimestampMicroseconds  int64   `json:"HandlerExitTimestampMicroseconds"`
+}
+
+// InstanceTelemetry identifies the instance serving the request and its host
+type InstanceTelemetry struct {
+	InstanceID            string `json:"InstanceID"`
+	InstanceBootTimestamp int64  `json:"InstanceBootTimestamp"`
+	InstanceRequestCount  int64  `json:"InstanceRequestCount"`
+	RuntimeVersion        string `json:"RuntimeVersion"`
+	CPUModel              string `json:"CPUModel"`
+	MemoryLimitMB         int64  `json:"MemoryLimitMB"`
 }
 
 func main() {
@@ -21,9 +52,20 @@ func main() {
 }
 
 func LambdaHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	incrementLimit := extractIncrementLimit(&request)
+	handlerStart := time.Now()
 
-	simulateWork(incrementLimit)
+	var serviceTimeMilliseconds float64
+	if clock := request.QueryStringParameters["ServiceTimeClock"]; clock != "" {
+		serviceTime, _ := strconv.ParseFloat(request.QueryStringParameters["ServiceTimeMilliseconds"], 64)
+		serviceTimeMilliseconds = spinFor(time.Duration(serviceTime*float64(time.Millisecond)), clock)
+	} else {
+		serviceTimeMilliseconds = simulateWork(extractIncrementLimit(&request))
+	}
+
+	workloadPhases, err := runWorkload()
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
 
 	reqId := "no-context"
 
@@ -35,8 +77,15 @@ func LambdaHandler(ctx context.Context, request events.APIGatewayProxyRequest) (
 	}
 
 	httpOutput, err := json.Marshal(HelloGoResponse{
-		RequestID:      reqId,
-		TimestampChain: []string{},
+		RequestID:                         reqId,
+		TimestampChain:                    []string{},
+		InitDurationMilliseconds:          initDurationMilliseconds,
+		ServiceTimeMilliseconds:           serviceTimeMilliseconds,
+		WorkloadPhases:                    workloadPhases,
+		InstanceTelemetry:                 instanceTelemetry(),
+		ExecutionMilliseconds:             float64(time.Since(handlerStart).Microseconds()) / 1000,
+		HandlerEntryTimestampMicroseconds: handlerStart.UnixMicro(),
+		HandlerExitTimestampMicroseconds:  time.Now().UnixMicro(),
 	})
 	if err != nil {
 		log.Fatalf("Could not marshal function output: %s", err)
@@ -60,9 +109,252 @@ func extractIncrementLimit(requestHTTP *events.APIGatewayProxyRequest) int {
 	return incrementLimit
 }
 
-// simulateWork will keep the CPU busy-spinning
-func simulateWork(incrementLimit int) {
+// simulateWork will keep the CPU busy-spinning and return the measured duration in milliseconds
+func simulateWork(incrementLimit int) float64 {
 	log.Infof("Running function up to increment limit (%d)...", incrementLimit)
+	start := time.Now()
 	for i := 0; i < incrementLimit; i++ {
 	}
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// spinFor will spin for the service time on the CPU clock of the process for the cpu clock, on the wall clock otherwise,
+// and return the measured duration in milliseconds
+func spinFor(serviceTime time.Duration, clock string) float64 {
+	log.Infof("Running function for %v on the %s clock...", serviceTime, clock)
+	start := time.Now()
+	now := func() time.Duration { return time.Since(start) }
+	if clock == "cpu" {
+		now = func() time.Duration {
+			var usage syscall.Rusage
+			_ = syscall.Getrusage(syscall.RUSAGE_SELF, &usage)
+			return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+		}
+	}
+	for deadline := now() + serviceTime; now() < deadline; {
+	}
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// initDurationMilliseconds is the duration of the init-time workload of the instance
+var initDurationMilliseconds float64
+
+// initMemory keeps the memory allocated at init for the lifetime of the instance
+var initMemory []byte
+
+// init runs the init-time workload set through the STELLAR_INIT_* environment variables. Go functions cannot load
+// modules at runtime, STELLAR_INIT_IMPORT_MODULES is therefore ignored.
+func init() {
+	start := time.Now()
+
+	if initEnvFloat("STELLAR_INIT_DEPENDENCY_BUNDLE_MB") > 0 {
+		readFiles("stellar.bundle", -1)
+	}
+	readFiles("filler*.file", int64(initEnvFloat("STELLAR_INIT_READ_FILLER_MB")*1024*1024))
+	initMemory = make([]byte, int(initEnvFloat("STELLAR_INIT_MEMORY_MB"))*1024*1024)
+	for offset := 0; offset < len(initMemory); offset += 4096 {
+		initMemory[offset] = 1
+	}
+	time.Sleep(time.Duration(initEnvFloat("STELLAR_INIT_SLEEP_MS")) * time.Millisecond)
+
+	initDurationMilliseconds = float64(time.Since(start).Microseconds()) / 1000
+}
+
+func initEnvFloat(name string) float64 {
+	value, _ := strconv.ParseFloat(os.Getenv(name), 64)
+	return value
+}
+
+// readFiles reads up to the given number of bytes, or all bytes if negative, of the files of the function matching
+// the pattern
+func readFiles(pattern string, size int64) {
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+	paths, _ := filepath.Glob(filepath.Join(filepath.Dir(executable), pattern))
+	for _, path := range paths {
+		if size == 0 {
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		reader := io.Reader(file)
+		if size > 0 {
+			reader = io.LimitReader(file, size)
+		}
+		read, _ := io.Copy(io.Discard, reader)
+		file.Close()
+		if size > 0 {
+			size -= read
+		}
+	}
+}
+
+// workloadSink keeps the result of reading the memory of the memory workload, so that the read is not optimized away
+var workloadSink int
+
+// runWorkload runs the per-request workload set through the STELLAR_WORKLOAD* environment variables and returns the
+// durations of its phases in milliseconds
+func runWorkload() (map[string]float64, error) {
+	size, _ := strconv.ParseInt(os.Getenv("STELLAR_WORKLOAD_BYTES"), 10, 64)
+	phases := make(map[string]float64)
+	switch os.Getenv("STELLAR_WORKLOAD") {
+	case "memory":
+		memoryWorkload(size, phases)
+	case "disk":
+		return phases, diskWorkload(size, os.Getenv("STELLAR_WORKLOAD_FSYNC") == "true", phases)
+	case "fetch":
+		return phases, fetchWorkload(os.Getenv("STELLAR_WORKLOAD_URL"), size, phases)
+	}
+	return phases, nil
+}
+
+// lap records the duration of the phase started at start and returns the start of the next phase
+func lap(phases map[string]float64, phase string, start time.Time) time.Time {
+	now := time.Now()
+	phases[phase] = float64(now.Sub(start).Microseconds()) / 1000
+	return now
+}
+
+// memoryWorkload allocates a buffer of the given number of bytes, then writes and reads all of it
+func memoryWorkload(size int64, phases map[string]float64) {
+	start := time.Now()
+	buffer := make([]byte, size)
+	start = lap(phases, "allocate", start)
+	for i := range buffer {
+		buffer[i] = 0xa5
+	}
+	start = lap(phases, "write", start)
+	workloadSink = bytes.IndexByte(buffer, 0)
+	lap(phases, "read", start)
+}
+
+// diskWorkload writes a file of the given number of bytes to the temporary directory, syncs it to disk if asked, then
+// reads and deletes it
+func diskWorkload(size int64, fsync bool, phases map[string]float64) error {
+	chunk := bytes.Repeat([]byte{0xa5}, 1024*1024)
+	start := time.Now()
+	file, err := os.CreateTemp("", "stellar-workload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	for remaining := size; remaining > 0; remaining -= int64(len(chunk)) {
+		if remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+		if _, err := file.Write(chunk); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	start = lap(phases, "write", start)
+	if fsync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+		start = lap(phases, "fsync", start)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	reader, err := os.Open(file.Name())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	start = lap(phases, "read", start)
+
+	if err := os.Remove(file.Name()); err != nil {
+		return err
+	}
+	lap(phases, "delete", start)
+	return nil
+}
+
+// fetchWorkload downloads up to the given number of bytes, or the whole body for 0, from the URL
+func fetchWorkload(workloadURL string, size int64, phases map[string]float64) error {
+	start := time.Now()
+	response, err := http.Get(workloadURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	start = lap(phases, "headers", start)
+
+	body := io.Reader(response.Body)
+	if size > 0 {
+		body = io.LimitReader(response.Body, size)
+	}
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+	lap(phases, "download", start)
+	return nil
+}
+
+// instanceID identifies the instance serving the requests, generated at init
+var instanceID = newInstanceID()
+var instanceBootTimestamp = time.Now().UnixMilli()
+var instanceRequestCount int64
+var cpuModel = readCPUModel()
+var memoryLimitMB = readMemoryLimitMB()
+
+// instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+func instanceTelemetry() InstanceTelemetry {
+	return InstanceTelemetry{
+		InstanceID:            instanceID,
+		InstanceBootTimestamp: instanceBootTimestamp,
+		InstanceRequestCount:  atomic.AddInt64(&instanceRequestCount, 1),
+		RuntimeVersion:        runtime.Version(),
+		CPUModel:              cpuModel,
+		MemoryLimitMB:         memoryLimitMB,
+	}
+}
+
+func newInstanceID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// readCPUModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+func readCPUModel() string {
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if strings.HasPrefix(line, "model name") {
+			return strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
+		}
+	}
+	return ""
+}
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+func readMemoryLimitMB() int64 {
+	if memorySize, err := strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64); err == nil {
+		return memorySize
+	}
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err == nil && limit > 0 && limit < 1<<50 {
+			return limit / (1024 * 1024)
+		}
+	}
+	return 0
 }
@@ -1,13 +1,20 @@
 package org.hellojava;
 
+import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Paths;
 import java.util.Map;
 import java.util.HashMap;
+import java.util.LinkedHashMap;
+import java.util.UUID;
+import java.util.concurrent.atomic.AtomicLong;
 import java.time.Instant;
 import com.amazonaws.services.lambda.runtime.Context;
 import com.amazonaws.services.lambda.runtime.RequestHandler;
 import com.amazonaws.services.lambda.runtime.events.APIGatewayProxyRequestEvent;
 import com.amazonaws.services.lambda.runtime.events.APIGatewayProxyResponseEvent;
 import com.google.gson.Gson;
+import com.google.gson.JsonObject;
 
 class ResponseEventBody {
     String region;
@@ -26,6 +33,8 @@ public class Handler implements RequestHandler<APIGatewayProxyRequestEvent, APIG
     @Override
     public APIGatewayProxyResponseEvent handleRequest(APIGatewayProxyRequestEvent event, Context context)
     {
+        long handlerEntryMicroseconds = epochMicroseconds();
+        long handlerStart = System.nanoTime();
         Gson gson = new Gson();
 	int incrementLimit = 0;
 	if (event.getQueryStringParameters() != null) {
@@ -39,7 +48,11 @@ public class Handler implements RequestHandler<APIGatewayProxyRequestEvent, APIG
 
         Instant now = Instant.now();
         String[] timestampChain = new String[]{""+now.getEpochSecond()+now.getNano()};
-        ResponseEventBody resBody = new ResponseEventBody(System.getenv("AWS_REGION"), requestId, timestampChain);
+        JsonObject resBody = gson.toJsonTree(new ResponseEventBody(System.getenv("AWS_REGION"), requestId, timestampChain)).getAsJsonObject();
+        instanceTelemetry().forEach((name, value) -> resBody.add(name, gson.toJsonTree(value)));
+        resBody.addProperty("ExecutionMilliseconds", (System.nanoTime() - handlerStart) / 1e6);
+        resBody.addProperty("HandlerEntryTimestampMicroseconds", handlerEntryMicroseconds);
+        resBody.addProperty("HandlerExitTimestampMicroseconds", epochMicroseconds());
 
 	Map<String, String> responseHeaders = new HashMap<>();
 	responseHeaders.put("Content-Type", "application/json");
@@ -57,4 +70,63 @@ public class Handler implements RequestHandler<APIGatewayProxyRequestEvent, APIG
             i++;
         }
     }
+
+    // epochMicroseconds returns the Unix time in microseconds
+    static long epochMicroseconds() {
+        Instant now = Instant.now();
+        return now.getEpochSecond() * 1_000_000L + now.getNano() / 1_000;
+    }
+
+    // INSTANCE_ID identifies the instance serving the requests, generated at init
+    static final String INSTANCE_ID = UUID.randomUUID().toString().replace("-", "");
+    static final long INSTANCE_BOOT_TIMESTAMP = System.currentTimeMillis();
+    static final AtomicLong INSTANCE_REQUEST_COUNT = new AtomicLong();
+    static final String CPU_MODEL = readCpuModel();
+    static final long MEMORY_LIMIT_MB = readMemoryLimitMB();
+
+    // instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance
+    // and its host
+    static Map<String, Object> instanceTelemetry() {
+        Map<String, Object> telemetry = new LinkedHashMap<>();
+        telemetry.put("InstanceID", INSTANCE_ID);
+        telemetry.put("InstanceBootTimestamp", INSTANCE_BOOT_TIMESTAMP);
+        telemetry.put("InstanceRequestCount", INSTANCE_REQUEST_COUNT.incrementAndGet());
+        telemetry.put("RuntimeVersion", "java " + System.getProperty("java.version"));
+        telemetry.put("CPUModel", CPU_MODEL);
+        telemetry.put("MemoryLimitMB", MEMORY_LIMIT_MB);
+        return telemetry;
+    }
+
+    // readCpuModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+    static String readCpuModel() {
+        try {
+            for (String line : Files.readAllLines(Paths.get("/proc/cpuinfo"))) {
+                if (line.startsWith("model name")) {
+                    return line.substring(line.indexOf(':') + 1).trim();
+                }
+            }
+        } catch (IOException e) {
+            // the model is left empty
+        }
+        return "";
+    }
+
+    // readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+    static long readMemoryLimitMB() {
+        String memorySize = System.getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE");
+        if (memorySize != null) {
+            return Long.parseLong(memorySize);
+        }
+        for (String path : new String[]{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"}) {
+            try {
+                long limit = Long.parseLong(new String(Files.readAllBytes(Paths.get(path))).trim());
+                if (limit > 0 && limit < (1L << 50)) {
+                    return limit / (1024 * 1024);
+                }
+            } catch (IOException | NumberFormatException e) {
+                // the next cgroup version is tried
+            }
+        }
+        return 0;
+    }
 }
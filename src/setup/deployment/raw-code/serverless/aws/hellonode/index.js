@@ -1,22 +1,234 @@
 // Handler
 exports.handler = async function (event, context) {
+  const handlerEntryMicroseconds = epochMicroseconds();
+  const handlerStart = process.hrtime.bigint();
   let incrementLimit = 0;
   if (event.queryStringParameters.incrementLimit) {
     incrementLimit = event.queryStringParameters.incrementLimit;
   }
-  simulateWork(incrementLimit);
+  const clock = event.queryStringParameters.ServiceTimeClock;
+  const serviceTimeMilliseconds = clock
+    ? spinFor(parseFloat(event.queryStringParameters.ServiceTimeMilliseconds || "0"), clock)
+    : simulateWork(incrementLimit);
+  const workloadPhases = await runWorkload();
   const res = {
     statusCode: 200,
     headers: { "Content-Type": "application/json" },
     body: {
       RequestID: context.aws_request_id,
       TimestampChain: [Date.now().toString()],
+      InitDurationMilliseconds: initDurationMilliseconds,
+      ServiceTimeMilliseconds: serviceTimeMilliseconds,
+      WorkloadPhases: workloadPhases,
+      ...instanceTelemetry(),
+      ExecutionMilliseconds: Number(process.hrtime.bigint() - handlerStart) / 1e6,
+      HandlerEntryTimestampMicroseconds: handlerEntryMicroseconds,
+      HandlerExitTimestampMicroseconds: epochMicroseconds(),
     },
   };
 
   return JSON.stringify(res);
 };
 
+// epochMicroseconds returns the Unix time in microseconds
+const epochMicroseconds = () => Math.round((performance.timeOrigin + performance.now()) * 1000);
+
+// simulateWork keeps the CPU busy-spinning and returns the measured duration in milliseconds
 const simulateWork = (incrementLimit) => {
+  const start = process.hrtime.bigint();
   for (let i = 0; i < incrementLimit; i++) {}
+  return Number(process.hrtime.bigint() - start) / 1e6;
+};
+
+// spinFor spins for the service time on the CPU clock of the process for the cpu clock, on the wall clock otherwise,
+// and returns the measured duration in milliseconds
+const spinFor = (serviceTimeMilliseconds, clock) => {
+  const start = process.hrtime.bigint();
+  const now = clock === "cpu"
+    ? () => { const usage = process.cpuUsage(); return (usage.user + usage.system) / 1e3; }
+    : () => Number(process.hrtime.bigint()) / 1e6;
+  const deadline = now() + serviceTimeMilliseconds;
+  while (now() < deadline) {}
+  return Number(process.hrtime.bigint() - start) / 1e6;
+};
+
+// runInitWorkload runs the init-time workload set through the STELLAR_INIT_* environment variables and returns its
+// duration in milliseconds
+const runInitWorkload = () => {
+  const start = process.hrtime.bigint();
+
+  importModules(parseInt(process.env.STELLAR_INIT_IMPORT_MODULES || "0", 10));
+  if (parseFloat(process.env.STELLAR_INIT_DEPENDENCY_BUNDLE_MB || "0") > 0) {
+    require("./stellar_bundle.js");
+  }
+  readFiller(Math.floor(parseFloat(process.env.STELLAR_INIT_READ_FILLER_MB || "0") * 1024 * 1024));
+  touchMemory(parseInt(process.env.STELLAR_INIT_MEMORY_MB || "0", 10) * 1024 * 1024);
+  const sleepMilliseconds = parseInt(process.env.STELLAR_INIT_SLEEP_MS || "0", 10);
+  if (sleepMilliseconds > 0) {
+    Atomics.wait(new Int32Array(new SharedArrayBuffer(4)), 0, 0, sleepMilliseconds);
+  }
+
+  return Number(process.hrtime.bigint() - start) / 1e6;
+};
+
+// importModules loads the given number of built-in modules
+const importModules = (count) => {
+  const modules = require("module").builtinModules.filter((name) => !name.startsWith("_") && !name.includes(":"));
+  for (const name of modules.sort().slice(0, count)) {
+    try {
+      require(name);
+    } catch (error) {}
+  }
+};
+
+// readFiller reads up to the given number of bytes of the filler files of the function
+const readFiller = (size) => {
+  const fs = require("fs");
+  const path = require("path");
+  const buffer = Buffer.alloc(1024 * 1024);
+  const fillerFiles = fs.readdirSync(__dirname).filter((name) => name.startsWith("filler") && name.endsWith(".file"));
+  for (const name of fillerFiles.sort()) {
+    const file = fs.openSync(path.join(__dirname, name), "r");
+    let read = 0;
+    while (size > 0 && (read = fs.readSync(file, buffer, 0, Math.min(size, buffer.length), null)) > 0) {
+      size -= read;
+    }
+    fs.closeSync(file);
+  }
+};
+
+// touchMemory allocates the given number of bytes, kept for the lifetime of the instance, and writes to every page
+let initMemory;
+const touchMemory = (size) => {
+  initMemory = Buffer.allocUnsafe(size);
+  for (let offset = 0; offset < size; offset += 4096) {
+    initMemory[offset] = 1;
+  }
+};
+
+const initDurationMilliseconds = runInitWorkload();
+
+// runWorkload runs the per-request workload set through the STELLAR_WORKLOAD* environment variables and returns the
+// durations of its phases in milliseconds
+const runWorkload = async () => {
+  const size = parseInt(process.env.STELLAR_WORKLOAD_BYTES || "0", 10);
+  switch (process.env.STELLAR_WORKLOAD) {
+    case "memory":
+      return memoryWorkload(size);
+    case "disk":
+      return diskWorkload(size, process.env.STELLAR_WORKLOAD_FSYNC === "true");
+    case "fetch":
+      return fetchWorkload(process.env.STELLAR_WORKLOAD_URL, size);
+    default:
+      return {};
+  }
+};
+
+// lap records the duration of the phase started at start and returns the start of the next phase
+const lap = (phases, phase, start) => {
+  const now = process.hrtime.bigint();
+  phases[phase] = Number(now - start) / 1e6;
+  return now;
+};
+
+// memoryWorkload allocates a buffer of the given number of bytes, then writes and reads all of it
+const memoryWorkload = (size) => {
+  const phases = {};
+  let start = process.hrtime.bigint();
+  const buffer = Buffer.allocUnsafe(size);
+  start = lap(phases, "allocate", start);
+  buffer.fill(0xa5);
+  start = lap(phases, "write", start);
+  buffer.indexOf(0);
+  lap(phases, "read", start);
+  return phases;
+};
+
+// diskWorkload writes a file of the given number of bytes to the temporary directory, syncs it to disk if asked, then
+// reads and deletes it
+const diskWorkload = (size, fsync) => {
+  const fs = require("fs");
+  const os = require("os");
+  const path = require("path");
+  const phases = {};
+  const chunk = Buffer.alloc(Math.min(size, 1024 * 1024), 0xa5);
+  let start = process.hrtime.bigint();
+  const directory = fs.mkdtempSync(path.join(os.tmpdir(), "stellar-workload-"));
+  try {
+    const file = path.join(directory, "workload");
+    const writer = fs.openSync(file, "w");
+    for (let offset = 0; offset < size; offset += chunk.length) {
+      fs.writeSync(writer, chunk, 0, Math.min(chunk.length, size - offset));
+    }
+    start = lap(phases, "write", start);
+    if (fsync) {
+      fs.fsyncSync(writer);
+      start = lap(phases, "fsync", start);
+    }
+    fs.closeSync(writer);
+    const reader = fs.openSync(file, "r");
+    while (fs.readSync(reader, chunk, 0, chunk.length, null) > 0) {}
+    fs.closeSync(reader);
+    start = lap(phases, "read", start);
+  } finally {
+    fs.rmSync(directory, { recursive: true, force: true });
+  }
+  lap(phases, "delete", start);
+  return phases;
+};
+
+// fetchWorkload downloads up to the given number of bytes, or the whole body for 0, from the URL
+const fetchWorkload = async (url, size) => {
+  const phases = {};
+  let start = process.hrtime.bigint();
+  const response = await fetch(url);
+  start = lap(phases, "headers", start);
+  const reader = response.body.getReader();
+  for (let read = 0; size === 0 || read < size; ) {
+    const { done, value } = await reader.read();
+    if (done) {
+      break;
+    }
+    read += value.length;
+  }
+  await reader.cancel();
+  lap(phases, "download", start);
+  return phases;
+};
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+const readMemoryLimitMB = () => {
+  if (process.env.AWS_LAMBDA_FUNCTION_MEMORY_SIZE) {
+    return parseInt(process.env.AWS_LAMBDA_FUNCTION_MEMORY_SIZE, 10);
+  }
+  for (const path of ["/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"]) {
+    try {
+      const limit = Number(require("fs").readFileSync(path, "utf8").trim());
+      if (limit > 0 && limit < 2 ** 50) {
+        return Math.floor(limit / (1024 * 1024));
+      }
+    } catch (error) {}
+  }
+  return 0;
+};
+
+// instanceId identifies the instance serving the requests, generated at init
+const instanceId = require("crypto").randomBytes(16).toString("hex");
+const instanceBootTimestamp = Date.now();
+const cpuModel = (require("os").cpus()[0] || {}).model || "";
+const memoryLimitMB = readMemoryLimitMB();
+let instanceRequestCount = 0;
+
+// instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+const instanceTelemetry = () => {
+  instanceRequestCount++;
+  return {
+    InstanceID: instanceId,
+    InstanceBootTimestamp: instanceBootTimestamp,
+    InstanceRequestCount: instanceRequestCount,
+    RuntimeVersion: `node ${process.version}`,
+    CPUModel: cpuModel,
+    MemoryLimitMB: memoryLimitMB,
+  };
 };
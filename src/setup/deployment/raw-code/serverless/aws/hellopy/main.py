@@ -1,9 +1,19 @@
+import importlib
 import json
 import os
+import pkgutil
+import platform
+import sys
+import tempfile
 import time
+import urllib.request
+import uuid
 
 
 def lambda_handler(request, context):
+    handler_entry_us = time.time_ns() // 1000
+    handler_start = time.perf_counter()
+
     incr_limit = 0
 
     if 'queryStringParameters' in request and 'IncrementLimit' in request['queryStringParameters']:
@@ -11,7 +21,13 @@ def lambda_handler(request, context):
     elif 'body' in request and json.loads(request['body'])['IncrementLimit']:
         incr_limit = int(json.loads(request['body'])['IncrementLimit'])
 
-    simulate_work(incr_limit)
+    parameters = request.get('queryStringParameters') or {}
+    if parameters.get('ServiceTimeClock'):
+        service_time_ms = spin_for(float(parameters.get('ServiceTimeMilliseconds', 0)), parameters['ServiceTimeClock'])
+    else:
+        service_time_ms = simulate_work(incr_limit)
+
+    workload_phases = run_workload()
 
     json_region = os.environ.get('AWS_REGION', 'Unknown')
 
@@ -23,7 +39,14 @@ def lambda_handler(request, context):
         "body": json.dumps({
             "Region ": json_region,
             "RequestID": context.aws_request_id,
-            "TimestampChain": [str(time.time_ns())]
+            "TimestampChain": [str(time.time_ns())],
+            "InitDurationMilliseconds": INIT_DURATION_MS,
+            "ServiceTimeMilliseconds": service_time_ms,
+            "WorkloadPhases": workload_phases,
+            **instance_telemetry(),
+            "ExecutionMilliseconds": (time.perf_counter() - handler_start) * 1000,
+            "HandlerEntryTimestampMicroseconds": handler_entry_us,
+            "HandlerExitTimestampMicroseconds": time.time_ns() // 1000
         }, indent=4)
     }
 
@@ -32,6 +55,206 @@ def lambda_handler(request, context):
 
 def simulate_work(increment):
     # MAXNUM = 6103705
+    start = time.perf_counter()
     num = 0
     while num < increment:
         num += 1
+    return (time.perf_counter() - start) * 1000
+
+
+def spin_for(service_time_ms, clock):
+    # Spins on the CPU clock of the process for the cpu clock, on the wall clock otherwise
+    start = time.perf_counter()
+    now = time.process_time if clock == 'cpu' else time.perf_counter
+    deadline = now() + service_time_ms / 1000
+    while now() < deadline:
+        pass
+    return (time.perf_counter() - start) * 1000
+
+
+def run_init_workload():
+    """Runs the init-time workload set through the STELLAR_INIT_* environment variables and returns its duration in ms"""
+    start = time.perf_counter()
+
+    import_modules(int(os.environ.get('STELLAR_INIT_IMPORT_MODULES', 0)))
+    if float(os.environ.get('STELLAR_INIT_DEPENDENCY_BUNDLE_MB', 0)) > 0:
+        importlib.import_module('stellar_bundle')
+    read_filler(int(float(os.environ.get('STELLAR_INIT_READ_FILLER_MB', 0)) * 1024 * 1024))
+    touch_memory(int(os.environ.get('STELLAR_INIT_MEMORY_MB', 0)) * 1024 * 1024)
+    time.sleep(int(os.environ.get('STELLAR_INIT_SLEEP_MS', 0)) / 1000)
+
+    return (time.perf_counter() - start) * 1000
+
+
+def import_modules(count):
+    """Imports the given number of standard library modules not imported yet"""
+    if count <= 0:
+        return
+    skipped = {'antigravity', 'idlelib', 'this', 'tkinter', 'turtle', 'turtledemo'}
+    library = os.path.dirname(os.__file__)
+    names = sorted(module.name for module in pkgutil.iter_modules([library]) if not module.name.startswith('_'))
+    for name in names:
+        if count <= 0:
+            return
+        if name in skipped or name in sys.modules:
+            continue
+        try:
+            importlib.import_module(name)
+            count -= 1
+        except Exception:
+            pass
+
+
+def read_filler(size):
+    """Reads up to the given number of bytes of the filler files of the function"""
+    directory = os.path.dirname(os.path.abspath(__file__))
+    for name in sorted(os.listdir(directory)):
+        if size <= 0:
+            return
+        if name.startswith('filler') and name.endswith('.file'):
+            with open(os.path.join(directory, name), 'rb') as filler:
+                while size > 0:
+                    chunk = filler.read(min(size, 1024 * 1024))
+                    if not chunk:
+                        break
+                    size -= len(chunk)
+
+
+def touch_memory(size):
+    """Allocates the given number of bytes, kept for the lifetime of the instance, and writes to every page"""
+    global init_memory
+    init_memory = bytearray(size)
+    for offset in range(0, size, 4096):
+        init_memory[offset] = 1
+
+
+INIT_DURATION_MS = run_init_workload()
+
+
+def run_workload():
+    """Runs the per-request workload set through the STELLAR_WORKLOAD* environment variables and returns the durations
+    of its phases in ms"""
+    workload = os.environ.get('STELLAR_WORKLOAD', '')
+    size = int(os.environ.get('STELLAR_WORKLOAD_BYTES', 0))
+    if workload == 'memory':
+        return memory_workload(size)
+    if workload == 'disk':
+        return disk_workload(size, os.environ.get('STELLAR_WORKLOAD_FSYNC') == 'true')
+    if workload == 'fetch':
+        return fetch_workload(os.environ['STELLAR_WORKLOAD_URL'], size)
+    return {}
+
+
+def lap(phases, phase, start):
+    """Records the duration of the phase started at start and returns the start of the next phase"""
+    now = time.perf_counter()
+    phases[phase] = (now - start) * 1000
+    return now
+
+
+def memory_workload(size):
+    """Allocates a buffer of the given number of bytes, then writes and reads all of it"""
+    phases = {}
+    start = time.perf_counter()
+    buffer = bytearray(size)
+    start = lap(phases, 'allocate', start)
+    chunk = b'\xa5' * min(size, 1024 * 1024)
+    for offset in range(0, size, len(chunk)):
+        buffer[offset:offset + len(chunk)] = chunk[:size - offset]
+    start = lap(phases, 'write', start)
+    buffer.count(0)
+    lap(phases, 'read', start)
+    return phases
+
+
+def disk_workload(size, fsync):
+    """Writes a file of the given number of bytes to the temporary directory, syncs it to disk if asked, then reads
+    and deletes it"""
+    phases = {}
+    chunk = b'\xa5' * min(size, 1024 * 1024)
+    start = time.perf_counter()
+    descriptor, path = tempfile.mkstemp(prefix='stellar-workload-')
+    try:
+        with os.fdopen(descriptor, 'wb') as file:
+            for offset in range(0, size, len(chunk)):
+                file.write(chunk[:size - offset])
+            file.flush()
+            start = lap(phases, 'write', start)
+            if fsync:
+                os.fsync(file.fileno())
+                start = lap(phases, 'fsync', start)
+        with open(path, 'rb') as file:
+            while file.read(1024 * 1024):
+                pass
+        start = lap(phases, 'read', start)
+    finally:
+        os.remove(path)
+    lap(phases, 'delete', start)
+    return phases
+
+
+def fetch_workload(url, size):
+    """Downloads up to the given number of bytes, or the whole body for 0, from the URL"""
+    phases = {}
+    start = time.perf_counter()
+    with urllib.request.urlopen(url) as response:
+        start = lap(phases, 'headers', start)
+        read = 0
+        while size == 0 or read < size:
+            chunk = response.read(1024 * 1024 if size == 0 else min(size - read, 1024 * 1024))
+            if not chunk:
+                break
+            read += len(chunk)
+    lap(phases, 'download', start)
+    return phases
+
+
+
+
+def read_cpu_model():
+    """Returns the model name of the CPU of the host from /proc/cpuinfo, if listed"""
+    try:
+        with open('/proc/cpuinfo') as cpuinfo:
+            for line in cpuinfo:
+                if line.startswith('model name'):
+                    return line.split(':', 1)[1].strip()
+    except OSError:
+        pass
+    return ''
+
+
+def read_memory_limit_mb():
+    """Returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited"""
+    if os.environ.get('AWS_LAMBDA_FUNCTION_MEMORY_SIZE'):
+        return int(os.environ['AWS_LAMBDA_FUNCTION_MEMORY_SIZE'])
+    for path in ('/sys/fs/cgroup/memory.max', '/sys/fs/cgroup/memory/memory.limit_in_bytes'):
+        try:
+            with open(path) as limit_file:
+                limit = int(limit_file.read().strip())
+            if 0 < limit < 2 ** 50:
+                return limit // (1024 * 1024)
+        except (OSError, ValueError):
+            pass
+    return 0
+
+
+# INSTANCE_ID identifies the instance serving the requests, generated at init
+INSTANCE_ID = uuid.uuid4().hex
+INSTANCE_BOOT_TIMESTAMP = time.time_ns() // 1_000_000
+CPU_MODEL = read_cpu_model()
+MEMORY_LIMIT_MB = read_memory_limit_mb()
+instance_request_count = 0
+
+
+def instance_telemetry():
+    """Counts the request served by the instance and returns the telemetry identifying the instance and its host"""
+    global instance_request_count
+    instance_request_count += 1
+    return {
+        "InstanceID": INSTANCE_ID,
+        "InstanceBootTimestamp": INSTANCE_BOOT_TIMESTAMP,
+        "InstanceRequestCount": instance_request_count,
+        "RuntimeVersion": "python " + platform.python_version(),
+        "CPUModel": CPU_MODEL,
+        "MemoryLimitMB": MEMORY_LIMIT_MB,
+    }
@@ -1,6 +1,9 @@
 require 'json'
+require 'securerandom'
 
 def handler(event:, context:)
+  handler_entry_us = Process.clock_gettime(Process::CLOCK_REALTIME, :microsecond)
+  handler_start = Process.clock_gettime(Process::CLOCK_MONOTONIC)
   incrementLimit = 0
   if event['queryStringParameters'] != nil
     if event['queryStringParameters']['IncrementLimit'] != nil
@@ -10,7 +13,11 @@ def handler(event:, context:)
 
   simulateWork(incrementLimit)
 
-  { RequestID: context.aws_request_id, TimestampChain: [ DateTime.now.strftime('%Q') ] }
+  body = { RequestID: context.aws_request_id, TimestampChain: [ DateTime.now.strftime('%Q') ] }.merge(instance_telemetry)
+  body[:ExecutionMilliseconds] = (Process.clock_gettime(Process::CLOCK_MONOTONIC) - handler_start) * 1000
+  body[:HandlerEntryTimestampMicroseconds] = handler_entry_us
+  body[:HandlerExitTimestampMicroseconds] = Process.clock_gettime(Process::CLOCK_REALTIME, :microsecond)
+  body
 end
 
 def simulateWork(incrementLimit)
@@ -19,3 +26,45 @@ def simulateWork(incrementLimit)
     i += 1
   end
 end
+
+# read_cpu_model returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+def read_cpu_model
+  line = File.foreach('/proc/cpuinfo').find { |cpuinfo_line| cpuinfo_line.start_with?('model name') }
+  line ? line.split(':', 2)[1].strip : ''
+rescue SystemCallError
+  ''
+end
+
+# read_memory_limit_mb returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+def read_memory_limit_mb
+  return ENV['AWS_LAMBDA_FUNCTION_MEMORY_SIZE'].to_i if ENV['AWS_LAMBDA_FUNCTION_MEMORY_SIZE']
+
+  ['/sys/fs/cgroup/memory.max', '/sys/fs/cgroup/memory/memory.limit_in_bytes'].each do |path|
+    limit = Integer(File.read(path).strip, exception: false)
+    return limit / (1024 * 1024) if limit&.positive? && limit < 2**50
+  rescue SystemCallError
+    next
+  end
+  0
+end
+
+# INSTANCE_ID identifies the instance serving the requests, generated at init
+INSTANCE_ID = SecureRandom.hex(16)
+INSTANCE_BOOT_TIMESTAMP = (Time.now.to_f * 1000).to_i
+CPU_MODEL = read_cpu_model
+MEMORY_LIMIT_MB = read_memory_limit_mb
+$instance_request_count = 0
+
+# instance_telemetry counts the request served by the instance and returns the telemetry identifying the instance and
+# its host
+def instance_telemetry
+  $instance_request_count += 1
+  {
+    InstanceID: INSTANCE_ID,
+    InstanceBootTimestamp: INSTANCE_BOOT_TIMESTAMP,
+    InstanceRequestCount: $instance_request_count,
+    RuntimeVersion: "ruby #{RUBY_VERSION}",
+    CPUModel: CPU_MODEL,
+    MemoryLimitMB: MEMORY_LIMIT_MB
+  }
+end
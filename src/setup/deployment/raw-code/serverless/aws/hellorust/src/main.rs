@@ -0,0 +1,138 @@
+use lambda_http::{run, service_fn, Body, Error, Request, RequestExt, Response};
+use serde_json::{json, Value};
+use std::collections::hash_map::RandomState;
+use std::hash::{BuildHasher, Hasher};
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::sync::OnceLock;
+use std::time::{Instant, SystemTime, UNIX_EPOCH};
+
+async fn handler(request: Request) -> Result<Response<Body>, Error> {
+    let handler_entry_microseconds = epoch_microseconds();
+    let handler_start = Instant::now();
+
+    let increment_limit = request
+        .query_string_parameters_ref()
+        .and_then(|parameters| parameters.first("IncrementLimit"))
+        .and_then(|limit| limit.parse::<u64>().ok())
+        .unwrap_or(0);
+
+    simulate_work(increment_limit);
+
+    let timestamp = SystemTime::now().duration_since(UNIX_EPOCH)?.as_millis();
+    let mut body = json!({
+        "RequestID": request.lambda_context().request_id,
+        "TimestampChain": [timestamp.to_string()],
+    });
+    if let (Value::Object(body), Value::Object(telemetry)) = (&mut body, instance_telemetry()) {
+        body.extend(telemetry);
+        body.insert(
+            "ExecutionMilliseconds".to_owned(),
+            json!(handler_start.elapsed().as_secs_f64() * 1000.0),
+        );
+        body.insert(
+            "HandlerEntryTimestampMicroseconds".to_owned(),
+            json!(handler_entry_microseconds),
+        );
+        body.insert(
+            "HandlerExitTimestampMicroseconds".to_owned(),
+            json!(epoch_microseconds()),
+        );
+    }
+
+    Ok(Response::builder()
+        .status(200)
+        .header("content-type", "application/json")
+        .body(body.to_string().into())?)
+}
+
+// epoch_microseconds returns the Unix time in microseconds
+fn epoch_microseconds() -> u64 {
+    SystemTime::now()
+        .duration_since(UNIX_EPOCH)
+        .map_or(0, |now| now.as_micros() as u64)
+}
+
+fn simulate_work(increment_limit: u64) {
+    let mut i: u64 = 0;
+    while std::hint::black_box(i) < increment_limit {
+        i += 1;
+    }
+}
+
+// Instance identifies the instance serving the requests and its host, created at init
+struct Instance {
+    id: String,
+    boot_timestamp: u128,
+    cpu_model: String,
+    memory_limit_mb: u64,
+}
+
+static INSTANCE: OnceLock<Instance> = OnceLock::new();
+static INSTANCE_REQUEST_COUNT: AtomicU64 = AtomicU64::new(0);
+
+fn instance() -> &'static Instance {
+    INSTANCE.get_or_init(|| Instance {
+        id: new_instance_id(),
+        boot_timestamp: SystemTime::now()
+            .duration_since(UNIX_EPOCH)
+            .map_or(0, |now| now.as_millis()),
+        cpu_model: read_cpu_model(),
+        memory_limit_mb: read_memory_limit_mb(),
+    })
+}
+
+// new_instance_id returns 128 random bits from the randomly seeded hashers of the standard library
+fn new_instance_id() -> String {
+    let half = || RandomState::new().build_hasher().finish();
+    format!("{:016x}{:016x}", half(), half())
+}
+
+// read_cpu_model returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+fn read_cpu_model() -> String {
+    std::fs::read_to_string("/proc/cpuinfo")
+        .unwrap_or_default()
+        .lines()
+        .find(|line| line.starts_with("model name"))
+        .and_then(|line| line.split_once(':'))
+        .map(|(_, model)| model.trim().to_owned())
+        .unwrap_or_default()
+}
+
+// read_memory_limit_mb returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+fn read_memory_limit_mb() -> u64 {
+    if let Some(memory_size) = std::env::var("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")
+        .ok()
+        .and_then(|size| size.parse().ok())
+    {
+        return memory_size;
+    }
+    [
+        "/sys/fs/cgroup/memory.max",
+        "/sys/fs/cgroup/memory/memory.limit_in_bytes",
+    ]
+    .iter()
+    .filter_map(|path| std::fs::read_to_string(path).ok())
+    .filter_map(|content| content.trim().parse::<u64>().ok())
+    .find(|limit| *limit > 0 && *limit < 1 << 50)
+    .map_or(0, |limit| limit / (1024 * 1024))
+}
+
+// instance_telemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+fn instance_telemetry() -> Value {
+    let instance = instance();
+    json!({
+        "InstanceID": instance.id,
+        "InstanceBootTimestamp": instance.boot_timestamp as u64,
+        "InstanceRequestCount": INSTANCE_REQUEST_COUNT.fetch_add(1, Ordering::Relaxed) + 1,
+        "RuntimeVersion": "rust",
+        "CPUModel": instance.cpu_model,
+        "MemoryLimitMB": instance.memory_limit_mb,
+    })
+}
+
+#[tokio::main]
+async fn main() -> Result<(), Error> {
+    instance();
+    run(service_fn(handler)).await
+}
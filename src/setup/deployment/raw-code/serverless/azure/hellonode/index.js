@@ -1,4 +1,6 @@
 async function handler(context, request) {
+  const handlerEntryMicroseconds = epochMicroseconds();
+  const handlerStart = process.hrtime.bigint();
   let q = request.query;
   let incrementLimit = 0;
   if (q.incrementLimit) {
@@ -12,6 +14,10 @@ async function handler(context, request) {
     body: {
       RequestID: context.invocationId,
       TimestampChain: [Date.now().toString()],
+      ...instanceTelemetry(),
+      ExecutionMilliseconds: Number(process.hrtime.bigint() - handlerStart) / 1e6,
+      HandlerEntryTimestampMicroseconds: handlerEntryMicroseconds,
+      HandlerExitTimestampMicroseconds: epochMicroseconds(),
     }
   };
 };
@@ -20,4 +26,45 @@ const simulateWork = (incrementLimit) => {
   for (let i = 0; i < incrementLimit; i++) { }
 };
 
+// epochMicroseconds returns the Unix time in microseconds
+const { performance } = require("perf_hooks");
+const epochMicroseconds = () => Math.round((performance.timeOrigin + performance.now()) * 1000);
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+const readMemoryLimitMB = () => {
+  if (process.env.AWS_LAMBDA_FUNCTION_MEMORY_SIZE) {
+    return parseInt(process.env.AWS_LAMBDA_FUNCTION_MEMORY_SIZE, 10);
+  }
+  for (const path of ["/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"]) {
+    try {
+      const limit = Number(require("fs").readFileSync(path, "utf8").trim());
+      if (limit > 0 && limit < 2 ** 50) {
+        return Math.floor(limit / (1024 * 1024));
+      }
+    } catch (error) {}
+  }
+  return 0;
+};
+
+// instanceId identifies the instance serving the requests, generated at init
+const instanceId = require("crypto").randomBytes(16).toString("hex");
+const instanceBootTimestamp = Date.now();
+const cpuModel = (require("os").cpus()[0] || {}).model || "";
+const memoryLimitMB = readMemoryLimitMB();
+let instanceRequestCount = 0;
+
+// instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+const instanceTelemetry = () => {
+  instanceRequestCount++;
+  return {
+    InstanceID: instanceId,
+    InstanceBootTimestamp: instanceBootTimestamp,
+    InstanceRequestCount: instanceRequestCount,
+    RuntimeVersion: `node ${process.version}`,
+    CPUModel: cpuModel,
+    MemoryLimitMB: memoryLimitMB,
+  };
+};
+
 module.exports = handler
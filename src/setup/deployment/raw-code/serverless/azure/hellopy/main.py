@@ -1,10 +1,16 @@
 import json
+import os
+import platform
 import time
+import uuid
 
 import azure.functions as func
 
 
 def main(req: func.HttpRequest, context: func.Context) -> func.HttpResponse:
+    handler_entry_us = time.time_ns() // 1000
+    handler_start = time.perf_counter()
+
     incr_limit = int(req.params.get('IncrementLimit')) if req.params.get('IncrementLimit') else None
     if not incr_limit:
         try:
@@ -17,12 +23,21 @@ def main(req: func.HttpRequest, context: func.Context) -> func.HttpResponse:
     else:
         incr_limit = 0
 
-    simulate_work(incr_limit)
+    clock = req.params.get('ServiceTimeClock')
+    if clock:
+        service_time_ms = spin_for(float(req.params.get('ServiceTimeMilliseconds', 0)), clock)
+    else:
+        service_time_ms = simulate_work(incr_limit)
 
     return func.HttpResponse(
         body=json.dumps({
             "RequestID": context.invocation_id,
-            "TimestampChain": [str(time.time_ns())]
+            "TimestampChain": [str(time.time_ns())],
+            "ServiceTimeMilliseconds": service_time_ms,
+            **instance_telemetry(),
+            "ExecutionMilliseconds": (time.perf_counter() - handler_start) * 1000,
+            "HandlerEntryTimestampMicroseconds": handler_entry_us,
+            "HandlerExitTimestampMicroseconds": time.time_ns() // 1000
         }, indent=4),
         status_code=200,
         headers={
@@ -33,6 +48,69 @@ def main(req: func.HttpRequest, context: func.Context) -> func.HttpResponse:
 
 def simulate_work(increment):
     # MAXNUM = 6103705
+    start = time.perf_counter()
     num = 0
     while num < increment:
         num += 1
+    return (time.perf_counter() - start) * 1000
+
+
+def spin_for(service_time_ms, clock):
+    # Spins on the CPU clock of the process for the cpu clock, on the wall clock otherwise
+    start = time.perf_counter()
+    now = time.process_time if clock == 'cpu' else time.perf_counter
+    deadline = now() + service_time_ms / 1000
+    while now() < deadline:
+        pass
+    return (time.perf_counter() - start) * 1000
+
+
+
+
+def read_cpu_model():
+    """Returns the model name of the CPU of the host from /proc/cpuinfo, if listed"""
+    try:
+        with open('/proc/cpuinfo') as cpuinfo:
+            for line in cpuinfo:
+                if line.startswith('model name'):
+                    return line.split(':', 1)[1].strip()
+    except OSError:
+        pass
+    return ''
+
+
+def read_memory_limit_mb():
+    """Returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited"""
+    if os.environ.get('AWS_LAMBDA_FUNCTION_MEMORY_SIZE'):
+        return int(os.environ['AWS_LAMBDA_FUNCTION_MEMORY_SIZE'])
+    for path in ('/sys/fs/cgroup/memory.max', '/sys/fs/cgroup/memory/memory.limit_in_bytes'):
+        try:
+            with open(path) as limit_file:
+                limit = int(limit_file.read().strip())
+            if 0 < limit < 2 ** 50:
+                return limit // (1024 * 1024)
+        except (OSError, ValueError):
+            pass
+    return 0
+
+
+# INSTANCE_ID identifies the instance serving the requests, generated at init
+INSTANCE_ID = uuid.uuid4().hex
+INSTANCE_BOOT_TIMESTAMP = time.time_ns() // 1_000_000
+CPU_MODEL = read_cpu_model()
+MEMORY_LIMIT_MB = read_memory_limit_mb()
+instance_request_count = 0
+
+
+def instance_telemetry():
+    """Counts the request served by the instance and returns the telemetry identifying the instance and its host"""
+    global instance_request_count
+    instance_request_count += 1
+    return {
+        "InstanceID": INSTANCE_ID,
+        "InstanceBootTimestamp": INSTANCE_BOOT_TIMESTAMP,
+        "InstanceRequestCount": instance_request_count,
+        "RuntimeVersion": "python " + platform.python_version(),
+        "CPUModel": CPU_MODEL,
+        "MemoryLimitMB": MEMORY_LIMIT_MB,
+    }
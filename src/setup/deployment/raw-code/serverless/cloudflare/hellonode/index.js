@@ -1,5 +1,7 @@
 export default {
 	async fetch(request) {
+		// The clock of Workers only advances on I/O, the handler timestamps are therefore in whole milliseconds
+		const handlerEntryMilliseconds = Date.now();
 		var incrLimit = 0
 
 		if (request.url.includes("incrementLimit")) {
@@ -11,6 +13,10 @@ export default {
 		const resData = {
 			"RequestID": "cloudflare-does-not-specify",
 			"TimestampChain": [Date.now().toString()],
+			...instanceTelemetry(),
+			"ExecutionMilliseconds": Date.now() - handlerEntryMilliseconds,
+			"HandlerEntryTimestampMicroseconds": handlerEntryMilliseconds * 1000,
+			"HandlerExitTimestampMicroseconds": Date.now() * 1000,
 		};
 
 		const body = JSON.stringify(resData, null, 2);
@@ -30,3 +36,25 @@ function simulateWork(incr) {
 	}
 }
 
+// Workers cannot generate random values nor read the time outside of a request, the isolate serving the requests is
+// therefore identified on its first request. Isolates do not expose the CPU of their host and are limited to 128MB.
+let instanceId = "";
+let instanceBootTimestamp = 0;
+let instanceRequestCount = 0;
+
+// instanceTelemetry counts the request served by the isolate and returns the telemetry identifying it
+function instanceTelemetry() {
+	if (instanceId === "") {
+		instanceId = crypto.randomUUID().replaceAll("-", "");
+		instanceBootTimestamp = Date.now();
+	}
+	instanceRequestCount++;
+	return {
+		"InstanceID": instanceId,
+		"InstanceBootTimestamp": instanceBootTimestamp,
+		"InstanceRequestCount": instanceRequestCount,
+		"RuntimeVersion": navigator.userAgent,
+		"CPUModel": "",
+		"MemoryLimitMB": 128,
+	};
+}
@@ -3,6 +3,7 @@ import {AssertionError, AttributeError, BaseException, DeprecationWarning, Excep
 import {datetime} from './datetime.js';
 var __name__ = '__main__';
 export var handleRequest = function (request) {
+	var handler_entry_ms = Date.now ();
 	var incr_limit = 0;
 	if (__in__ ('queryStringParameters', request) && __in__ ('IncrementLimit', request ['queryStringParameters'])) {
 		var incr_limit = int (request ['queryStringParameters'].py_get ('IncrementLimit', 0));
@@ -11,7 +12,13 @@ export var handleRequest = function (request) {
 		var incr_limit = int (JSON.parse (request ['body']) ['IncrementLimit']);
 	}
 	simulate_work (incr_limit);
-	var response = JSON.stringify (dict ({'RequestID': 'cloudflare-does-not-specify', 'TimestampChain': [str (datetime.now ())]}));
+	var body = instance_telemetry ();
+	body ['RequestID'] = 'cloudflare-does-not-specify';
+	body ['TimestampChain'] = [str (datetime.now ())];
+	body ['ExecutionMilliseconds'] = Date.now () - handler_entry_ms;
+	body ['HandlerEntryTimestampMicroseconds'] = handler_entry_ms * 1000;
+	body ['HandlerExitTimestampMicroseconds'] = Date.now () * 1000;
+	var response = JSON.stringify (body);
 	return new Response (response, dict ({'headers': dict ({'content-type': 'application/json'})}));
 };
 export var simulate_work = function (increment) {
@@ -20,6 +27,15 @@ export var simulate_work = function (increment) {
 		num++;
 	}
 };
+export var INSTANCE = dict ({'ID': '', 'BootTimestamp': 0, 'RequestCount': 0});
+export var instance_telemetry = function () {
+	if (INSTANCE ['ID'] == '') {
+		INSTANCE ['ID'] = crypto.randomUUID ().replaceAll ('-', '');
+		INSTANCE ['BootTimestamp'] = Date.now ();
+	}
+	INSTANCE ['RequestCount']++;
+	return dict ({'InstanceID': INSTANCE ['ID'], 'InstanceBootTimestamp': INSTANCE ['BootTimestamp'], 'InstanceRequestCount': INSTANCE ['RequestCount'], 'RuntimeVersion': navigator.userAgent, 'CPUModel': '', 'MemoryLimitMB': 128});
+};
 addEventListener ('fetch', (function __lambda__ (event) {
 	return event.respondWith (handleRequest (event.request));
 }));
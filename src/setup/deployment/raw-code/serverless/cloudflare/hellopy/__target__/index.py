@@ -1,5 +1,7 @@
 from datetime import datetime
 def handleRequest(request):
+    # The clock of Workers only advances on I/O, the handler timestamps are therefore in whole milliseconds
+    handler_entry_ms = Date.now()
     incr_limit = 0
 
     if 'queryStringParameters' in request and 'IncrementLimit' in request['queryStringParameters']:
@@ -9,10 +11,13 @@ def handleRequest(request):
 
     simulate_work(incr_limit)
 
-    response = JSON.stringify({
-        "RequestID": "cloudflare-does-not-specify",
-        "TimestampChain": [str(datetime.now())]
-    })
+    body = instance_telemetry()
+    body["RequestID"] = "cloudflare-does-not-specify"
+    body["TimestampChain"] = [str(datetime.now())]
+    body["ExecutionMilliseconds"] = Date.now() - handler_entry_ms
+    body["HandlerEntryTimestampMicroseconds"] = handler_entry_ms * 1000
+    body["HandlerExitTimestampMicroseconds"] = Date.now() * 1000
+    response = JSON.stringify(body)
 
 
     return __new__(Response(response, {
@@ -25,4 +30,25 @@ def simulate_work(increment):
     while num < increment:
         num += 1
 
+
+# Workers cannot generate random values nor read the time outside of a request, the isolate serving the requests is
+# therefore identified on its first request. Isolates do not expose the CPU of their host and are limited to 128MB.
+INSTANCE = {"ID": "", "BootTimestamp": 0, "RequestCount": 0}
+
+
+def instance_telemetry():
+    """Counts the request served by the isolate and returns the telemetry identifying it"""
+    if INSTANCE["ID"] == "":
+        INSTANCE["ID"] = crypto.randomUUID().replaceAll("-", "")
+        INSTANCE["BootTimestamp"] = Date.now()
+    INSTANCE["RequestCount"] += 1
+    return {
+        "InstanceID": INSTANCE["ID"],
+        "InstanceBootTimestamp": INSTANCE["BootTimestamp"],
+        "InstanceRequestCount": INSTANCE["RequestCount"],
+        "RuntimeVersion": navigator.userAgent,
+        "CPUModel": "",
+        "MemoryLimitMB": 128
+    }
+
 addEventListener('fetch', (lambda event: event.respondWith(handleRequest(event.request))))
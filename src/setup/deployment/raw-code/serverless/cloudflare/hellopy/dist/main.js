@@ -1 +1 @@
-(()=>{"use strict";var t={d:(e,r)=>{for(var n in r)t.o(r,n)&&!t.o(e,n)&&Object.defineProperty(e,n,{enumerable:!0,get:r[n]})},o:(t,e)=>Object.prototype.hasOwnProperty.call(t,e),r:t=>{"undefined"!=typeof Symbol&&Symbol.toStringTag&&Object.defineProperty(t,Symbol.toStringTag,{value:"Module"}),Object.defineProperty(t,"__esModule",{value:!0})}},e={};t.r(e),t.d(e,{__adapt__:()=>vt,__d:()=>Pt,__date:()=>wt,__debugGetLanguage:()=>gt,__jan_jun_tz:()=>Ct,__lu:()=>xt,__months:()=>At,__months_long:()=>St,__now:()=>bt,__tzn:()=>Ht,__weekdays:()=>Ot,__weekdays_long:()=>kt,_day_of_year:()=>Ut,_daylight:()=>Dt,_daylight_in_effect:()=>Et,_is_leap:()=>qt,_local_time_tuple:()=>Tt,_lsplit:()=>Nt,_timezone:()=>Ft,_tzname:()=>Lt,_utc_time_tuple:()=>It,altzone:()=>Wt,asctime:()=>Rt,ctime:()=>Kt,daylight:()=>Bt,gmtime:()=>Xt,localtime:()=>Vt,mktime:()=>Gt,strftime:()=>te,strptime:()=>Qt,time:()=>Zt,timezone:()=>Yt,tzname:()=>Jt});var r="org.transcrypt.__runtime__",n={};function _(t,e,r){return t&&(t.hasOwnProperty("__class__")||"string"==typeof t||t instanceof String)?(r&&Object.defineProperty(t,r,{value:function(){var r=[].slice.apply(arguments);return e.apply(null,[t].concat(r))},writable:!0,enumerable:!0,configurable:!0}),function(){var r=[].slice.apply(arguments);return e.apply(null,[t.__proxy__?t.__proxy__:t].concat(r))}):e}function o(t,e,r){return t.hasOwnProperty("__class__")?function(){var r=[].slice.apply(arguments);return e.apply(null,[t.__class__].concat(r))}:function(){var r=[].slice.apply(arguments);return e.apply(null,[t].concat(r))}}n.interpreter_name="python",n.transpiler_name="transcrypt",n.executor_name=n.transpiler_name,n.transpiler_version="3.9.0";var i={__name__:"type",__bases__:[],__new__:function(t,e,r,n){for(var _=function(){var t=[].slice.apply(arguments);return _.__new__(t)},o=r.length-1;o>=0;o--){var i=r[o];for(var u in i)null!=(a=Object.getOwnPropertyDescriptor(i,u))&&Object.defineProperty(_,u,a);for(let t of Object.getOwnPropertySymbols(i)){let e=Object.getOwnPropertyDescriptor(i,t);Object.defineProperty(_,t,e)}}for(var u in _.__metaclass__=t,_.__name__=e.startsWith("py_")?e.slice(3):e,_.__bases__=r,n){var a=Object.getOwnPropertyDescriptor(n,u);Object.defineProperty(_,u,a)}for(let t of Object.getOwnPropertySymbols(n)){let e=Object.getOwnPropertyDescriptor(n,t);Object.defineProperty(_,t,e)}return _}};i.__metaclass__=i;var u={__init__:function(t){},__metaclass__:i,__name__:"object",__bases__:[],__new__:function(t){var e=Object.create(this,{__class__:{value:this,enumerable:!0}});return("__getattr__"in this||"__setattr__"in this)&&(e.__proxy__=new Proxy(e,{get:function(t,e){let r=t[e];return null==r?t.__getattr__(e):r},set:function(t,e,r){try{t.__setattr__(e,r)}catch(n){t[e]=r}return!0}}),e=e.__proxy__),this.__init__.apply(null,[e].concat(t)),e}};function a(t,e,r,n){return void 0===n&&(n=e[0].__metaclass__),n.__new__(n,t,e,r)}function s(){var t=[].slice.apply(arguments);return"object"==typeof t[0]&&"__call__"in t[0]?t[0].__call__.apply(t[1],t.slice(2)):t[0].apply(t[1],t.slice(2))}function l(t){return t.__kwargtrans__=null,t.constructor=Object,t}function c(t,e){return e||(e=function(){}),{get:function(){return t(this)},set:function(t){e(this,t)},enumerable:!0}}function f(t,e,r){t.hasOwnProperty(e)||Object.defineProperty(t,e,r)}function h(t,e){try{return e in t||"py_"+e in t}catch(t){return!1}}function m(t,e){return null!=e&&(e.__contains__ instanceof Function?e.__contains__(t):e.indexOf?e.indexOf(t)>-1:e.hasOwnProperty(t))}function p(t){return t.startswith("__")&&t.endswith("__")||"constructor"==t||t.startswith("py_")}function d(t){if(null==t)return 0;if(t.__len__ instanceof Function)return t.__len__();if(void 0!==t.length)return t.length;var e=0;for(var r in t)p(r)||e++;return e}function y(t){if("inf"==t)return 1/0;if("-inf"==t)return-1/0;if("nan"==t)return NaN;if(isNaN(parseFloat(t))){if(!1===t)return 0;if(!0===t)return 1;throw ut("could not convert string to float: '"+T(t)+"'",new Error)}return+t}function g(t){return 0|y(t)}function v(t){return!(null==(e=t)||!(["boolean","number"].indexOf(typeof e)>=0?e:e.__bool__ instanceof Function?e.__bool__()&&e:e.__len__ instanceof Function?0!==e.__len__()&&e:(e instanceof Function||0!==d(e))&&e));var e}function w(t){var e=typeof t;if("object"!=e)return"boolean"==e?v:"string"==e?T:"number"==e?t%1==0?g:y:null;try{return"__class__"in t?t.__class__:u}catch(t){return e}}function b(t,e){if(e instanceof Array){for(let r of e)if(b(t,r))return!0;return!1}try{var r=t;if(r==e)return!0;for(var n=[].slice.call(r.__bases__);n.length;){if((r=n.shift())==e)return!0;r.__bases__.length&&(n=[].slice.call(r.__bases__).concat(n))}return!1}catch(r){return t==e||e==u}}function O(t,e){try{return b("__class__"in t?t.__class__:w(t),e)}catch(r){return b(w(t),e)}}function k(t){try{return t.__repr__()}catch(o){try{return t.__str__()}catch(o){try{if(null==t)return"None";if(t.constructor==Object){var e="{",r=!1;for(var n in t)if(!p(n)){if(n.isnumeric())var _=n;else _="'"+n+"'";r?e+=", ":r=!0,e+=_+": "+k(t[n])}return e+"}"}return"boolean"==typeof t?t.toString().capitalize():t.toString()}catch(e){return"<object of type: "+typeof t+">"}}}}function P(t){return 1==arguments.length?Math.min(...t):Math.min(...arguments)}n.executor_name=n.transpiler_name,y.__name__="float",y.__bases__=[u],g.__name__="int",g.__bases__=[u],v.__name__="bool",v.__bases__=[g];var z=Math.abs;function j(t,e){if(e){var r=Math.pow(10,e);t*=r}var n=Math.round(t);return n-t==.5&&n%2&&(n-=1),e&&(n/=r),n}function M(t){this.iterable=t,this.index=0}function A(t){this.iterable=t,this.index=0}function S(t){return t?Array.from(t):[]}function x(t){let e=t?[].slice.apply(t):[];return e.__class__=x,e}function N(t){let e=[];if(t)for(let r=0;r<t.length;r++)e.add(t[r]);return e.__class__=N,e}function T(t){if("number"==typeof t)return t.toString();try{return t.__str__()}catch(e){try{return k(t)}catch(e){return String(t)}}}function I(t){return this.hasOwnProperty(t)}function U(){var t=[];for(var e in this)p(e)||t.push(e);return t}function q(){var t=[];for(var e in this)p(e)||t.push([e,this[e]]);return t}function C(t){delete this[t]}function D(){for(var t in this)delete this[t]}function E(t,e){var r=this[t];return null==r&&(r=this["py_"+t]),null==r?null==e?null:e:r}function F(t,e){var r=this[t];if(null!=r)return r;var n=null==e?null:e;return this[t]=n,n}function H(t,e){var r=this[t];if(null!=r)return delete this[t],r;if(void 0===e)throw at(t,new Error);return e}function L(){var t=Object.keys(this)[0];if(null==t)throw at("popitem(): dictionary is empty",new Error);var e=x([t,this[t]]);return delete this[t],e}function W(t){for(var e in t)this[e]=t[e]}function $(){var t=[];for(var e in this)p(e)||t.push(this[e]);return t}function Y(t){return this[t]}function B(t,e){this[t]=e}function J(t){var e={};if(!t||t instanceof Array){if(t)for(var r=0;r<t.length;r++){var n=t[r];if(!(n instanceof Array)||2!=n.length)throw ut("dict update sequence element #"+r+" has length "+n.length+"; 2 is required",new Error);var _=n[0],o=n[1];!(t instanceof Array)&&t instanceof Object&&(O(t,J)||(o=J(o))),e[_]=o}}else if(O(t,J)){var i=t.py_keys();for(r=0;r<i.length;r++)e[_=i[r]]=t[_]}else{if(!(t instanceof Object))throw ut("Invalid type of object for dict creation",new Error);e=t}return f(e,"__class__",{value:J,enumerable:!1,writable:!0}),f(e,"__contains__",{value:I,enumerable:!1}),f(e,"py_keys",{value:U,enumerable:!1}),f(e,"__iter__",{value:function(){new M(this.py_keys())},enumerable:!1}),f(e,Symbol.iterator,{value:function(){new A(this.py_keys())},enumerable:!1}),f(e,"py_items",{value:q,enumerable:!1}),f(e,"py_del",{value:C,enumerable:!1}),f(e,"py_clear",{value:D,enumerable:!1}),f(e,"py_get",{value:E,enumerable:!1}),f(e,"py_setdefault",{value:F,enumerable:!1}),f(e,"py_pop",{value:H,enumerable:!1}),f(e,"py_popitem",{value:L,enumerable:!1}),f(e,"py_update",{value:W,enumerable:!1}),f(e,"py_values",{value:$,enumerable:!1}),f(e,"__getitem__",{value:Y,enumerable:!1}),f(e,"__setitem__",{value:B,enumerable:!1}),e}function Z(t,e){return"object"==typeof t&&"__mod__"in t?t.__mod__(e):"object"==typeof e&&"__rmod__"in e?e.__rmod__(t):(t%e+e)%e}function R(t){return"object"==typeof t&&"__neg__"in t?t.__neg__():-t}function G(t,e){return"object"==typeof t&&"__mul__"in t?t.__mul__(e):"object"==typeof e&&"__rmul__"in e?e.__rmul__(t):"string"==typeof t?t.__mul__(e):"string"==typeof e?e.__rmul__(t):t*e}function K(t,e){return"object"==typeof t&&"__floordiv__"in t?t.__floordiv__(e):"object"==typeof e&&"__rfloordiv__"in e?e.__rfloordiv__(t):"object"==typeof t&&"__div__"in t?t.__div__(e):"object"==typeof e&&"__rdiv__"in e?e.__rdiv__(t):Math.floor(t/e)}function V(t,e){return"object"==typeof t&&"__add__"in t?t.__add__(e):"object"==typeof e&&"__radd__"in e?e.__radd__(t):t+e}function X(t,e){return"object"==typeof t&&"__sub__"in t?t.__sub__(e):"object"==typeof e&&"__rsub__"in e?e.__rsub__(t):t-e}function Q(t,e){return"object"==typeof t&&"__lt__"in t?t.__lt__(e):t<e}function tt(t,e){return"object"==typeof t&&"__le__"in t?t.__le__(e):t<=e}function et(t,e){return"object"==typeof t&&"__iadd__"in t?t.__iadd__(e):"object"==typeof t&&"__add__"in t?t.__add__(e):"object"==typeof e&&"__radd__"in e?e.__radd__(t):t+e}function rt(t,e){return"object"==typeof t&&"__isub__"in t?t.__isub__(e):"object"==typeof t&&"__sub__"in t?t.__sub__(e):"object"==typeof e&&"__rsub__"in e?e.__rsub__(t):t-e}function nt(t,e){return"object"==typeof t&&"__getitem__"in t?t.__getitem__(e):("string"==typeof t||t instanceof Array)&&e<0?t[t.length+e]:t[e]}M.prototype.__next__=function(){if(this.index<this.iterable.length)return this.iterable[this.index++];throw it(new Error)},A.prototype.next=function(){return this.index<this.iterable.py_keys.length?{value:this.index++,done:!1}:{value:void 0,done:!0}},Array.prototype.__class__=S,S.__name__="list",S.__bases__=[u],Array.prototype.__iter__=function(){return new M(this)},Array.prototype.__getslice__=function(t,e,r){if(t<0&&(t=this.length+t),null==e?e=this.length:e<0?e=this.length+e:e>this.length&&(e=this.length),1==r)return Array.prototype.slice.call(this,t,e);let n=S([]);for(let _=t;_<e;_+=r)n.push(this[_]);return n},Array.prototype.__setslice__=function(t,e,r,n){if(t<0&&(t=this.length+t),null==e?e=this.length:e<0&&(e=this.length+e),null==r)Array.prototype.splice.apply(this,[t,e-t].concat(n));else{let _=0;for(let o=t;o<e;o+=r)this[o]=n[_++]}},Array.prototype.__repr__=function(){if(this.__class__==N&&!this.length)return"set()";let t=this.__class__&&this.__class__!=S?this.__class__==x?"(":"{":"[";for(let e=0;e<this.length;e++)e&&(t+=", "),t+=k(this[e]);return this.__class__==x&&1==this.length&&(t+=","),t+=this.__class__&&this.__class__!=S?this.__class__==x?")":"}":"]",t},Array.prototype.__str__=Array.prototype.__repr__,Array.prototype.append=function(t){this.push(t)},Array.prototype.py_clear=function(){this.length=0},Array.prototype.extend=function(t){this.push.apply(this,t)},Array.prototype.insert=function(t,e){this.splice(t,0,e)},Array.prototype.remove=function(t){let e=this.indexOf(t);if(-1==e)throw ut("list.remove(x): x not in list",new Error);this.splice(e,1)},Array.prototype.index=function(t){return this.indexOf(t)},Array.prototype.py_pop=function(t){return null==t?this.pop():this.splice(t,1)[0]},Array.prototype.py_sort=function(){ht.apply(null,[this].concat([].slice.apply(arguments)))},Array.prototype.__add__=function(t){return S(this.concat(t))},Array.prototype.__mul__=function(t){let e=this;for(let r=1;r<t;r++)e=e.concat(this);return e},Array.prototype.__rmul__=Array.prototype.__mul__,x.__name__="tuple",x.__bases__=[u],N.__name__="set",N.__bases__=[u],Array.prototype.__bindexOf__=function(t){t+="";let e=0,r=this.length-1;for(;e<=r;){let n=(e+r)/2|0,_=this[n]+"";if(_<t)e=n+1;else{if(!(_>t))return n;r=n-1}}return-1},Array.prototype.add=function(t){-1==this.indexOf(t)&&this.push(t)},Array.prototype.discard=function(t){var e=this.indexOf(t);-1!=e&&this.splice(e,1)},Array.prototype.isdisjoint=function(t){this.sort();for(let e=0;e<t.length;e++)if(-1!=this.__bindexOf__(t[e]))return!1;return!0},Array.prototype.issuperset=function(t){this.sort();for(let e=0;e<t.length;e++)if(-1==this.__bindexOf__(t[e]))return!1;return!0},Array.prototype.issubset=function(t){return N(t.slice()).issuperset(this)},Array.prototype.union=function(t){let e=N(this.slice().sort());for(let r=0;r<t.length;r++)-1==e.__bindexOf__(t[r])&&e.push(t[r]);return e},Array.prototype.intersection=function(t){this.sort();let e=N();for(let r=0;r<t.length;r++)-1!=this.__bindexOf__(t[r])&&e.push(t[r]);return e},Array.prototype.difference=function(t){let e=N(t.slice().sort()),r=N();for(let t=0;t<this.length;t++)-1==e.__bindexOf__(this[t])&&r.push(this[t]);return r},Array.prototype.symmetric_difference=function(t){return this.union(t).difference(this.intersection(t))},Array.prototype.py_update=function(){let t=[].concat.apply(this.slice(),arguments).sort();this.py_clear();for(let e=0;e<t.length;e++)t[e]!=t[e-1]&&this.push(t[e])},Array.prototype.__eq__=function(t){if(this.length!=t.length)return!1;this.__class__==N&&(this.sort(),t.sort());for(let e=0;e<this.length;e++)if(this[e]!=t[e])return!1;return!0},Array.prototype.__ne__=function(t){return!this.__eq__(t)},Array.prototype.__le__=function(t){if(this.__class__==N)return this.issubset(t);for(let e=0;e<this.length;e++){if(this[e]>t[e])return!1;if(this[e]<t[e])return!0}return!0},Array.prototype.__ge__=function(t){if(this.__class__==N)return this.issuperset(t);for(let e=0;e<this.length;e++){if(this[e]<t[e])return!1;if(this[e]>t[e])return!0}return!0},Array.prototype.__lt__=function(t){return this.__class__==N?this.issubset(t)&&!this.issuperset(t):!this.__ge__(t)},Array.prototype.__gt__=function(t){return this.__class__==N?this.issuperset(t)&&!this.issubset(t):!this.__le__(t)},Uint8Array.prototype.__add__=function(t){let e=new Uint8Array(this.length+t.length);return e.set(this),e.set(t,this.length),e},Uint8Array.prototype.__mul__=function(t){let e=new Uint8Array(t*this.length);for(let r=0;r<t;r++)e.set(this,r*this.length);return e},Uint8Array.prototype.__rmul__=Uint8Array.prototype.__mul__,String.prototype.__class__=T,T.__name__="str",T.__bases__=[u],String.prototype.__iter__=function(){new M(this)},String.prototype.__repr__=function(){return(-1==this.indexOf("'")?"'"+this+"'":'"'+this+'"').py_replace("\t","\\t").py_replace("\n","\\n")},String.prototype.__str__=function(){return this},String.prototype.capitalize=function(){return this.charAt(0).toUpperCase()+this.slice(1)},String.prototype.endswith=function(t){if(!(t instanceof Array))return""==t||this.slice(-t.length)==t;for(var e=0;e<t.length;e++)if(this.slice(-t[e].length)==t[e])return!0;return!1},String.prototype.find=function(t,e){return this.indexOf(t,e)},String.prototype.__getslice__=function(t,e,r){t<0&&(t=this.length+t),null==e?e=this.length:e<0&&(e=this.length+e);var n="";if(1==r)n=this.substring(t,e);else for(var _=t;_<e;_+=r)n=n.concat(this.charAt(_));return n},f(String.prototype,"format",{get:function(){return _(this,(function(t){var e=x([].slice.apply(arguments).slice(1)),r=0;return t.replace(/\{(\w*)\}/g,(function(t,n){if(""==n&&(n=r++),n==+n)return void 0===e[n]?t:T(e[n]);for(var _=0;_<e.length;_++)if("object"==typeof e[_]&&void 0!==e[_][n])return T(e[_][n]);return t}))}))},enumerable:!0}),String.prototype.isalnum=function(){return/^[0-9a-zA-Z]{1,}$/.test(this)},String.prototype.isalpha=function(){return/^[a-zA-Z]{1,}$/.test(this)},String.prototype.isdecimal=function(){return/^[0-9]{1,}$/.test(this)},String.prototype.isdigit=function(){return this.isdecimal()},String.prototype.islower=function(){return/^[a-z]{1,}$/.test(this)},String.prototype.isupper=function(){return/^[A-Z]{1,}$/.test(this)},String.prototype.isspace=function(){return/^[\s]{1,}$/.test(this)},String.prototype.isnumeric=function(){return!isNaN(parseFloat(this))&&isFinite(this)},String.prototype.join=function(t){return(t=Array.from(t)).join(this)},String.prototype.lower=function(){return this.toLowerCase()},String.prototype.py_replace=function(t,e,r){return this.split(t,r).join(e)},String.prototype.lstrip=function(){return this.replace(/^\s*/g,"")},String.prototype.rfind=function(t,e){return this.lastIndexOf(t,e)},String.prototype.rsplit=function(t,e){if(null==t||null==t){t=/\s+/;var r=this.strip()}else r=this;if(null==e||-1==e)return r.split(t);var n=r.split(t);if(e<n.length){var _=n.length-e;return[n.slice(0,_).join(t)].concat(n.slice(_))}return n},String.prototype.rstrip=function(){return this.replace(/\s*$/g,"")},String.prototype.py_split=function(t,e){if(null==t||null==t){t=/\s+/;var r=this.strip()}else r=this;if(null==e||-1==e)return r.split(t);var n=r.split(t);return e<n.length?n.slice(0,e).concat([n.slice(e).join(t)]):n},String.prototype.startswith=function(t){if(!(t instanceof Array))return 0==this.indexOf(t);for(var e=0;e<t.length;e++)if(0==this.indexOf(t[e]))return!0;return!1},String.prototype.strip=function(){return this.trim()},String.prototype.upper=function(){return this.toUpperCase()},String.prototype.__mul__=function(t){for(var e="",r=0;r<t;r++)e+=this;return e},String.prototype.__rmul__=String.prototype.__mul__,J.__name__="dict",J.__bases__=[u],f(Function.prototype,"__setdoc__",{value:function(t){return this.__doc__=t,this},enumerable:!1});var _t=a("BaseException",[u],{__module__:r}),ot=a("Exception",[_t],{__module__:r,get __init__(){return _(this,(function(t){var e=J();if(arguments.length){var r=arguments.length-1;if(arguments[r]&&arguments[r].hasOwnProperty("__kwargtrans__")){var n=arguments[r--];for(var _ in n)"self"===_?t=n[_]:e[_]=n[_];delete e.__kwargtrans__}var o=x([].slice.apply(arguments).slice(1,r+1))}else o=x();t.__args__=o,null!=e.error?t.stack=e.error.stack:Error?t.stack=(new Error).stack:t.stack="No stack trace available"}))},get __repr__(){return _(this,(function(t){return d(t.__args__)>1?"{}{}".format(t.__class__.__name__,k(x(t.__args__))):d(t.__args__)?"{}({})".format(t.__class__.__name__,k(t.__args__[0])):"{}()".format(t.__class__.__name__)}))},get __str__(){return _(this,(function(t){return d(t.__args__)>1?T(x(t.__args__)):d(t.__args__)?T(t.__args__[0]):""}))}}),it=(a("IterableError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e){ot.__init__(t,"Can't iterate over non-iterable",l({error:e}))}))}}),a("StopIteration",[ot],{__module__:r,get __init__(){return _(this,(function(t,e){ot.__init__(t,"Iterator exhausted",l({error:e}))}))}})),ut=a("ValueError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),at=a("KeyError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),st=(a("AssertionError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){e?ot.__init__(t,e,l({error:r})):ot.__init__(t,l({error:r}))}))}}),a("NotImplementedError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}})),lt=(a("IndexError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),a("AttributeError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}})),ct=a("py_TypeError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),ft=a("Warning",[ot],{__module__:r}),ht=(a("UserWarning",[ft],{__module__:r}),a("DeprecationWarning",[ft],{__module__:r}),a("RuntimeWarning",[ft],{__module__:r}),function(t,e,r){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=!1),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"iterable":t=_[o];break;case"key":e=_[o];break;case"reverse":r=_[o]}}}e?t.sort((function(t,r){if(arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"a":t=_[o];break;case"b":r=_[o]}}}return e(t)>e(r)?1:-1})):t.sort(),r&&t.reverse()}),mt=function(t,e){return x([Math.floor(t/e),Z(t,e)])},pt=a("__Terminal__",[u],{__module__:r,get __init__(){return _(this,(function(t){t.buffer="";try{t.element=document.getElementById("__terminal__")}catch(e){t.element=null}t.element&&(t.element.style.overflowX="auto",t.element.style.boxSizing="border-box",t.element.style.padding="5px",t.element.innerHTML="_")}))},get print(){return _(this,(function(t){var e=" ",r="\n";if(arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"sep":e=_[o];break;case"end":r=_[o]}}var i=x([].slice.apply(arguments).slice(1,n+1))}else i=x();t.buffer="{}{}{}".format(t.buffer,e.join(function(){var t=[];for(var e of i)t.append(T(e));return t}()),r).__getslice__(-4096,null,1),t.element?(t.element.innerHTML=t.buffer.py_replace("\n","<br>").py_replace(" ","&nbsp"),t.element.scrollTop=t.element.scrollHeight):console.log(e.join(function(){var t=[];for(var e of i)t.append(T(e));return t}()))}))},get input(){return _(this,(function(t,e){if(arguments.length){var r=arguments.length-1;if(arguments[r]&&arguments[r].hasOwnProperty("__kwargtrans__")){var n=arguments[r--];for(var _ in n)switch(_){case"self":t=n[_];break;case"question":e=n[_]}}}t.print("{}".format(e),l({end:""}));var o=window.prompt("\n".join(t.buffer.py_split("\n").__getslice__(-8,null,1)));return t.print(o),o}))}})(),dt=(pt.print,pt.input,Math.PI,Math.E,Math.exp,Math.pow,Math.sqrt,Math.sin,Math.cos,Math.tan,Math.asin,Math.acos,Math.atan,Math.atan2,Math.hypot,Math.sinh,Math.cosh,Math.tanh,Math.asinh,Math.acosh,Math.atanh,Math.floor,Math.ceil,Math.trunc,isNaN,function(t){var e=t>=0?1:-1,r=mt(z(t),1),n=r[0];return x([r[1]*e,n*e])});try{var yt=window.navigator.language}catch(t){yt="en-US"}for(var gt=function(){return yt},vt=function(t){yt=t.headers["accept-language"].py_split(",")[0]},wt=new Date(0),bt=new Date,Ot=[],kt=[],Pt=new Date(1467662339080),zt=0;zt<7;zt++){for(var[jt,Mt]of x([x([Ot,"short"]),x([kt,"long"])]))jt.append(Pt.toLocaleString(yt,J({weekday:Mt})).lower());Pt.setDate(Pt.getDate()+1)}var At=[],St=[];for(Pt=new Date(9466812e5),zt=0;zt<12;zt++){for(var[jt,Mt]of x([x([At,"short"]),x([St,"long"])]))jt.append(Pt.toLocaleString(yt,J({month:Mt})).lower());Pt.setMonth(Pt.getMonth()+1)}var xt=J({Y:0,m:1,d:2,H:3,M:4,S:5}),Nt=function(t,e,r){if(0==r)return[t];var n=t.py_split(e);if(!r)return n;var _=n.slice(0,r,1);return d(_)==d(n)||_.append(e.join(n.__getslice__(r,null,1))),_},Tt=function(t){return x([t.getFullYear(),t.getMonth()+1,t.getDate(),t.getHours(),t.getMinutes(),t.getSeconds(),t.getDay()>0?t.getDay()-1:6,Ut(t,!0),Et(t),t.getMilliseconds()])},It=function(t){return x([t.getUTCFullYear(),t.getUTCMonth()+1,t.getUTCDate(),t.getUTCHours(),t.getUTCMinutes(),t.getUTCSeconds(),t.getUTCDay()-1,Ut(t,!1),0,t.getUTCMilliseconds()])},Ut=function(t,e){var r=0;t.getHours()+60*t.getTimezoneOffset()/3600<0&&(r=-1);var n=t.getTime(),_=t.setHours(23);t.setUTCDate(1),t.setUTCMonth(0),t.setUTCHours(0),t.setUTCMinutes(0),t.setUTCSeconds(0);var o=j((_-t)/864e5);if(e||(o+=r),0==o){o=365,t.setTime(t.getTime()-86400);var i=t.getUTCFullYear();qt(i)&&(o=366)}return t.setTime(n),o},qt=function(t){return 0==Z(t,4)&&(0!=Z(t,100)||0==Z(t,400))},Ct=function(t,e){var r=t.getTime();t.setDate(1);var n=[];for(var _ of x([0,6]))t.setMonth(_),e?n.append(e(t)):n.append(t.getTimezoneOffset());return t.setTime(r),n},Dt=function(t){var e=Ct(t);return e[0]!=e[1]?1:0},Et=function(t){var e=Ct(t);return P(e[0],e[1])==t.getTimezoneOffset()?1:0},Ft=function(t){var e=Ct(t);return function(t){return 1==arguments.length?Math.max(...t):Math.max(...arguments)}(e[0],e[1])},Ht=function(t){try{return T(t).py_split("(")[1].py_split(")")[0]}catch(t){return"n.a."}},Lt=function(t){var e=Ht(t),r=[e,e],n=Ct(t,Ht),_=0;for(var o of(Et(t)||(_=1),n))o!=e&&(r[_]=o);return x(r)},Wt=bt.getTimezoneOffset();if(!Et(bt)){var $t=Ct(bt);Wt=Wt==$t[1]?$t[0]:$t[1]}Wt*=60;var Yt=60*Ft(bt),Bt=Dt(bt),Jt=Lt(bt),Zt=function(){return Date.now()/1e3},Rt=function(t){return te("%a %b %d %H:%M:%S %Y",t)},Gt=function(t){return(new Date(t[0],t[1]-1,t[2],t[3],t[4],t[5],0)-0)/1e3},Kt=function(t){return t||(t=Zt()),Rt(Vt(t))},Vt=function(t){return t||(t=Zt()),Xt(t,!0)},Xt=function(t,e){t||(t=Zt());var r=1e3*t;if(wt.setTime(r),e)var n=Tt(wt);else n=It(wt);return n.__getslice__(0,9,1)},Qt=function(t,e){e||(e="%a %b %d %H:%M:%S %Y");for(var r=(i=x([t,e]))[0],n=i[1],_=function(t){var e=function(t){var e=[];if(!t)return x(["",""]);for(var r=0;r<d(t)-1;r++){var n=t[r];if("%"==n)break;e.append(n)}return x(["".join(e),t.__getslice__(r,null,1)])},r=(o=x([null,null,null]))[0],n=o[1],_=o[2];if(t)if("%"==t[0])r=t[1],n=(o=e(t.__getslice__(2,null,1)))[0],_=o[1];else{var o;n=(o=e(t))[0],_=o[1]}return x([r,n,_])},o=J({});r;){var i,u=(i=_(n))[0],a=i[1];if(n=i[2],""==a){var s=null;if(u){var l=-1;"Y"==u?l=4:"a"==u?l=d(Ot[0]):"A"==u?l=d(kt[0]):"b"==u?l=d(At[0]):m(u,x(["d","m","H","M","S"]))&&(l=2),l>-1&&(s=[r.__getslice__(0,l,1),r.__getslice__(l,null,1)])}s||(s=[r,""])}else s=Nt(r,a,1);if(null!=u){if(r=(i=x([s[1],s[0]]))[0],o[u]=i[1],""==n)break}else r=s[1]}var c=[1900,1,1,0,0,0,0,1,-1],f=[],h=!1;for(var[u,p]of o.py_items())if(!m(u,f)&&"p"!=u)if(m(u,xt.py_keys()))c[xt[u]]=g(p);else if(m(u,x(["a","A","b","B"]))&&(p=p.lower()),"m"==u&&(f.append("b"),f.append("B")),"a"==u){if(!m(p,Ot))throw(v=ut("Weekday unknown in your locale")).__cause__=null,v;h=!0,c[6]=Ot.index(p)}else if("A"==u){if(!m(p,kt))throw(v=ut("Weekday unknown in your locale")).__cause__=null,v;h=!0,c[6]=kt.index(p)}else if("b"==u){if(!m(p,At))throw(v=ut("Month unknown in your locale")).__cause__=null,v;c[1]=At.index(p)+1}else if("B"==u){if(!m(p,St))throw(v=ut("Month unknown in your locale")).__cause__=null,v;c[1]=St.index(p)+1}else if("I"==u){var y=(y=o.p||"am").lower();if(12==(p=g(p)))var p=0;else if(p>12){var v;throw(v=ut("time data '"+t+"' does not match format '"+e+"'")).__cause__=null,v}"pm"==y&&(p+=12),c[xt.H]=p}else"y"==u?c[0]=2e3+g(p):"Z"==u&&m(p.lower(),["gmt","utc"])&&(c[-1]=0);var w=new Date(0);return w.setUTCFullYear(c[0]),w.setUTCMonth(c[1]-1),w.setUTCDate(c[2]),w.setUTCHours(c[3]),c[7]=Ut(w,!0),h||(c[6]=w.getUTCDay()-1),c},te=function(t,e){var r=function(t){return t<10?"0"+T(t):t};e||(e=Vt());var n=t;for(var _ of xt.py_keys()){var o="%"+_;if(m(o,n)){var i=r(e[xt[_]]);n=n.py_replace(o,i)}}for(var[_,u,a]of x([x(["b",At,1]),x(["B",St,1]),x(["a",Ot,6]),x(["A",kt,6])])){var s=e[a];1==a&&(s-=1),i=u[s].capitalize(),n=n.py_replace("%"+_,i)}if(m("%p",n)){if(e[3]>11)var l="PM";else l="AM";n=n.py_replace("%p",l)}return m("%y",n)&&(n=n.py_replace("%y",T(e[0]).__getslice__(-2,null,1))),m("%I",n)&&(0==(i=e[3])?i=12:i>12&&(i-=12),n=n.py_replace("%I",r(i))),n},ee="datetime",re=function(t,e){return d(t=T(t))<e?V(G("0",X(e,s(d,null,t))),t):t},ne=function(t,e){return t==e?0:t>e?1:-1},_e=3652059,oe=[-1,31,28,31,30,31,30,31,31,30,31,30,31],ie=[-1],ue=0;for(var ae of oe.__getslice__(1,null,1))ie.append(ue),ue+=ae;var se=function(t){return 0==Z(t,4)&&(0!=Z(t,100)||0==Z(t,400))},le=function(t){var e=t-1;return 365*e+Math.floor(e/4)-Math.floor(e/100)+Math.floor(e/400)},ce=function(t,e){return 2==e&&se(t)?29:oe[e]},fe=function(t,e){return ie[e]+(e>2&&se(t))},he=function(t,e,r){return ce(t,e),le(t)+fe(t,e)+r},me=le(401),pe=le(101),de=le(5),ye=[null,"Jan","Feb","Mar","Apr","May","Jun","Jul","Aug","Sep","Oct","Nov","Dec"],ge=[null,"Mon","Tue","Wed","Thu","Fri","Sat","Sun"],ve=function(t,e,r,n,_,o,i){return x([t,e,r,n,_,o,Z(he(t,e,r)+6,7),fe(t,e)+r,i])},we=function(t,e,r,n){var _="{}:{}:{}".format(re(t,2),re(e,2),re(r,2));return n&&(_+=".{}".format(re(n,6))),_},be=function(t,e,r){for(var n=null,_=null,o=null,i=[],u=(m=x([0,d(e)]))[0],a=m[1];u<a;){var s=e[u];if(u++,"%"==s)if(u<a)if(s=e[u],u++,"f"==s)null===n&&(n="{}".format(re((w="microsecond")in(v=t)?v[w]:v["py_"+w],6))),i.append(n);else if("z"==s){if(null===_&&(_="",h(t,"utcoffset")&&null!==(f=t.utcoffset()))){var c="+";if(f.days<0){var f=-f;c="-"}var m,p=(m=mt(f,xe(l({hours:1}))))[0],y=m[1];y=Math.floor(y/xe(l({minutes:1}))),_="{}{}{}".format(c,re(p,2),re(y,2))}i.append(_)}else if("Z"==s){if(null===o&&(o="",h(t,"tzname"))){var g=t.tzname();null!==g&&(o=g.py_replace("%","%%"))}i.append(o)}else i.append("%"),i.append(s);else i.append("%");else i.append(s)}var v,w;return i="".join(i),te(i,r)},Oe=function(t){if(null!==t&&!O(t,T)){var e=ct("tzinfo.tzname() must return None or string, not '{}'".format(w(t)));throw e.__cause__=null,e}},ke=function(t,e){if(null!==e){var r;if(!O(e,xe))throw(r=ct("tzinfo.{}() must return None or timedelta, not '{}'".format(t,w(e)))).__cause__=null,r;if(e.__mod__(xe(l({minutes:1}))).microseconds||e.microseconds)throw(r=ut("tzinfo.{}() must return a whole number of minutes, got {}".format(t,e))).__cause__=null,r;if(!Q(R(s(xe,null,1)),e)||!Q(e,s(xe,null,1)))throw(r=s(ut,null,s("{}()={}, must be must be strictly between -timedelta(hours=24) and timedelta(hours=24)".format,"{}()={}, must be must be strictly between -timedelta(hours=24) and timedelta(hours=24)",t,e))).__cause__=null,r}},Pe=function(t){var e=w(t);if(e==g)return t;if(e!=y){try{t=t.__int__();try{if(w(t)==g)return t;throw(r=ct("__int__ returned non-int (type {})".format(w(t).__name__))).__cause__=null,r}catch(r){}}catch(r){if(!O(r,lt))throw r}var r;throw(r=ct("an integer is required (got type {})".format(w(t).__name__))).__cause__=null,r}throw(r=ct("integer argument expected, got float")).__cause__=null,r},ze=function(t,e,r){if(t=Pe(t),e=Pe(e),r=Pe(r),!(1<=t&&t<=9999))throw(n=ut("year must be in {}..{}".format(1,9999),t)).__cause__=null,n;if(!(1<=e&&e<=12))throw(n=ut("month must be in 1..12",e)).__cause__=null,n;var n,_=ce(t,e);if(!(1<=r&&r<=_))throw(n=ut("day must be in 1..{}".format(_),r)).__cause__=null,n;return x([t,e,r])},je=function(t,e,r,n){var _;if(t=Pe(t),e=Pe(e),r=Pe(r),n=Pe(n),!(0<=t&&t<=23))throw(_=ut("hour must be in 0..23",t)).__cause__=null,_;if(!(0<=e&&e<=59))throw(_=ut("minute must be in 0..59",e)).__cause__=null,_;if(!(0<=r&&r<=59))throw(_=ut("second must be in 0..59",r)).__cause__=null,_;if(!(0<=n&&n<=999999))throw(_=ut("microsecond must be in 0..999999",n)).__cause__=null,_;return x([t,e,r,n])},Me=function(t){if(null!==t&&!O(t,Ee)){var e=ct("tzinfo argument must be None or of a tzinfo subclass");throw e.__cause__=null,e}},Ae=function(t,e){var r=ct("can't compare '{}' to '{}'".format(w(t).__name__,w(e).__name__));throw r.__cause__=null,r},Se=function(t,e){var r=mt(t,e),n=r[0],_=r[1];return _*=2,((e>0?_>e:_<e)||_==e&&1==Z(n,2))&&n++,n},xe=a("timedelta",[u],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n,_,o,i,u){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=0),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=0),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=0),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=0),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=0),(void 0===i||null!=i&&i.hasOwnProperty("__kwargtrans__"))&&(i=0),(void 0===u||null!=u&&u.hasOwnProperty("__kwargtrans__"))&&(u=0),arguments.length){var a=arguments.length-1;if(arguments[a]&&arguments[a].hasOwnProperty("__kwargtrans__")){var s=arguments[a--];for(var l in s)switch(l){case"self":t=s[l];break;case"days":e=s[l];break;case"seconds":r=s[l];break;case"microseconds":n=s[l];break;case"milliseconds":_=s[l];break;case"minutes":o=s[l];break;case"hours":i=s[l];break;case"weeks":u=s[l]}}}var c=v=0,f=v;if(r+=60*o+3600*i,n+=1e3*_,O(e+=7*u,y)){var h=(v=dt(e))[0],m=(e=v[1],(v=dt(86400*h))[0]);f=g(v[1]),c=g(e)}else m=0,c=e;if(O(r,y)){var p=(v=dt(r))[0];r=g(r=v[1]);p+=m}else p=m;c+=e=(v=mt(r,86400))[0],f+=g(r=v[1]);var d=1e6*p;O(n,y)?(n=j(n+d),r=(v=mt(n,1e6))[0],n=v[1],c+=e=(v=mt(r,86400))[0],f+=r=v[1]):(n=g(n),r=(v=mt(n,1e6))[0],n=v[1],c+=e=(v=mt(r,86400))[0],f+=r=v[1],n=j(n+d)),r=(v=mt(n,1e6))[0];var v,w=v[1];if(e=(v=mt(f+=r,86400))[0],f=v[1],z(c+=e)>999999999){var b=OverflowError(Z("timedelta # of days is too large: %d",c));throw b.__cause__=null,b}t._days=c,t._seconds=f,t._microseconds=w}))},get __repr__(){return _(this,(function(t){return t._microseconds?"datetime.timedelta(days={}, seconds={}, microseconds={})".format(t._days,t._seconds,t._microseconds):t._seconds?"datetime.timedelta(days={}, seconds={})".format(t._days,t._seconds):"datetime.timedelta(days={})".format(t._days)}))},get __str__(){return _(this,(function(t){var e,r,n=(e=mt(t._seconds,60))[0],_=e[1],o=(e=mt(n,60))[0],i=(n=e[1],"{}:{}:{}".format(o,re(n,2),re(_,2)));return t._days&&(i="{} day{}, ".format(x([r=t._days,1!=z(r)?"s":""]))+i),t._microseconds&&(i+=".{}".format(re(t._microseconds,6))),i}))},get total_seconds(){return _(this,(function(t){return((86400*t.days+t.seconds)*Math.pow(10,6)+t.microseconds)/Math.pow(10,6)}))},get _get_days(){return _(this,(function(t){return t._days}))},get _get_seconds(){return _(this,(function(t){return t._seconds}))},get _get_microseconds(){return _(this,(function(t){return t._microseconds}))},get __add__(){return _(this,(function(t,e){return O(e,xe)?xe(t._days+e._days,t._seconds+e._seconds,t._microseconds+e._microseconds):NotImplemented}))},get __radd__(){return _(this,(function(t,e){return t.__add__(e)}))},get __sub__(){return _(this,(function(t,e){return O(e,xe)?xe(t._days-e._days,t._seconds-e._seconds,t._microseconds-e._microseconds):NotImplemented}))},get __rsub__(){return _(this,(function(t,e){return O(e,xe)?-t+e:NotImplemented}))},get __neg__(){return _(this,(function(t){return xe(-t._days,-t._seconds,-t._microseconds)}))},get __pos__(){return _(this,(function(t){return t}))},get __abs__(){return _(this,(function(t){return t._days<0?R(t):t}))},get __mul__(){return _(this,(function(t,e){if(O(e,g))return xe(t._days*e,t._seconds*e,t._microseconds*e);if(O(e,y)){var r=t._to_microseconds(),n=e.as_integer_ratio(),_=n[0],o=n[1];return xe(0,0,Se(r*_,o))}return NotImplemented}))},get __rmul__(){return _(this,(function(t,e){return t.__mul__(e)}))},get _to_microseconds(){return _(this,(function(t){return 1e6*(86400*t._days+t._seconds)+t._microseconds}))},get __floordiv__(){return _(this,(function(t,e){if(!O(e,x([g,xe])))return NotImplemented;var r=t._to_microseconds();return O(e,xe)?Math.floor(r/e._to_microseconds()):O(e,g)?xe(0,0,Math.floor(r/e)):void 0}))},get __truediv__(){return _(this,(function(t,e){if(!O(e,x([g,y,xe])))return NotImplemented;var r=t._to_microseconds();if(O(e,xe))return r/e._to_microseconds();if(O(e,g))return xe(0,0,Se(r,e));if(O(e,y)){var n=e.as_integer_ratio(),_=n[0],o=n[1];return xe(0,0,Se(o*r,_))}}))},get __mod__(){return _(this,(function(t,e){if(O(e,xe)){var r=Z(t._to_microseconds(),e._to_microseconds());return xe(0,0,r)}return NotImplemented}))},get __divmod__(){return _(this,(function(t,e){if(O(e,xe)){var r=mt(t._to_microseconds(),e._to_microseconds()),n=r[0],_=r[1];return x([n,xe(0,0,_)])}return NotImplemented}))},get __eq__(){return _(this,(function(t,e){return!!O(e,xe)&&0==t._cmp(e)}))},get __le__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)<=0;Ae(t,e)}))},get __lt__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)<0;Ae(t,e)}))},get __ge__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)>=0;Ae(t,e)}))},get __gt__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)>0;Ae(t,e)}))},get _cmp(){return _(this,(function(t,e){return ne(t._to_microseconds(),e._to_microseconds())}))},get __bool__(){return _(this,(function(t){return 0!=t._days||0!=t._seconds||0!=t._microseconds}))}});Object.defineProperty(xe,"microseconds",c.call(xe,xe._get_microseconds)),Object.defineProperty(xe,"seconds",c.call(xe,xe._get_seconds)),Object.defineProperty(xe,"days",c.call(xe,xe._get_days));var Ne=xe(-999999999),Te=xe(l({days:999999999,hours:23,minutes:59,seconds:59,microseconds:999999})),Ie=xe(l({microseconds:1}));Object.defineProperty(xe,"min",{get:function(){return Ne}}),Object.defineProperty(xe,"max",{get:function(){return Te}}),Object.defineProperty(xe,"resolution",{get:function(){return Ie}});var Ue=a("date",[u],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),arguments.length){var _=arguments.length-1;if(arguments[_]&&arguments[_].hasOwnProperty("__kwargtrans__")){var o=arguments[_--];for(var i in o)switch(i){case"self":t=o[i];break;case"year":e=o[i];break;case"month":r=o[i];break;case"day":n=o[i]}}}var u=ze(e,r,n);e=u[0],r=u[1],n=u[2],t._year=e,t._month=r,t._day=n}))},get fromtimestamp(){return o(this,(function(t,e){var r=Vt(e),n=r[0],_=r[1],o=r[2];return r[3],r[4],r[5],r[6],r[7],r[8],t(n,_,o)}))},get today(){return o(this,(function(t){var e=Zt();return t.fromtimestamp(e)}))},get fromordinal(){return o(this,(function(t,e){var r=function(t){t--;var e,r=(e=mt(t,me))[0],n=(t=e[1],400*r+1),_=(e=mt(t,pe))[0],o=(t=e[1],(e=mt(t,de))[0]),i=(t=e[1],(e=mt(t,365))[0]);if(t=e[1],n+=100*_+4*o+i,4==i||4==_)return x([n-1,12,31]);var u=3==i&&(24!=o||3==_),a=t+50>>5,s=ie[a]+(a>2&&u);return s>t&&(a--,s-=oe[a]+(2==a&&u)),x([n,a,1+(t-=s)])}(e);return t(r[0],r[1],r[2])}))},get __repr__(){return _(this,(function(t){return"datetime.date({}, {}, {})".format(t._year,t._month,t._day)}))},get ctime(){return _(this,(function(t){var e=Z(t.toordinal(),7)||7;return"{} {} {} 00:00:00 {}".format(ge[e],ye[t._month],function(t,e){return d(t=T(t))<2?V(G(" ",X(2,s(d,null,t))),t):t}(t._day),re(t._year,4))}))},get strftime(){return _(this,(function(t,e){return be(t,e,t.timetuple())}))},get __format__(){return _(this,(function(t,e){if(!O(e,T)){var r=ct("must be str, not {}".format(w(e).__name__));throw r.__cause__=null,r}return 0!=d(e)?t.strftime(e):T(t)}))},get isoformat(){return _(this,(function(t){return"{}-{}-{}".format(re(t._year,4),re(t._month,2),re(t._day,2))}))},get __str__(){return _(this,(function(t){return t.isoformat()}))},get _get_year(){return _(this,(function(t){return t._year}))},get _get_month(){return _(this,(function(t){return t._month}))},get _get_day(){return _(this,(function(t){return t._day}))},get timetuple(){return _(this,(function(t){return ve(t._year,t._month,t._day,0,0,0,-1)}))},get toordinal(){return _(this,(function(t){return he(t._year,t._month,t._day)}))},get py_replace(){return _(this,(function(t,e,r,n){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),arguments.length){var _=arguments.length-1;if(arguments[_]&&arguments[_].hasOwnProperty("__kwargtrans__")){var o=arguments[_--];for(var i in o)switch(i){case"self":t=o[i];break;case"year":e=o[i];break;case"month":r=o[i];break;case"day":n=o[i]}}}return null===e&&(e=t._year),null===r&&(r=t._month),null===n&&(n=t._day),Ue(e,r,n)}))},get __eq__(){return _(this,(function(t,e){return O(e,Ue)?0==t._cmp(e):NotImplemented}))},get __le__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)<=0:NotImplemented}))},get __lt__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)<0:NotImplemented}))},get __ge__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)>=0:NotImplemented}))},get __gt__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)>0:NotImplemented}))},get _cmp(){return _(this,(function(t,e){var r,n=(r=x([t._year,t._month,t._day]))[0],_=r[1],o=r[2],i=(r=x([e._year,e._month,e._day]))[0],u=r[1],a=r[2];return ne("{}{}{}".format(re(n,4),re(_,2),re(o,2)),"{}{}{}".format(re(i,4),re(u,2),re(a,2)))}))},get __add__(){return _(this,(function(t,e){if(O(e,xe)){var r=t.toordinal()+e.days;if(0<r&&r<=_e)return Ue.fromordinal(r);var n=OverflowError("result out of range");throw n.__cause__=null,n}return NotImplemented}))},get __radd__(){return _(this,(function(t,e){return t.__add__(e)}))},get __sub__(){return _(this,(function(t,e){if(O(e,xe))return V(t,s(xe,null,R(e.days)));if(O(e,Ue)){var r=t.toordinal(),n=e.toordinal();return s(xe,null,X(r,n))}return NotImplemented}))},get weekday(){return _(this,(function(t){return Z(t.toordinal()+6,7)}))},get isoweekday(){return _(this,(function(t){return Z(t.toordinal(),7)||7}))},get isocalendar(){return _(this,(function(t){var e,r=t._year,n=Je(r),_=he(t._year,t._month,t._day),o=(e=mt(_-n,7))[0],i=e[1];o<0?(r--,n=Je(r),o=(e=mt(_-n,7))[0],i=e[1]):o>=52&&_>=Je(r+1)&&(r++,o=0);return x([r,o+1,i+1])}))},resolution:xe(l({days:1}))});Object.defineProperty(Ue,"day",c.call(Ue,Ue._get_day)),Object.defineProperty(Ue,"month",c.call(Ue,Ue._get_month)),Object.defineProperty(Ue,"year",c.call(Ue,Ue._get_year));var qe=Ue,Ce=Ue(1,1,1),De=Ue(9999,12,31);Object.defineProperty(Ue,"min",{get:function(){return Ce}}),Object.defineProperty(Ue,"max",{get:function(){return De}});var Ee=a("tzinfo",[u],{__module__:ee,get tzname(){return _(this,(function(t,e){var r=st("tzinfo subclass must override tzname()");throw r.__cause__=null,r}))},get utcoffset(){return _(this,(function(t,e){var r=st("tzinfo subclass must override utcoffset()");throw r.__cause__=null,r}))},get dst(){return _(this,(function(t,e){var r=st("tzinfo subclass must override dst()");throw r.__cause__=null,r}))},get fromutc(){return _(this,(function(t,e){if(!O(e,$e))throw(_=ct("fromutc() requires a datetime argument")).__cause__=null,_;if(e.tzinfo!==t)throw(_=ut("dt.tzinfo is not self")).__cause__=null,_;var r=e.utcoffset();if(null===r)throw(_=ut("fromutc() requires a non-None utcoffset() result")).__cause__=null,_;if(null===(n=e.dst()))throw(_=ut("fromutc() requires a non-None dst() result")).__cause__=null,_;var n,_,o=r-n;if(o&&null===(n=(e+=o).dst()))throw(_=ut("fromutc(): dt.dst gave inconsistent results; cannot convert")).__cause__=null,_;return e+n}))}}),Fe=a("time",[u],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n,_,o){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=0),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=0),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=0),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=0),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=null),arguments.length){var i=arguments.length-1;if(arguments[i]&&arguments[i].hasOwnProperty("__kwargtrans__")){var u=arguments[i--];for(var a in u)switch(a){case"self":t=u[a];break;case"hour":e=u[a];break;case"minute":r=u[a];break;case"second":n=u[a];break;case"microsecond":_=u[a];break;case"tzinfo":o=u[a]}}}var s=je(e,r,n,_);e=s[0],r=s[1],n=s[2],_=s[3],Me(o),t._hour=e,t._minute=r,t._second=n,t._microsecond=_,t._tzinfo=o}))},get _get_hour(){return _(this,(function(t){return t._hour}))},get _get_minute(){return _(this,(function(t){return t._minute}))},get _get_second(){return _(this,(function(t){return t._second}))},get _get_microsecond(){return _(this,(function(t){return t._microsecond}))},get _get_tzinfo(){return _(this,(function(t){return t._tzinfo}))},get __eq__(){return _(this,(function(t,e){return!!O(e,Fe)&&0==t._cmp(e,l({allow_mixed:!0}))}))},get __le__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)<=0;Ae(t,e)}))},get __lt__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)<0;Ae(t,e)}))},get __ge__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)>=0;Ae(t,e)}))},get __gt__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)>0;Ae(t,e)}))},get _cmp(){return _(this,(function(t,e,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=!1),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"other":e=_[o];break;case"allow_mixed":r=_[o]}}}var i=null,u=null;if(t._tzinfo===e._tzinfo)var a=!0;else a=(i=t.utcoffset())==(u=e.utcoffset());if(a)return ne(x([t._hour,t._minute,t._second,t._microsecond]),x([e._hour,e._minute,e._second,e._microsecond]));if(null===i||null===u){if(r)return 2;var c=ct("cannot compare naive and aware times");throw c.__cause__=null,c}var f=X(V(G(t._hour,60),t._minute),K(i,s(xe,null,l({minutes:1})))),h=X(V(G(e._hour,60),e._minute),K(u,s(xe,null,l({minutes:1}))));return ne(x([f,t._second,t._microsecond]),x([h,e._second,e._microsecond]))}))},get _tzstr(){return _(this,(function(t,e){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=":"),null!==(n=t.utcoffset())){if(n.days<0)var r="-",n=-n;else r="+";var _=mt(n,xe(l({hours:1}))),o=_[0],i=_[1];i=Math.floor(i/xe(l({minutes:1}))),n="{}{}{}{}".format(r,re(o,2),e,re(i,2))}return n}))},get __repr__(){return _(this,(function(t){if(0!=t._microsecond)var e=", {}, {}".format(t._second,t._microsecond);else e=0!=t._second?", {}".format(t._second):"";return e="datetime.time({}, {}{})".format(t._hour,t._minute,e),null!==t._tzinfo&&(e=e.__getslice__(0,d(e)-1,1)+", tzinfo={}".format(t._tzinfo.__repr__())+")"),e}))},get isoformat(){return _(this,(function(t){var e=we(t._hour,t._minute,t._second,t._microsecond),r=t._tzstr();return r&&(e+=r),e}))},get __str__(){return _(this,(function(t){return t.isoformat()}))},get strftime(){return _(this,(function(t,e){var r=x([1900,1,1,t._hour,t._minute,t._second,0,1,-1]);return be(t,e,r)}))},get __format__(){return _(this,(function(t,e){if(!O(e,T)){var r=ct(Z("must be str, not %s",w(e).__name__));throw r.__cause__=null,r}return 0!=d(e)?t.strftime(e):T(t)}))},get utcoffset(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.utcoffset(null);return ke("utcoffset",e),e}))},get tzname(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.tzname(null);return Oe(e),e}))},get dst(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.dst(null);return ke("dst",e),e}))},get py_replace(){return _(this,(function(t,e,r,n,_,o){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=null),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=!0),arguments.length){var i=arguments.length-1;if(arguments[i]&&arguments[i].hasOwnProperty("__kwargtrans__")){var u=arguments[i--];for(var a in u)switch(a){case"self":t=u[a];break;case"hour":e=u[a];break;case"minute":r=u[a];break;case"second":n=u[a];break;case"microsecond":_=u[a];break;case"tzinfo":o=u[a]}}}return null===e&&(e=t.hour),null===r&&(r=t.minute),null===n&&(n=t.second),null===_&&(_=t.microsecond),!0===o&&(o=t.tzinfo),Fe(e,r,n,_,o)}))},resolution:xe(l({microseconds:1}))});Object.defineProperty(Fe,"tzinfo",c.call(Fe,Fe._get_tzinfo)),Object.defineProperty(Fe,"microsecond",c.call(Fe,Fe._get_microsecond)),Object.defineProperty(Fe,"second",c.call(Fe,Fe._get_second)),Object.defineProperty(Fe,"minute",c.call(Fe,Fe._get_minute)),Object.defineProperty(Fe,"hour",c.call(Fe,Fe._get_hour));var He=Fe,Le=Fe(0,0,0),We=Fe(23,59,59,999999);Object.defineProperty(Fe,"min",{get:function(){return Le}}),Object.defineProperty(Fe,"max",{get:function(){return We}});var $e=a("datetime",[Ue],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n,_,o,i,u,a){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=0),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=0),(void 0===i||null!=i&&i.hasOwnProperty("__kwargtrans__"))&&(i=0),(void 0===u||null!=u&&u.hasOwnProperty("__kwargtrans__"))&&(u=0),(void 0===a||null!=a&&a.hasOwnProperty("__kwargtrans__"))&&(a=null),arguments.length){var s=arguments.length-1;if(arguments[s]&&arguments[s].hasOwnProperty("__kwargtrans__")){var l=arguments[s--];for(var c in l)switch(c){case"self":t=l[c];break;case"year":e=l[c];break;case"month":r=l[c];break;case"day":n=l[c];break;case"hour":_=l[c];break;case"minute":o=l[c];break;case"second":i=l[c];break;case"microsecond":u=l[c];break;case"tzinfo":a=l[c]}}}var f;e=(f=ze(e,r,n))[0],r=f[1],n=f[2],_=(f=je(_,o,i,u))[0],o=f[1],i=f[2],u=f[3],Me(a),t._year=e,t._month=r,t._day=n,t._hour=_,t._minute=o,t._second=i,t._microsecond=u,t._tzinfo=a}))},get _get_hour(){return _(this,(function(t){return t._hour}))},get _get_minute(){return _(this,(function(t){return t._minute}))},get _get_second(){return _(this,(function(t){return t._second}))},get _get_microsecond(){return _(this,(function(t){return t._microsecond}))},get _get_tzinfo(){return _(this,(function(t){return t._tzinfo}))},get _fromtimestamp(){return o(this,(function(t,e,r,n){var _=(i=dt(e))[0],o=(e=i[1],j(1e6*_));o>=1e6?(e++,o-=1e6):o<0&&(e--,o+=1e6);var i,u=(i=(r?Xt:Vt)(e))[0],a=i[1],s=i[2],l=i[3],c=i[4],f=i[5];return i[6],i[7],i[8],t(u,a,s,l,c,f=P(f,59),o,n)}))},get fromtimestamp(){return o(this,(function(t,e,r){(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),Me(r);var n=t._fromtimestamp(e,null!==r,r);return null!==r&&(n=r.fromutc(n)),n}))},get utcfromtimestamp(){return o(this,(function(t,e){return t._fromtimestamp(e,!0,null)}))},get now(){return o(this,(function(t,e){(void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null);var r=Zt();return t.fromtimestamp(r,e)}))},get utcnow(){return o(this,(function(t){var e=Zt();return t.utcfromtimestamp(e)}))},get combine(){return o(this,(function(t,e,r){var n;if(!O(e,qe))throw(n=ct("date argument must be a date instance")).__cause__=null,n;if(!O(r,He))throw(n=ct("time argument must be a time instance")).__cause__=null,n;return t(e.year,e.month,e.day,r.hour,r.minute,r.second,r.microsecond,r.tzinfo)}))},get timetuple(){return _(this,(function(t){if(null===(e=t.dst()))var e=-1;else e=e?1:0;return ve(t.year,t.month,t.day,t.hour,t.minute,t.second,e)}))},get timestamp(){return _(this,(function(t){return null===t._tzinfo?Gt(x([t.year,t.month,t.day,t.hour,t.minute,t.second,-1,-1,-1]))+t.microsecond/1e6:s((e=X(t,Xe)).total_seconds,e);var e}))},get utctimetuple(){return _(this,(function(t){var e=t.utcoffset();e&&(t=s(rt,null,t,e));var r,n=(r=x([t.year,t.month,t.day]))[0],_=r[1],o=r[2],i=(r=x([t.hour,t.minute,t.second]))[0],u=r[1],a=r[2];return ve(n,_,o,i,u,a,0)}))},get date(){return _(this,(function(t){return Ue(t._year,t._month,t._day)}))},get time(){return _(this,(function(t){return Fe(t.hour,t.minute,t.second,t.microsecond)}))},get timetz(){return _(this,(function(t){return Fe(t.hour,t.minute,t.second,t.microsecond,t._tzinfo)}))},get py_replace(){return _(this,(function(t,e,r,n,_,o,i,u,a){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=null),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=null),(void 0===i||null!=i&&i.hasOwnProperty("__kwargtrans__"))&&(i=null),(void 0===u||null!=u&&u.hasOwnProperty("__kwargtrans__"))&&(u=null),(void 0===a||null!=a&&a.hasOwnProperty("__kwargtrans__"))&&(a=!0),arguments.length){var s=arguments.length-1;if(arguments[s]&&arguments[s].hasOwnProperty("__kwargtrans__")){var l=arguments[s--];for(var c in l)switch(c){case"self":t=l[c];break;case"year":e=l[c];break;case"month":r=l[c];break;case"day":n=l[c];break;case"hour":_=l[c];break;case"minute":o=l[c];break;case"second":i=l[c];break;case"microsecond":u=l[c];break;case"tzinfo":a=l[c]}}}return null===e&&(e=t.year),null===r&&(r=t.month),null===n&&(n=t.day),null===_&&(_=t.hour),null===o&&(o=t.minute),null===i&&(i=t.second),null===u&&(u=t.microsecond),!0===a&&(a=t.tzinfo),$e(e,r,n,_,o,i,u,a)}))},get astimezone(){return _(this,(function(t,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"tz":r=_[o]}}}if(null===r){if(null===t.tzinfo)throw(v=ut("astimezone() requires an aware datetime")).__cause__=null,v;var i=K(X(t,Xe),s(xe,null,l({seconds:1}))),u=Vt(i),a=$e(...u.__getslice__(0,6,1));if(d(u)>9){var c=u[10],f=u[9];r=Re(xe(l({seconds:c})),f)}else{var h=X(a,s($e,null,...(y=s(e.gmtime,e,i),0,6,1,"object"==typeof y&&"__getitem__"in y?y.__getitem__([0,6,1]):y.__getslice__(0,6,1)))),m=Bt&&(0,"object"==typeof(p=nt(u,8))&&"__gt__"in p?p.__gt__(0):p>0);c=R(m?Wt:Yt),r=function(t,e){return"object"==typeof t&&"__eq__"in t?t.__eq__(e):t==e}(h,s(xe,null,l({seconds:c})))?s(Re,null,h,nt(Jt,m)):s(Re,null,h)}}else if(!O(r,Ee))throw(v=ct("tz argument must be an instance of tzinfo")).__cause__=null,v;var p,y,g=t.tzinfo;if(null===g)throw(v=ut("astimezone() requires an aware datetime")).__cause__=null,v;if(r===g)return t;var v,w=t.utcoffset();if(null===w)throw(v=ut("astimezone() requires an aware datetime")).__cause__=null,v;var b,k=s((b=X(t,w)).py_replace,b,l({tzinfo:r}));return r.fromutc(k)}))},get ctime(){return _(this,(function(t){var e=Z(t.toordinal(),7)||7;return"{} {} {} {}:{}:{} {}".format(ge[e],ye[t._month],re(t._day,2),re(t._hour,2),re(t._minute,2),re(t._second,2),re(t._year,4))}))},get isoformat(){return _(this,(function(t,e){(void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e="T");var r="{}-{}-{}{}".format(re(t._year,4),re(t._month,2),re(t._day,2),e)+we(t._hour,t._minute,t._second,t._microsecond);if(null!==(_=t.utcoffset())){if(_.days<0)var n="-",_=-_;else n="+";var o=mt(_,xe(l({hours:1}))),i=o[0],u=o[1];u=Math.floor(u/xe(l({minutes:1}))),r+="{}{}:{}".format(n,re(i,2),re(u,2))}return r}))},get __repr__(){return _(this,(function(t){var e=[t._year,t._month,t._day,t._hour,t._minute,t._second,t._microsecond];0==e[d(e)-1]&&e.py_pop(),0==e[d(e)-1]&&e.py_pop();var r,n,_="datetime.datetime({})".format(", ".join((r=T,n=e,function(){var t=[];for(var e of n)t.append(r(e));return t}())));return null!==t._tzinfo&&(_=_.__getslice__(0,d(_)-1,1)+", tzinfo={}".format(t._tzinfo.__repr__())+")"),_}))},get __str__(){return _(this,(function(t){return t.isoformat(l({sep:" "}))}))},get strptime(){return o(this,(function(t,e,r){return t(...Qt(e,r).__getslice__(0,6,1))}))},get utcoffset(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.utcoffset(t);return ke("utcoffset",e),e}))},get tzname(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.tzname(t);return Oe(e),e}))},get dst(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.dst(t);return ke("dst",e),e}))},get __eq__(){return _(this,(function(t,e){return O(e,$e)?0==t._cmp(e,l({allow_mixed:!0})):!O(e,Ue)&&NotImplemented}))},get __le__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)<=0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get __lt__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)<0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get __ge__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)>=0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get __gt__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)>0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get _cmp(){return _(this,(function(t,e,r){(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=!1);var n=null,_=null;if(t._tzinfo===e._tzinfo)var o=!0;else o=(n=t.utcoffset())==(_=e.utcoffset());if(o){var i="{}{}{}{}{}{}{}".format(re(t._year,4),re(t._month,2),re(t._day,2),re(t._hour,2),re(t._minute,2),re(t._second,2),re(t._microsecond,6)),u="{}{}{}{}{}{}{}".format(re(e._year,4),re(e._month,2),re(e._day,2),re(e._hour,2),re(e._minute,2),re(e._second,2),re(e._microsecond,6));return ne(i,u)}if(null===n||null===_){if(r)return 2;var a=ct("cannot compare naive and aware datetimes");throw a.__cause__=null,a}var s=X(t,e);return s.days<0?-1:s?1:0}))},get __add__(){return _(this,(function(t,e){if(!O(e,xe))return NotImplemented;var r,n=s(et,null,n=xe(t.toordinal(),l({hours:t._hour,minutes:t._minute,seconds:t._second,microseconds:t._microsecond})),e),_=(r=mt(n.seconds,3600))[0],o=r[1],i=(r=mt(o,60))[0],u=r[1];if(0<n.days&&n.days<=_e)return $e.combine(Ue.fromordinal(n.days),Fe(_,i,u,n.microseconds,l({tzinfo:t._tzinfo})));var a=OverflowError("result out of range");throw a.__cause__=null,a}))},get __radd__(){return _(this,(function(t,e){return t.__add__(e)}))},get __sub__(){return _(this,(function(t,e){if(!O(e,$e))return O(e,xe)?V(t,R(e)):NotImplemented;var r=t.toordinal(),n=e.toordinal(),_=t._second+60*t._minute+3600*t._hour,o=e._second+60*e._minute+3600*e._hour,i=xe(r-n,_-o,t._microsecond-e._microsecond);if(t._tzinfo===e._tzinfo)return i;var u=t.utcoffset(),a=e.utcoffset();if(u==a)return i;if(null===u||null===a){var s=ct("cannot mix naive and timezone-aware time");throw s.__cause__=null,s}return X(V(i,a),u)}))},resolution:xe(l({microseconds:1}))});Object.defineProperty($e,"tzinfo",c.call($e,$e._get_tzinfo)),Object.defineProperty($e,"microsecond",c.call($e,$e._get_microsecond)),Object.defineProperty($e,"second",c.call($e,$e._get_second)),Object.defineProperty($e,"minute",c.call($e,$e._get_minute)),Object.defineProperty($e,"hour",c.call($e,$e._get_hour));var Ye=$e(1,1,1),Be=$e(9999,12,31,23,59,59,999999);Object.defineProperty($e,"min",{get:function(){return Ye}}),Object.defineProperty($e,"max",{get:function(){return Be}});var Je=function(t){var e=he(t,1,1),r=Z(e+6,7),n=e-r;return r>3&&(n+=7),n},Ze="@#$^&$^",Re=a("timezone",[Ee],{__module__:ee,get __init__(){return _(this,(function(t,e,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=Ze),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"offset":e=_[o];break;case"py_name":r=_[o]}}}if(!O(e,xe))throw(i=ct("offset must be a timedelta")).__cause__=null,i;if(r===Ze)e||(e=t.utc),r=null;else if(!O(r,T)){var i;throw(i=ct("name must be a string")).__cause__=null,i}if(!tt(t._minoffset,e)||!tt(e,t._maxoffset))throw(i=s(ut,null,"offset must be a timedelta strictly between -timedelta(hours=24) and timedelta(hours=24).")).__cause__=null,i;if(0!=e.microseconds||0!=Z(e.seconds,60))throw(i=ut("offset must be a timedelta representing a whole number of minutes")).__cause__=null,i;t._offset=e,t._name=r}))},get _create(){return o(this,(function(t,e,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=Ze),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"cls":t=_[o];break;case"offset":e=_[o];break;case"py_name":r=_[o]}}}return t(e,r)}))},get __eq__(){return _(this,(function(t,e){return w(e)==Re&&t._offset==e._offset}))},get __repr__(){return _(this,(function(t){return t===t.utc?"datetime.timezone.utc":null===t._name?"datetime.timezone({})".format(t._offset.__repr__()):"datetime.timezone({}, {})".format(t._offset.__repr__(),t._name.__repr__())}))},get __str__(){return _(this,(function(t){return t.tzname(null)}))},get utcoffset(){return _(this,(function(t,e){if(O(e,$e)||null===e)return t._offset;var r=ct("utcoffset() argument must be a datetime instance or None");throw r.__cause__=null,r}))},get tzname(){return _(this,(function(t,e){if(O(e,$e)||null===e)return null===t._name?t._name_from_offset(t._offset):t._name;var r=ct("tzname() argument must be a datetime instance or None");throw r.__cause__=null,r}))},get dst(){return _(this,(function(t,e){if(O(e,$e)||null===e)return null;var r=ct("dst() argument must be a datetime instance or None");throw r.__cause__=null,r}))},get fromutc(){return _(this,(function(t,e){if(O(e,$e)){if(e.tzinfo!==t)throw(r=ut("fromutc: dt.tzinfo is not self")).__cause__=null,r;return V(e,t._offset)}var r;throw(r=ct("fromutc() argument must be a datetime instance or None")).__cause__=null,r}))},_maxoffset:xe(l({hours:23,minutes:59})),_minoffset:R(s(xe,null,l({hours:23,minutes:59}))),get _name_from_offset(){return function(t){if(Q(t,s(xe,null,0))){var e="-";t=R(t)}else e="+";var r=s(mt,null,t,s(xe,null,l({hours:1}))),n=r[0],_=K(r[1],s(xe,null,l({minutes:1})));return"UTC{}{}:{}".format(e,re(n,2),re(_,2))}}}),Ge=Re._create(xe(0)),Ke=Re._create(Re._minoffset),Ve=Re._create(Re._maxoffset);Object.defineProperty(Re,"utc",{get:function(){return Ge}}),Object.defineProperty(Re,"min",{get:function(){return Ke}}),Object.defineProperty(Re,"max",{get:function(){return Ve}});var Xe=$e(1970,1,1,l({tzinfo:Re.utc}));addEventListener("fetch",(function(t){return t.respondWith(function(t){var e=0;m("queryStringParameters",t)&&m("IncrementLimit",t.queryStringParameters)?e=g(t.queryStringParameters.py_get("IncrementLimit",0)):m("body",t)&&JSON.parse(t.body).IncrementLimit&&(e=g(JSON.parse(t.body).IncrementLimit)),function(t){for(var e=0;e<t;)e++}(e);var r=JSON.stringify(J({RequestID:"cloudflare-does-not-specify",TimestampChain:[T($e.now())]}));return new Response(r,J({headers:J({"content-type":"application/json"})}))}(t.request))}))})();
\ No newline at end of file
+(()=>{"use strict";var t={d:(e,r)=>{for(var n in r)t.o(r,n)&&!t.o(e,n)&&Object.defineProperty(e,n,{enumerable:!0,get:r[n]})},o:(t,e)=>Object.prototype.hasOwnProperty.call(t,e),r:t=>{"undefined"!=typeof Symbol&&Symbol.toStringTag&&Object.defineProperty(t,Symbol.toStringTag,{value:"Module"}),Object.defineProperty(t,"__esModule",{value:!0})}},e={};t.r(e),t.d(e,{__adapt__:()=>vt,__d:()=>Pt,__date:()=>wt,__debugGetLanguage:()=>gt,__jan_jun_tz:()=>Ct,__lu:()=>xt,__months:()=>At,__months_long:()=>St,__now:()=>bt,__tzn:()=>Ht,__weekdays:()=>Ot,__weekdays_long:()=>kt,_day_of_year:()=>Ut,_daylight:()=>Dt,_daylight_in_effect:()=>Et,_is_leap:()=>qt,_local_time_tuple:()=>Tt,_lsplit:()=>Nt,_timezone:()=>Ft,_tzname:()=>Lt,_utc_time_tuple:()=>It,altzone:()=>Wt,asctime:()=>Rt,ctime:()=>Kt,daylight:()=>Bt,gmtime:()=>Xt,localtime:()=>Vt,mktime:()=>Gt,strftime:()=>te,strptime:()=>Qt,time:()=>Zt,timezone:()=>Yt,tzname:()=>Jt});var r="org.transcrypt.__runtime__",n={};function _(t,e,r){return t&&(t.hasOwnProperty("__class__")||"string"==typeof t||t instanceof String)?(r&&Object.defineProperty(t,r,{value:function(){var r=[].slice.apply(arguments);return e.apply(null,[t].concat(r))},writable:!0,enumerable:!0,configurable:!0}),function(){var r=[].slice.apply(arguments);return e.apply(null,[t.__proxy__?t.__proxy__:t].concat(r))}):e}function o(t,e,r){return t.hasOwnProperty("__class__")?function(){var r=[].slice.apply(arguments);return e.apply(null,[t.__class__].concat(r))}:function(){var r=[].slice.apply(arguments);return e.apply(null,[t].concat(r))}}n.interpreter_name="python",n.transpiler_name="transcrypt",n.executor_name=n.transpiler_name,n.transpiler_version="3.9.0";var i={__name__:"type",__bases__:[],__new__:function(t,e,r,n){for(var _=function(){var t=[].slice.apply(arguments);return _.__new__(t)},o=r.length-1;o>=0;o--){var i=r[o];for(var u in i)null!=(a=Object.getOwnPropertyDescriptor(i,u))&&Object.defineProperty(_,u,a);for(let t of Object.getOwnPropertySymbols(i)){let e=Object.getOwnPropertyDescriptor(i,t);Object.defineProperty(_,t,e)}}for(var u in _.__metaclass__=t,_.__name__=e.startsWith("py_")?e.slice(3):e,_.__bases__=r,n){var a=Object.getOwnPropertyDescriptor(n,u);Object.defineProperty(_,u,a)}for(let t of Object.getOwnPropertySymbols(n)){let e=Object.getOwnPropertyDescriptor(n,t);Object.defineProperty(_,t,e)}return _}};i.__metaclass__=i;var u={__init__:function(t){},__metaclass__:i,__name__:"object",__bases__:[],__new__:function(t){var e=Object.create(this,{__class__:{value:this,enumerable:!0}});return("__getattr__"in this||"__setattr__"in this)&&(e.__proxy__=new Proxy(e,{get:function(t,e){let r=t[e];return null==r?t.__getattr__(e):r},set:function(t,e,r){try{t.__setattr__(e,r)}catch(n){t[e]=r}return!0}}),e=e.__proxy__),this.__init__.apply(null,[e].concat(t)),e}};function a(t,e,r,n){return void 0===n&&(n=e[0].__metaclass__),n.__new__(n,t,e,r)}function s(){var t=[].slice.apply(arguments);return"object"==typeof t[0]&&"__call__"in t[0]?t[0].__call__.apply(t[1],t.slice(2)):t[0].apply(t[1],t.slice(2))}function l(t){return t.__kwargtrans__=null,t.constructor=Object,t}function c(t,e){return e||(e=function(){}),{get:function(){return t(this)},set:function(t){e(this,t)},enumerable:!0}}function f(t,e,r){t.hasOwnProperty(e)||Object.defineProperty(t,e,r)}function h(t,e){try{return e in t||"py_"+e in t}catch(t){return!1}}function m(t,e){return null!=e&&(e.__contains__ instanceof Function?e.__contains__(t):e.indexOf?e.indexOf(t)>-1:e.hasOwnProperty(t))}function p(t){return t.startswith("__")&&t.endswith("__")||"constructor"==t||t.startswith("py_")}function d(t){if(null==t)return 0;if(t.__len__ instanceof Function)return t.__len__();if(void 0!==t.length)return t.length;var e=0;for(var r in t)p(r)||e++;return e}function y(t){if("inf"==t)return 1/0;if("-inf"==t)return-1/0;if("nan"==t)return NaN;if(isNaN(parseFloat(t))){if(!1===t)return 0;if(!0===t)return 1;throw ut("could not convert string to float: '"+T(t)+"'",new Error)}return+t}function g(t){return 0|y(t)}function v(t){return!(null==(e=t)||!(["boolean","number"].indexOf(typeof e)>=0?e:e.__bool__ instanceof Function?e.__bool__()&&e:e.__len__ instanceof Function?0!==e.__len__()&&e:(e instanceof Function||0!==d(e))&&e));var e}function w(t){var e=typeof t;if("object"!=e)return"boolean"==e?v:"string"==e?T:"number"==e?t%1==0?g:y:null;try{return"__class__"in t?t.__class__:u}catch(t){return e}}function b(t,e){if(e instanceof Array){for(let r of e)if(b(t,r))return!0;return!1}try{var r=t;if(r==e)return!0;for(var n=[].slice.call(r.__bases__);n.length;){if((r=n.shift())==e)return!0;r.__bases__.length&&(n=[].slice.call(r.__bases__).concat(n))}return!1}catch(r){return t==e||e==u}}function O(t,e){try{return b("__class__"in t?t.__class__:w(t),e)}catch(r){return b(w(t),e)}}function k(t){try{return t.__repr__()}catch(o){try{return t.__str__()}catch(o){try{if(null==t)return"None";if(t.constructor==Object){var e="{",r=!1;for(var n in t)if(!p(n)){if(n.isnumeric())var _=n;else _="'"+n+"'";r?e+=", ":r=!0,e+=_+": "+k(t[n])}return e+"}"}return"boolean"==typeof t?t.toString().capitalize():t.toString()}catch(e){return"<object of type: "+typeof t+">"}}}}function P(t){return 1==arguments.length?Math.min(...t):Math.min(...arguments)}n.executor_name=n.transpiler_name,y.__name__="float",y.__bases__=[u],g.__name__="int",g.__bases__=[u],v.__name__="bool",v.__bases__=[g];var z=Math.abs;function j(t,e){if(e){var r=Math.pow(10,e);t*=r}var n=Math.round(t);return n-t==.5&&n%2&&(n-=1),e&&(n/=r),n}function M(t){this.iterable=t,this.index=0}function A(t){this.iterable=t,this.index=0}function S(t){return t?Array.from(t):[]}function x(t){let e=t?[].slice.apply(t):[];return e.__class__=x,e}function N(t){let e=[];if(t)for(let r=0;r<t.length;r++)e.add(t[r]);return e.__class__=N,e}function T(t){if("number"==typeof t)return t.toString();try{return t.__str__()}catch(e){try{return k(t)}catch(e){return String(t)}}}function I(t){return this.hasOwnProperty(t)}function U(){var t=[];for(var e in this)p(e)||t.push(e);return t}function q(){var t=[];for(var e in this)p(e)||t.push([e,this[e]]);return t}function C(t){delete this[t]}function D(){for(var t in this)delete this[t]}function E(t,e){var r=this[t];return null==r&&(r=this["py_"+t]),null==r?null==e?null:e:r}function F(t,e){var r=this[t];if(null!=r)return r;var n=null==e?null:e;return this[t]=n,n}function H(t,e){var r=this[t];if(null!=r)return delete this[t],r;if(void 0===e)throw at(t,new Error);return e}function L(){var t=Object.keys(this)[0];if(null==t)throw at("popitem(): dictionary is empty",new Error);var e=x([t,this[t]]);return delete this[t],e}function W(t){for(var e in t)this[e]=t[e]}function $(){var t=[];for(var e in this)p(e)||t.push(this[e]);return t}function Y(t){return this[t]}function B(t,e){this[t]=e}function J(t){var e={};if(!t||t instanceof Array){if(t)for(var r=0;r<t.length;r++){var n=t[r];if(!(n instanceof Array)||2!=n.length)throw ut("dict update sequence element #"+r+" has length "+n.length+"; 2 is required",new Error);var _=n[0],o=n[1];!(t instanceof Array)&&t instanceof Object&&(O(t,J)||(o=J(o))),e[_]=o}}else if(O(t,J)){var i=t.py_keys();for(r=0;r<i.length;r++)e[_=i[r]]=t[_]}else{if(!(t instanceof Object))throw ut("Invalid type of object for dict creation",new Error);e=t}return f(e,"__class__",{value:J,enumerable:!1,writable:!0}),f(e,"__contains__",{value:I,enumerable:!1}),f(e,"py_keys",{value:U,enumerable:!1}),f(e,"__iter__",{value:function(){new M(this.py_keys())},enumerable:!1}),f(e,Symbol.iterator,{value:function(){new A(this.py_keys())},enumerable:!1}),f(e,"py_items",{value:q,enumerable:!1}),f(e,"py_del",{value:C,enumerable:!1}),f(e,"py_clear",{value:D,enumerable:!1}),f(e,"py_get",{value:E,enumerable:!1}),f(e,"py_setdefault",{value:F,enumerable:!1}),f(e,"py_pop",{value:H,enumerable:!1}),f(e,"py_popitem",{value:L,enumerable:!1}),f(e,"py_update",{value:W,enumerable:!1}),f(e,"py_values",{value:$,enumerable:!1}),f(e,"__getitem__",{value:Y,enumerable:!1}),f(e,"__setitem__",{value:B,enumerable:!1}),e}function Z(t,e){return"object"==typeof t&&"__mod__"in t?t.__mod__(e):"object"==typeof e&&"__rmod__"in e?e.__rmod__(t):(t%e+e)%e}function R(t){return"object"==typeof t&&"__neg__"in t?t.__neg__():-t}function G(t,e){return"object"==typeof t&&"__mul__"in t?t.__mul__(e):"object"==typeof e&&"__rmul__"in e?e.__rmul__(t):"string"==typeof t?t.__mul__(e):"string"==typeof e?e.__rmul__(t):t*e}function K(t,e){return"object"==typeof t&&"__floordiv__"in t?t.__floordiv__(e):"object"==typeof e&&"__rfloordiv__"in e?e.__rfloordiv__(t):"object"==typeof t&&"__div__"in t?t.__div__(e):"object"==typeof e&&"__rdiv__"in e?e.__rdiv__(t):Math.floor(t/e)}function V(t,e){return"object"==typeof t&&"__add__"in t?t.__add__(e):"object"==typeof e&&"__radd__"in e?e.__radd__(t):t+e}function X(t,e){return"object"==typeof t&&"__sub__"in t?t.__sub__(e):"object"==typeof e&&"__rsub__"in e?e.__rsub__(t):t-e}function Q(t,e){return"object"==typeof t&&"__lt__"in t?t.__lt__(e):t<e}function tt(t,e){return"object"==typeof t&&"__le__"in t?t.__le__(e):t<=e}function et(t,e){return"object"==typeof t&&"__iadd__"in t?t.__iadd__(e):"object"==typeof t&&"__add__"in t?t.__add__(e):"object"==typeof e&&"__radd__"in e?e.__radd__(t):t+e}function rt(t,e){return"object"==typeof t&&"__isub__"in t?t.__isub__(e):"object"==typeof t&&"__sub__"in t?t.__sub__(e):"object"==typeof e&&"__rsub__"in e?e.__rsub__(t):t-e}function nt(t,e){return"object"==typeof t&&"__getitem__"in t?t.__getitem__(e):("string"==typeof t||t instanceof Array)&&e<0?t[t.length+e]:t[e]}M.prototype.__next__=function(){if(this.index<this.iterable.length)return this.iterable[this.index++];throw it(new Error)},A.prototype.next=function(){return this.index<this.iterable.py_keys.length?{value:this.index++,done:!1}:{value:void 0,done:!0}},Array.prototype.__class__=S,S.__name__="list",S.__bases__=[u],Array.prototype.__iter__=function(){return new M(this)},Array.prototype.__getslice__=function(t,e,r){if(t<0&&(t=this.length+t),null==e?e=this.length:e<0?e=this.length+e:e>this.length&&(e=this.length),1==r)return Array.prototype.slice.call(this,t,e);let n=S([]);for(let _=t;_<e;_+=r)n.push(this[_]);return n},Array.prototype.__setslice__=function(t,e,r,n){if(t<0&&(t=this.length+t),null==e?e=this.length:e<0&&(e=this.length+e),null==r)Array.prototype.splice.apply(this,[t,e-t].concat(n));else{let _=0;for(let o=t;o<e;o+=r)this[o]=n[_++]}},Array.prototype.__repr__=function(){if(this.__class__==N&&!this.length)return"set()";let t=this.__class__&&this.__class__!=S?this.__class__==x?"(":"{":"[";for(let e=0;e<this.length;e++)e&&(t+=", "),t+=k(this[e]);return this.__class__==x&&1==this.length&&(t+=","),t+=this.__class__&&this.__class__!=S?this.__class__==x?")":"}":"]",t},Array.prototype.__str__=Array.prototype.__repr__,Array.prototype.append=function(t){this.push(t)},Array.prototype.py_clear=function(){this.length=0},Array.prototype.extend=function(t){this.push.apply(this,t)},Array.prototype.insert=function(t,e){this.splice(t,0,e)},Array.prototype.remove=function(t){let e=this.indexOf(t);if(-1==e)throw ut("list.remove(x): x not in list",new Error);this.splice(e,1)},Array.prototype.index=function(t){return this.indexOf(t)},Array.prototype.py_pop=function(t){return null==t?this.pop():this.splice(t,1)[0]},Array.prototype.py_sort=function(){ht.apply(null,[this].concat([].slice.apply(arguments)))},Array.prototype.__add__=function(t){return S(this.concat(t))},Array.prototype.__mul__=function(t){let e=this;for(let r=1;r<t;r++)e=e.concat(this);return e},Array.prototype.__rmul__=Array.prototype.__mul__,x.__name__="tuple",x.__bases__=[u],N.__name__="set",N.__bases__=[u],Array.prototype.__bindexOf__=function(t){t+="";let e=0,r=this.length-1;for(;e<=r;){let n=(e+r)/2|0,_=this[n]+"";if(_<t)e=n+1;else{if(!(_>t))return n;r=n-1}}return-1},Array.prototype.add=function(t){-1==this.indexOf(t)&&this.push(t)},Array.prototype.discard=function(t){var e=this.indexOf(t);-1!=e&&this.splice(e,1)},Array.prototype.isdisjoint=function(t){this.sort();for(let e=0;e<t.length;e++)if(-1!=this.__bindexOf__(t[e]))return!1;return!0},Array.prototype.issuperset=function(t){this.sort();for(let e=0;e<t.length;e++)if(-1==this.__bindexOf__(t[e]))return!1;return!0},Array.prototype.issubset=function(t){return N(t.slice()).issuperset(this)},Array.prototype.union=function(t){let e=N(this.slice().sort());for(let r=0;r<t.length;r++)-1==e.__bindexOf__(t[r])&&e.push(t[r]);return e},Array.prototype.intersection=function(t){this.sort();let e=N();for(let r=0;r<t.length;r++)-1!=this.__bindexOf__(t[r])&&e.push(t[r]);return e},Array.prototype.difference=function(t){let e=N(t.slice().sort()),r=N();for(let t=0;t<this.length;t++)-1==e.__bindexOf__(this[t])&&r.push(this[t]);return r},Array.prototype.symmetric_difference=function(t){return this.union(t).difference(this.intersection(t))},Array.prototype.py_update=function(){let t=[].concat.apply(this.slice(),arguments).sort();this.py_clear();for(let e=0;e<t.length;e++)t[e]!=t[e-1]&&this.push(t[e])},Array.prototype.__eq__=function(t){if(this.length!=t.length)return!1;this.__class__==N&&(this.sort(),t.sort());for(let e=0;e<this.length;e++)if(this[e]!=t[e])return!1;return!0},Array.prototype.__ne__=function(t){return!this.__eq__(t)},Array.prototype.__le__=function(t){if(this.__class__==N)return this.issubset(t);for(let e=0;e<this.length;e++){if(this[e]>t[e])return!1;if(this[e]<t[e])return!0}return!0},Array.prototype.__ge__=function(t){if(this.__class__==N)return this.issuperset(t);for(let e=0;e<this.length;e++){if(this[e]<t[e])return!1;if(this[e]>t[e])return!0}return!0},Array.prototype.__lt__=function(t){return this.__class__==N?this.issubset(t)&&!this.issuperset(t):!this.__ge__(t)},Array.prototype.__gt__=function(t){return this.__class__==N?this.issuperset(t)&&!this.issubset(t):!this.__le__(t)},Uint8Array.prototype.__add__=function(t){let e=new Uint8Array(this.length+t.length);return e.set(this),e.set(t,this.length),e},Uint8Array.prototype.__mul__=function(t){let e=new Uint8Array(t*this.length);for(let r=0;r<t;r++)e.set(this,r*this.length);return e},Uint8Array.prototype.__rmul__=Uint8Array.prototype.__mul__,String.prototype.__class__=T,T.__name__="str",T.__bases__=[u],String.prototype.__iter__=function(){new M(this)},String.prototype.__repr__=function(){return(-1==this.indexOf("'")?"'"+this+"'":'"'+this+'"').py_replace("\t","\\t").py_replace("\n","\\n")},String.prototype.__str__=function(){return this},String.prototype.capitalize=function(){return this.charAt(0).toUpperCase()+this.slice(1)},String.prototype.endswith=function(t){if(!(t instanceof Array))return""==t||this.slice(-t.length)==t;for(var e=0;e<t.length;e++)if(this.slice(-t[e].length)==t[e])return!0;return!1},String.prototype.find=function(t,e){return this.indexOf(t,e)},String.prototype.__getslice__=function(t,e,r){t<0&&(t=this.length+t),null==e?e=this.length:e<0&&(e=this.length+e);var n="";if(1==r)n=this.substring(t,e);else for(var _=t;_<e;_+=r)n=n.concat(this.charAt(_));return n},f(String.prototype,"format",{get:function(){return _(this,(function(t){var e=x([].slice.apply(arguments).slice(1)),r=0;return t.replace(/\{(\w*)\}/g,(function(t,n){if(""==n&&(n=r++),n==+n)return void 0===e[n]?t:T(e[n]);for(var _=0;_<e.length;_++)if("object"==typeof e[_]&&void 0!==e[_][n])return T(e[_][n]);return t}))}))},enumerable:!0}),String.prototype.isalnum=function(){return/^[0-9a-zA-Z]{1,}$/.test(this)},String.prototype.isalpha=function(){return/^[a-zA-Z]{1,}$/.test(this)},String.prototype.isdecimal=function(){return/^[0-9]{1,}$/.test(this)},String.prototype.isdigit=function(){return this.isdecimal()},String.prototype.islower=function(){return/^[a-z]{1,}$/.test(this)},String.prototype.isupper=function(){return/^[A-Z]{1,}$/.test(this)},String.prototype.isspace=function(){return/^[\s]{1,}$/.test(this)},String.prototype.isnumeric=function(){return!isNaN(parseFloat(this))&&isFinite(this)},String.prototype.join=function(t){return(t=Array.from(t)).join(this)},String.prototype.lower=function(){return this.toLowerCase()},String.prototype.py_replace=function(t,e,r){return this.split(t,r).join(e)},String.prototype.lstrip=function(){return this.replace(/^\s*/g,"")},String.prototype.rfind=function(t,e){return this.lastIndexOf(t,e)},String.prototype.rsplit=function(t,e){if(null==t||null==t){t=/\s+/;var r=this.strip()}else r=this;if(null==e||-1==e)return r.split(t);var n=r.split(t);if(e<n.length){var _=n.length-e;return[n.slice(0,_).join(t)].concat(n.slice(_))}return n},String.prototype.rstrip=function(){return this.replace(/\s*$/g,"")},String.prototype.py_split=function(t,e){if(null==t||null==t){t=/\s+/;var r=this.strip()}else r=this;if(null==e||-1==e)return r.split(t);var n=r.split(t);return e<n.length?n.slice(0,e).concat([n.slice(e).join(t)]):n},String.prototype.startswith=function(t){if(!(t instanceof Array))return 0==this.indexOf(t);for(var e=0;e<t.length;e++)if(0==this.indexOf(t[e]))return!0;return!1},String.prototype.strip=function(){return this.trim()},String.prototype.upper=function(){return this.toUpperCase()},String.prototype.__mul__=function(t){for(var e="",r=0;r<t;r++)e+=this;return e},String.prototype.__rmul__=String.prototype.__mul__,J.__name__="dict",J.__bases__=[u],f(Function.prototype,"__setdoc__",{value:function(t){return this.__doc__=t,this},enumerable:!1});var _t=a("BaseException",[u],{__module__:r}),ot=a("Exception",[_t],{__module__:r,get __init__(){return _(this,(function(t){var e=J();if(arguments.length){var r=arguments.length-1;if(arguments[r]&&arguments[r].hasOwnProperty("__kwargtrans__")){var n=arguments[r--];for(var _ in n)"self"===_?t=n[_]:e[_]=n[_];delete e.__kwargtrans__}var o=x([].slice.apply(arguments).slice(1,r+1))}else o=x();t.__args__=o,null!=e.error?t.stack=e.error.stack:Error?t.stack=(new Error).stack:t.stack="No stack trace available"}))},get __repr__(){return _(this,(function(t){return d(t.__args__)>1?"{}{}".format(t.__class__.__name__,k(x(t.__args__))):d(t.__args__)?"{}({})".format(t.__class__.__name__,k(t.__args__[0])):"{}()".format(t.__class__.__name__)}))},get __str__(){return _(this,(function(t){return d(t.__args__)>1?T(x(t.__args__)):d(t.__args__)?T(t.__args__[0]):""}))}}),it=(a("IterableError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e){ot.__init__(t,"Can't iterate over non-iterable",l({error:e}))}))}}),a("StopIteration",[ot],{__module__:r,get __init__(){return _(this,(function(t,e){ot.__init__(t,"Iterator exhausted",l({error:e}))}))}})),ut=a("ValueError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),at=a("KeyError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),st=(a("AssertionError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){e?ot.__init__(t,e,l({error:r})):ot.__init__(t,l({error:r}))}))}}),a("NotImplementedError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}})),lt=(a("IndexError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),a("AttributeError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}})),ct=a("py_TypeError",[ot],{__module__:r,get __init__(){return _(this,(function(t,e,r){ot.__init__(t,e,l({error:r}))}))}}),ft=a("Warning",[ot],{__module__:r}),ht=(a("UserWarning",[ft],{__module__:r}),a("DeprecationWarning",[ft],{__module__:r}),a("RuntimeWarning",[ft],{__module__:r}),function(t,e,r){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=!1),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"iterable":t=_[o];break;case"key":e=_[o];break;case"reverse":r=_[o]}}}e?t.sort((function(t,r){if(arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"a":t=_[o];break;case"b":r=_[o]}}}return e(t)>e(r)?1:-1})):t.sort(),r&&t.reverse()}),mt=function(t,e){return x([Math.floor(t/e),Z(t,e)])},pt=a("__Terminal__",[u],{__module__:r,get __init__(){return _(this,(function(t){t.buffer="";try{t.element=document.getElementById("__terminal__")}catch(e){t.element=null}t.element&&(t.element.style.overflowX="auto",t.element.style.boxSizing="border-box",t.element.style.padding="5px",t.element.innerHTML="_")}))},get print(){return _(this,(function(t){var e=" ",r="\n";if(arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"sep":e=_[o];break;case"end":r=_[o]}}var i=x([].slice.apply(arguments).slice(1,n+1))}else i=x();t.buffer="{}{}{}".format(t.buffer,e.join(function(){var t=[];for(var e of i)t.append(T(e));return t}()),r).__getslice__(-4096,null,1),t.element?(t.element.innerHTML=t.buffer.py_replace("\n","<br>").py_replace(" ","&nbsp"),t.element.scrollTop=t.element.scrollHeight):console.log(e.join(function(){var t=[];for(var e of i)t.append(T(e));return t}()))}))},get input(){return _(this,(function(t,e){if(arguments.length){var r=arguments.length-1;if(arguments[r]&&arguments[r].hasOwnProperty("__kwargtrans__")){var n=arguments[r--];for(var _ in n)switch(_){case"self":t=n[_];break;case"question":e=n[_]}}}t.print("{}".format(e),l({end:""}));var o=window.prompt("\n".join(t.buffer.py_split("\n").__getslice__(-8,null,1)));return t.print(o),o}))}})(),dt=(pt.print,pt.input,Math.PI,Math.E,Math.exp,Math.pow,Math.sqrt,Math.sin,Math.cos,Math.tan,Math.asin,Math.acos,Math.atan,Math.atan2,Math.hypot,Math.sinh,Math.cosh,Math.tanh,Math.asinh,Math.acosh,Math.atanh,Math.floor,Math.ceil,Math.trunc,isNaN,function(t){var e=t>=0?1:-1,r=mt(z(t),1),n=r[0];return x([r[1]*e,n*e])});try{var yt=window.navigator.language}catch(t){yt="en-US"}for(var gt=function(){return yt},vt=function(t){yt=t.headers["accept-language"].py_split(",")[0]},wt=new Date(0),bt=new Date,Ot=[],kt=[],Pt=new Date(1467662339080),zt=0;zt<7;zt++){for(var[jt,Mt]of x([x([Ot,"short"]),x([kt,"long"])]))jt.append(Pt.toLocaleString(yt,J({weekday:Mt})).lower());Pt.setDate(Pt.getDate()+1)}var At=[],St=[];for(Pt=new Date(9466812e5),zt=0;zt<12;zt++){for(var[jt,Mt]of x([x([At,"short"]),x([St,"long"])]))jt.append(Pt.toLocaleString(yt,J({month:Mt})).lower());Pt.setMonth(Pt.getMonth()+1)}var xt=J({Y:0,m:1,d:2,H:3,M:4,S:5}),Nt=function(t,e,r){if(0==r)return[t];var n=t.py_split(e);if(!r)return n;var _=n.slice(0,r,1);return d(_)==d(n)||_.append(e.join(n.__getslice__(r,null,1))),_},Tt=function(t){return x([t.getFullYear(),t.getMonth()+1,t.getDate(),t.getHours(),t.getMinutes(),t.getSeconds(),t.getDay()>0?t.getDay()-1:6,Ut(t,!0),Et(t),t.getMilliseconds()])},It=function(t){return x([t.getUTCFullYear(),t.getUTCMonth()+1,t.getUTCDate(),t.getUTCHours(),t.getUTCMinutes(),t.getUTCSeconds(),t.getUTCDay()-1,Ut(t,!1),0,t.getUTCMilliseconds()])},Ut=function(t,e){var r=0;t.getHours()+60*t.getTimezoneOffset()/3600<0&&(r=-1);var n=t.getTime(),_=t.setHours(23);t.setUTCDate(1),t.setUTCMonth(0),t.setUTCHours(0),t.setUTCMinutes(0),t.setUTCSeconds(0);var o=j((_-t)/864e5);if(e||(o+=r),0==o){o=365,t.setTime(t.getTime()-86400);var i=t.getUTCFullYear();qt(i)&&(o=366)}return t.setTime(n),o},qt=function(t){return 0==Z(t,4)&&(0!=Z(t,100)||0==Z(t,400))},Ct=function(t,e){var r=t.getTime();t.setDate(1);var n=[];for(var _ of x([0,6]))t.setMonth(_),e?n.append(e(t)):n.append(t.getTimezoneOffset());return t.setTime(r),n},Dt=function(t){var e=Ct(t);return e[0]!=e[1]?1:0},Et=function(t){var e=Ct(t);return P(e[0],e[1])==t.getTimezoneOffset()?1:0},Ft=function(t){var e=Ct(t);return function(t){return 1==arguments.length?Math.max(...t):Math.max(...arguments)}(e[0],e[1])},Ht=function(t){try{return T(t).py_split("(")[1].py_split(")")[0]}catch(t){return"n.a."}},Lt=function(t){var e=Ht(t),r=[e,e],n=Ct(t,Ht),_=0;for(var o of(Et(t)||(_=1),n))o!=e&&(r[_]=o);return x(r)},Wt=bt.getTimezoneOffset();if(!Et(bt)){var $t=Ct(bt);Wt=Wt==$t[1]?$t[0]:$t[1]}Wt*=60;var Yt=60*Ft(bt),Bt=Dt(bt),Jt=Lt(bt),Zt=function(){return Date.now()/1e3},Rt=function(t){return te("%a %b %d %H:%M:%S %Y",t)},Gt=function(t){return(new Date(t[0],t[1]-1,t[2],t[3],t[4],t[5],0)-0)/1e3},Kt=function(t){return t||(t=Zt()),Rt(Vt(t))},Vt=function(t){return t||(t=Zt()),Xt(t,!0)},Xt=function(t,e){t||(t=Zt());var r=1e3*t;if(wt.setTime(r),e)var n=Tt(wt);else n=It(wt);return n.__getslice__(0,9,1)},Qt=function(t,e){e||(e="%a %b %d %H:%M:%S %Y");for(var r=(i=x([t,e]))[0],n=i[1],_=function(t){var e=function(t){var e=[];if(!t)return x(["",""]);for(var r=0;r<d(t)-1;r++){var n=t[r];if("%"==n)break;e.append(n)}return x(["".join(e),t.__getslice__(r,null,1)])},r=(o=x([null,null,null]))[0],n=o[1],_=o[2];if(t)if("%"==t[0])r=t[1],n=(o=e(t.__getslice__(2,null,1)))[0],_=o[1];else{var o;n=(o=e(t))[0],_=o[1]}return x([r,n,_])},o=J({});r;){var i,u=(i=_(n))[0],a=i[1];if(n=i[2],""==a){var s=null;if(u){var l=-1;"Y"==u?l=4:"a"==u?l=d(Ot[0]):"A"==u?l=d(kt[0]):"b"==u?l=d(At[0]):m(u,x(["d","m","H","M","S"]))&&(l=2),l>-1&&(s=[r.__getslice__(0,l,1),r.__getslice__(l,null,1)])}s||(s=[r,""])}else s=Nt(r,a,1);if(null!=u){if(r=(i=x([s[1],s[0]]))[0],o[u]=i[1],""==n)break}else r=s[1]}var c=[1900,1,1,0,0,0,0,1,-1],f=[],h=!1;for(var[u,p]of o.py_items())if(!m(u,f)&&"p"!=u)if(m(u,xt.py_keys()))c[xt[u]]=g(p);else if(m(u,x(["a","A","b","B"]))&&(p=p.lower()),"m"==u&&(f.append("b"),f.append("B")),"a"==u){if(!m(p,Ot))throw(v=ut("Weekday unknown in your locale")).__cause__=null,v;h=!0,c[6]=Ot.index(p)}else if("A"==u){if(!m(p,kt))throw(v=ut("Weekday unknown in your locale")).__cause__=null,v;h=!0,c[6]=kt.index(p)}else if("b"==u){if(!m(p,At))throw(v=ut("Month unknown in your locale")).__cause__=null,v;c[1]=At.index(p)+1}else if("B"==u){if(!m(p,St))throw(v=ut("Month unknown in your locale")).__cause__=null,v;c[1]=St.index(p)+1}else if("I"==u){var y=(y=o.p||"am").lower();if(12==(p=g(p)))var p=0;else if(p>12){var v;throw(v=ut("time data '"+t+"' does not match format '"+e+"'")).__cause__=null,v}"pm"==y&&(p+=12),c[xt.H]=p}else"y"==u?c[0]=2e3+g(p):"Z"==u&&m(p.lower(),["gmt","utc"])&&(c[-1]=0);var w=new Date(0);return w.setUTCFullYear(c[0]),w.setUTCMonth(c[1]-1),w.setUTCDate(c[2]),w.setUTCHours(c[3]),c[7]=Ut(w,!0),h||(c[6]=w.getUTCDay()-1),c},te=function(t,e){var r=function(t){return t<10?"0"+T(t):t};e||(e=Vt());var n=t;for(var _ of xt.py_keys()){var o="%"+_;if(m(o,n)){var i=r(e[xt[_]]);n=n.py_replace(o,i)}}for(var[_,u,a]of x([x(["b",At,1]),x(["B",St,1]),x(["a",Ot,6]),x(["A",kt,6])])){var s=e[a];1==a&&(s-=1),i=u[s].capitalize(),n=n.py_replace("%"+_,i)}if(m("%p",n)){if(e[3]>11)var l="PM";else l="AM";n=n.py_replace("%p",l)}return m("%y",n)&&(n=n.py_replace("%y",T(e[0]).__getslice__(-2,null,1))),m("%I",n)&&(0==(i=e[3])?i=12:i>12&&(i-=12),n=n.py_replace("%I",r(i))),n},ee="datetime",re=function(t,e){return d(t=T(t))<e?V(G("0",X(e,s(d,null,t))),t):t},ne=function(t,e){return t==e?0:t>e?1:-1},_e=3652059,oe=[-1,31,28,31,30,31,30,31,31,30,31,30,31],ie=[-1],ue=0;for(var ae of oe.__getslice__(1,null,1))ie.append(ue),ue+=ae;var se=function(t){return 0==Z(t,4)&&(0!=Z(t,100)||0==Z(t,400))},le=function(t){var e=t-1;return 365*e+Math.floor(e/4)-Math.floor(e/100)+Math.floor(e/400)},ce=function(t,e){return 2==e&&se(t)?29:oe[e]},fe=function(t,e){return ie[e]+(e>2&&se(t))},he=function(t,e,r){return ce(t,e),le(t)+fe(t,e)+r},me=le(401),pe=le(101),de=le(5),ye=[null,"Jan","Feb","Mar","Apr","May","Jun","Jul","Aug","Sep","Oct","Nov","Dec"],ge=[null,"Mon","Tue","Wed","Thu","Fri","Sat","Sun"],ve=function(t,e,r,n,_,o,i){return x([t,e,r,n,_,o,Z(he(t,e,r)+6,7),fe(t,e)+r,i])},we=function(t,e,r,n){var _="{}:{}:{}".format(re(t,2),re(e,2),re(r,2));return n&&(_+=".{}".format(re(n,6))),_},be=function(t,e,r){for(var n=null,_=null,o=null,i=[],u=(m=x([0,d(e)]))[0],a=m[1];u<a;){var s=e[u];if(u++,"%"==s)if(u<a)if(s=e[u],u++,"f"==s)null===n&&(n="{}".format(re((w="microsecond")in(v=t)?v[w]:v["py_"+w],6))),i.append(n);else if("z"==s){if(null===_&&(_="",h(t,"utcoffset")&&null!==(f=t.utcoffset()))){var c="+";if(f.days<0){var f=-f;c="-"}var m,p=(m=mt(f,xe(l({hours:1}))))[0],y=m[1];y=Math.floor(y/xe(l({minutes:1}))),_="{}{}{}".format(c,re(p,2),re(y,2))}i.append(_)}else if("Z"==s){if(null===o&&(o="",h(t,"tzname"))){var g=t.tzname();null!==g&&(o=g.py_replace("%","%%"))}i.append(o)}else i.append("%"),i.append(s);else i.append("%");else i.append(s)}var v,w;return i="".join(i),te(i,r)},Oe=function(t){if(null!==t&&!O(t,T)){var e=ct("tzinfo.tzname() must return None or string, not '{}'".format(w(t)));throw e.__cause__=null,e}},ke=function(t,e){if(null!==e){var r;if(!O(e,xe))throw(r=ct("tzinfo.{}() must return None or timedelta, not '{}'".format(t,w(e)))).__cause__=null,r;if(e.__mod__(xe(l({minutes:1}))).microseconds||e.microseconds)throw(r=ut("tzinfo.{}() must return a whole number of minutes, got {}".format(t,e))).__cause__=null,r;if(!Q(R(s(xe,null,1)),e)||!Q(e,s(xe,null,1)))throw(r=s(ut,null,s("{}()={}, must be must be strictly between -timedelta(hours=24) and timedelta(hours=24)".format,"{}()={}, must be must be strictly between -timedelta(hours=24) and timedelta(hours=24)",t,e))).__cause__=null,r}},Pe=function(t){var e=w(t);if(e==g)return t;if(e!=y){try{t=t.__int__();try{if(w(t)==g)return t;throw(r=ct("__int__ returned non-int (type {})".format(w(t).__name__))).__cause__=null,r}catch(r){}}catch(r){if(!O(r,lt))throw r}var r;throw(r=ct("an integer is required (got type {})".format(w(t).__name__))).__cause__=null,r}throw(r=ct("integer argument expected, got float")).__cause__=null,r},ze=function(t,e,r){if(t=Pe(t),e=Pe(e),r=Pe(r),!(1<=t&&t<=9999))throw(n=ut("year must be in {}..{}".format(1,9999),t)).__cause__=null,n;if(!(1<=e&&e<=12))throw(n=ut("month must be in 1..12",e)).__cause__=null,n;var n,_=ce(t,e);if(!(1<=r&&r<=_))throw(n=ut("day must be in 1..{}".format(_),r)).__cause__=null,n;return x([t,e,r])},je=function(t,e,r,n){var _;if(t=Pe(t),e=Pe(e),r=Pe(r),n=Pe(n),!(0<=t&&t<=23))throw(_=ut("hour must be in 0..23",t)).__cause__=null,_;if(!(0<=e&&e<=59))throw(_=ut("minute must be in 0..59",e)).__cause__=null,_;if(!(0<=r&&r<=59))throw(_=ut("second must be in 0..59",r)).__cause__=null,_;if(!(0<=n&&n<=999999))throw(_=ut("microsecond must be in 0..999999",n)).__cause__=null,_;return x([t,e,r,n])},Me=function(t){if(null!==t&&!O(t,Ee)){var e=ct("tzinfo argument must be None or of a tzinfo subclass");throw e.__cause__=null,e}},Ae=function(t,e){var r=ct("can't compare '{}' to '{}'".format(w(t).__name__,w(e).__name__));throw r.__cause__=null,r},Se=function(t,e){var r=mt(t,e),n=r[0],_=r[1];return _*=2,((e>0?_>e:_<e)||_==e&&1==Z(n,2))&&n++,n},xe=a("timedelta",[u],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n,_,o,i,u){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=0),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=0),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=0),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=0),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=0),(void 0===i||null!=i&&i.hasOwnProperty("__kwargtrans__"))&&(i=0),(void 0===u||null!=u&&u.hasOwnProperty("__kwargtrans__"))&&(u=0),arguments.length){var a=arguments.length-1;if(arguments[a]&&arguments[a].hasOwnProperty("__kwargtrans__")){var s=arguments[a--];for(var l in s)switch(l){case"self":t=s[l];break;case"days":e=s[l];break;case"seconds":r=s[l];break;case"microseconds":n=s[l];break;case"milliseconds":_=s[l];break;case"minutes":o=s[l];break;case"hours":i=s[l];break;case"weeks":u=s[l]}}}var c=v=0,f=v;if(r+=60*o+3600*i,n+=1e3*_,O(e+=7*u,y)){var h=(v=dt(e))[0],m=(e=v[1],(v=dt(86400*h))[0]);f=g(v[1]),c=g(e)}else m=0,c=e;if(O(r,y)){var p=(v=dt(r))[0];r=g(r=v[1]);p+=m}else p=m;c+=e=(v=mt(r,86400))[0],f+=g(r=v[1]);var d=1e6*p;O(n,y)?(n=j(n+d),r=(v=mt(n,1e6))[0],n=v[1],c+=e=(v=mt(r,86400))[0],f+=r=v[1]):(n=g(n),r=(v=mt(n,1e6))[0],n=v[1],c+=e=(v=mt(r,86400))[0],f+=r=v[1],n=j(n+d)),r=(v=mt(n,1e6))[0];var v,w=v[1];if(e=(v=mt(f+=r,86400))[0],f=v[1],z(c+=e)>999999999){var b=OverflowError(Z("timedelta # of days is too large: %d",c));throw b.__cause__=null,b}t._days=c,t._seconds=f,t._microseconds=w}))},get __repr__(){return _(this,(function(t){return t._microseconds?"datetime.timedelta(days={}, seconds={}, microseconds={})".format(t._days,t._seconds,t._microseconds):t._seconds?"datetime.timedelta(days={}, seconds={})".format(t._days,t._seconds):"datetime.timedelta(days={})".format(t._days)}))},get __str__(){return _(this,(function(t){var e,r,n=(e=mt(t._seconds,60))[0],_=e[1],o=(e=mt(n,60))[0],i=(n=e[1],"{}:{}:{}".format(o,re(n,2),re(_,2)));return t._days&&(i="{} day{}, ".format(x([r=t._days,1!=z(r)?"s":""]))+i),t._microseconds&&(i+=".{}".format(re(t._microseconds,6))),i}))},get total_seconds(){return _(this,(function(t){return((86400*t.days+t.seconds)*Math.pow(10,6)+t.microseconds)/Math.pow(10,6)}))},get _get_days(){return _(this,(function(t){return t._days}))},get _get_seconds(){return _(this,(function(t){return t._seconds}))},get _get_microseconds(){return _(this,(function(t){return t._microseconds}))},get __add__(){return _(this,(function(t,e){return O(e,xe)?xe(t._days+e._days,t._seconds+e._seconds,t._microseconds+e._microseconds):NotImplemented}))},get __radd__(){return _(this,(function(t,e){return t.__add__(e)}))},get __sub__(){return _(this,(function(t,e){return O(e,xe)?xe(t._days-e._days,t._seconds-e._seconds,t._microseconds-e._microseconds):NotImplemented}))},get __rsub__(){return _(this,(function(t,e){return O(e,xe)?-t+e:NotImplemented}))},get __neg__(){return _(this,(function(t){return xe(-t._days,-t._seconds,-t._microseconds)}))},get __pos__(){return _(this,(function(t){return t}))},get __abs__(){return _(this,(function(t){return t._days<0?R(t):t}))},get __mul__(){return _(this,(function(t,e){if(O(e,g))return xe(t._days*e,t._seconds*e,t._microseconds*e);if(O(e,y)){var r=t._to_microseconds(),n=e.as_integer_ratio(),_=n[0],o=n[1];return xe(0,0,Se(r*_,o))}return NotImplemented}))},get __rmul__(){return _(this,(function(t,e){return t.__mul__(e)}))},get _to_microseconds(){return _(this,(function(t){return 1e6*(86400*t._days+t._seconds)+t._microseconds}))},get __floordiv__(){return _(this,(function(t,e){if(!O(e,x([g,xe])))return NotImplemented;var r=t._to_microseconds();return O(e,xe)?Math.floor(r/e._to_microseconds()):O(e,g)?xe(0,0,Math.floor(r/e)):void 0}))},get __truediv__(){return _(this,(function(t,e){if(!O(e,x([g,y,xe])))return NotImplemented;var r=t._to_microseconds();if(O(e,xe))return r/e._to_microseconds();if(O(e,g))return xe(0,0,Se(r,e));if(O(e,y)){var n=e.as_integer_ratio(),_=n[0],o=n[1];return xe(0,0,Se(o*r,_))}}))},get __mod__(){return _(this,(function(t,e){if(O(e,xe)){var r=Z(t._to_microseconds(),e._to_microseconds());return xe(0,0,r)}return NotImplemented}))},get __divmod__(){return _(this,(function(t,e){if(O(e,xe)){var r=mt(t._to_microseconds(),e._to_microseconds()),n=r[0],_=r[1];return x([n,xe(0,0,_)])}return NotImplemented}))},get __eq__(){return _(this,(function(t,e){return!!O(e,xe)&&0==t._cmp(e)}))},get __le__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)<=0;Ae(t,e)}))},get __lt__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)<0;Ae(t,e)}))},get __ge__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)>=0;Ae(t,e)}))},get __gt__(){return _(this,(function(t,e){if(O(e,xe))return t._cmp(e)>0;Ae(t,e)}))},get _cmp(){return _(this,(function(t,e){return ne(t._to_microseconds(),e._to_microseconds())}))},get __bool__(){return _(this,(function(t){return 0!=t._days||0!=t._seconds||0!=t._microseconds}))}});Object.defineProperty(xe,"microseconds",c.call(xe,xe._get_microseconds)),Object.defineProperty(xe,"seconds",c.call(xe,xe._get_seconds)),Object.defineProperty(xe,"days",c.call(xe,xe._get_days));var Ne=xe(-999999999),Te=xe(l({days:999999999,hours:23,minutes:59,seconds:59,microseconds:999999})),Ie=xe(l({microseconds:1}));Object.defineProperty(xe,"min",{get:function(){return Ne}}),Object.defineProperty(xe,"max",{get:function(){return Te}}),Object.defineProperty(xe,"resolution",{get:function(){return Ie}});var Ue=a("date",[u],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),arguments.length){var _=arguments.length-1;if(arguments[_]&&arguments[_].hasOwnProperty("__kwargtrans__")){var o=arguments[_--];for(var i in o)switch(i){case"self":t=o[i];break;case"year":e=o[i];break;case"month":r=o[i];break;case"day":n=o[i]}}}var u=ze(e,r,n);e=u[0],r=u[1],n=u[2],t._year=e,t._month=r,t._day=n}))},get fromtimestamp(){return o(this,(function(t,e){var r=Vt(e),n=r[0],_=r[1],o=r[2];return r[3],r[4],r[5],r[6],r[7],r[8],t(n,_,o)}))},get today(){return o(this,(function(t){var e=Zt();return t.fromtimestamp(e)}))},get fromordinal(){return o(this,(function(t,e){var r=function(t){t--;var e,r=(e=mt(t,me))[0],n=(t=e[1],400*r+1),_=(e=mt(t,pe))[0],o=(t=e[1],(e=mt(t,de))[0]),i=(t=e[1],(e=mt(t,365))[0]);if(t=e[1],n+=100*_+4*o+i,4==i||4==_)return x([n-1,12,31]);var u=3==i&&(24!=o||3==_),a=t+50>>5,s=ie[a]+(a>2&&u);return s>t&&(a--,s-=oe[a]+(2==a&&u)),x([n,a,1+(t-=s)])}(e);return t(r[0],r[1],r[2])}))},get __repr__(){return _(this,(function(t){return"datetime.date({}, {}, {})".format(t._year,t._month,t._day)}))},get ctime(){return _(this,(function(t){var e=Z(t.toordinal(),7)||7;return"{} {} {} 00:00:00 {}".format(ge[e],ye[t._month],function(t,e){return d(t=T(t))<2?V(G(" ",X(2,s(d,null,t))),t):t}(t._day),re(t._year,4))}))},get strftime(){return _(this,(function(t,e){return be(t,e,t.timetuple())}))},get __format__(){return _(this,(function(t,e){if(!O(e,T)){var r=ct("must be str, not {}".format(w(e).__name__));throw r.__cause__=null,r}return 0!=d(e)?t.strftime(e):T(t)}))},get isoformat(){return _(this,(function(t){return"{}-{}-{}".format(re(t._year,4),re(t._month,2),re(t._day,2))}))},get __str__(){return _(this,(function(t){return t.isoformat()}))},get _get_year(){return _(this,(function(t){return t._year}))},get _get_month(){return _(this,(function(t){return t._month}))},get _get_day(){return _(this,(function(t){return t._day}))},get timetuple(){return _(this,(function(t){return ve(t._year,t._month,t._day,0,0,0,-1)}))},get toordinal(){return _(this,(function(t){return he(t._year,t._month,t._day)}))},get py_replace(){return _(this,(function(t,e,r,n){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),arguments.length){var _=arguments.length-1;if(arguments[_]&&arguments[_].hasOwnProperty("__kwargtrans__")){var o=arguments[_--];for(var i in o)switch(i){case"self":t=o[i];break;case"year":e=o[i];break;case"month":r=o[i];break;case"day":n=o[i]}}}return null===e&&(e=t._year),null===r&&(r=t._month),null===n&&(n=t._day),Ue(e,r,n)}))},get __eq__(){return _(this,(function(t,e){return O(e,Ue)?0==t._cmp(e):NotImplemented}))},get __le__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)<=0:NotImplemented}))},get __lt__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)<0:NotImplemented}))},get __ge__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)>=0:NotImplemented}))},get __gt__(){return _(this,(function(t,e){return O(e,Ue)?t._cmp(e)>0:NotImplemented}))},get _cmp(){return _(this,(function(t,e){var r,n=(r=x([t._year,t._month,t._day]))[0],_=r[1],o=r[2],i=(r=x([e._year,e._month,e._day]))[0],u=r[1],a=r[2];return ne("{}{}{}".format(re(n,4),re(_,2),re(o,2)),"{}{}{}".format(re(i,4),re(u,2),re(a,2)))}))},get __add__(){return _(this,(function(t,e){if(O(e,xe)){var r=t.toordinal()+e.days;if(0<r&&r<=_e)return Ue.fromordinal(r);var n=OverflowError("result out of range");throw n.__cause__=null,n}return NotImplemented}))},get __radd__(){return _(this,(function(t,e){return t.__add__(e)}))},get __sub__(){return _(this,(function(t,e){if(O(e,xe))return V(t,s(xe,null,R(e.days)));if(O(e,Ue)){var r=t.toordinal(),n=e.toordinal();return s(xe,null,X(r,n))}return NotImplemented}))},get weekday(){return _(this,(function(t){return Z(t.toordinal()+6,7)}))},get isoweekday(){return _(this,(function(t){return Z(t.toordinal(),7)||7}))},get isocalendar(){return _(this,(function(t){var e,r=t._year,n=Je(r),_=he(t._year,t._month,t._day),o=(e=mt(_-n,7))[0],i=e[1];o<0?(r--,n=Je(r),o=(e=mt(_-n,7))[0],i=e[1]):o>=52&&_>=Je(r+1)&&(r++,o=0);return x([r,o+1,i+1])}))},resolution:xe(l({days:1}))});Object.defineProperty(Ue,"day",c.call(Ue,Ue._get_day)),Object.defineProperty(Ue,"month",c.call(Ue,Ue._get_month)),Object.defineProperty(Ue,"year",c.call(Ue,Ue._get_year));var qe=Ue,Ce=Ue(1,1,1),De=Ue(9999,12,31);Object.defineProperty(Ue,"min",{get:function(){return Ce}}),Object.defineProperty(Ue,"max",{get:function(){return De}});var Ee=a("tzinfo",[u],{__module__:ee,get tzname(){return _(this,(function(t,e){var r=st("tzinfo subclass must override tzname()");throw r.__cause__=null,r}))},get utcoffset(){return _(this,(function(t,e){var r=st("tzinfo subclass must override utcoffset()");throw r.__cause__=null,r}))},get dst(){return _(this,(function(t,e){var r=st("tzinfo subclass must override dst()");throw r.__cause__=null,r}))},get fromutc(){return _(this,(function(t,e){if(!O(e,$e))throw(_=ct("fromutc() requires a datetime argument")).__cause__=null,_;if(e.tzinfo!==t)throw(_=ut("dt.tzinfo is not self")).__cause__=null,_;var r=e.utcoffset();if(null===r)throw(_=ut("fromutc() requires a non-None utcoffset() result")).__cause__=null,_;if(null===(n=e.dst()))throw(_=ut("fromutc() requires a non-None dst() result")).__cause__=null,_;var n,_,o=r-n;if(o&&null===(n=(e+=o).dst()))throw(_=ut("fromutc(): dt.dst gave inconsistent results; cannot convert")).__cause__=null,_;return e+n}))}}),Fe=a("time",[u],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n,_,o){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=0),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=0),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=0),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=0),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=null),arguments.length){var i=arguments.length-1;if(arguments[i]&&arguments[i].hasOwnProperty("__kwargtrans__")){var u=arguments[i--];for(var a in u)switch(a){case"self":t=u[a];break;case"hour":e=u[a];break;case"minute":r=u[a];break;case"second":n=u[a];break;case"microsecond":_=u[a];break;case"tzinfo":o=u[a]}}}var s=je(e,r,n,_);e=s[0],r=s[1],n=s[2],_=s[3],Me(o),t._hour=e,t._minute=r,t._second=n,t._microsecond=_,t._tzinfo=o}))},get _get_hour(){return _(this,(function(t){return t._hour}))},get _get_minute(){return _(this,(function(t){return t._minute}))},get _get_second(){return _(this,(function(t){return t._second}))},get _get_microsecond(){return _(this,(function(t){return t._microsecond}))},get _get_tzinfo(){return _(this,(function(t){return t._tzinfo}))},get __eq__(){return _(this,(function(t,e){return!!O(e,Fe)&&0==t._cmp(e,l({allow_mixed:!0}))}))},get __le__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)<=0;Ae(t,e)}))},get __lt__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)<0;Ae(t,e)}))},get __ge__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)>=0;Ae(t,e)}))},get __gt__(){return _(this,(function(t,e){if(O(e,Fe))return t._cmp(e)>0;Ae(t,e)}))},get _cmp(){return _(this,(function(t,e,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=!1),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"other":e=_[o];break;case"allow_mixed":r=_[o]}}}var i=null,u=null;if(t._tzinfo===e._tzinfo)var a=!0;else a=(i=t.utcoffset())==(u=e.utcoffset());if(a)return ne(x([t._hour,t._minute,t._second,t._microsecond]),x([e._hour,e._minute,e._second,e._microsecond]));if(null===i||null===u){if(r)return 2;var c=ct("cannot compare naive and aware times");throw c.__cause__=null,c}var f=X(V(G(t._hour,60),t._minute),K(i,s(xe,null,l({minutes:1})))),h=X(V(G(e._hour,60),e._minute),K(u,s(xe,null,l({minutes:1}))));return ne(x([f,t._second,t._microsecond]),x([h,e._second,e._microsecond]))}))},get _tzstr(){return _(this,(function(t,e){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=":"),null!==(n=t.utcoffset())){if(n.days<0)var r="-",n=-n;else r="+";var _=mt(n,xe(l({hours:1}))),o=_[0],i=_[1];i=Math.floor(i/xe(l({minutes:1}))),n="{}{}{}{}".format(r,re(o,2),e,re(i,2))}return n}))},get __repr__(){return _(this,(function(t){if(0!=t._microsecond)var e=", {}, {}".format(t._second,t._microsecond);else e=0!=t._second?", {}".format(t._second):"";return e="datetime.time({}, {}{})".format(t._hour,t._minute,e),null!==t._tzinfo&&(e=e.__getslice__(0,d(e)-1,1)+", tzinfo={}".format(t._tzinfo.__repr__())+")"),e}))},get isoformat(){return _(this,(function(t){var e=we(t._hour,t._minute,t._second,t._microsecond),r=t._tzstr();return r&&(e+=r),e}))},get __str__(){return _(this,(function(t){return t.isoformat()}))},get strftime(){return _(this,(function(t,e){var r=x([1900,1,1,t._hour,t._minute,t._second,0,1,-1]);return be(t,e,r)}))},get __format__(){return _(this,(function(t,e){if(!O(e,T)){var r=ct(Z("must be str, not %s",w(e).__name__));throw r.__cause__=null,r}return 0!=d(e)?t.strftime(e):T(t)}))},get utcoffset(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.utcoffset(null);return ke("utcoffset",e),e}))},get tzname(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.tzname(null);return Oe(e),e}))},get dst(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.dst(null);return ke("dst",e),e}))},get py_replace(){return _(this,(function(t,e,r,n,_,o){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=null),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=!0),arguments.length){var i=arguments.length-1;if(arguments[i]&&arguments[i].hasOwnProperty("__kwargtrans__")){var u=arguments[i--];for(var a in u)switch(a){case"self":t=u[a];break;case"hour":e=u[a];break;case"minute":r=u[a];break;case"second":n=u[a];break;case"microsecond":_=u[a];break;case"tzinfo":o=u[a]}}}return null===e&&(e=t.hour),null===r&&(r=t.minute),null===n&&(n=t.second),null===_&&(_=t.microsecond),!0===o&&(o=t.tzinfo),Fe(e,r,n,_,o)}))},resolution:xe(l({microseconds:1}))});Object.defineProperty(Fe,"tzinfo",c.call(Fe,Fe._get_tzinfo)),Object.defineProperty(Fe,"microsecond",c.call(Fe,Fe._get_microsecond)),Object.defineProperty(Fe,"second",c.call(Fe,Fe._get_second)),Object.defineProperty(Fe,"minute",c.call(Fe,Fe._get_minute)),Object.defineProperty(Fe,"hour",c.call(Fe,Fe._get_hour));var He=Fe,Le=Fe(0,0,0),We=Fe(23,59,59,999999);Object.defineProperty(Fe,"min",{get:function(){return Le}}),Object.defineProperty(Fe,"max",{get:function(){return We}});var $e=a("datetime",[Ue],{__module__:ee,get __init__(){return _(this,(function(t,e,r,n,_,o,i,u,a){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=0),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=0),(void 0===i||null!=i&&i.hasOwnProperty("__kwargtrans__"))&&(i=0),(void 0===u||null!=u&&u.hasOwnProperty("__kwargtrans__"))&&(u=0),(void 0===a||null!=a&&a.hasOwnProperty("__kwargtrans__"))&&(a=null),arguments.length){var s=arguments.length-1;if(arguments[s]&&arguments[s].hasOwnProperty("__kwargtrans__")){var l=arguments[s--];for(var c in l)switch(c){case"self":t=l[c];break;case"year":e=l[c];break;case"month":r=l[c];break;case"day":n=l[c];break;case"hour":_=l[c];break;case"minute":o=l[c];break;case"second":i=l[c];break;case"microsecond":u=l[c];break;case"tzinfo":a=l[c]}}}var f;e=(f=ze(e,r,n))[0],r=f[1],n=f[2],_=(f=je(_,o,i,u))[0],o=f[1],i=f[2],u=f[3],Me(a),t._year=e,t._month=r,t._day=n,t._hour=_,t._minute=o,t._second=i,t._microsecond=u,t._tzinfo=a}))},get _get_hour(){return _(this,(function(t){return t._hour}))},get _get_minute(){return _(this,(function(t){return t._minute}))},get _get_second(){return _(this,(function(t){return t._second}))},get _get_microsecond(){return _(this,(function(t){return t._microsecond}))},get _get_tzinfo(){return _(this,(function(t){return t._tzinfo}))},get _fromtimestamp(){return o(this,(function(t,e,r,n){var _=(i=dt(e))[0],o=(e=i[1],j(1e6*_));o>=1e6?(e++,o-=1e6):o<0&&(e--,o+=1e6);var i,u=(i=(r?Xt:Vt)(e))[0],a=i[1],s=i[2],l=i[3],c=i[4],f=i[5];return i[6],i[7],i[8],t(u,a,s,l,c,f=P(f,59),o,n)}))},get fromtimestamp(){return o(this,(function(t,e,r){(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),Me(r);var n=t._fromtimestamp(e,null!==r,r);return null!==r&&(n=r.fromutc(n)),n}))},get utcfromtimestamp(){return o(this,(function(t,e){return t._fromtimestamp(e,!0,null)}))},get now(){return o(this,(function(t,e){(void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null);var r=Zt();return t.fromtimestamp(r,e)}))},get utcnow(){return o(this,(function(t){var e=Zt();return t.utcfromtimestamp(e)}))},get combine(){return o(this,(function(t,e,r){var n;if(!O(e,qe))throw(n=ct("date argument must be a date instance")).__cause__=null,n;if(!O(r,He))throw(n=ct("time argument must be a time instance")).__cause__=null,n;return t(e.year,e.month,e.day,r.hour,r.minute,r.second,r.microsecond,r.tzinfo)}))},get timetuple(){return _(this,(function(t){if(null===(e=t.dst()))var e=-1;else e=e?1:0;return ve(t.year,t.month,t.day,t.hour,t.minute,t.second,e)}))},get timestamp(){return _(this,(function(t){return null===t._tzinfo?Gt(x([t.year,t.month,t.day,t.hour,t.minute,t.second,-1,-1,-1]))+t.microsecond/1e6:s((e=X(t,Xe)).total_seconds,e);var e}))},get utctimetuple(){return _(this,(function(t){var e=t.utcoffset();e&&(t=s(rt,null,t,e));var r,n=(r=x([t.year,t.month,t.day]))[0],_=r[1],o=r[2],i=(r=x([t.hour,t.minute,t.second]))[0],u=r[1],a=r[2];return ve(n,_,o,i,u,a,0)}))},get date(){return _(this,(function(t){return Ue(t._year,t._month,t._day)}))},get time(){return _(this,(function(t){return Fe(t.hour,t.minute,t.second,t.microsecond)}))},get timetz(){return _(this,(function(t){return Fe(t.hour,t.minute,t.second,t.microsecond,t._tzinfo)}))},get py_replace(){return _(this,(function(t,e,r,n,_,o,i,u,a){if((void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e=null),(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),(void 0===n||null!=n&&n.hasOwnProperty("__kwargtrans__"))&&(n=null),(void 0===_||null!=_&&_.hasOwnProperty("__kwargtrans__"))&&(_=null),(void 0===o||null!=o&&o.hasOwnProperty("__kwargtrans__"))&&(o=null),(void 0===i||null!=i&&i.hasOwnProperty("__kwargtrans__"))&&(i=null),(void 0===u||null!=u&&u.hasOwnProperty("__kwargtrans__"))&&(u=null),(void 0===a||null!=a&&a.hasOwnProperty("__kwargtrans__"))&&(a=!0),arguments.length){var s=arguments.length-1;if(arguments[s]&&arguments[s].hasOwnProperty("__kwargtrans__")){var l=arguments[s--];for(var c in l)switch(c){case"self":t=l[c];break;case"year":e=l[c];break;case"month":r=l[c];break;case"day":n=l[c];break;case"hour":_=l[c];break;case"minute":o=l[c];break;case"second":i=l[c];break;case"microsecond":u=l[c];break;case"tzinfo":a=l[c]}}}return null===e&&(e=t.year),null===r&&(r=t.month),null===n&&(n=t.day),null===_&&(_=t.hour),null===o&&(o=t.minute),null===i&&(i=t.second),null===u&&(u=t.microsecond),!0===a&&(a=t.tzinfo),$e(e,r,n,_,o,i,u,a)}))},get astimezone(){return _(this,(function(t,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=null),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"tz":r=_[o]}}}if(null===r){if(null===t.tzinfo)throw(v=ut("astimezone() requires an aware datetime")).__cause__=null,v;var i=K(X(t,Xe),s(xe,null,l({seconds:1}))),u=Vt(i),a=$e(...u.__getslice__(0,6,1));if(d(u)>9){var c=u[10],f=u[9];r=Re(xe(l({seconds:c})),f)}else{var h=X(a,s($e,null,...(y=s(e.gmtime,e,i),0,6,1,"object"==typeof y&&"__getitem__"in y?y.__getitem__([0,6,1]):y.__getslice__(0,6,1)))),m=Bt&&(0,"object"==typeof(p=nt(u,8))&&"__gt__"in p?p.__gt__(0):p>0);c=R(m?Wt:Yt),r=function(t,e){return"object"==typeof t&&"__eq__"in t?t.__eq__(e):t==e}(h,s(xe,null,l({seconds:c})))?s(Re,null,h,nt(Jt,m)):s(Re,null,h)}}else if(!O(r,Ee))throw(v=ct("tz argument must be an instance of tzinfo")).__cause__=null,v;var p,y,g=t.tzinfo;if(null===g)throw(v=ut("astimezone() requires an aware datetime")).__cause__=null,v;if(r===g)return t;var v,w=t.utcoffset();if(null===w)throw(v=ut("astimezone() requires an aware datetime")).__cause__=null,v;var b,k=s((b=X(t,w)).py_replace,b,l({tzinfo:r}));return r.fromutc(k)}))},get ctime(){return _(this,(function(t){var e=Z(t.toordinal(),7)||7;return"{} {} {} {}:{}:{} {}".format(ge[e],ye[t._month],re(t._day,2),re(t._hour,2),re(t._minute,2),re(t._second,2),re(t._year,4))}))},get isoformat(){return _(this,(function(t,e){(void 0===e||null!=e&&e.hasOwnProperty("__kwargtrans__"))&&(e="T");var r="{}-{}-{}{}".format(re(t._year,4),re(t._month,2),re(t._day,2),e)+we(t._hour,t._minute,t._second,t._microsecond);if(null!==(_=t.utcoffset())){if(_.days<0)var n="-",_=-_;else n="+";var o=mt(_,xe(l({hours:1}))),i=o[0],u=o[1];u=Math.floor(u/xe(l({minutes:1}))),r+="{}{}:{}".format(n,re(i,2),re(u,2))}return r}))},get __repr__(){return _(this,(function(t){var e=[t._year,t._month,t._day,t._hour,t._minute,t._second,t._microsecond];0==e[d(e)-1]&&e.py_pop(),0==e[d(e)-1]&&e.py_pop();var r,n,_="datetime.datetime({})".format(", ".join((r=T,n=e,function(){var t=[];for(var e of n)t.append(r(e));return t}())));return null!==t._tzinfo&&(_=_.__getslice__(0,d(_)-1,1)+", tzinfo={}".format(t._tzinfo.__repr__())+")"),_}))},get __str__(){return _(this,(function(t){return t.isoformat(l({sep:" "}))}))},get strptime(){return o(this,(function(t,e,r){return t(...Qt(e,r).__getslice__(0,6,1))}))},get utcoffset(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.utcoffset(t);return ke("utcoffset",e),e}))},get tzname(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.tzname(t);return Oe(e),e}))},get dst(){return _(this,(function(t){if(null===t._tzinfo)return null;var e=t._tzinfo.dst(t);return ke("dst",e),e}))},get __eq__(){return _(this,(function(t,e){return O(e,$e)?0==t._cmp(e,l({allow_mixed:!0})):!O(e,Ue)&&NotImplemented}))},get __le__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)<=0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get __lt__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)<0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get __ge__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)>=0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get __gt__(){return _(this,(function(t,e){return O(e,$e)?t._cmp(e)>0:O(e,Ue)?void Ae(t,e):NotImplemented}))},get _cmp(){return _(this,(function(t,e,r){(void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=!1);var n=null,_=null;if(t._tzinfo===e._tzinfo)var o=!0;else o=(n=t.utcoffset())==(_=e.utcoffset());if(o){var i="{}{}{}{}{}{}{}".format(re(t._year,4),re(t._month,2),re(t._day,2),re(t._hour,2),re(t._minute,2),re(t._second,2),re(t._microsecond,6)),u="{}{}{}{}{}{}{}".format(re(e._year,4),re(e._month,2),re(e._day,2),re(e._hour,2),re(e._minute,2),re(e._second,2),re(e._microsecond,6));return ne(i,u)}if(null===n||null===_){if(r)return 2;var a=ct("cannot compare naive and aware datetimes");throw a.__cause__=null,a}var s=X(t,e);return s.days<0?-1:s?1:0}))},get __add__(){return _(this,(function(t,e){if(!O(e,xe))return NotImplemented;var r,n=s(et,null,n=xe(t.toordinal(),l({hours:t._hour,minutes:t._minute,seconds:t._second,microseconds:t._microsecond})),e),_=(r=mt(n.seconds,3600))[0],o=r[1],i=(r=mt(o,60))[0],u=r[1];if(0<n.days&&n.days<=_e)return $e.combine(Ue.fromordinal(n.days),Fe(_,i,u,n.microseconds,l({tzinfo:t._tzinfo})));var a=OverflowError("result out of range");throw a.__cause__=null,a}))},get __radd__(){return _(this,(function(t,e){return t.__add__(e)}))},get __sub__(){return _(this,(function(t,e){if(!O(e,$e))return O(e,xe)?V(t,R(e)):NotImplemented;var r=t.toordinal(),n=e.toordinal(),_=t._second+60*t._minute+3600*t._hour,o=e._second+60*e._minute+3600*e._hour,i=xe(r-n,_-o,t._microsecond-e._microsecond);if(t._tzinfo===e._tzinfo)return i;var u=t.utcoffset(),a=e.utcoffset();if(u==a)return i;if(null===u||null===a){var s=ct("cannot mix naive and timezone-aware time");throw s.__cause__=null,s}return X(V(i,a),u)}))},resolution:xe(l({microseconds:1}))});Object.defineProperty($e,"tzinfo",c.call($e,$e._get_tzinfo)),Object.defineProperty($e,"microsecond",c.call($e,$e._get_microsecond)),Object.defineProperty($e,"second",c.call($e,$e._get_second)),Object.defineProperty($e,"minute",c.call($e,$e._get_minute)),Object.defineProperty($e,"hour",c.call($e,$e._get_hour));var Ye=$e(1,1,1),Be=$e(9999,12,31,23,59,59,999999);Object.defineProperty($e,"min",{get:function(){return Ye}}),Object.defineProperty($e,"max",{get:function(){return Be}});var Je=function(t){var e=he(t,1,1),r=Z(e+6,7),n=e-r;return r>3&&(n+=7),n},Ze="@#$^&$^",Re=a("timezone",[Ee],{__module__:ee,get __init__(){return _(this,(function(t,e,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=Ze),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"self":t=_[o];break;case"offset":e=_[o];break;case"py_name":r=_[o]}}}if(!O(e,xe))throw(i=ct("offset must be a timedelta")).__cause__=null,i;if(r===Ze)e||(e=t.utc),r=null;else if(!O(r,T)){var i;throw(i=ct("name must be a string")).__cause__=null,i}if(!tt(t._minoffset,e)||!tt(e,t._maxoffset))throw(i=s(ut,null,"offset must be a timedelta strictly between -timedelta(hours=24) and timedelta(hours=24).")).__cause__=null,i;if(0!=e.microseconds||0!=Z(e.seconds,60))throw(i=ut("offset must be a timedelta representing a whole number of minutes")).__cause__=null,i;t._offset=e,t._name=r}))},get _create(){return o(this,(function(t,e,r){if((void 0===r||null!=r&&r.hasOwnProperty("__kwargtrans__"))&&(r=Ze),arguments.length){var n=arguments.length-1;if(arguments[n]&&arguments[n].hasOwnProperty("__kwargtrans__")){var _=arguments[n--];for(var o in _)switch(o){case"cls":t=_[o];break;case"offset":e=_[o];break;case"py_name":r=_[o]}}}return t(e,r)}))},get __eq__(){return _(this,(function(t,e){return w(e)==Re&&t._offset==e._offset}))},get __repr__(){return _(this,(function(t){return t===t.utc?"datetime.timezone.utc":null===t._name?"datetime.timezone({})".format(t._offset.__repr__()):"datetime.timezone({}, {})".format(t._offset.__repr__(),t._name.__repr__())}))},get __str__(){return _(this,(function(t){return t.tzname(null)}))},get utcoffset(){return _(this,(function(t,e){if(O(e,$e)||null===e)return t._offset;var r=ct("utcoffset() argument must be a datetime instance or None");throw r.__cause__=null,r}))},get tzname(){return _(this,(function(t,e){if(O(e,$e)||null===e)return null===t._name?t._name_from_offset(t._offset):t._name;var r=ct("tzname() argument must be a datetime instance or None");throw r.__cause__=null,r}))},get dst(){return _(this,(function(t,e){if(O(e,$e)||null===e)return null;var r=ct("dst() argument must be a datetime instance or None");throw r.__cause__=null,r}))},get fromutc(){return _(this,(function(t,e){if(O(e,$e)){if(e.tzinfo!==t)throw(r=ut("fromutc: dt.tzinfo is not self")).__cause__=null,r;return V(e,t._offset)}var r;throw(r=ct("fromutc() argument must be a datetime instance or None")).__cause__=null,r}))},_maxoffset:xe(l({hours:23,minutes:59})),_minoffset:R(s(xe,null,l({hours:23,minutes:59}))),get _name_from_offset(){return function(t){if(Q(t,s(xe,null,0))){var e="-";t=R(t)}else e="+";var r=s(mt,null,t,s(xe,null,l({hours:1}))),n=r[0],_=K(r[1],s(xe,null,l({minutes:1})));return"UTC{}{}:{}".format(e,re(n,2),re(_,2))}}}),Ge=Re._create(xe(0)),Ke=Re._create(Re._minoffset),Ve=Re._create(Re._maxoffset);Object.defineProperty(Re,"utc",{get:function(){return Ge}}),Object.defineProperty(Re,"min",{get:function(){return Ke}}),Object.defineProperty(Re,"max",{get:function(){return Ve}});var Xe=$e(1970,1,1,l({tzinfo:Re.utc}));var stellarInstance=J({ID:"",BootTimestamp:0,RequestCount:0});addEventListener("fetch",(function(t){return t.respondWith(function(t){var o=Date.now(),e=0;m("queryStringParameters",t)&&m("IncrementLimit",t.queryStringParameters)?e=g(t.queryStringParameters.py_get("IncrementLimit",0)):m("body",t)&&JSON.parse(t.body).IncrementLimit&&(e=g(JSON.parse(t.body).IncrementLimit)),function(t){for(var e=0;e<t;)e++}(e);var n=(""==stellarInstance.ID&&(stellarInstance.ID=crypto.randomUUID().replaceAll("-",""),stellarInstance.BootTimestamp=Date.now()),stellarInstance.RequestCount++,J({InstanceID:stellarInstance.ID,InstanceBootTimestamp:stellarInstance.BootTimestamp,InstanceRequestCount:stellarInstance.RequestCount,RuntimeVersion:navigator.userAgent,CPUModel:"",MemoryLimitMB:128}));n.RequestID="cloudflare-does-not-specify",n.TimestampChain=[T($e.now())],n.ExecutionMilliseconds=Date.now()-o,n.HandlerEntryTimestampMicroseconds=1e3*o,n.HandlerExitTimestampMicroseconds=1e3*Date.now();var r=JSON.stringify(n);return new Response(r,J({headers:J({"content-type":"application/json"})}))}(t.request))}))})();
\ No newline at end of file
@@ -1,21 +1,44 @@
 package main
 
 import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type HelloGoResponse struct {
 	RequestID      string   `json:"RequestID"`
 	TimestampChain []string `json:"TimestampChain"`
+	InstanceTelemetry
+	// ExecutionMilliseconds is the duration of the handler, from HandlerEntryTimestampMicroseconds to
+	// HandlerExitTimestampMicroseconds on the monotonic clock
+	ExecutionMilliseconds             float64 `json:"ExecutionMilliseconds"`
+	HandlerEntryTimestampMicroseconds int64   `json:"HandlerEntryTimestampMicroseconds"`
+	HandlerExitTimestampMicroseconds  int64   `json:"HandlerExitTimestampMicroseconds"`
+}
+
+// InstanceTelemetry identifies the instance serving the request and its host
+type InstanceTelemetry struct {
+	InstanceID            string `json:"InstanceID"`
+	InstanceBootTimestamp int64  `json:"InstanceBootTimestamp"`
+	InstanceRequestCount  int64  `json:"InstanceRequestCount"`
+	RuntimeVersion        string `json:"RuntimeVersion"`
+	CPUModel              string `json:"CPUModel"`
+	MemoryLimitMB         int64  `json:"MemoryLimitMB"`
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {
+	handlerStart := time.Now()
+
 	incrementLimit, err := extractIncrementLimit(r)
 	if err != nil {
 		log.Errorf("Error extracting IncrementLimit: %s", err)
@@ -29,6 +52,10 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		TimestampChain: []string{
 			strconv.Itoa(int(time.Now().Nanosecond())),
 		},
+		InstanceTelemetry:                 instanceTelemetry(),
+		ExecutionMilliseconds:             float64(time.Since(handlerStart).Microseconds()) / 1000,
+		HandlerEntryTimestampMicroseconds: handlerStart.UnixMicro(),
+		HandlerExitTimestampMicroseconds:  time.Now().UnixMicro(),
 	}
 	err = json.NewEncoder(w).Encode(res)
 	if err != nil {
@@ -68,3 +95,59 @@ func main() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
 }
 
+// instanceID identifies the instance serving the requests, generated at init
+var instanceID = newInstanceID()
+var instanceBootTimestamp = time.Now().UnixMilli()
+var instanceRequestCount int64
+var cpuModel = readCPUModel()
+var memoryLimitMB = readMemoryLimitMB()
+
+// instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+func instanceTelemetry() InstanceTelemetry {
+	return InstanceTelemetry{
+		InstanceID:            instanceID,
+		InstanceBootTimestamp: instanceBootTimestamp,
+		InstanceRequestCount:  atomic.AddInt64(&instanceRequestCount, 1),
+		RuntimeVersion:        runtime.Version(),
+		CPUModel:              cpuModel,
+		MemoryLimitMB:         memoryLimitMB,
+	}
+}
+
+func newInstanceID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// readCPUModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+func readCPUModel() string {
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if strings.HasPrefix(line, "model name") {
+			return strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
+		}
+	}
+	return ""
+}
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+func readMemoryLimitMB() int64 {
+	if memorySize, err := strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64); err == nil {
+		return memorySize
+	}
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err == nil && limit > 0 && limit < 1<<50 {
+			return limit / (1024 * 1024)
+		}
+	}
+	return 0
+}
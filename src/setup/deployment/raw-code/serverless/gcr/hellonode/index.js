@@ -6,6 +6,8 @@ const simulateWork = (incrementLimit) => {
 }
 
 app.get('/', (req, res) => {
+  const handlerEntryMicroseconds = epochMicroseconds();
+  const handlerStart = process.hrtime.bigint();
   let incrementLimit = 0
   if (req.query.IncrementLimit) {
     incrementLimit = req.query.IncrementLimit
@@ -14,10 +16,54 @@ app.get('/', (req, res) => {
 
   res.json({
     RequestID: "google-does-not-specify",
-    TimestampChain: [Date.now().toString()]
+    TimestampChain: [Date.now().toString()],
+    ...instanceTelemetry(),
+    ExecutionMilliseconds: Number(process.hrtime.bigint() - handlerStart) / 1e6,
+    HandlerEntryTimestampMicroseconds: handlerEntryMicroseconds,
+    HandlerExitTimestampMicroseconds: epochMicroseconds(),
   });
 });
 
+// epochMicroseconds returns the Unix time in microseconds
+const epochMicroseconds = () => Math.round((performance.timeOrigin + performance.now()) * 1000);
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+const readMemoryLimitMB = () => {
+  if (process.env.AWS_LAMBDA_FUNCTION_MEMORY_SIZE) {
+    return parseInt(process.env.AWS_LAMBDA_FUNCTION_MEMORY_SIZE, 10);
+  }
+  for (const path of ["/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"]) {
+    try {
+      const limit = Number(require("fs").readFileSync(path, "utf8").trim());
+      if (limit > 0 && limit < 2 ** 50) {
+        return Math.floor(limit / (1024 * 1024));
+      }
+    } catch (error) {}
+  }
+  return 0;
+};
+
+// instanceId identifies the instance serving the requests, generated at init
+const instanceId = require("crypto").randomBytes(16).toString("hex");
+const instanceBootTimestamp = Date.now();
+const cpuModel = (require("os").cpus()[0] || {}).model || "";
+const memoryLimitMB = readMemoryLimitMB();
+let instanceRequestCount = 0;
+
+// instanceTelemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+const instanceTelemetry = () => {
+  instanceRequestCount++;
+  return {
+    InstanceID: instanceId,
+    InstanceBootTimestamp: instanceBootTimestamp,
+    InstanceRequestCount: instanceRequestCount,
+    RuntimeVersion: `node ${process.version}`,
+    CPUModel: cpuModel,
+    MemoryLimitMB: memoryLimitMB,
+  };
+};
+
 const port = process.env.PORT || 8080;
 
 app.use(express.json())
@@ -1,6 +1,10 @@
 use actix_web::{get, web, App, HttpRequest, HttpServer, Responder, Result};
 use serde::{Deserialize, Serialize};
-use std::time::SystemTime;
+use std::collections::hash_map::RandomState;
+use std::hash::{BuildHasher, Hasher};
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::sync::OnceLock;
+use std::time::{Instant, SystemTime, UNIX_EPOCH};
 
 #[derive(Debug, Deserialize)]
 pub struct Params {
@@ -15,6 +19,103 @@ struct Response {
     request_id: String,
     #[serde(rename = "TimestampChain")]
     timestamp_chain: Vec<String>,
+    #[serde(flatten)]
+    telemetry: InstanceTelemetry,
+    // execution_milliseconds is the duration of the handler, from handler_entry_timestamp_microseconds to
+    // handler_exit_timestamp_microseconds on the monotonic clock
+    #[serde(rename = "ExecutionMilliseconds")]
+    execution_milliseconds: f64,
+    #[serde(rename = "HandlerEntryTimestampMicroseconds")]
+    handler_entry_timestamp_microseconds: u64,
+    #[serde(rename = "HandlerExitTimestampMicroseconds")]
+    handler_exit_timestamp_microseconds: u64,
+}
+
+// InstanceTelemetry identifies the instance serving the request and its host
+#[derive(Serialize)]
+#[serde(rename_all = "PascalCase")]
+struct InstanceTelemetry {
+    #[serde(rename = "InstanceID")]
+    instance_id: String,
+    instance_boot_timestamp: u64,
+    instance_request_count: u64,
+    runtime_version: String,
+    #[serde(rename = "CPUModel")]
+    cpu_model: String,
+    #[serde(rename = "MemoryLimitMB")]
+    memory_limit_mb: u64,
+}
+
+// Instance identifies the instance serving the requests and its host, created at init
+struct Instance {
+    id: String,
+    boot_timestamp: u128,
+    cpu_model: String,
+    memory_limit_mb: u64,
+}
+
+static INSTANCE: OnceLock<Instance> = OnceLock::new();
+static INSTANCE_REQUEST_COUNT: AtomicU64 = AtomicU64::new(0);
+
+fn instance() -> &'static Instance {
+    INSTANCE.get_or_init(|| Instance {
+        id: new_instance_id(),
+        boot_timestamp: SystemTime::now()
+            .duration_since(UNIX_EPOCH)
+            .map_or(0, |now| now.as_millis()),
+        cpu_model: read_cpu_model(),
+        memory_limit_mb: read_memory_limit_mb(),
+    })
+}
+
+// new_instance_id returns 128 random bits from the randomly seeded hashers of the standard library
+fn new_instance_id() -> String {
+    let half = || RandomState::new().build_hasher().finish();
+    format!("{:016x}{:016x}", half(), half())
+}
+
+// read_cpu_model returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+fn read_cpu_model() -> String {
+    std::fs::read_to_string("/proc/cpuinfo")
+        .unwrap_or_default()
+        .lines()
+        .find(|line| line.starts_with("model name"))
+        .and_then(|line| line.split_once(':'))
+        .map(|(_, model)| model.trim().to_owned())
+        .unwrap_or_default()
+}
+
+// read_memory_limit_mb returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+fn read_memory_limit_mb() -> u64 {
+    if let Some(memory_size) = std::env::var("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")
+        .ok()
+        .and_then(|size| size.parse().ok())
+    {
+        return memory_size;
+    }
+    [
+        "/sys/fs/cgroup/memory.max",
+        "/sys/fs/cgroup/memory/memory.limit_in_bytes",
+    ]
+    .iter()
+    .filter_map(|path| std::fs::read_to_string(path).ok())
+    .filter_map(|content| content.trim().parse::<u64>().ok())
+    .find(|limit| *limit > 0 && *limit < 1 << 50)
+    .map_or(0, |limit| limit / (1024 * 1024))
+}
+
+// instance_telemetry counts the request served by the instance and returns the telemetry identifying the instance and
+// its host
+fn instance_telemetry() -> InstanceTelemetry {
+    let instance = instance();
+    InstanceTelemetry {
+        instance_id: instance.id.clone(),
+        instance_boot_timestamp: instance.boot_timestamp as u64,
+        instance_request_count: INSTANCE_REQUEST_COUNT.fetch_add(1, Ordering::Relaxed) + 1,
+        runtime_version: "rust".to_owned(),
+        cpu_model: instance.cpu_model.clone(),
+        memory_limit_mb: instance.memory_limit_mb,
+    }
 }
 
 fn simulate_work(increment_limit: u64) {
@@ -24,6 +125,13 @@ fn simulate_work(increment_limit: u64) {
     }
 }
 
+// epoch_microseconds returns the Unix time in microseconds
+fn epoch_microseconds() -> u64 {
+    SystemTime::now()
+        .duration_since(UNIX_EPOCH)
+        .map_or(0, |now| now.as_micros() as u64)
+}
+
 fn get_system_time() -> String {
     let mut buffer = itoa::Buffer::new();
     match SystemTime::now().duration_since(SystemTime::UNIX_EPOCH) {
@@ -34,6 +142,9 @@ fn get_system_time() -> String {
 
 #[get("/")]
 async fn hellorust(req: HttpRequest) -> Result<impl Responder> {
+    let handler_entry_microseconds = epoch_microseconds();
+    let handler_start = Instant::now();
+
     let params = web::Query::<Params>::from_query(req.query_string()).unwrap();
     if params.increment_limit > 0 {
         simulate_work(params.increment_limit)
@@ -42,12 +153,17 @@ async fn hellorust(req: HttpRequest) -> Result<impl Responder> {
     let res_obj = Response {
         request_id: "google-does-not-specify".to_owned(),
         timestamp_chain: vec![get_system_time()],
+        telemetry: instance_telemetry(),
+        execution_milliseconds: handler_start.elapsed().as_secs_f64() * 1000.0,
+        handler_entry_timestamp_microseconds: handler_entry_microseconds,
+        handler_exit_timestamp_microseconds: epoch_microseconds(),
     };
     Ok(web::Json(res_obj))
 }
 
 #[actix_web::main]
 async fn main() -> std::io::Result<()> {
+    instance();
     HttpServer::new(|| App::new().service(hellorust))
         .bind(("0.0.0.0", 8080))?
         .run()
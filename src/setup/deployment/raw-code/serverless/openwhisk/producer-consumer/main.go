@@ -0,0 +1,114 @@
+package main
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Main is the entry point of the OpenWhisk action. Only chains of a single function are supported, so the
+// generated payload of PayloadLengthBytes is not transferred.
+func Main(args map[string]interface{}) map[string]interface{} {
+	handlerStart := time.Now()
+
+	incrementLimit, _ := strconv.Atoi(argument(args, "IncrementLimit"))
+	payloadLengthBytes, _ := strconv.Atoi(argument(args, "PayloadLengthBytes"))
+
+	simulateWork(incrementLimit)
+	payload := generatePayload(payloadLengthBytes)
+
+	response := addInstanceTelemetry(map[string]interface{}{
+		"RequestID":          os.Getenv("__OW_ACTIVATION_ID"),
+		"TimestampChain":     []string{strconv.FormatInt(time.Now().UnixNano(), 10)},
+		"PayloadLengthBytes": len(payload),
+	})
+	response["ExecutionMilliseconds"] = float64(time.Since(handlerStart).Microseconds()) / 1000
+	response["HandlerEntryTimestampMicroseconds"] = handlerStart.UnixMicro()
+	response["HandlerExitTimestampMicroseconds"] = time.Now().UnixMicro()
+	return response
+}
+
+// argument returns the web action parameter with the given key, which is a string when passed in the query
+func argument(args map[string]interface{}, key string) string {
+	switch value := args[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	default:
+		return "0"
+	}
+}
+
+func simulateWork(incrementLimit int) {
+	for i := 0; i < incrementLimit; i++ {
+	}
+}
+
+func generatePayload(length int) []byte {
+	payload := make([]byte, length)
+	rand.Read(payload)
+	return payload
+}
+
+// instanceID identifies the instance serving the requests, generated at init
+var instanceID = newInstanceID()
+var instanceBootTimestamp = time.Now().UnixMilli()
+var instanceRequestCount int64
+var cpuModel = readCPUModel()
+var memoryLimitMB = readMemoryLimitMB()
+
+// addInstanceTelemetry counts the request served by the instance and adds the telemetry identifying the instance and
+// its host to the response
+func addInstanceTelemetry(response map[string]interface{}) map[string]interface{} {
+	response["InstanceID"] = instanceID
+	response["InstanceBootTimestamp"] = instanceBootTimestamp
+	response["InstanceRequestCount"] = atomic.AddInt64(&instanceRequestCount, 1)
+	response["RuntimeVersion"] = runtime.Version()
+	response["CPUModel"] = cpuModel
+	response["MemoryLimitMB"] = memoryLimitMB
+	return response
+}
+
+func newInstanceID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// readCPUModel returns the model name of the CPU of the host from /proc/cpuinfo, if listed
+func readCPUModel() string {
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		if strings.HasPrefix(line, "model name") {
+			return strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
+		}
+	}
+	return ""
+}
+
+// readMemoryLimitMB returns the memory limit of the instance set by the provider or its cgroup, 0 if unlimited
+func readMemoryLimitMB() int64 {
+	if memorySize, err := strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64); err == nil {
+		return memorySize
+	}
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err == nil && limit > 0 && limit < 1<<50 {
+			return limit / (1024 * 1024)
+		}
+	}
+	return 0
+}
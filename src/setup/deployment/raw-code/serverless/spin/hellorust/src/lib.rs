@@ -0,0 +1,119 @@
+use spin_sdk::http::{IntoResponse, Request, Response};
+use spin_sdk::http_component;
+use std::collections::hash_map::RandomState;
+use std::hash::{BuildHasher, Hasher};
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::sync::OnceLock;
+use std::time::{Duration, Instant, SystemTime};
+
+// Random data embedded in the module to reach the configured image size (FunctionImageSizeMB)
+static FILLER: &[u8] = include_bytes!("../filler.file");
+
+// simulate_work busy-spins up to the increment limit and returns the measured duration in milliseconds
+fn simulate_work(increment_limit: u64) -> f64 {
+    let start = Instant::now();
+    let mut i: u64 = 0;
+    while std::hint::black_box(i) < increment_limit {
+        i += 1;
+    }
+    start.elapsed().as_secs_f64() * 1000.0
+}
+
+// spin_for spins for the service time on the wall clock and returns the measured duration in milliseconds. The
+// sandbox has no CPU clock, so the cpu service time mode is rejected for Spin functions when the experiments are set up.
+fn spin_for(service_time: Duration) -> f64 {
+    let start = Instant::now();
+    while start.elapsed() < service_time {
+        std::hint::spin_loop();
+    }
+    start.elapsed().as_secs_f64() * 1000.0
+}
+
+fn query_parameter<'a>(query: &'a str, name: &str) -> Option<&'a str> {
+    query
+        .split('&')
+        .filter_map(|parameter| parameter.split_once('='))
+        .find(|(key, _)| *key == name)
+        .map(|(_, value)| value)
+}
+
+fn increment_limit(query: &str) -> u64 {
+    query_parameter(query, "IncrementLimit")
+        .and_then(|value| value.parse().ok())
+        .unwrap_or(0)
+}
+
+// Instance identifies the instance serving the requests. Spin instantiates the module afresh for every request, so
+// every instance serves a single request, and the sandbox exposes neither the CPU model nor a memory limit.
+struct Instance {
+    id: String,
+    boot_timestamp: u128,
+}
+
+static INSTANCE: OnceLock<Instance> = OnceLock::new();
+static INSTANCE_REQUEST_COUNT: AtomicU64 = AtomicU64::new(0);
+
+// instance_telemetry counts the request served by the instance and returns the telemetry identifying the instance as
+// JSON fields
+fn instance_telemetry() -> String {
+    let instance = INSTANCE.get_or_init(|| Instance {
+        id: new_instance_id(),
+        boot_timestamp: SystemTime::now()
+            .duration_since(SystemTime::UNIX_EPOCH)
+            .map_or(0, |now| now.as_millis()),
+    });
+    format!(
+        "\"InstanceID\": \"{}\", \"InstanceBootTimestamp\": {}, \"InstanceRequestCount\": {}, \"RuntimeVersion\": \"rust\", \"CPUModel\": \"\", \"MemoryLimitMB\": 0",
+        instance.id,
+        instance.boot_timestamp,
+        INSTANCE_REQUEST_COUNT.fetch_add(1, Ordering::Relaxed) + 1
+    )
+}
+
+// epoch_microseconds returns the Unix time in microseconds
+fn epoch_microseconds() -> u64 {
+    SystemTime::now()
+        .duration_since(SystemTime::UNIX_EPOCH)
+        .map_or(0, |now| now.as_micros() as u64)
+}
+
+// new_instance_id returns 128 random bits from the randomly seeded hashers of the standard library
+fn new_instance_id() -> String {
+    let half = || RandomState::new().build_hasher().finish();
+    format!("{:016x}{:016x}", half(), half())
+}
+
+#[http_component]
+fn hellorust(req: Request) -> anyhow::Result<impl IntoResponse> {
+    let handler_entry_microseconds = epoch_microseconds();
+    let handler_start = Instant::now();
+
+    let query = req.query();
+    let service_time_milliseconds = if query_parameter(query, "ServiceTimeClock").is_some() {
+        let service_time: f64 = query_parameter(query, "ServiceTimeMilliseconds")
+            .and_then(|value| value.parse().ok())
+            .unwrap_or(0.0);
+        spin_for(Duration::try_from_secs_f64(service_time / 1000.0).unwrap_or_default())
+    } else {
+        simulate_work(increment_limit(query))
+    };
+
+    let timestamp = SystemTime::now().duration_since(SystemTime::UNIX_EPOCH)?.as_nanos();
+    // black_box keeps the filler from being optimized out of the module
+    let filler_bytes = std::hint::black_box(FILLER).len();
+
+    Ok(Response::builder()
+        .status(200)
+        .header("content-type", "application/json")
+        .body(format!(
+            "{{\"RequestID\": \"\", \"TimestampChain\": [\"{}\"], \"FillerBytes\": {}, \"ServiceTimeMilliseconds\": {}, {}, \"ExecutionMilliseconds\": {}, \"HandlerEntryTimestampMicroseconds\": {}, \"HandlerExitTimestampMicroseconds\": {}}}",
+            timestamp,
+            filler_bytes,
+            service_time_milliseconds,
+            instance_telemetry(),
+            handler_start.elapsed().as_secs_f64() * 1000.0,
+            handler_entry_microseconds,
+            epoch_microseconds()
+        ))
+        .build())
+}
@@ -25,19 +25,18 @@ package deployment
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
-	"math"
 	"os"
 	"stellar/setup/deployment/packaging"
 	"stellar/util"
 )
 
-// SetupDeployment will create the serverless function zip deployment for the given provider,
-// in the given language and of the given size in bytes. Returns size of deployment in MB and the handler path for AWS automation.
-func SetupDeployment(rawCodePath string, provider string, deploymentSizeBytes int64, packageType string, experimentID int, function string) (float64, string) {
-	fillerFilePath := rawCodePath + "/filler.file"
-
+// SetupDeployment will create the serverless function zip deployment for the given provider and region,
+// in the given language and of the given size in bytes. Returns size of deployment in MB and the handler path for AWS automation,
+// as well as the measured sizes of container images, which are built for the given architecture.
+func SetupDeployment(rawCodePath string, provider string, region string, deploymentSizeBytes int64, packageType string, architecture string, experimentID int, function string) (float64, string, *packaging.ImageSize) {
 	switch packageType {
 	case "Zip":
+		fillerFilePath := rawCodePath + "/filler.file"
 		_, binaryPath, handlerPath := getExecutableInfo(rawCodePath, experimentID, function)
 
 		zippedBinaryFileSizeBytes := packaging.GetZippedBinaryFileSize(experimentID, binaryPath)
@@ -60,21 +59,19 @@ func SetupDeployment(rawCodePath string, provider string, deploymentSizeBytes in
 
 		packaging.GenerateFillerFile(experimentID, fillerFilePath, deploymentSizeBytes-zippedBinaryFileSizeBytes)
 		zipPath := packaging.GenerateZIP(experimentID, fillerFilePath, binaryPath, "benchmarking.zip")
-		packaging.SetupZIPDeployment(provider, deploymentSizeBytes, zipPath)
+		packaging.SetupZIPDeployment(provider, region, deploymentSizeBytes, zipPath)
 
-		return util.BytesToMebibyte(deploymentSizeBytes), handlerPath
+		return util.BytesToMebibyte(deploymentSizeBytes), handlerPath, nil
 	case "Image":
-		log.Warn("Container image deployment does not support code size verification on AWS, making the image size benchmarks unreliable.")
-
-		// TODO: Size of containerized binary should be subtracted, seems to be 134MB in Amazon ECR...
-		packaging.GenerateFillerFile(experimentID, fillerFilePath, int64(math.Max(float64(deploymentSizeBytes)-134, 0)))
-		//packaging.SetupContainerImageDeployment(function, provider, rawCodePath)
-
+		// The filler layer is sized after the measured compressed size of the image of the function
+		_, imageSize := packaging.SetupContainerImageDeploymentFromDirectory(rawCodePath, function, provider, region, architecture,
+			util.BytesToMebibyte(deploymentSizeBytes), packaging.FillerOptions{})
+		return util.BytesToMebibyte(imageSize.CompressedBytes), "", &imageSize
 	default:
 		log.Fatalf("[sub-experiment %d] Unrecognized package type: %s", experimentID, packageType)
 	}
 
-	return util.BytesToMebibyte(deploymentSizeBytes), ""
+	return util.BytesToMebibyte(deploymentSizeBytes), "", nil
 }
 
 func getExecutableInfo(rawCodePath string, experimentID int, function string) (int64, string, string) {
@@ -44,6 +44,10 @@ func FindBusySpinIncrements(config *Configuration) {
 
 	standardDurationMs := timeSession(standardIncrement).Milliseconds()
 	for subExperimentIndex := range config.SubExperiments {
+		if config.SubExperiments[subExperimentIndex].ServiceTimeDistribution != nil {
+			// Sampled service times are converted per request, at the rate of the standard session
+			config.SubExperiments[subExperimentIndex].BusySpinIncrementsPerMillisecond = float64(standardIncrement) / math.Max(float64(standardDurationMs), 1)
+		}
 		findBusySpinIncrement(&config.SubExperiments[subExperimentIndex], standardDurationMs)
 	}
 }
@@ -0,0 +1,272 @@
+package setup
+
+import (
+	"archive/zip"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"stellar/setup/building"
+	"stellar/setup/deployment/connection/docker"
+	"stellar/setup/deployment/packaging"
+	"stellar/util"
+	"strings"
+	"time"
+)
+
+const (
+	dockerLocalDefaultIdleTimeout = 5 * time.Minute
+	dockerLocalReadinessTimeout   = time.Minute
+	// dockerLocalContainerPort is the port the gcr functions listen on, set through their PORT environment variable
+	dockerLocalContainerPort = 8080
+)
+
+// dockerLocalFunctions are the emulated functions serving the endpoints of the sub-experiments
+var dockerLocalFunctions []*docker.Function
+
+// ProvisionFunctionsDockerLocal runs the functions of the sub-experiments in local containers, one emulated function
+// per parallelism. Zip packages are built as for AWS and invoked through the Lambda Runtime Interface Emulator of the
+// AWS base images, while Container packages are built from the Dockerfiles of the gcr functions and invoked over HTTP.
+func ProvisionFunctionsDockerLocal(config *Configuration, serverlessDirPath string) {
+	engine, err := docker.EngineInstance()
+	if err != nil {
+		log.Fatalf("Could not connect to the Docker Engine: %s", err.Error())
+	}
+	// The artifacts of the aws and gcr functions are shared with the cloud providers
+	serverlessRootPath := filepath.Dir(filepath.Clean(serverlessDirPath))
+	builder := &building.Builder{}
+	randomTag := util.GenerateRandLowercaseLetters(5)
+
+	for index := range config.SubExperiments {
+		subExperiment := &config.SubExperiments[index]
+		if subExperiment.DataTransferChainLength > 1 || subExperiment.StorageTransfer {
+			log.Fatalf("[sub-experiment %d] Data transfers are not supported on docker-local.", subExperiment.ID)
+		}
+
+		var functionConfig docker.FunctionConfig
+		switch subExperiment.PackageType {
+		case "Zip":
+			taskRoot := buildDockerLocalZIPFunction(subExperiment, index, builder, serverlessDirPath, serverlessRootPath)
+			functionConfig = DockerLocalLambdaFunctionConfig(subExperiment, taskRoot)
+		case "Container":
+			image := buildDockerLocalImage(engine, subExperiment, filepath.Join(serverlessRootPath, "gcr"), randomTag)
+			functionConfig = DockerLocalHTTPFunctionConfig(subExperiment, image)
+		default:
+			log.Fatalf("[sub-experiment %d] Package type %s is not supported on docker-local.", subExperiment.ID, subExperiment.PackageType)
+		}
+
+		if functionConfig.Lambda {
+			log.Infof("[sub-experiment %d] Pulling Lambda base image %s...", subExperiment.ID, functionConfig.Container.Image)
+			if err := engine.PullImage(functionConfig.Container.Image); err != nil {
+				log.Fatalf("[sub-experiment %d] Could not pull image %s: %s", subExperiment.ID, functionConfig.Container.Image, err.Error())
+			}
+		}
+
+		for parallelism := 0; parallelism < subExperiment.Parallelism; parallelism++ {
+			functionConfig.Name = fmt.Sprintf("stellar-%s-%s", randomTag, strings.ReplaceAll(createName(subExperiment, index, parallelism), " ", ""))
+			function, err := docker.StartFunction(engine, functionConfig)
+			if err != nil {
+				log.Fatalf("[sub-experiment %d] Could not start function %s: %s", subExperiment.ID, functionConfig.Name, err.Error())
+			}
+			dockerLocalFunctions = append(dockerLocalFunctions, function) // Used for function removal
+			log.Infof("[sub-experiment %d] Function %s is served at %s.", subExperiment.ID, functionConfig.Name, function.Address())
+
+			subExperiment.Endpoints = append(subExperiment.Endpoints, EndpointInfo{ID: function.Address(), Region: subExperiment.Region})
+			subExperiment.AddRoute("")
+		}
+	}
+}
+
+// DockerLocalLambdaFunctionConfig configures a function invoked through the Lambda Runtime Interface Emulator, with
+// its unzipped artifact mounted as the task root of the AWS base image of its runtime.
+func DockerLocalLambdaFunctionConfig(subExperiment *SubExperiment, taskRoot string) docker.FunctionConfig {
+	image, err := docker.LambdaBaseImage(subExperiment.Runtime)
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] %s", subExperiment.ID, err.Error())
+	}
+	functionConfig := dockerLocalFunctionConfig(subExperiment)
+	functionConfig.Lambda = true
+	functionConfig.Container = docker.ContainerSpec{
+		Image:         image,
+		Cmd:           []string{subExperiment.Handler},
+		Env:           environmentAssignments(subExperiment.FunctionEnvironment()),
+		Binds:         []string{fmt.Sprintf("%s:%s:ro", taskRoot, docker.LambdaTaskRoot)},
+		ContainerPort: docker.LambdaPort,
+		MemoryMB:      subExperiment.FunctionMemoryMB,
+		CPU:           subExperiment.FunctionCPU,
+	}
+	return functionConfig
+}
+
+// DockerLocalHTTPFunctionConfig configures a function serving HTTP requests from the given image.
+func DockerLocalHTTPFunctionConfig(subExperiment *SubExperiment, image string) docker.FunctionConfig {
+	functionConfig := dockerLocalFunctionConfig(subExperiment)
+	functionConfig.Container = docker.ContainerSpec{
+		Image:         image,
+		Env:           append([]string{fmt.Sprintf("PORT=%d", dockerLocalContainerPort)}, environmentAssignments(subExperiment.FunctionEnvironment())...),
+		ContainerPort: dockerLocalContainerPort,
+		MemoryMB:      subExperiment.FunctionMemoryMB,
+		CPU:           subExperiment.FunctionCPU,
+	}
+	return functionConfig
+}
+
+func dockerLocalFunctionConfig(subExperiment *SubExperiment) docker.FunctionConfig {
+	idleTimeout := dockerLocalDefaultIdleTimeout
+	if subExperiment.IdleTimeoutSeconds > 0 {
+		idleTimeout = time.Duration(subExperiment.IdleTimeoutSeconds) * time.Second
+	}
+	return docker.FunctionConfig{
+		IdleTimeout:      idleTimeout,
+		MinInstances:     subExperiment.MinInstances,
+		MaxInstances:     subExperiment.MaxInstances,
+		ReadinessTimeout: dockerLocalReadinessTimeout,
+	}
+}
+
+// buildDockerLocalZIPFunction builds the ZIP artifact of the function as for AWS and unzips it into the directory
+// of the sub-experiment, mounted as the task root of the containers.
+func buildDockerLocalZIPFunction(subExperiment *SubExperiment, index int, builder *building.Builder, serverlessDirPath string, serverlessRootPath string) string {
+	builder.BuildFunction("aws", subExperiment.Function, subExperiment.Runtime, subExperiment.Architecture)
+	artifactName := building.ArtifactName(subExperiment.Function, subExperiment.Architecture)
+	zipPath := packaging.GenerateServerlessZIPArtifacts(subExperiment.ID, "aws", subExperiment.Runtime, artifactName, subExperiment.FunctionImageSizeMB, subExperiment.FillerOptions(), subExperiment.InitZIPSources()...)
+	if zipPath == "" {
+		log.Fatalf("[sub-experiment %d] Could not generate the ZIP artifact of runtime %s.", subExperiment.ID, subExperiment.Runtime)
+	}
+	zipPath = filepath.Join(serverlessRootPath, "aws", zipPath)
+	taskRoot, err := filepath.Abs(filepath.Join(serverlessDirPath, fmt.Sprintf("sub-experiment-%d", index)))
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not find task root directory: %s", subExperiment.ID, err.Error())
+	}
+	if err := os.RemoveAll(taskRoot); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not clean task root directory %s: %s", subExperiment.ID, taskRoot, err.Error())
+	}
+	if err := UnzipArtifact(zipPath, taskRoot); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not unzip artifact %s: %s", subExperiment.ID, zipPath, err.Error())
+	}
+	return taskRoot
+}
+
+// buildDockerLocalImage builds the image of the gcr function and appends a filler layer to it as for GCR, sized after
+// the compressed size of the image of the function code
+func buildDockerLocalImage(engine *docker.Engine, subExperiment *SubExperiment, functionsDirPath string, randomTag string) string {
+	functionDir := filepath.Join(functionsDirPath, subExperiment.Function)
+	// Filler files left in the function directory by previous versions must not end up in the image of the function code
+	packaging.RemoveStaleFillerFiles(subExperiment.ID, filepath.Join(functionDir, "filler.file"))
+
+	image := fmt.Sprintf("stellar-%s:%s-%d", subExperiment.Function, randomTag, subExperiment.ID)
+	baseImage := image + "-base"
+	if subExperiment.FunctionImageSizeMB == 0 {
+		baseImage = image
+	}
+	log.Infof("[sub-experiment %d] Building image %s from %s...", subExperiment.ID, baseImage, functionDir)
+	if err := engine.BuildImage(functionDir, baseImage); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not build image %s: %s", subExperiment.ID, baseImage, err.Error())
+	}
+	if subExperiment.FunctionImageSizeMB == 0 {
+		return image
+	}
+
+	log.Infof("[sub-experiment %d] Appending filler layer to %s as %s...", subExperiment.ID, baseImage, image)
+	size, err := padDockerLocalImage(engine, baseImage, image, util.MebibyteToBytes(subExperiment.FunctionImageSizeMB), subExperiment.FillerOptions())
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not append filler layer to image %s: %s", subExperiment.ID, baseImage, err.Error())
+	}
+	subExperiment.ImageSize = &size
+	return image
+}
+
+// padDockerLocalImage saves the base image from the Docker daemon, appends the filler layer to it and loads the padded
+// image back as the target image
+func padDockerLocalImage(engine *docker.Engine, baseImage string, image string, targetSizeBytes int64, options packaging.FillerOptions) (packaging.ImageSize, error) {
+	baseTarball, err := os.CreateTemp("", "stellar-image-*.tar")
+	if err != nil {
+		return packaging.ImageSize{}, err
+	}
+	defer os.Remove(baseTarball.Name())
+	if err := engine.SaveImage(baseImage, baseTarball); err != nil {
+		baseTarball.Close()
+		return packaging.ImageSize{}, err
+	}
+	if err := baseTarball.Close(); err != nil {
+		return packaging.ImageSize{}, err
+	}
+
+	// The padded image is streamed to the daemon as it is written
+	reader, writer := io.Pipe()
+	var size packaging.ImageSize
+	go func() {
+		var err error
+		size, err = packaging.PadImageTarball(baseTarball.Name(), image, writer, targetSizeBytes, options)
+		writer.CloseWithError(err)
+	}()
+	err = engine.LoadImage(reader)
+	reader.CloseWithError(err)
+	return size, err
+}
+
+// UnzipArtifact extracts the ZIP artifact at zipPath into the destination directory.
+func UnzipArtifact(zipPath string, destination string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		path := filepath.Join(destination, file.Name)
+		if !strings.HasPrefix(path, filepath.Clean(destination)+string(os.PathSeparator)) {
+			return fmt.Errorf("artifact entry %q is outside of the destination directory", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := unzipFile(file, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unzipFile(file *zip.File, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	source, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	mode := file.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	destination, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode|0444)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+
+// RemoveDockerLocalAllFunctions stops the emulated functions and removes their containers
+func RemoveDockerLocalAllFunctions() []string {
+	var removeServiceMessages []string
+	for _, function := range dockerLocalFunctions {
+		if err := function.Stop(); err != nil {
+			log.Errorf("Could not stop function at %s: %s", function.Address(), err.Error())
+			continue
+		}
+		removeServiceMessages = append(removeServiceMessages, fmt.Sprintf("Function at %s stopped.", function.Address()))
+	}
+	dockerLocalFunctions = nil
+	return removeServiceMessages
+}
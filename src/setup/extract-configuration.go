@@ -24,29 +24,46 @@ package setup
 
 import (
 	"encoding/json"
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"io"
+	"sort"
+	code_generation "stellar/setup/code-generation"
+	"stellar/setup/deployment/packaging"
+	"stellar/setup/runtimes"
 	"stellar/util"
+	"strconv"
+	"time"
 )
 
 // Configuration is the schema for all experiment configurations.
 type Configuration struct {
-	Sequential     bool            `json:"Sequential"`
-	Provider       string          `json:"Provider"`
-	Runtime        string          `json:"Runtime"`
-	SubExperiments []SubExperiment `json:"SubExperiments"`
+	Sequential bool   `json:"Sequential"`
+	Provider   string `json:"Provider"`
+	Runtime    string `json:"Runtime"`
+	// FillerSeed is added to the sub-experiment ID to seed the filler content of sub-experiments without a FillerSeed
+	FillerSeed int64 `json:"FillerSeed"`
+	// RandomFillerSeed replaces the FillerSeed with the start of the run, so that every run gets its own filler
+	RandomFillerSeed bool            `json:"RandomFillerSeed"`
+	SubExperiments   []SubExperiment `json:"SubExperiments"`
 }
 
 // EndpointInfo contains an ID identifying the function together with the IDs of other functions further in the data transfer chain
 type EndpointInfo struct {
 	ID                   string
 	DataTransferChainIDs []string
+	Region               string
+	// BusySpinIncrementsPerMillisecond is the busy-spin rate of the function, calibrated in the probe service time mode
+	BusySpinIncrementsPerMillisecond float64
+	// ServiceNames are the services deployed behind the endpoint on Cloud Run or Knative, updated to force cold starts
+	ServiceNames []string
 }
 
 // SubExperiment contains all the information needed for a sub-experiment to run.
 type SubExperiment struct {
 	ID                      int
 	Title                   string   `json:"Title"`
+	Provider                string   `json:"Provider"`
 	Bursts                  int      `json:"Bursts"`
 	BurstSizes              []int    `json:"BurstSizes"`
 	PayloadLengthBytes      int      `json:"PayloadLengthBytes"`
@@ -66,10 +83,63 @@ type SubExperiment struct {
 	SnapStartEnabled        bool     `json:"SnapStartEnabled"`
 	CPUBoostEnabled         bool     `json:"CPUBoostEnabled"`
 	PackagePattern          string   `json:"PackagePattern"`
+	Region                  string   `json:"Region"`
+	Architecture            string   `json:"Architecture"`
+	ProvisionedConcurrency  int      `json:"ProvisionedConcurrency"`
+	MinInstances            int      `json:"MinInstances"`
+	MaxInstances            int      `json:"MaxInstances"`
+	AlwaysReadyInstances    int      `json:"AlwaysReadyInstances"`
+	FunctionCPU             float64  `json:"FunctionCPU"`
+	Concurrency             int      `json:"Concurrency"`
+	TimeoutSeconds          int      `json:"TimeoutSeconds"`
+	ExecutionEnvironment    string   `json:"ExecutionEnvironment"`
+	CPUAlwaysAllocated      bool     `json:"CPUAlwaysAllocated"`
+	ContainerImage          string   `json:"ContainerImage"`
+	StockKnative            bool     `json:"StockKnative"`
+	IdleTimeoutSeconds      int      `json:"IdleTimeoutSeconds"`
+	FillerProfile           string   `json:"FillerProfile"`
+	FillerCompressionRatio  float64  `json:"FillerCompressionRatio"`
+	FillerFiles             int      `json:"FillerFiles"`
+	// FillerSeed seeds the filler content, derived from the FillerSeed of the configuration and the sub-experiment ID when unset
+	FillerSeed             *int64  `json:"FillerSeed,omitempty"`
+	InitImportModules      int     `json:"InitImportModules"`
+	InitDependencyBundleMB float64 `json:"InitDependencyBundleMB"`
+	InitReadFillerMB       float64 `json:"InitReadFillerMB"`
+	InitMemoryMB           int     `json:"InitMemoryMB"`
+	InitSleepMilliseconds  int     `json:"InitSleepMilliseconds"`
+	// ServiceTimeDistribution samples the service time of every request, instead of cycling the DesiredServiceTimes per burst
+	ServiceTimeDistribution *ServiceTimeDistribution `json:"ServiceTimeDistribution,omitempty"`
+	// ServiceTimeMode is how the functions are made to run for the service times, e.g., spinning on their wall clock
+	ServiceTimeMode string `json:"ServiceTimeMode"`
+	// Workload selects a memory-, disk- or network-bound workload run by the functions on every request, besides busy-spinning
+	Workload      string `json:"Workload"`
+	WorkloadBytes int64  `json:"WorkloadBytes"`
+	WorkloadFsync bool   `json:"WorkloadFsync"`
+	WorkloadURL   string `json:"WorkloadURL"`
+	// ExperimentType is either bursts of requests, a scale-out experiment sustaining an ArrivalRate (requests per
+	// second) for DurationSeconds, timing how long the function takes to have TargetInstances serving concurrently, or
+	// a power-tuning experiment
+	ExperimentType  string  `json:"ExperimentType"`
+	ArrivalRate     float64 `json:"ArrivalRate"`
+	DurationSeconds float64 `json:"DurationSeconds"`
+	TargetInstances int     `json:"TargetInstances"`
+	// MemorySizesMB are swept by the power-tuning experiment, sending RoundsPerMemorySize cold and warm bursts at every
+	// size, whose recommended setting is the cheapest meeting LatencyTargetMs with its warm 95th percentile latency
+	MemorySizesMB       []int64 `json:"MemorySizesMB"`
+	RoundsPerMemorySize int     `json:"RoundsPerMemorySize"`
+	LatencyTargetMs     float64 `json:"LatencyTargetMs"`
+	// ForceCold invalidates the warm instances of the functions before every burst, so that all requests are cold starts
+	ForceCold bool `json:"ForceCold"`
 	// All of the below are computed after reading the configuration
 	BusySpinIncrements []int64 `json:"BusySpinIncrements"`
 	Endpoints          []EndpointInfo
 	Routes             []string
+	// BusySpinIncrementsPerMillisecond converts the sampled service times into busy-spin increments
+	BusySpinIncrementsPerMillisecond float64 `json:"BusySpinIncrementsPerMillisecond,omitempty"`
+	// AppliedSettings are the resource settings reported by the provider once the functions are deployed
+	AppliedSettings map[string]string `json:"AppliedSettings,omitempty"`
+	// ImageSize is the measured size of the container image of the functions, once pushed
+	ImageSize *packaging.ImageSize `json:"ImageSize,omitempty"`
 }
 
 const (
@@ -84,8 +154,15 @@ const (
 	defaultParallelism             = 1
 	defaultDataTransferChainLength = 1
 	defaultFunctionMemoryMB        = 128
+	defaultArchitecture            = "x86_64"
+	defaultServiceTimeMode         = ClientServiceTime
+	defaultExperimentType          = BurstsExperiment
 )
 
+// memoryDefaultedByProvider are the providers deploying the functions with the memory of their own default, rather than
+// defaultFunctionMemoryMB, when FunctionMemoryMB is not set
+var memoryDefaultedByProvider = map[string]bool{"gcr": true, "google": true, "vhive": true}
+
 // ExtractConfiguration will read and parse the JSON configuration file, assign any default values and return the config object
 func ExtractConfiguration(configFilePath string) Configuration {
 	configFile := util.ReadFile(configFilePath)
@@ -103,16 +180,24 @@ func ExtractConfiguration(configFilePath string) Configuration {
 		parsedConfig.Runtime = defaultRuntime
 	}
 
+	if parsedConfig.RandomFillerSeed {
+		parsedConfig.FillerSeed = time.Now().UnixNano()
+	}
 	for index := range parsedConfig.SubExperiments {
+		parsedConfig.SubExperiments[index].ID = index
+
+		if parsedConfig.SubExperiments[index].Provider == "" {
+			parsedConfig.SubExperiments[index].Provider = parsedConfig.Provider
+		}
 		if parsedConfig.SubExperiments[index].Function == "" {
 			parsedConfig.SubExperiments[index].Function = defaultFunction
 		}
-		if parsedConfig.SubExperiments[index].Handler == "" {
-			parsedConfig.SubExperiments[index].Handler = defaultHandler
-		}
 		if parsedConfig.SubExperiments[index].Runtime == "" {
 			parsedConfig.SubExperiments[index].Runtime = parsedConfig.Runtime
 		}
+		if parsedConfig.SubExperiments[index].Handler == "" {
+			parsedConfig.SubExperiments[index].Handler = defaultFunctionHandler(parsedConfig.SubExperiments[index])
+		}
 		if parsedConfig.SubExperiments[index].Visualization == "" {
 			parsedConfig.SubExperiments[index].Visualization = defaultVisualization
 		}
@@ -128,14 +213,221 @@ func ExtractConfiguration(configFilePath string) Configuration {
 		if parsedConfig.SubExperiments[index].DataTransferChainLength == 0 {
 			parsedConfig.SubExperiments[index].DataTransferChainLength = defaultDataTransferChainLength
 		}
-		if parsedConfig.SubExperiments[index].FunctionMemoryMB == 0 {
+		if parsedConfig.SubExperiments[index].FunctionMemoryMB == 0 && !memoryDefaultedByProvider[parsedConfig.SubExperiments[index].Provider] {
 			parsedConfig.SubExperiments[index].FunctionMemoryMB = defaultFunctionMemoryMB
 		}
 		if parsedConfig.SubExperiments[index].Parallelism == 0 {
 			parsedConfig.SubExperiments[index].Parallelism = defaultParallelism
 		}
+		if parsedConfig.SubExperiments[index].Region == "" {
+			parsedConfig.SubExperiments[index].Region = DefaultRegion(parsedConfig.SubExperiments[index].Provider)
+		}
+		if parsedConfig.SubExperiments[index].FillerSeed == nil {
+			fillerSeed := parsedConfig.FillerSeed + int64(index)
+			parsedConfig.SubExperiments[index].FillerSeed = &fillerSeed
+		}
+		if parsedConfig.SubExperiments[index].Architecture == "" {
+			parsedConfig.SubExperiments[index].Architecture = defaultArchitecture
+		}
+		validateArchitecture(parsedConfig.SubExperiments[index])
+		validateInitWorkload(parsedConfig.SubExperiments[index])
+		validateDataTransferChain(parsedConfig.SubExperiments[index])
+		if parsedConfig.SubExperiments[index].ServiceTimeMode == "" {
+			parsedConfig.SubExperiments[index].ServiceTimeMode = defaultServiceTimeMode
+		}
+		validateServiceTimes(parsedConfig.SubExperiments[index])
+		validateServiceTimeMode(parsedConfig.SubExperiments[index])
+		validateWorkload(parsedConfig.SubExperiments[index])
+		if parsedConfig.SubExperiments[index].ExperimentType == "" {
+			parsedConfig.SubExperiments[index].ExperimentType = defaultExperimentType
+		}
+		validateExperimentType(&parsedConfig.SubExperiments[index])
+		validateForceCold(parsedConfig.SubExperiments[index])
 	}
 
 	log.Debugf("Extracted %d sub-experiments from given configuration file.", len(parsedConfig.SubExperiments))
 	return parsedConfig
 }
+
+// defaultFunctionHandler returns the handler of the function of the repository in the runtime of the sub-experiment
+func defaultFunctionHandler(subExperiment SubExperiment) string {
+	if runtime, ok := runtimes.Lookup(subExperiment.Runtime); ok {
+		return runtime.Handler(subExperiment.Function)
+	}
+	return defaultHandler
+}
+
+// validateArchitecture ensures the instruction set architecture of the sub-experiment can be deployed to its provider
+func validateArchitecture(subExperiment SubExperiment) {
+	switch subExperiment.Architecture {
+	case "x86_64":
+		return
+	case "arm64":
+		switch subExperiment.Provider {
+		case "aws", "vhive", "openfaas":
+			return
+		case "gcr":
+			log.Fatalf("Sub-experiment %q targets arm64, but Cloud Run only runs x86_64 container images.", subExperiment.Title)
+		default:
+			log.Fatalf("Sub-experiment %q targets arm64, which is not supported for provider %s.", subExperiment.Title, subExperiment.Provider)
+		}
+	default:
+		log.Fatalf("Sub-experiment %q has unrecognized architecture %q, expected x86_64 or arm64.", subExperiment.Title, subExperiment.Architecture)
+	}
+}
+
+// validateInitWorkload ensures the init-time workload knobs of the sub-experiment are not negative and, if set, are run
+// by its functions
+func validateInitWorkload(subExperiment SubExperiment) {
+	if subExperiment.InitImportModules < 0 || subExperiment.InitDependencyBundleMB < 0 || subExperiment.InitReadFillerMB < 0 ||
+		subExperiment.InitMemoryMB < 0 || subExperiment.InitSleepMilliseconds < 0 {
+		log.Fatalf("Sub-experiment %q has a negative init-time workload setting.", subExperiment.Title)
+	}
+	if len(subExperiment.InitEnvironment()) > 0 && !runsWorkloads(subExperiment) {
+		log.Fatalf("Sub-experiment %q: the init-time workload is only run by the hellopy, hellonode and hellogo functions and the functions generated from a specification, on aws or from ZIP artifacts on docker-local.",
+			subExperiment.Title)
+	}
+}
+
+// validateDataTransferChain ensures the functions of the sub-experiment can invoke the next functions of its data
+// transfer chain. Functions generated from a specification call the chain IDs as URLs without signing the requests,
+// whereas the chain IDs of AWS functions are bare API Gateway IDs.
+func validateDataTransferChain(subExperiment SubExperiment) {
+	if subExperiment.DataTransferChainLength > 1 && code_generation.HasSpecification(subExperiment.Function) {
+		log.Fatalf("Sub-experiment %q: DataTransferChainLength %d is not supported by function %s, generated from a specification.",
+			subExperiment.Title, subExperiment.DataTransferChainLength, subExperiment.Function)
+	}
+}
+
+// validateServiceTimes ensures the service time distribution of the sub-experiment, if any, is valid
+func validateServiceTimes(subExperiment SubExperiment) {
+	if subExperiment.ServiceTimeDistribution == nil {
+		return
+	}
+	if err := subExperiment.ServiceTimeDistribution.Validate(); err != nil {
+		log.Fatalf("Sub-experiment %q has an invalid service time distribution: %s", subExperiment.Title, err.Error())
+	}
+}
+
+// ServiceTimeLabel summarizes the service times of the sub-experiment, e.g., 10ms or exponential50ms
+func (s *SubExperiment) ServiceTimeLabel() string {
+	if s.ServiceTimeDistribution != nil {
+		return s.ServiceTimeDistribution.Label()
+	}
+	return s.DesiredServiceTimes[0]
+}
+
+// BusySpinIncrement returns the busy-spin increment of a request of the given service time
+func (s *SubExperiment) BusySpinIncrement(serviceTimeMs float64) int64 {
+	return int64(serviceTimeMs * s.BusySpinIncrementsPerMillisecond)
+}
+
+// InitEnvironment returns the environment variables through which the functions of the sub-experiment receive its
+// init-time workload, for the knobs that are set
+func (s *SubExperiment) InitEnvironment() map[string]string {
+	environment := make(map[string]string)
+	if s.InitImportModules > 0 {
+		environment["STELLAR_INIT_IMPORT_MODULES"] = strconv.Itoa(s.InitImportModules)
+	}
+	if s.InitDependencyBundleMB > 0 {
+		environment["STELLAR_INIT_DEPENDENCY_BUNDLE_MB"] = strconv.FormatFloat(s.InitDependencyBundleMB, 'f', -1, 64)
+	}
+	if s.InitReadFillerMB > 0 {
+		environment["STELLAR_INIT_READ_FILLER_MB"] = strconv.FormatFloat(s.InitReadFillerMB, 'f', -1, 64)
+	}
+	if s.InitMemoryMB > 0 {
+		environment["STELLAR_INIT_MEMORY_MB"] = strconv.Itoa(s.InitMemoryMB)
+	}
+	if s.InitSleepMilliseconds > 0 {
+		environment["STELLAR_INIT_SLEEP_MS"] = strconv.Itoa(s.InitSleepMilliseconds)
+	}
+	return environment
+}
+
+// environmentAssignments returns the NAME=value assignments of the environment variables, sorted by name
+func environmentAssignments(environment map[string]string) []string {
+	var assignments []string
+	for name, value := range environment {
+		assignments = append(assignments, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(assignments)
+	return assignments
+}
+
+// FillerOptions returns the content and layout of the filler padding the artifacts of the sub-experiment
+func (s *SubExperiment) FillerOptions() packaging.FillerOptions {
+	var seed int64
+	if s.FillerSeed != nil {
+		seed = *s.FillerSeed
+	}
+	return packaging.FillerOptions{
+		Seed:             seed,
+		Profile:          s.FillerProfile,
+		CompressionRatio: s.FillerCompressionRatio,
+		Files:            s.FillerFiles,
+	}
+}
+
+// InitZIPSources returns the entries added to the ZIP artifacts of the sub-experiment for its init-time workload
+func (s *SubExperiment) InitZIPSources() []packaging.ZIPSource {
+	if s.InitDependencyBundleMB <= 0 {
+		return nil
+	}
+	return []packaging.ZIPSource{packaging.DependencyBundleZIPSource(s.Runtime, util.MebibyteToBytes(s.InitDependencyBundleMB))}
+}
+
+// Regions returns the distinct regions targeted by the sub-experiments, in order of first appearance.
+func (c *Configuration) Regions() []string {
+	var regions []string
+	for _, subExperiment := range c.SubExperiments {
+		if !util.StringContains(regions, subExperiment.Region) {
+			regions = append(regions, subExperiment.Region)
+		}
+	}
+	return regions
+}
+
+// Providers returns the distinct providers targeted by the sub-experiments, in order of first appearance.
+func (c *Configuration) Providers() []string {
+	var providers []string
+	for _, subExperiment := range c.SubExperiments {
+		if !util.StringContains(providers, subExperiment.Provider) {
+			providers = append(providers, subExperiment.Provider)
+		}
+	}
+	return providers
+}
+
+// ForProvider returns a copy of the configuration restricted to the sub-experiments targeting the given provider.
+// The provider-wide runtime is taken from the first of these sub-experiments setting one, never from the sub-experiments
+// of other providers, so that each provider can be given its own.
+// Results of provisioning the copy can be brought back with MergeSubExperiments.
+func (c *Configuration) ForProvider(provider string) Configuration {
+	providerConfig := *c
+	providerConfig.Provider = provider
+	providerConfig.SubExperiments = []SubExperiment{}
+	providerRuntimeSet := false
+	for _, subExperiment := range c.SubExperiments {
+		if subExperiment.Provider != provider {
+			continue
+		}
+		providerConfig.SubExperiments = append(providerConfig.SubExperiments, subExperiment)
+		if !providerRuntimeSet && subExperiment.Runtime != "" {
+			providerConfig.Runtime = subExperiment.Runtime
+			providerRuntimeSet = true
+		}
+	}
+	return providerConfig
+}
+
+// MergeSubExperiments overwrites the sub-experiments of the configuration with those of the same ID in the given one.
+func (c *Configuration) MergeSubExperiments(other Configuration) {
+	for _, subExperiment := range other.SubExperiments {
+		for index := range c.SubExperiments {
+			if c.SubExperiments[index].ID == subExperiment.ID {
+				c.SubExperiments[index] = subExperiment
+				break
+			}
+		}
+	}
+}
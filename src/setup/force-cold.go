@@ -0,0 +1,66 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os/exec"
+	"stellar/setup/deployment/connection/amazon"
+	"stellar/util"
+	"strconv"
+	"time"
+)
+
+// forceColdVariable is the environment variable of the functions updated to invalidate their warm instances
+const forceColdVariable = "STELLAR_FORCE_COLD"
+
+// validateForceCold ensures the warm instances of the sub-experiment can be invalidated before each of its bursts
+func validateForceCold(subExperiment SubExperiment) {
+	if !subExperiment.ForceCold {
+		return
+	}
+	switch subExperiment.Provider {
+	case "aws", "gcr", "vhive":
+	default:
+		log.Fatalf("Sub-experiment %q: ForceCold is not supported for provider %s, expected aws, gcr or vhive.", subExperiment.Title, subExperiment.Provider)
+	}
+	if subExperiment.ExperimentType != BurstsExperiment {
+		log.Fatalf("Sub-experiment %q: ForceCold can only be used with the bursts experiment.", subExperiment.Title)
+	}
+	if subExperiment.ProvisionedConcurrency > 0 || subExperiment.MinInstances > 0 {
+		log.Fatalf("Sub-experiment %q: ForceCold cannot be used with instances kept warm by ProvisionedConcurrency or MinInstances.", subExperiment.Title)
+	}
+}
+
+// ForceColdStart invalidates the warm instances of the functions behind the given endpoint of the sub-experiment: it
+// updates an environment variable of the Lambda function or rolls out a new revision of the Cloud Run or Knative
+// services, then waits until the update has taken effect, so that the next requests are served by new instances.
+func ForceColdStart(subExperiment SubExperiment, endpointIndex int) {
+	endpoint := subExperiment.Endpoints[endpointIndex]
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 10)
+	log.Infof("[sub-experiment %d] Forcing cold starts of the functions behind gateway with ID %q...", subExperiment.ID, endpoint.ID)
+
+	switch subExperiment.Provider {
+	case "aws":
+		amazon.Instance(subExperiment.Region).SetFunctionEnvironmentVariable(subExperiment.Routes[endpointIndex], forceColdVariable, nonce)
+	case "gcr":
+		for _, service := range endpoint.ServiceNames {
+			util.RunCommandAndLog(exec.Command("gcloud", GCRForceColdArguments(service, endpoint.Region, nonce)...))
+		}
+	case "vhive":
+		for _, service := range endpoint.ServiceNames {
+			if err := knativeDeployer().ForceNewRevision(context.Background(), service, nonce, knativeReadinessTimeout); err != nil {
+				log.Fatalf("[sub-experiment %d] Could not roll out a new revision of Knative Service %s: %s", subExperiment.ID, service, err.Error())
+			}
+		}
+	default:
+		log.Fatalf("[sub-experiment %d] ForceCold is not supported for provider %s.", subExperiment.ID, subExperiment.Provider)
+	}
+}
+
+// GCRForceColdArguments returns the arguments of the gcloud command rolling out a new revision of the given container
+// service, which returns once the revision serves all of its traffic
+func GCRForceColdArguments(service string, region string, nonce string) []string {
+	return []string{"run", "services", "update", service, "--quiet", "--region", region,
+		"--update-env-vars", fmt.Sprintf("%s=%s", forceColdVariable, nonce)}
+}
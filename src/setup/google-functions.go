@@ -0,0 +1,171 @@
+package setup
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os/exec"
+	"regexp"
+	"stellar/setup/runtimes"
+	"stellar/util"
+	"strconv"
+	"strings"
+)
+
+// GoogleFunctionSource locates the source code of a function deployable to Google Cloud Functions
+type GoogleFunctionSource struct {
+	Directory  string
+	EntryPoint string
+	// Runtime is used when the sub-experiment runtime is for another language than the source
+	Runtime string
+}
+
+// googleFunctionSources are the functions deployable to Google Cloud Functions. Sources outside of the
+// serverless directory of the provider are given relative to the src directory.
+var googleFunctionSources = map[string]GoogleFunctionSource{
+	"producer-consumer": {Directory: "setup/deployment/raw-code/functions/producer-consumer/google", EntryPoint: "ProducerConsumer", Runtime: "go121"},
+	"hellopy":           {Directory: "hellopy", EntryPoint: "hello_world", Runtime: "python39"},
+}
+
+var googleRuntimeRegex = regexp.MustCompile(`^[a-z]+[0-9]+$`)
+
+// ProvisionFunctionsGoogle deploys the functions of the sub-experiments to Google Cloud Functions (gen1 or gen2),
+// one per parallelism, and assigns their endpoints.
+func ProvisionFunctionsGoogle(config *Configuration, serverlessDirPath string) {
+	for index := range config.SubExperiments {
+		subExperiment := &config.SubExperiments[index]
+		source, ok := googleFunctionSources[subExperiment.Function]
+		if !ok {
+			log.Fatalf("[sub-experiment %d] Function %s is not available for Google Cloud Functions.", subExperiment.ID, subExperiment.Function)
+		}
+		if !strings.HasPrefix(source.Directory, "setup/") {
+			source.Directory = serverlessDirPath + source.Directory
+		}
+
+		randomTag := util.GenerateRandLowercaseLetters(5)
+		DeployGoogleFunctions(subExperiment, index, randomTag, source)
+	}
+}
+
+// DeployGoogleFunctions deploys the functions of a sub-experiment to Google Cloud Functions
+func DeployGoogleFunctions(subex *SubExperiment, index int, randomTag string, source GoogleFunctionSource) {
+	region := subex.Region
+	if region == "" {
+		region = GOOGLE_DEFAULT_REGION
+	}
+
+	log.Infof("Deploying function(s) to Google Cloud Functions region %s...", region)
+	for i := 0; i < subex.Parallelism; i++ {
+		name := strings.ToLower(fmt.Sprintf("%s-%s", randomTag, createName(subex, index, i)))
+		providerDeployedServices["google"] = append(providerDeployedServices["google"], deployedService{Name: name, Region: region}) // Used for function removal
+
+		util.RunCommandAndLog(exec.Command("gcloud", GoogleDeployArguments(subex, name, source, region)...))
+
+		describeCommand := exec.Command("gcloud", "functions", "describe", name, "--region", region, "--format", "json")
+		endpointID := GetGoogleEndpointID(util.RunCommandAndLog(describeCommand))
+		subex.Endpoints = append(subex.Endpoints, EndpointInfo{ID: endpointID, Region: region})
+		subex.AddRoute("")
+	}
+}
+
+// GoogleDeployArguments returns the arguments of the gcloud command deploying the given HTTP function
+func GoogleDeployArguments(subex *SubExperiment, name string, source GoogleFunctionSource, region string) []string {
+	arguments := []string{"functions", "deploy", name, "--region", region, "--runtime", googleSubExperimentRuntime(subex, source),
+		"--source", source.Directory, "--entry-point", source.EntryPoint, "--trigger-http", "--allow-unauthenticated", "--quiet"}
+
+	switch subex.ExecutionEnvironment {
+	case "":
+	case "gen1":
+		arguments = append(arguments, "--no-gen2")
+	case "gen2":
+		arguments = append(arguments, "--gen2")
+	default:
+		log.Fatalf("[sub-experiment %d] Unrecognized Cloud Functions generation %q, expected gen1 or gen2.", subex.ID, subex.ExecutionEnvironment)
+	}
+	if subex.FunctionMemoryMB > 0 {
+		arguments = append(arguments, "--memory", fmt.Sprintf("%dMB", subex.FunctionMemoryMB))
+	}
+	if subex.MinInstances > 0 {
+		arguments = append(arguments, "--min-instances", strconv.Itoa(subex.MinInstances))
+	}
+	if subex.MaxInstances > 0 {
+		if subex.MaxInstances < subex.MinInstances {
+			log.Fatalf("[sub-experiment %d] Maximum instances (%d) cannot be lower than minimum instances (%d).",
+				subex.ID, subex.MaxInstances, subex.MinInstances)
+		}
+		arguments = append(arguments, "--max-instances", strconv.Itoa(subex.MaxInstances))
+	}
+	if subex.TimeoutSeconds > 0 {
+		arguments = append(arguments, "--timeout", fmt.Sprintf("%ds", subex.TimeoutSeconds))
+	}
+	if environment := subex.FunctionEnvironment(); len(environment) > 0 {
+		arguments = append(arguments, "--set-env-vars", strings.Join(environmentAssignments(environment), ","))
+	}
+	return arguments
+}
+
+// GoogleRuntime converts a runtime in the format of the configuration (e.g., python3.9, nodejs18.x or go1.x)
+// to a Google Cloud Functions runtime (e.g., python39, nodejs18 or go121).
+func GoogleRuntime(runtime string) string {
+	if googleRuntimeRegex.MatchString(runtime) {
+		return runtime
+	}
+	if registered, ok := runtimes.Lookup(runtime); ok {
+		if identifier, offered := registered.ProviderIdentifier("google"); offered {
+			return identifier
+		}
+		log.Warnf("Runtime %s is not offered by Google Cloud Functions.", runtime)
+		return registered.Name
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(runtime, ".x"), ".", "")
+}
+
+// googleSubExperimentRuntime returns the runtime of the sub-experiment, unless it targets another language than the function source
+func googleSubExperimentRuntime(subex *SubExperiment, source GoogleFunctionSource) string {
+	runtime := GoogleRuntime(subex.Runtime)
+	language := strings.TrimRight(source.Runtime, "0123456789")
+	if !strings.HasPrefix(runtime, language) {
+		log.Warnf("[sub-experiment %d] Runtime %s does not match the source of function %s, using %s instead.",
+			subex.ID, subex.Runtime, subex.Function, source.Runtime)
+		return source.Runtime
+	}
+	return runtime
+}
+
+// googleFunctionDescription is the part of a Cloud Functions description holding its URL, for both generations
+type googleFunctionDescription struct {
+	URL          string `json:"url"`
+	HTTPSTrigger struct {
+		URL string `json:"url"`
+	} `json:"httpsTrigger"`
+}
+
+// GetGoogleEndpointID extracts the endpoint ID (e.g., us-west2-zinc-hour-315914.cloudfunctions.net/hellopy-1)
+// from the JSON description of a deployed function
+func GetGoogleEndpointID(description string) string {
+	var parsedDescription googleFunctionDescription
+	if err := json.Unmarshal([]byte(description), &parsedDescription); err != nil {
+		log.Fatalf("Could not parse Google Cloud Functions description: %s", err.Error())
+	}
+
+	functionURL := parsedDescription.URL
+	if functionURL == "" {
+		functionURL = parsedDescription.HTTPSTrigger.URL
+	}
+	if functionURL == "" {
+		log.Fatalf("Could not find the URL of the Google Cloud Function in its description: %s", description)
+	}
+	return strings.TrimPrefix(functionURL, "https://")
+}
+
+// RemoveGoogleAllFunctions removes all Google Cloud Functions deployed for the sub-experiments
+func RemoveGoogleAllFunctions() []string {
+	var deleteMessages []string
+	for _, function := range providerDeployedServices["google"] {
+		log.Infof("Deleting Google Cloud Function %s in region %s...", function.Name, function.Region)
+		deleteCommand := exec.Command("gcloud", "functions", "delete", "--quiet", "--region", function.Region, function.Name)
+		deleteMessages = append(deleteMessages, util.RunCommandAndLog(deleteCommand))
+	}
+	providerDeployedServices["google"] = nil
+	return deleteMessages
+}
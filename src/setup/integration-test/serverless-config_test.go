@@ -41,7 +41,7 @@ func TestDeployAndRemoveServiceGCR(t *testing.T) {
 	}
 
 	s.DeployGCRContainerService(subex, 0, "abc12", "docker.io/kkmin/hellopy", "../deployment/raw-code/serverless/gcr/hellopy/", "us-west1")
-	deleteMsg := setup.RemoveGCRSingleService("abc12-hellopytest-0-0")
+	deleteMsg := setup.RemoveGCRSingleService("abc12-hellopytest-0-0", "us-west1")
 	assert.True(strings.Contains(deleteMsg, "Deleted service [abc12-hellopytest-0-0]"))
 }
 
@@ -64,7 +64,7 @@ func TestDeployAndRemoveServiceGCRCPUBoost(t *testing.T) {
 	}
 
 	s.DeployGCRContainerService(subex, 0, "def12", "docker.io/kkmin/hellopy", "../deployment/raw-code/serverless/gcr/hellopy/", "us-west1")
-	deleteMsg := setup.RemoveGCRSingleService("def12-cpuboosttest-0-0")
+	deleteMsg := setup.RemoveGCRSingleService("def12-cpuboosttest-0-0", "us-west1")
 	assert.True(strings.Contains(deleteMsg, "Deleted service [def12-cpuboosttest-0-0]"))
 }
 
@@ -0,0 +1,134 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"stellar/setup/deployment/connection/knative"
+	"stellar/util"
+	"strings"
+	"time"
+)
+
+const (
+	knativeReadinessTimeout = 5 * time.Minute
+	maxKnativeNameLength    = 63
+)
+
+// knativeFunctionImages are the images deployed to vHive for each function, unless the sub-experiment sets ContainerImage
+var knativeFunctionImages = map[string]string{
+	"producer-consumer": "vhiveease/stellar:prodcons",
+	"hellopy":           "vhiveease/stellar:hellopy",
+	"chameleon":         "vhiveease/stellar:chameleon",
+	"rnnserving":        "vhiveease/stellar:rnnserving",
+}
+
+// KnativeDeployer deploys the functions of vHive sub-experiments. It connects to the cluster of the default
+// kubeconfig unless set beforehand, e.g., to a deployer using a fake Kubernetes client in tests.
+var KnativeDeployer *knative.Deployer
+
+func knativeDeployer() *knative.Deployer {
+	if KnativeDeployer == nil {
+		KnativeDeployer = knative.NewDeployerFromKubeconfig("", knative.DefaultNamespace)
+	}
+	return KnativeDeployer
+}
+
+// ProvisionFunctionsKnative deploys a Knative Service for every function of the sub-experiments (one per parallelism
+// and position in the data transfer chain), waits for them to be ready and assigns their endpoints.
+func ProvisionFunctionsKnative(config *Configuration) {
+	deployer := knativeDeployer()
+	ctx := context.Background()
+	randomTag := util.GenerateRandLowercaseLetters(5)
+
+	for index := range config.SubExperiments {
+		subExperiment := &config.SubExperiments[index]
+		image := KnativeImage(subExperiment)
+
+		serviceNames := make([][]string, subExperiment.Parallelism)
+		for parallelism := 0; parallelism < subExperiment.Parallelism; parallelism++ {
+			for chainPosition := 0; chainPosition < subExperiment.DataTransferChainLength; chainPosition++ {
+				name := knativeServiceName(subExperiment, randomTag, index, parallelism, chainPosition)
+				spec := knative.ServiceSpec{
+					Name:                 name,
+					Image:                image,
+					StockKnative:         subExperiment.StockKnative,
+					MemoryMB:             subExperiment.FunctionMemoryMB,
+					MinScale:             subExperiment.MinInstances,
+					MaxScale:             subExperiment.MaxInstances,
+					ContainerConcurrency: subExperiment.Concurrency,
+				}
+				if err := deployer.Apply(ctx, spec); err != nil {
+					log.Fatalf("[sub-experiment %d] Could not apply Knative Service %s: %s", subExperiment.ID, name, err.Error())
+				}
+				providerDeployedServices["vhive"] = append(providerDeployedServices["vhive"], deployedService{Name: name}) // Used for function removal
+				serviceNames[parallelism] = append(serviceNames[parallelism], name)
+			}
+		}
+
+		if subExperiment.Endpoints == nil {
+			subExperiment.Endpoints = []EndpointInfo{}
+		}
+		for _, chainNames := range serviceNames {
+			var chainTargets []string
+			for _, name := range chainNames {
+				serviceURL, err := deployer.WaitForReady(ctx, name, knativeReadinessTimeout)
+				if err != nil {
+					log.Fatalf("[sub-experiment %d] %s", subExperiment.ID, err.Error())
+				}
+				chainTargets = append(chainTargets, knative.GRPCTarget(serviceURL))
+			}
+
+			subExperiment.Endpoints = append(subExperiment.Endpoints, EndpointInfo{
+				ID:                   chainTargets[0],
+				DataTransferChainIDs: chainTargets[1:],
+				Region:               subExperiment.Region,
+				ServiceNames:         chainNames,
+			})
+			subExperiment.AddRoute("")
+		}
+		log.Infof("[sub-experiment %d] Deployed %d Knative Service(s) with image %s.",
+			subExperiment.ID, subExperiment.Parallelism*subExperiment.DataTransferChainLength, image)
+	}
+}
+
+// RemoveKnativeAllServices removes all Knative Services deployed for the sub-experiments
+func RemoveKnativeAllServices() []string {
+	deployer := knativeDeployer()
+
+	var removeServiceMessages []string
+	for _, service := range providerDeployedServices["vhive"] {
+		if err := deployer.Remove(context.Background(), service.Name); err != nil {
+			log.Errorf("Could not remove Knative Service %s: %s", service.Name, err.Error())
+			continue
+		}
+		removeServiceMessages = append(removeServiceMessages, fmt.Sprintf("Knative Service %s removed.", service.Name))
+	}
+	providerDeployedServices["vhive"] = nil
+	return removeServiceMessages
+}
+
+// KnativeImage returns the container image deployed for the function of the sub-experiment
+func KnativeImage(subExperiment *SubExperiment) string {
+	if subExperiment.ContainerImage != "" {
+		return subExperiment.ContainerImage
+	}
+	image, ok := knativeFunctionImages[subExperiment.Function]
+	if !ok {
+		log.Fatalf("[sub-experiment %d] No vHive image known for function %s, please set ContainerImage.", subExperiment.ID, subExperiment.Function)
+	}
+	return image
+}
+
+// knativeServiceName creates a DNS-compatible name, as required for Knative Services
+func knativeServiceName(subExperiment *SubExperiment, randomTag string, index int, parallelism int, chainPosition int) string {
+	suffix := fmt.Sprintf("-%d-%d-%d", index, parallelism, chainPosition)
+	prefix := fmt.Sprintf("stellar-%s-", randomTag)
+
+	title := strings.ToLower(nonAlphanumericRegex.ReplaceAllString(subExperiment.Title, ""))
+	title = strings.ReplaceAll(title, " ", "")
+	if maxTitleLength := maxKnativeNameLength - len(prefix) - len(suffix); len(title) > maxTitleLength {
+		title = title[:maxTitleLength]
+	}
+	return strings.TrimSuffix(prefix+title, "-") + suffix
+}
@@ -0,0 +1,100 @@
+package setup
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"stellar/setup/deployment/connection/openfaas"
+	"stellar/setup/deployment/packaging"
+	"stellar/util"
+	"strings"
+	"time"
+)
+
+const (
+	openfaasReadinessTimeout = 5 * time.Minute
+	maxOpenFaaSNameLength    = 63
+)
+
+// ProvisionFunctionsOpenFaaS deploys a function for every parallelism of the sub-experiments through the REST API
+// of the OpenFaaS gateway, waits for them to be ready and assigns their endpoints.
+func ProvisionFunctionsOpenFaaS(config *Configuration) {
+	client := openfaas.Instance()
+	randomTag := util.GenerateRandLowercaseLetters(5)
+
+	for index := range config.SubExperiments {
+		subExperiment := &config.SubExperiments[index]
+		if subExperiment.DataTransferChainLength > 1 || subExperiment.StorageTransfer {
+			log.Fatalf("[sub-experiment %d] Data transfers are not supported on OpenFaaS.", subExperiment.ID)
+		}
+
+		image := subExperiment.ContainerImage
+		if image == "" {
+			var imageSize packaging.ImageSize
+			image, imageSize = packaging.SetupContainerImageDeployment(subExperiment.Function, config.Provider, subExperiment.Region,
+				subExperiment.Architecture, subExperiment.FunctionImageSizeMB, subExperiment.FillerOptions())
+			subExperiment.ImageSize = &imageSize
+		}
+
+		var names []string
+		for parallelism := 0; parallelism < subExperiment.Parallelism; parallelism++ {
+			name := openfaasFunctionName(subExperiment, randomTag, index, parallelism)
+			spec := openfaas.FunctionSpec{
+				Name:           name,
+				Image:          image,
+				MemoryMB:       subExperiment.FunctionMemoryMB,
+				CPU:            subExperiment.FunctionCPU,
+				MinScale:       subExperiment.MinInstances,
+				MaxScale:       subExperiment.MaxInstances,
+				Concurrency:    subExperiment.Concurrency,
+				TimeoutSeconds: subExperiment.TimeoutSeconds,
+				Environment:    subExperiment.FunctionEnvironment(),
+			}
+			log.Infof("[sub-experiment %d] Deploying OpenFaaS function %s with image %s", subExperiment.ID, name, image)
+			if err := client.Deploy(spec); err != nil {
+				log.Fatalf("[sub-experiment %d] Could not deploy OpenFaaS function %s: %s", subExperiment.ID, name, err.Error())
+			}
+			providerDeployedServices["openfaas"] = append(providerDeployedServices["openfaas"], deployedService{Name: name}) // Used for function removal
+			names = append(names, name)
+		}
+
+		if subExperiment.Endpoints == nil {
+			subExperiment.Endpoints = []EndpointInfo{}
+		}
+		for _, name := range names {
+			if err := client.WaitForReady(name, openfaasReadinessTimeout); err != nil {
+				log.Fatalf("[sub-experiment %d] %s", subExperiment.ID, err.Error())
+			}
+			subExperiment.Endpoints = append(subExperiment.Endpoints, EndpointInfo{ID: client.FunctionURL(name), Region: subExperiment.Region})
+			subExperiment.AddRoute("")
+		}
+		log.Infof("[sub-experiment %d] Deployed %d OpenFaaS function(s).", subExperiment.ID, subExperiment.Parallelism)
+	}
+}
+
+// RemoveOpenFaaSAllFunctions removes all OpenFaaS functions deployed for the sub-experiments
+func RemoveOpenFaaSAllFunctions() []string {
+	client := openfaas.Instance()
+
+	var removeServiceMessages []string
+	for _, function := range providerDeployedServices["openfaas"] {
+		if err := client.Remove(function.Name); err != nil {
+			log.Errorf("Could not remove OpenFaaS function %s: %s", function.Name, err.Error())
+			continue
+		}
+		removeServiceMessages = append(removeServiceMessages, fmt.Sprintf("OpenFaaS function %s removed.", function.Name))
+	}
+	providerDeployedServices["openfaas"] = nil
+	return removeServiceMessages
+}
+
+// openfaasFunctionName creates a DNS-compatible name, as required for OpenFaaS functions
+func openfaasFunctionName(subExperiment *SubExperiment, randomTag string, index int, parallelism int) string {
+	suffix := fmt.Sprintf("-%d-%d", index, parallelism)
+	prefix := fmt.Sprintf("stellar-%s-", randomTag)
+
+	title := strings.ToLower(strings.ReplaceAll(nonAlphanumericRegex.ReplaceAllString(subExperiment.Title, ""), " ", ""))
+	if maxTitleLength := maxOpenFaaSNameLength - len(prefix) - len(suffix); len(title) > maxTitleLength {
+		title = title[:maxTitleLength]
+	}
+	return prefix + title + suffix
+}
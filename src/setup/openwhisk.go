@@ -0,0 +1,102 @@
+package setup
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"stellar/setup/deployment/connection/openwhisk"
+	"stellar/util"
+	"strings"
+)
+
+// openwhiskFunctionSources are the single-file action sources deployed to OpenWhisk for each function
+var openwhiskFunctionSources = map[string]string{
+	"hellopy":           "main.py",
+	"hellonode":         "index.js",
+	"hellogo":           "main.go",
+	"producer-consumer": "main.go",
+}
+
+// ProvisionFunctionsOpenWhisk deploys a web action for every function of the sub-experiments (one per parallelism)
+// through the OpenWhisk REST API and assigns their endpoints.
+func ProvisionFunctionsOpenWhisk(config *Configuration, serverlessDirPath string) {
+	client, err := openwhisk.Instance()
+	if err != nil {
+		log.Fatalf("Could not connect to OpenWhisk: %s", err.Error())
+	}
+	if err := client.ResolveNamespace(); err != nil {
+		log.Fatalf("Could not resolve the OpenWhisk namespace: %s", err.Error())
+	}
+	randomTag := util.GenerateRandLowercaseLetters(5)
+
+	for index := range config.SubExperiments {
+		subExperiment := &config.SubExperiments[index]
+		if subExperiment.DataTransferChainLength > 1 || subExperiment.StorageTransfer {
+			log.Fatalf("[sub-experiment %d] Data transfers are not supported on OpenWhisk.", subExperiment.ID)
+		}
+
+		sourceFile, ok := openwhiskFunctionSources[subExperiment.Function]
+		if !ok {
+			log.Fatalf("[sub-experiment %d] Function %s is not available for OpenWhisk.", subExperiment.ID, subExperiment.Function)
+		}
+		sourcePath := filepath.Join(serverlessDirPath, subExperiment.Function, sourceFile)
+		code, err := os.ReadFile(sourcePath)
+		if err != nil {
+			log.Fatalf("[sub-experiment %d] Could not read action source %s: %s", subExperiment.ID, sourcePath, err.Error())
+		}
+		kind, err := openwhisk.KindForSource(sourcePath, subExperiment.Runtime)
+		if err != nil {
+			log.Fatalf("[sub-experiment %d] %s", subExperiment.ID, err.Error())
+		}
+
+		if subExperiment.Endpoints == nil {
+			subExperiment.Endpoints = []EndpointInfo{}
+		}
+		for parallelism := 0; parallelism < subExperiment.Parallelism; parallelism++ {
+			name := openwhiskActionName(subExperiment, randomTag, index, parallelism)
+			spec := openwhisk.ActionSpec{
+				Name:           name,
+				Kind:           kind,
+				Code:           string(code),
+				MemoryMB:       subExperiment.FunctionMemoryMB,
+				TimeoutSeconds: subExperiment.TimeoutSeconds,
+				Concurrency:    subExperiment.Concurrency,
+			}
+			log.Infof("[sub-experiment %d] Deploying OpenWhisk action %s/%s (%s)", subExperiment.ID, client.Namespace, name, kind)
+			if err := client.PutAction(spec); err != nil {
+				log.Fatalf("[sub-experiment %d] Could not deploy OpenWhisk action %s: %s", subExperiment.ID, name, err.Error())
+			}
+			providerDeployedServices["openwhisk"] = append(providerDeployedServices["openwhisk"], deployedService{Name: name}) // Used for function removal
+
+			subExperiment.Endpoints = append(subExperiment.Endpoints, EndpointInfo{ID: client.WebActionURL(name), Region: subExperiment.Region})
+			subExperiment.AddRoute("")
+		}
+		log.Infof("[sub-experiment %d] Deployed %d OpenWhisk action(s).", subExperiment.ID, subExperiment.Parallelism)
+	}
+}
+
+// RemoveOpenWhiskAllActions removes all OpenWhisk actions deployed for the sub-experiments
+func RemoveOpenWhiskAllActions() []string {
+	client, err := openwhisk.Instance()
+	if err != nil {
+		log.Fatalf("Could not connect to OpenWhisk: %s", err.Error())
+	}
+
+	var removeServiceMessages []string
+	for _, action := range providerDeployedServices["openwhisk"] {
+		if err := client.DeleteAction(action.Name); err != nil {
+			log.Errorf("Could not remove OpenWhisk action %s: %s", action.Name, err.Error())
+			continue
+		}
+		removeServiceMessages = append(removeServiceMessages, fmt.Sprintf("OpenWhisk action %s removed.", action.Name))
+	}
+	providerDeployedServices["openwhisk"] = nil
+	return removeServiceMessages
+}
+
+// openwhiskActionName creates a name made of the characters allowed in OpenWhisk entity names
+func openwhiskActionName(subExperiment *SubExperiment, randomTag string, index int, parallelism int) string {
+	title := strings.ReplaceAll(nonAlphanumericRegex.ReplaceAllString(subExperiment.Title, ""), " ", "")
+	return fmt.Sprintf("stellar-%s-%s-%d-%d", randomTag, title, index, parallelism)
+}
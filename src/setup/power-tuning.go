@@ -0,0 +1,62 @@
+package setup
+
+import (
+	log "github.com/sirupsen/logrus"
+	"stellar/setup/deployment/connection/amazon"
+)
+
+const (
+	// PowerTuningExperiment sweeps the memory of one Lambda function, sending cold and warm bursts at every memory size
+	PowerTuningExperiment = "power-tuning"
+
+	minLambdaMemoryMB          = 128
+	maxLambdaMemoryMB          = 10240
+	defaultRoundsPerMemorySize = 1
+)
+
+// validatePowerTuning ensures the memory sizes of the power-tuning sub-experiment can be swept. The sub-experiment is
+// recorded as a cold and a warm burst per round at every memory size, all sized as its first burst size, and its
+// function is deployed with the first memory size.
+func validatePowerTuning(subExperiment *SubExperiment) {
+	if subExperiment.Provider != "aws" {
+		log.Fatalf("Sub-experiment %q: the power-tuning experiment is only supported by aws.", subExperiment.Title)
+	}
+	if len(subExperiment.MemorySizesMB) == 0 || len(subExperiment.BurstSizes) == 0 {
+		log.Fatalf("Sub-experiment %q: the power-tuning experiment needs MemorySizesMB and BurstSizes.", subExperiment.Title)
+	}
+	for _, memoryMB := range subExperiment.MemorySizesMB {
+		if memoryMB < minLambdaMemoryMB || memoryMB > maxLambdaMemoryMB {
+			log.Fatalf("Sub-experiment %q has a memory size of %dMB, expected between %dMB and %dMB.", subExperiment.Title,
+				memoryMB, minLambdaMemoryMB, maxLambdaMemoryMB)
+		}
+	}
+	if subExperiment.RoundsPerMemorySize == 0 {
+		subExperiment.RoundsPerMemorySize = defaultRoundsPerMemorySize
+	}
+	if subExperiment.RoundsPerMemorySize < 0 || subExperiment.LatencyTargetMs < 0 {
+		log.Fatalf("Sub-experiment %q has a negative RoundsPerMemorySize or LatencyTargetMs.", subExperiment.Title)
+	}
+	if subExperiment.Parallelism > 1 || subExperiment.ProvisionedConcurrency > 0 {
+		log.Fatalf("Sub-experiment %q: the power-tuning experiment sweeps one function without provisioned concurrency.", subExperiment.Title)
+	}
+
+	subExperiment.FunctionMemoryMB = subExperiment.MemorySizesMB[0]
+	subExperiment.Bursts = 2 * subExperiment.RoundsPerMemorySize * len(subExperiment.MemorySizesMB)
+	subExperiment.BurstSizes = subExperiment.BurstSizes[:1]
+	// The bursts follow each other without idle time, the cold starts being forced
+	subExperiment.IATSeconds = 0
+	subExperiment.IATType = "deterministic"
+}
+
+// PowerTuningBurst returns the memory size the burst with the given ID of the power-tuning sub-experiment is sent at,
+// and whether it is sent to new instances. Every round at a memory size is a cold burst followed by a warm one.
+func (s *SubExperiment) PowerTuningBurst(burstID int) (int64, bool) {
+	return s.MemorySizesMB[burstID/(2*s.RoundsPerMemorySize)], burstID%2 == 0
+}
+
+// UpdateFunctionMemory sets the memory of the function of the power-tuning sub-experiment and waits until the update
+// has taken effect. The requests that follow are served by new instances.
+func UpdateFunctionMemory(subExperiment SubExperiment, memoryMB int64) {
+	log.Infof("[sub-experiment %d] Setting the memory of function %s to %dMB...", subExperiment.ID, subExperiment.Routes[0], memoryMB)
+	amazon.Instance(subExperiment.Region).UpdateFunctionMemory(subExperiment.Routes[0], memoryMB)
+}
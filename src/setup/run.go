@@ -49,7 +49,8 @@ func ProvisionFunctions(config Configuration) {
 		storageSpaceWarnThreshold  = 500 // 500 * ~18KiB = 10MB just for 1 sub-experiment
 	)
 
-	availableEndpoints := connection.Singleton.ListAPIs()
+	// Endpoints are listed once per provider and region and consumed as they are assigned to sub-experiments
+	availableEndpointsByLocation := make(map[string][]connection.Endpoint)
 
 	for index, subExperiment := range config.SubExperiments {
 		config.SubExperiments[index].ID = index
@@ -73,21 +74,48 @@ func ProvisionFunctions(config Configuration) {
 			}
 		}
 
+		if subExperiment.Provider == "aws" {
+			validateAWSSDKDeployment(subExperiment)
+		}
+
+		location := fmt.Sprintf("%s/%s", subExperiment.Provider, subExperiment.Region)
+		availableEndpoints, listed := availableEndpointsByLocation[location]
+		if !listed {
+			availableEndpoints = connection.Get(subExperiment.Provider).ListAPIs(subExperiment.Region)
+		}
+
 		if availableEndpoints == nil { // hostname must be the endpoint itself (external URL)
-			config.SubExperiments[index].Endpoints = []EndpointInfo{{ID: config.Provider}}
+			config.SubExperiments[index].Endpoints = []EndpointInfo{{ID: subExperiment.Provider, Region: subExperiment.Region}}
 			continue
 		}
 
-		availableEndpoints = assignEndpoints(
+		availableEndpointsByLocation[location] = assignEndpoints(
 			availableEndpoints,
 			&config.SubExperiments[index],
-			config.Provider,
 		)
 	}
 
-	if amazon.AWSSingletonInstance != nil && amazon.AWSSingletonInstance.ImageURI != "" {
-		log.Info("A deployment was made using container images, waiting 10 seconds for changes to take effect with the provider...")
-		time.Sleep(time.Second * 10)
+	if amazon.AWSSingletonInstance != nil {
+		for _, region := range amazon.Regions() {
+			if amazon.Instance(region).ImageURI != "" {
+				log.Info("A deployment was made using container images, waiting 10 seconds for changes to take effect with the provider...")
+				time.Sleep(time.Second * 10)
+				break
+			}
+		}
+	}
+}
+
+// validateAWSSDKDeployment ensures the sub-experiment does not need settings that only the Serverless framework deploys
+// (-s), as the functions deployed and repurposed through the AWS SDK get their code, memory and architecture only
+func validateAWSSDKDeployment(subExperiment SubExperiment) {
+	if subExperiment.ProvisionedConcurrency > 0 || subExperiment.MinInstances > 0 || subExperiment.MaxInstances > 0 {
+		log.Fatalf("Sub-experiment %q: ProvisionedConcurrency, MinInstances and MaxInstances need functions deployed with the Serverless framework (-s).",
+			subExperiment.Title)
+	}
+	if len(subExperiment.FunctionEnvironment()) > 0 {
+		log.Fatalf("Sub-experiment %q: the init-time and per-request workloads need functions deployed with the Serverless framework (-s).",
+			subExperiment.Title)
 	}
 }
 
@@ -102,60 +130,88 @@ func ProvisionFunctionsServerless(config *Configuration, serverlessDirPath strin
 		ProvisionFunctionsGCR(config, serverlessDirPath)
 	case "cloudflare":
 		ProvisionFunctionsCloudflare(config, serverlessDirPath)
+	case "google":
+		ProvisionFunctionsGoogle(config, serverlessDirPath)
 	case "aliyun":
 		ProvisionFunctionsServerlessAlibaba(config, serverlessDirPath)
+	case "vhive":
+		ProvisionFunctionsKnative(config)
+	case "openwhisk":
+		ProvisionFunctionsOpenWhisk(config, serverlessDirPath)
+	case "openfaas":
+		ProvisionFunctionsOpenFaaS(config)
+	case "fermyon":
+		fallthrough
+	case "spin":
+		// Fermyon Cloud and local Spin runtimes share the applications in the spin directory
+		ProvisionFunctionsSpin(config, filepath.Join(filepath.Dir(filepath.Clean(serverlessDirPath)), "spin"))
+	case "docker-local":
+		ProvisionFunctionsDockerLocal(config, serverlessDirPath)
 	default:
 		log.Fatalf("Provider %s not supported for deployment", config.Provider)
 	}
 }
 
 // ProvisionFunctionsServerlessAWS will deploy, reconfigure, etc. functions to get ready for the sub-experiments.
+// One Serverless service is deployed for each region targeted by the sub-experiments.
 func ProvisionFunctionsServerlessAWS(config *Configuration, serverlessDirPath string) {
-	slsConfig := &Serverless{}
 	builder := &building.Builder{}
 
 	randomTag := util.GenerateRandLowercaseLetters(5)
-	slsConfig.CreateHeaderConfig(config, fmt.Sprintf("STeLLAR-%s", randomTag))
-	slsConfig.packageIndividually()
+	slsConfigs := make(map[string]*Serverless)
 
 	for index, subExperiment := range config.SubExperiments {
-		//TODO: generate the code
-		code_generation.GenerateCode(subExperiment.Function, config.Provider)
+		slsConfig, ok := slsConfigs[subExperiment.Region]
+		if !ok {
+			slsConfig = &Serverless{}
+			slsConfig.CreateHeaderConfig(config, fmt.Sprintf("STeLLAR-%s", randomTag), subExperiment.Region)
+			slsConfig.packageIndividually()
+			slsConfigs[subExperiment.Region] = slsConfig
+		}
+
+		// generate the source code of functions described by a specification
+		code_generation.GenerateCode(subExperiment.Function, config.Provider, subExperiment.Runtime)
 
 		// TODO: build the functions (Java and Golang)
-		artifactPathRelativeToServerlessConfigFile := builder.BuildFunction(config.Provider, subExperiment.Function, subExperiment.Runtime)
-		slsConfig.AddFunctionConfigAWS(&config.SubExperiments[index], index, randomTag, artifactPathRelativeToServerlessConfigFile)
+		artifactPathRelativeToServerlessConfigFile := builder.BuildFunction(config.Provider, subExperiment.Function, subExperiment.Runtime, subExperiment.Architecture)
 
-		// generate filler files and zip used as Serverless artifacts
-		packaging.GenerateServerlessZIPArtifacts(subExperiment.ID, config.Provider, subExperiment.Runtime, subExperiment.Function, subExperiment.FunctionImageSizeMB)
+		// generate filler files and zip used as Serverless artifacts, one per sub-experiment
+		if zipPath := packaging.GenerateServerlessZIPArtifacts(subExperiment.ID, config.Provider, subExperiment.Runtime, building.ArtifactName(subExperiment.Function, subExperiment.Architecture), subExperiment.FunctionImageSizeMB, subExperiment.FillerOptions(), subExperiment.InitZIPSources()...); zipPath != "" {
+			artifactPathRelativeToServerlessConfigFile = zipPath
+		}
+		slsConfig.AddFunctionConfigAWS(&config.SubExperiments[index], index, randomTag, artifactPathRelativeToServerlessConfigFile)
 	}
 
-	slsConfig.CreateServerlessConfigFile(fmt.Sprintf("%sserverless.yml", serverlessDirPath))
-
-	log.Infof("Starting functions deployment. Deploying %d functions to %s.", len(slsConfig.Functions), config.Provider)
-	slsDeployMessage := DeployService(serverlessDirPath)
-	log.Info(slsDeployMessage)
+	for _, region := range config.Regions() {
+		slsConfig := slsConfigs[region]
+		configFileName := ServerlessConfigFileName(config.Provider, region)
+		slsConfig.CreateServerlessConfigFile(filepath.Join(serverlessDirPath, configFileName))
 
-	// TODO: assign endpoints to subexperiments
-	// Get the endpoints by scraping the serverless deploy message.
+		log.Infof("Starting functions deployment. Deploying %d functions to %s (%s).", len(slsConfig.Functions), config.Provider, region)
+		slsDeployMessage := DeployServiceConfig(serverlessDirPath, configFileName)
+		log.Info(slsDeployMessage)
 
-	endpointID := GetAWSEndpointID(slsDeployMessage)
+		// Get the endpoints by scraping the serverless deploy message.
+		endpointID := GetAWSEndpointID(slsDeployMessage)
 
-	// Assign Endpoint ID to each deployed function
-	for i := range config.SubExperiments {
-		config.SubExperiments[i].AssignEndpointIDs(endpointID)
+		// Assign Endpoint ID to each function deployed in this region
+		for i := range config.SubExperiments {
+			if config.SubExperiments[i].Region == region {
+				config.SubExperiments[i].AssignEndpointIDs(endpointID)
+				WaitForAWSProvisionedConcurrency(config.SubExperiments[i])
+			}
+		}
 	}
-
 }
 
 func ProvisionFunctionsServerlessAzure(config *Configuration, serverlessDirPath string) {
 	randomExperimentTag := util.GenerateRandLowercaseLetters(5)
 
 	for subExperimentIndex, subExperiment := range config.SubExperiments {
-		code_generation.GenerateCode(subExperiment.Function, config.Provider)
+		code_generation.GenerateCode(subExperiment.Function, config.Provider, subExperiment.Runtime)
 
 		builder := &building.Builder{}
-		builder.BuildFunction(config.Provider, subExperiment.Function, subExperiment.Runtime)
+		builder.BuildFunction(config.Provider, subExperiment.Function, subExperiment.Runtime, subExperiment.Architecture)
 
 		if config.SubExperiments[subExperimentIndex].Endpoints == nil {
 			config.SubExperiments[subExperimentIndex].Endpoints = []EndpointInfo{}
@@ -196,11 +252,14 @@ func deploySubExperimentParallelismInBatches(config *Configuration, serverlessDi
 
 				fillerFileSize := packaging.CalculateFillerFileSizeInBytes(currentSizeInBytes, targetSizeInBytes)
 				fillerFilePath := filepath.Join(deploymentDir, "filler.file")
-				packaging.GenerateFillerFile(subExperiment.ID, fillerFilePath, fillerFileSize)
+				packaging.GenerateFillerFiles(subExperiment.ID, fillerFilePath, fillerFileSize, subExperiment.FillerOptions())
 
 				slsConfig := &Serverless{}
-				slsConfig.CreateHeaderConfig(config, fmt.Sprintf("%s-subex%d-para%d", randomExperimentTag, subExperimentIndex, parallelism))
+				slsConfig.CreateHeaderConfig(config, fmt.Sprintf("%s-subex%d-para%d", randomExperimentTag, subExperimentIndex, parallelism), subExperiment.Region)
 				slsConfig.addPlugin("serverless-azure-functions")
+				if subExperiment.AlwaysReadyInstances > 0 { // always ready instances are only offered on the Premium plan
+					slsConfig.Provider.Type = "premium"
+				}
 				name := createName(&subExperiment, subExperimentIndex, parallelism)
 				slsConfig.AddFunctionConfigAzure(&config.SubExperiments[subExperimentIndex], subExperimentIndex, name)
 				slsConfig.CreateServerlessConfigFile(filepath.Join(deploymentDir, "serverless.yml"))
@@ -209,9 +268,13 @@ func deploySubExperimentParallelismInBatches(config *Configuration, serverlessDi
 				slsDeployMessage := DeployService(deploymentDir)
 
 				endpointID := GetAzureEndpointID(slsDeployMessage)
+				if subExperiment.AlwaysReadyInstances > 0 {
+					SetAzureAlwaysReadyInstances(endpointID, subExperiment.AlwaysReadyInstances)
+				}
+
 				mu.Lock()
 				defer mu.Unlock()
-				endpoints[parallelism] = EndpointInfo{ID: endpointID}
+				endpoints[parallelism] = EndpointInfo{ID: endpointID, Region: subExperiment.Region}
 				routes[parallelism] = name
 			}(parallelism)
 		}
@@ -226,21 +289,16 @@ func deploySubExperimentParallelismInBatches(config *Configuration, serverlessDi
 
 func ProvisionFunctionsGCR(config *Configuration, serverlessDirPath string) {
 	slsConfig := &Serverless{}
-	slsConfig.CreateHeaderConfig(config, "STeLLAR-GCR")
+	slsConfig.CreateHeaderConfig(config, "STeLLAR-GCR", "")
 
 	for index, subExperiment := range config.SubExperiments {
 		switch subExperiment.PackageType {
 		case "Container":
-			// size of compressed images of GCR functions on Docker Hub are experimentally found to be approximately 21.84 MiB
-			currentSizeInBytes := util.MebibyteToBytes(21.84)
-			targetSizeInBytes := util.MebibyteToBytes(subExperiment.FunctionImageSizeMB)
-			fillerFileSize := packaging.CalculateFillerFileSizeInBytes(currentSizeInBytes, targetSizeInBytes)
-			fillerFilePath := filepath.Join(serverlessDirPath, subExperiment.Function, "filler.file")
-			packaging.GenerateFillerFile(subExperiment.ID, fillerFilePath, fillerFileSize)
-
-			imageLink := packaging.SetupContainerImageDeployment(subExperiment.Function, config.Provider, subExperiment.FunctionImageSizeMB)
+			// The filler is appended to the image of the function as its own layer, sized after the compressed base image
+			imageLink, imageSize := packaging.SetupContainerImageDeployment(subExperiment.Function, config.Provider, subExperiment.Region, subExperiment.Architecture, subExperiment.FunctionImageSizeMB, subExperiment.FillerOptions())
+			config.SubExperiments[index].ImageSize = &imageSize
 			randomTag := util.GenerateRandLowercaseLetters(5)
-			slsConfig.DeployGCRContainerService(&config.SubExperiments[index], index, randomTag, imageLink, serverlessDirPath, slsConfig.Provider.Region)
+			slsConfig.DeployGCRContainerService(&config.SubExperiments[index], index, randomTag, imageLink, serverlessDirPath, subExperiment.Region)
 		default:
 			log.Fatalf("Package type %s is not supported", subExperiment.PackageType)
 		}
@@ -256,10 +314,10 @@ func ProvisionFunctionsCloudflare(config *Configuration, serverlessDirPath strin
 
 func ProvisionFunctionsServerlessAlibaba(config *Configuration, serverlessDirPath string) {
 	for index, subExperiment := range config.SubExperiments {
-		code_generation.GenerateCode(subExperiment.Function, config.Provider)
+		code_generation.GenerateCode(subExperiment.Function, config.Provider, subExperiment.Runtime)
 
 		builder := &building.Builder{}
-		builder.BuildFunction(config.Provider, subExperiment.Function, subExperiment.Runtime)
+		builder.BuildFunction(config.Provider, subExperiment.Function, subExperiment.Runtime, subExperiment.Architecture)
 
 		preDeploymentDir := fmt.Sprintf("setup/deployment/raw-code/serverless/%s/sub-experiment-%d", config.Provider, index)
 		if err := os.MkdirAll(preDeploymentDir, os.ModePerm); err != nil {
@@ -269,7 +327,7 @@ func ProvisionFunctionsServerlessAlibaba(config *Configuration, serverlessDirPat
 		util.RunCommandAndLog(exec.Command("cp", artifactsPath, preDeploymentDir))
 
 		slsConfig := &Serverless{}
-		slsConfig.CreateHeaderConfig(config, fmt.Sprintf("stellar-aliyun-subex%d", index))
+		slsConfig.CreateHeaderConfig(config, fmt.Sprintf("stellar-aliyun-subex%d", index), subExperiment.Region)
 		slsConfig.addPlugin("serverless-aliyun-function-compute")
 		slsConfig.AddFunctionConfigAlibaba(&config.SubExperiments[index], index, "")
 		slsConfig.CreateServerlessConfigFile(fmt.Sprintf("%s/sub-experiment-%d/serverless.yml", serverlessDirPath, index))
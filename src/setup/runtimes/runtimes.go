@@ -0,0 +1,202 @@
+package runtimes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Build is the step turning the source of a function into the files of its deployment artifact
+type Build string
+
+const (
+	// BuildCopy copies the entry file of interpreted languages as is
+	BuildCopy Build = "copy"
+	// BuildGradle builds a ZIP archive of the compiled classes and their dependencies with Gradle
+	BuildGradle Build = "gradle"
+	// BuildDotnet publishes the compiled assemblies and their dependencies with the .NET CLI
+	BuildDotnet Build = "dotnet"
+	// BuildBootstrap compiles a native executable named bootstrap, from Go (go.mod) or Rust (Cargo.toml) sources
+	BuildBootstrap Build = "bootstrap"
+)
+
+// Runtime describes how functions of a language version are built, packaged and named across providers.
+type Runtime struct {
+	// Name identifies the runtime in configurations, following the AWS Lambda identifiers, e.g., python3.12
+	Name     string
+	Language string
+	Build    Build
+	// EntryFile is the file, or directory for BuildDotnet, of the artifact holding the function, relative to the artifact
+	// directory. The name of the function replaces {function}, e.g., {function}.zip for BuildGradle.
+	EntryFile string
+	// HandlerFormat is the handler of the functions of the repository, the name of the function replacing {function}
+	HandlerFormat string
+	// Providers holds the identifiers of the runtime for the providers naming it differently than Name.
+	// An empty identifier marks a provider not offering the runtime.
+	Providers map[string]string
+	// ReplacedBy is the runtime to deploy instead of a runtime no longer offered by AWS Lambda, if any
+	ReplacedBy string
+}
+
+// lambdaBaseImageProvider is the pseudo-provider under which the AWS Lambda base images of the runtimes are registered
+const lambdaBaseImageProvider = "lambda-image"
+
+var registry = map[string]Runtime{}
+
+// aliases map alternative names of runtimes found in configurations to their registered names
+var aliases = map[string]string{
+	"nodejs18": "nodejs18.x",
+	"nodejs20": "nodejs20.x",
+	"nodejs22": "nodejs22.x",
+}
+
+func init() {
+	for _, version := range []string{"3.8", "3.9", "3.10", "3.11", "3.12", "3.13"} {
+		register(Runtime{
+			Name:          "python" + version,
+			Language:      "python",
+			Build:         BuildCopy,
+			EntryFile:     "main.py",
+			HandlerFormat: "main.lambda_handler",
+			Providers:     languageProviders("python", version),
+		})
+	}
+	for _, version := range []string{"18", "20", "22"} {
+		register(Runtime{
+			Name:          fmt.Sprintf("nodejs%s.x", version),
+			Language:      "nodejs",
+			Build:         BuildCopy,
+			EntryFile:     "index.js",
+			HandlerFormat: "index.handler",
+			Providers:     languageProviders("nodejs", version),
+		})
+	}
+	for _, version := range []string{"11", "17", "21"} {
+		register(Runtime{
+			Name:          "java" + version,
+			Language:      "java",
+			Build:         BuildGradle,
+			EntryFile:     "{function}.zip",
+			HandlerFormat: "org.{function}.Handler",
+			Providers:     languageProviders("java", version),
+		})
+	}
+	for _, version := range []string{"3.2", "3.3"} {
+		register(Runtime{
+			Name:          "ruby" + version,
+			Language:      "ruby",
+			Build:         BuildCopy,
+			EntryFile:     "function.rb",
+			HandlerFormat: "function.handler",
+			Providers:     languageProviders("ruby", version),
+		})
+	}
+	register(Runtime{
+		Name:          "dotnet8",
+		Language:      "dotnet",
+		Build:         BuildDotnet,
+		EntryFile:     "publish",
+		HandlerFormat: "{function}::{function}.Function::FunctionHandler",
+		Providers:     languageProviders("dotnet", "8"),
+	})
+	for _, version := range []string{"al2", "al2023"} {
+		register(Runtime{
+			Name:          "provided." + version,
+			Language:      "native",
+			Build:         BuildBootstrap,
+			EntryFile:     "bootstrap",
+			HandlerFormat: "bootstrap",
+			Providers: map[string]string{
+				"google":                "",
+				lambdaBaseImageProvider: "public.ecr.aws/lambda/provided:" + version,
+			},
+		})
+	}
+	register(Runtime{
+		Name:          "go1.x",
+		Language:      "go",
+		Build:         BuildBootstrap,
+		EntryFile:     "bootstrap",
+		HandlerFormat: "bootstrap",
+		Providers: map[string]string{
+			"google":                "go121",
+			lambdaBaseImageProvider: "public.ecr.aws/lambda/provided:al2023",
+		},
+		ReplacedBy: "provided.al2023",
+	})
+}
+
+// languageProviders returns the identifiers of a language version for the providers, e.g., python312 on Google
+// Cloud Functions and public.ecr.aws/lambda/python:3.12 as Lambda base image for python 3.12
+func languageProviders(language string, version string) map[string]string {
+	return map[string]string{
+		"google":                language + strings.ReplaceAll(version, ".", ""),
+		lambdaBaseImageProvider: fmt.Sprintf("public.ecr.aws/lambda/%s:%s", language, version),
+	}
+}
+
+func register(runtime Runtime) {
+	registry[runtime.Name] = runtime
+}
+
+// Lookup returns the registered runtime of the given name or alias.
+func Lookup(name string) (Runtime, bool) {
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	runtime, ok := registry[name]
+	return runtime, ok
+}
+
+// Names returns the names of the registered runtimes, in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderIdentifier returns the identifier of the runtime for the provider, and whether the provider offers it.
+// The AWS identifier of runtimes no longer offered by Lambda is the identifier of their replacement.
+func (r Runtime) ProviderIdentifier(provider string) (string, bool) {
+	if provider == "aws" && r.ReplacedBy != "" {
+		return r.ReplacedBy, true
+	}
+	identifier, ok := r.Providers[provider]
+	if !ok {
+		return r.Name, true
+	}
+	return identifier, identifier != ""
+}
+
+// LambdaBaseImage returns the AWS Lambda base image of the runtime, bundling the Runtime Interface Emulator.
+func (r Runtime) LambdaBaseImage() string {
+	return r.Providers[lambdaBaseImageProvider]
+}
+
+// EntryPath returns the path of the entry file of the function within its artifact directory.
+func (r Runtime) EntryPath(function string) string {
+	return strings.ReplaceAll(r.EntryFile, "{function}", function)
+}
+
+// Handler returns the handler of the function of the repository with the given name.
+func (r Runtime) Handler(function string) string {
+	return strings.ReplaceAll(r.HandlerFormat, "{function}", function)
+}
+
+// ProviderRuntime returns the identifier of the named runtime for the provider. Aliases resolve to the AWS Lambda
+// identifier for AWS. Unregistered names, e.g., runtimes specific to a provider such as python:3 on OpenWhisk, and
+// names of runtimes other providers do not name differently are returned as is.
+func ProviderRuntime(name string, provider string) string {
+	runtime, ok := Lookup(name)
+	if !ok {
+		return name
+	}
+	if _, named := runtime.Providers[provider]; !named && provider != "aws" {
+		return name
+	}
+	identifier, _ := runtime.ProviderIdentifier(provider)
+	return identifier
+}
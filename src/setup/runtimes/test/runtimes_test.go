@@ -0,0 +1,92 @@
+package runtimes
+
+import (
+	"github.com/stretchr/testify/require"
+	"stellar/setup/runtimes"
+	"testing"
+)
+
+func TestLookupCurrentRuntimes(t *testing.T) {
+	for _, name := range []string{"python3.11", "python3.12", "nodejs20.x", "nodejs22.x", "java17", "java21", "dotnet8", "provided.al2023"} {
+		_, ok := runtimes.Lookup(name)
+		require.True(t, ok, name)
+	}
+
+	runtime, ok := runtimes.Lookup("nodejs22")
+	require.True(t, ok)
+	require.Equal(t, "nodejs22.x", runtime.Name)
+
+	_, ok = runtimes.Lookup("cobol")
+	require.False(t, ok)
+}
+
+func TestRuntimeBuild(t *testing.T) {
+	for name, expected := range map[string]runtimes.Build{
+		"python3.12":      runtimes.BuildCopy,
+		"nodejs20.x":      runtimes.BuildCopy,
+		"ruby3.3":         runtimes.BuildCopy,
+		"java21":          runtimes.BuildGradle,
+		"dotnet8":         runtimes.BuildDotnet,
+		"go1.x":           runtimes.BuildBootstrap,
+		"provided.al2023": runtimes.BuildBootstrap,
+	} {
+		runtime, _ := runtimes.Lookup(name)
+		require.Equal(t, expected, runtime.Build, name)
+	}
+}
+
+func TestEntryPathAndHandler(t *testing.T) {
+	python, _ := runtimes.Lookup("python3.12")
+	require.Equal(t, "main.py", python.EntryPath("hellopy"))
+	require.Equal(t, "main.lambda_handler", python.Handler("hellopy"))
+
+	java, _ := runtimes.Lookup("java17")
+	require.Equal(t, "hellojava.zip", java.EntryPath("hellojava"))
+	require.Equal(t, "org.hellojava.Handler", java.Handler("hellojava"))
+
+	dotnet, _ := runtimes.Lookup("dotnet8")
+	require.Equal(t, "publish", dotnet.EntryPath("hellodotnet"))
+	require.Equal(t, "hellodotnet::hellodotnet.Function::FunctionHandler", dotnet.Handler("hellodotnet"))
+
+	native, _ := runtimes.Lookup("provided.al2023")
+	require.Equal(t, "bootstrap", native.Handler("hellorust"))
+}
+
+func TestProviderRuntime(t *testing.T) {
+	require.Equal(t, "python312", runtimes.ProviderRuntime("python3.12", "google"))
+	require.Equal(t, "nodejs22", runtimes.ProviderRuntime("nodejs22.x", "google"))
+	require.Equal(t, "java21", runtimes.ProviderRuntime("java21", "google"))
+	require.Equal(t, "go121", runtimes.ProviderRuntime("go1.x", "google"))
+
+	require.Equal(t, "python3.12", runtimes.ProviderRuntime("python3.12", "aws"))
+	require.Equal(t, "nodejs22.x", runtimes.ProviderRuntime("nodejs22", "aws"))
+	require.Equal(t, "nodejs22", runtimes.ProviderRuntime("nodejs22", "vhive"))
+	require.Equal(t, "provided.al2023", runtimes.ProviderRuntime("go1.x", "aws"))
+
+	require.Equal(t, "python:3", runtimes.ProviderRuntime("python:3", "openwhisk"))
+}
+
+func TestProviderIdentifierNotOffered(t *testing.T) {
+	native, _ := runtimes.Lookup("provided.al2023")
+	_, offered := native.ProviderIdentifier("google")
+	require.False(t, offered)
+
+	identifier, offered := native.ProviderIdentifier("aws")
+	require.True(t, offered)
+	require.Equal(t, "provided.al2023", identifier)
+}
+
+func TestLambdaBaseImage(t *testing.T) {
+	python, _ := runtimes.Lookup("python3.13")
+	require.Equal(t, "public.ecr.aws/lambda/python:3.13", python.LambdaBaseImage())
+
+	native, _ := runtimes.Lookup("provided.al2")
+	require.Equal(t, "public.ecr.aws/lambda/provided:al2", native.LambdaBaseImage())
+}
+
+func TestNamesSorted(t *testing.T) {
+	names := runtimes.Names()
+	require.IsIncreasing(t, names)
+	require.Contains(t, names, "dotnet8")
+	require.NotContains(t, names, "nodejs22")
+}
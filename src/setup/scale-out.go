@@ -0,0 +1,44 @@
+package setup
+
+import (
+	log "github.com/sirupsen/logrus"
+	"math"
+)
+
+const (
+	// BurstsExperiment sends bursts of requests separated by inter-arrival times
+	BurstsExperiment = "bursts"
+	// ScaleOutExperiment sustains an arrival rate of requests against one function to measure how quickly it scales out
+	ScaleOutExperiment = "scale-out"
+)
+
+// validateExperimentType ensures the sub-experiment can run as its type. A scale-out sub-experiment is recorded as one
+// burst per second of the run, sized by its arrival rate.
+func validateExperimentType(subExperiment *SubExperiment) {
+	switch subExperiment.ExperimentType {
+	case BurstsExperiment:
+	case ScaleOutExperiment:
+		if subExperiment.ArrivalRate <= 0 || subExperiment.DurationSeconds <= 0 {
+			log.Fatalf("Sub-experiment %q: the scale-out experiment needs a positive ArrivalRate and DurationSeconds.", subExperiment.Title)
+		}
+		if subExperiment.TargetInstances < 0 {
+			log.Fatalf("Sub-experiment %q has a negative TargetInstances.", subExperiment.Title)
+		}
+		if subExperiment.Provider == "vhive" {
+			// The gRPC functions of vHive do not report the instances serving the requests
+			log.Fatalf("Sub-experiment %q: the scale-out experiment is not supported by vhive.", subExperiment.Title)
+		}
+		subExperiment.Bursts = int(math.Ceil(subExperiment.DurationSeconds))
+		subExperiment.BurstSizes = []int{int(math.Ceil(subExperiment.ArrivalRate))}
+		subExperiment.IATSeconds = 1
+	case PowerTuningExperiment:
+		validatePowerTuning(subExperiment)
+	default:
+		log.Fatalf("Sub-experiment %q has an unknown ExperimentType %q, expected bursts, scale-out or power-tuning.", subExperiment.Title, subExperiment.ExperimentType)
+	}
+}
+
+// ScaleOutRequests returns the number of requests sent by the scale-out sub-experiment over its duration
+func (s *SubExperiment) ScaleOutRequests() int {
+	return int(math.Round(s.ArrivalRate * s.DurationSeconds))
+}
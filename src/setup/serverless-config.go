@@ -1,14 +1,19 @@
 package setup
 
 import (
+	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 	"math"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"stellar/setup/deployment/connection/amazon"
+	"stellar/setup/runtimes"
 	"stellar/util"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +33,7 @@ type Provider struct {
 	Name        string      `yaml:"name"`
 	Runtime     string      `yaml:"runtime"`
 	Region      string      `yaml:"region"`
+	Type        string      `yaml:"type,omitempty"`
 	Credentials string      `yaml:"credentials,omitempty"`
 	FunctionApp FunctionApp `yaml:"functionApp,omitempty"`
 }
@@ -41,12 +47,18 @@ type Package struct {
 }
 
 type Function struct {
-	Handler   string          `yaml:"handler"`
-	Runtime   string          `yaml:"runtime"`
-	Name      string          `yaml:"name"`
-	Events    []Event         `yaml:"events"`
-	Package   FunctionPackage `yaml:"package"`
-	SnapStart bool            `yaml:"snapStart,omitempty"`
+	Handler      string          `yaml:"handler"`
+	Runtime      string          `yaml:"runtime"`
+	Name         string          `yaml:"name"`
+	Events       []Event         `yaml:"events"`
+	Package      FunctionPackage `yaml:"package"`
+	SnapStart    bool            `yaml:"snapStart,omitempty"`
+	Architecture string          `yaml:"architecture,omitempty"`
+	// Environment holds the environment variables of the function, e.g., its init-time workload
+	Environment map[string]string `yaml:"environment,omitempty"`
+	// ProvisionedConcurrency makes the Serverless framework publish a version, point the `provisioned` alias to it and
+	// route the function events to that alias
+	ProvisionedConcurrency int `yaml:"provisionedConcurrency,omitempty"`
 }
 
 type FunctionPackage struct {
@@ -101,41 +113,57 @@ type AlibabaHttpEvent struct {
 }
 
 var nonAlphanumericRegex *regexp.Regexp = regexp.MustCompile(`[^a-zA-Z0-9 ]+`)
-var providerFunctionNames map[string]([]string) = make(map[string]([]string))
+
+// deployedService identifies a service deployed outside the Serverless framework, used for its removal
+type deployedService struct {
+	Name         string
+	Region       string
+	MinInstances int
+}
+
+var providerDeployedServices map[string][]deployedService = make(map[string][]deployedService)
 
 const (
-	AWS_DEFAULT_REGION         = "us-west-1"
+	AWS_DEFAULT_REGION         = amazon.DefaultAWSRegion
 	AZURE_DEFAULT_REGION       = "West US"
 	GCR_DEFAULT_REGION         = "us-west1"
+	GOOGLE_DEFAULT_REGION      = "us-west2"
+	gcrGen2MinimumMemoryMB     = 512
 	ALIBABA_DEFAULT_REGION     = "us-west-1"
 	ALIBABA_DEFAULT_ACCOUNT_ID = "5776795023355240"
 )
 
-// CreateHeaderConfig sets the fields Service, FrameworkVersion, and Provider
-func (s *Serverless) CreateHeaderConfig(config *Configuration, serviceName string) {
-
-	var region string
-	switch config.Provider {
+// DefaultRegion returns the region used for the given provider when a sub-experiment does not specify one
+func DefaultRegion(provider string) string {
+	switch provider {
 	case "aws":
-		region = AWS_DEFAULT_REGION
+		return AWS_DEFAULT_REGION
 	case "gcr":
-		region = GCR_DEFAULT_REGION
+		return GCR_DEFAULT_REGION
+	case "google":
+		return GOOGLE_DEFAULT_REGION
 	case "azure":
-		region = AZURE_DEFAULT_REGION
+		return AZURE_DEFAULT_REGION
 	case "aliyun":
-		region = ALIBABA_DEFAULT_REGION
+		return ALIBABA_DEFAULT_REGION
 	default:
+		return ""
+	}
+}
+
+// CreateHeaderConfig sets the fields Service, FrameworkVersion, and Provider. An empty region selects the provider default.
+func (s *Serverless) CreateHeaderConfig(config *Configuration, serviceName string, region string) {
+	if region == "" {
+		region = DefaultRegion(config.Provider)
+	}
+	if region == "" {
 		log.Errorf("Deployment to provider %s not supported yet.", config.Provider)
 	}
 
 	s.Service = serviceName
 	s.FrameworkVersion = "3"
 
-	runtimeValue := config.Runtime
-	if config.Runtime == "go1.x" {
-		log.Warnf("`go1.x` runtime is deprecated. Runtime `provided.al2023` will be used instead...")
-		runtimeValue = "provided.al2023"
-	}
+	runtimeValue := providerRuntime(config.Runtime, config.Provider)
 
 	switch config.Provider {
 	case "azure":
@@ -161,6 +189,15 @@ func (s *Serverless) CreateHeaderConfig(config *Configuration, serviceName strin
 	}
 }
 
+// providerRuntime returns the identifier of the runtime for the provider, warning about runtimes replaced by the provider
+func providerRuntime(runtime string, provider string) string {
+	identifier := runtimes.ProviderRuntime(runtime, provider)
+	if registered, ok := runtimes.Lookup(runtime); ok && provider == "aws" && registered.ReplacedBy != "" {
+		log.Warnf("`%s` runtime is deprecated. Runtime `%s` will be used instead...", runtime, identifier)
+	}
+	return identifier
+}
+
 func (s *Serverless) addPlugin(pluginName string) {
 	s.Plugins = append(s.Plugins, pluginName)
 }
@@ -186,11 +223,7 @@ func (s *Serverless) AddFunctionConfigAWS(subex *SubExperiment, index int, rando
 	
 	for i := 0; i < subex.Parallelism; i++ {
 		handler := subex.Handler
-		runtime := subex.Runtime
-		if runtime == "go1.x" {
-			log.Warnf("`go1.x` runtime is deprecated. Using `provided.al2023` runtime instead...")
-			runtime = "provided.al2023"
-		}
+		runtime := providerRuntime(subex.Runtime, "aws")
 		name := fmt.Sprintf("%s-%s", randomTag, createName(subex, index, i))
 		events := []Event{
 			{
@@ -211,6 +244,13 @@ func (s *Serverless) AddFunctionConfigAWS(subex *SubExperiment, index int, rando
 		if subex.SnapStartEnabled { // Add SnapStart field only if it is enabled
 			f.SnapStart = true
 		}
+		if subex.Architecture != "" && subex.Architecture != defaultArchitecture { // Lambda defaults to x86_64
+			f.Architecture = subex.Architecture
+		}
+		if environment := subex.FunctionEnvironment(); len(environment) > 0 {
+			f.Environment = environment
+		}
+		f.ProvisionedConcurrency = subex.ProvisionedConcurrency
 		s.Functions[name] = f
 		subex.AddRoute(name)
 		// TODO: producer-consumer sub-function definition
@@ -297,7 +337,13 @@ func (s *Serverless) CreateServerlessConfigFile(path string) {
 func RemoveService(config *Configuration, path string) string {
 	switch config.Provider {
 	case "aws":
-		return RemoveServerlessService(path)
+		removeAWSProvisionedConcurrency(config.SubExperiments)
+
+		var removeServiceMessages []string
+		for _, region := range config.Regions() {
+			removeServiceMessages = append(removeServiceMessages, RemoveServerlessServiceConfig(path, ServerlessConfigFileName(config.Provider, region)))
+		}
+		return strings.Join(removeServiceMessages, "\n")
 	case "azure":
 		RemoveAzureAllServices(config.SubExperiments, path)
 		return "All Azure services removed."
@@ -308,8 +354,28 @@ func RemoveService(config *Configuration, path string) string {
 		RemoveCloudflareAllWorkers(config.SubExperiments)
 		return "All Cloudflare Workers deleted."
 	case "aliyun":
-		RemoveAlibabaAllServices(path, len(config.SubExperiments))
+		RemoveAlibabaAllServices(path, config.SubExperiments)
 		return "All Alibaba Cloud services removed."
+	case "vhive":
+		RemoveKnativeAllServices()
+		return "All Knative services removed."
+	case "google":
+		RemoveGoogleAllFunctions()
+		return "All Google Cloud Functions deleted."
+	case "openwhisk":
+		RemoveOpenWhiskAllActions()
+		return "All OpenWhisk actions removed."
+	case "openfaas":
+		RemoveOpenFaaSAllFunctions()
+		return "All OpenFaaS functions removed."
+	case "fermyon":
+		fallthrough
+	case "spin":
+		RemoveSpinAllApplications()
+		return "All Spin applications removed."
+	case "docker-local":
+		RemoveDockerLocalAllFunctions()
+		return "All local functions stopped."
 	default:
 		// 25.09 error correction
 		// log.Fatalf(fmt.Sprintf("Failed to remove service for unrecognised provider %s", config.Provider))
@@ -318,16 +384,56 @@ func RemoveService(config *Configuration, path string) string {
 	}
 }
 
+// WaitForAWSProvisionedConcurrency waits until the provisioned concurrency of all functions of the sub-experiment is ready
+func WaitForAWSProvisionedConcurrency(subExperiment SubExperiment) {
+	if subExperiment.ProvisionedConcurrency == 0 {
+		return
+	}
+
+	log.Infof("[sub-experiment %d] Waiting for provisioned concurrency %d of %d function(s)...",
+		subExperiment.ID, subExperiment.ProvisionedConcurrency, len(subExperiment.Routes))
+	for _, functionName := range subExperiment.Routes {
+		amazon.Instance(subExperiment.Region).WaitForProvisionedConcurrency(functionName, amazon.ProvisionedConcurrencyAlias)
+	}
+}
+
+// removeAWSProvisionedConcurrency releases the provisioned concurrency of the functions before their removal,
+// so that no pre-initialized environments are left (and billed) should the removal of the service fail
+func removeAWSProvisionedConcurrency(subExperiments []SubExperiment) {
+	for _, subExperiment := range subExperiments {
+		if subExperiment.ProvisionedConcurrency == 0 {
+			continue
+		}
+		for _, functionName := range subExperiment.Routes {
+			amazon.Instance(subExperiment.Region).RemoveProvisionedConcurrency(functionName, amazon.ProvisionedConcurrencyAlias)
+		}
+	}
+}
+
+// ServerlessConfigFileName returns the name of the serverless.com file describing the service deployed in the given region.
+// Services in the provider's default region keep the standard serverless.yml name.
+func ServerlessConfigFileName(provider string, region string) string {
+	if region == "" || region == DefaultRegion(provider) {
+		return "serverless.yml"
+	}
+	return fmt.Sprintf("serverless-%s.yml", strings.ReplaceAll(region, " ", "-"))
+}
+
 // RemoveServerlessService removes a service that was deployed using the Serverless framework
 func RemoveServerlessService(path string) string {
+	return RemoveServerlessServiceConfig(path, "serverless.yml")
+}
+
+// RemoveServerlessServiceConfig removes a service that was deployed using the given serverless.com file
+func RemoveServerlessServiceConfig(path string, configFileName string) string {
 	// 25.09 update to correct syntax issue logrus
 	// log.Infof(fmt.Sprintf("Removing Serverless service at %s", path))
-	log.Infof("Removing Serverless service at %s", path)	
-	slsRemoveCmd := exec.Command("sls", "remove")
+	log.Infof("Removing Serverless service at %s (%s)", path, configFileName)
+	slsRemoveCmd := exec.Command("sls", "remove", "--config", configFileName)
 	slsRemoveCmd.Dir = path
 	slsRemoveCmdOutput := util.RunCommandAndLogWithRetries(slsRemoveCmd, 3)
 
-	util.RunCommandAndLog(exec.Command("rm", fmt.Sprintf("%sserverless.yml", path)))
+	util.RunCommandAndLog(exec.Command("rm", filepath.Join(path, configFileName)))
 
 	return slsRemoveCmdOutput
 }
@@ -371,6 +477,9 @@ func removeSubExperimentParallelismInBatches(path string, subExperimentIndex int
 				defer wg.Done()
 
 				deploymentDir := fmt.Sprintf("%ssub-experiment-%d/parallelism-%d", path, subExperimentIndex, parallelism)
+				if subExperiment.AlwaysReadyInstances > 0 && parallelism < len(subExperiment.Endpoints) {
+					SetAzureAlwaysReadyInstances(subExperiment.Endpoints[parallelism].ID, 0)
+				}
 				slsRemoveCmdOutput := RemoveServerlessServiceForcefully(deploymentDir)
 				mu.Lock()
 				defer mu.Unlock()
@@ -381,30 +490,49 @@ func removeSubExperimentParallelismInBatches(path string, subExperimentIndex int
 	}
 }
 
+// SetAzureAlwaysReadyInstances sets the number of always ready instances of a function app on the Premium plan.
+// The resource group of the app follows the naming of the serverless-azure-functions plugin.
+func SetAzureAlwaysReadyInstances(functionAppName string, instances int) string {
+	log.Infof("Setting %d always ready instance(s) for Azure function app %s...", instances, functionAppName)
+	updateCommand := exec.Command("az", "functionapp", "update", "--resource-group", fmt.Sprintf("%s-rg", functionAppName),
+		"--name", functionAppName, "--set", fmt.Sprintf("siteConfig.minimumElasticInstanceCount=%d", instances))
+	return util.RunCommandAndLog(updateCommand)
+}
+
 // RemoveGCRAllServices removes all GCR services defined in the Subexperiment array
 func RemoveGCRAllServices(subExperiments []SubExperiment) []string {
 	var deleteServiceMessages []string
-	for _, functionName := range providerFunctionNames["gcr"] {
-		deleteMsg := RemoveGCRSingleService(functionName)
+	for _, service := range providerDeployedServices["gcr"] {
+		if service.MinInstances > 0 {
+			ResetGCRMinInstances(service.Name, service.Region)
+		}
+		deleteMsg := RemoveGCRSingleService(service.Name, service.Region)
 		deleteServiceMessages = append(deleteServiceMessages, deleteMsg)
 	}
 	return deleteServiceMessages
 }
 
-// RemoveGCRSingleService removes a single GCR service
-func RemoveGCRSingleService(service string) string {
-	log.Infof("Deleting GCR service %s...", service)
-	deleteServiceCommand := exec.Command("gcloud", "run", "services", "delete", "--quiet", "--region", GCR_DEFAULT_REGION, service)
+// RemoveGCRSingleService removes a single GCR service from the given region
+func RemoveGCRSingleService(service string, region string) string {
+	log.Infof("Deleting GCR service %s in region %s...", service, region)
+	deleteServiceCommand := exec.Command("gcloud", "run", "services", "delete", "--quiet", "--region", region, service)
 	deleteMessage := util.RunCommandAndLog(deleteServiceCommand)
 	return deleteMessage
 }
 
+// ResetGCRMinInstances scales the minimum number of instances of a GCR service back to zero
+func ResetGCRMinInstances(service string, region string) string {
+	log.Infof("Resetting minimum instances of GCR service %s in region %s...", service, region)
+	resetCommand := exec.Command("gcloud", "run", "services", "update", service, "--quiet", "--region", region, "--min-instances", "0")
+	return util.RunCommandAndLog(resetCommand)
+}
+
 // RemoveCloudflareAllWorkers removes all Cloudflare Workers
 func RemoveCloudflareAllWorkers(subExperiments []SubExperiment) []string {
 	log.Infof("Removing Cloudflare Workers...")
 	var removeServiceMessages []string
-	for _, functionName := range providerFunctionNames["cloudflare"] {
-		removeMessage := RemoveCloudflareSingleWorker(functionName)
+	for _, worker := range providerDeployedServices["cloudflare"] {
+		removeMessage := RemoveCloudflareSingleWorker(worker.Name)
 		removeServiceMessages = append(removeServiceMessages, removeMessage)
 	}
 	return removeServiceMessages
@@ -418,17 +546,23 @@ func RemoveCloudflareSingleWorker(workerName string) string {
 	return removeMessage
 }
 
-// RemoveAlibabaAllServices removes all Alibaba Cloud services
-func RemoveAlibabaAllServices(path string, numSubExperiments int) []string {
+// RemoveAlibabaAllServices removes all Alibaba Cloud services, together with the deployment buckets of their regions
+func RemoveAlibabaAllServices(path string, subExperiments []SubExperiment) []string {
 	alibabaCloudAccountId := os.Getenv("ALIYUN_ACCOUNT_ID")
 	if alibabaCloudAccountId == "" {
 		alibabaCloudAccountId = ALIBABA_DEFAULT_ACCOUNT_ID
 	}
-	nameOfBucketToDelete := fmt.Sprintf("oss://sls-%s-%s", alibabaCloudAccountId, ALIBABA_DEFAULT_REGION)
-	util.RunCommandAndLog(exec.Command("aliyun", "oss", "rm", "--bucket", "--recursive", "--force", nameOfBucketToDelete))
+	config := Configuration{SubExperiments: subExperiments}
+	for _, region := range config.Regions() {
+		if region == "" {
+			region = ALIBABA_DEFAULT_REGION
+		}
+		nameOfBucketToDelete := fmt.Sprintf("oss://sls-%s-%s", alibabaCloudAccountId, region)
+		util.RunCommandAndLog(exec.Command("aliyun", "oss", "rm", "--bucket", "--recursive", "--force", nameOfBucketToDelete))
+	}
 
 	var removeServiceMessages []string
-	for i := 0; i < numSubExperiments; i++ {
+	for i := 0; i < len(subExperiments); i++ {
 		subExPath := fmt.Sprintf("%ssub-experiment-%d/", path, i)
 		slsRemoveCmdOutput := RemoveServerlessService(subExPath)
 		removeServiceMessages = append(removeServiceMessages, slsRemoveCmdOutput)
@@ -438,10 +572,15 @@ func RemoveAlibabaAllServices(path string, numSubExperiments int) []string {
 
 // DeployService deploys the functions defined in the serverless.com file
 func DeployService(path string) string {
-	// 25.09 update to correct syntax issue logrus	
+	return DeployServiceConfig(path, "serverless.yml")
+}
+
+// DeployServiceConfig deploys the functions defined in the given serverless.com file
+func DeployServiceConfig(path string, configFileName string) string {
+	// 25.09 update to correct syntax issue logrus
 	// log.Infof(fmt.Sprintf("Deploying service at %s", path))
-	log.Infof("Deploying service at %s", path)	
-	slsDeployCmd := exec.Command("sls", "deploy")
+	log.Infof("Deploying service at %s (%s)", path, configFileName)
+	slsDeployCmd := exec.Command("sls", "deploy", "--config", configFileName)
 	slsDeployCmd.Dir = path
 	slsDeployMessage := util.RunCommandAndLogWithRetries(slsDeployCmd, 3)
 	return slsDeployMessage
@@ -449,29 +588,128 @@ func DeployService(path string) string {
 
 // DeployGCRContainerService deploys a container service to cloud provider
 func (s *Serverless) DeployGCRContainerService(subex *SubExperiment, index int, randomTag string, imageLink string, path string, region string) {
-	log.Infof("Deploying container service(s) to GCR...")
+	log.Infof("Deploying container service(s) to GCR region %s...", region)
 	for i := 0; i < subex.Parallelism; i++ {
 		name := fmt.Sprintf("%s-%s", randomTag, createName(subex, index, i))
-		providerFunctionNames["gcr"] = append(providerFunctionNames["gcr"], name) // Used for function removal
+		providerDeployedServices["gcr"] = append(providerDeployedServices["gcr"], deployedService{Name: name, Region: region, MinInstances: subex.MinInstances}) // Used for function removal
 
-		var gcrDeployCommand *exec.Cmd
-		if subex.CPUBoostEnabled {
-			gcrDeployCommand = exec.Command("gcloud", "run", "deploy", name, "--image", imageLink, "--allow-unauthenticated", "--region", region, "--cpu-boost")
-		} else {
-			gcrDeployCommand = exec.Command("gcloud", "run", "deploy", name, "--image", imageLink, "--allow-unauthenticated", "--region", region)
-		}
+		gcrDeployCommand := exec.Command("gcloud", GCRDeployArguments(subex, name, imageLink, region)...)
 
 		deployMessage := util.RunCommandAndLog(gcrDeployCommand)
-		subex.Endpoints = append(subex.Endpoints, EndpointInfo{ID: GetGCREndpointID(deployMessage)})
+		subex.Endpoints = append(subex.Endpoints, EndpointInfo{ID: GetGCREndpointID(deployMessage), Region: region, ServiceNames: []string{name}})
 		subex.AddRoute("")
+
+		if i == 0 { // all services of the sub-experiment share the same settings
+			subex.AppliedSettings = GetGCRAppliedSettings(name, region)
+			log.Infof("[sub-experiment %d] Cloud Run applied settings: %v", subex.ID, subex.AppliedSettings)
+		}
+	}
+}
+
+// GCRDeployArguments returns the arguments of the gcloud command deploying the given container service
+func GCRDeployArguments(subex *SubExperiment, name string, imageLink string, region string) []string {
+	arguments := []string{"run", "deploy", name, "--image", imageLink, "--allow-unauthenticated", "--region", region}
+	if subex.CPUBoostEnabled {
+		arguments = append(arguments, "--cpu-boost")
+	}
+	if subex.MinInstances > 0 {
+		arguments = append(arguments, "--min-instances", strconv.Itoa(subex.MinInstances))
+	}
+	if subex.MaxInstances > 0 {
+		if subex.MaxInstances < subex.MinInstances {
+			log.Fatalf("[sub-experiment %d] Maximum instances (%d) cannot be lower than minimum instances (%d).",
+				subex.ID, subex.MaxInstances, subex.MinInstances)
+		}
+		arguments = append(arguments, "--max-instances", strconv.Itoa(subex.MaxInstances))
+	}
+	if subex.FunctionMemoryMB > 0 {
+		arguments = append(arguments, "--memory", fmt.Sprintf("%dMi", subex.FunctionMemoryMB))
+	}
+	if subex.FunctionCPU > 0 {
+		arguments = append(arguments, "--cpu", strconv.FormatFloat(subex.FunctionCPU, 'f', -1, 64))
+	}
+	if subex.Concurrency > 0 {
+		arguments = append(arguments, "--concurrency", strconv.Itoa(subex.Concurrency))
+	}
+	if subex.TimeoutSeconds > 0 {
+		arguments = append(arguments, "--timeout", strconv.Itoa(subex.TimeoutSeconds))
+	}
+	switch subex.ExecutionEnvironment {
+	case "":
+	case "gen1", "gen2":
+		if subex.ExecutionEnvironment == "gen2" && subex.FunctionMemoryMB > 0 && subex.FunctionMemoryMB < gcrGen2MinimumMemoryMB {
+			log.Fatalf("[sub-experiment %d] The gen2 execution environment requires at least %dMB of memory, %dMB requested.",
+				subex.ID, gcrGen2MinimumMemoryMB, subex.FunctionMemoryMB)
+		}
+		arguments = append(arguments, "--execution-environment", subex.ExecutionEnvironment)
+	default:
+		log.Fatalf("[sub-experiment %d] Unrecognized execution environment %q, expected gen1 or gen2.", subex.ID, subex.ExecutionEnvironment)
+	}
+	if subex.CPUAlwaysAllocated {
+		arguments = append(arguments, "--no-cpu-throttling")
+	}
+	if environment := subex.FunctionEnvironment(); len(environment) > 0 {
+		arguments = append(arguments, "--set-env-vars", strings.Join(environmentAssignments(environment), ","))
+	}
+	return arguments
+}
+
+// gcrServiceDescription is the part of a Cloud Run service description holding its resource settings
+type gcrServiceDescription struct {
+	Spec struct {
+		Template struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Spec struct {
+				ContainerConcurrency int `json:"containerConcurrency"`
+				TimeoutSeconds       int `json:"timeoutSeconds"`
+				Containers           []struct {
+					Resources struct {
+						Limits map[string]string `json:"limits"`
+					} `json:"resources"`
+				} `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+}
+
+// GetGCRAppliedSettings describes a deployed GCR service and returns the resource settings Cloud Run applied to it
+func GetGCRAppliedSettings(service string, region string) map[string]string {
+	describeCommand := exec.Command("gcloud", "run", "services", "describe", service, "--region", region, "--format", "json")
+	return ParseGCRServiceDescription(util.RunCommandAndLog(describeCommand))
+}
+
+// ParseGCRServiceDescription extracts the resource settings from the JSON description of a Cloud Run service
+func ParseGCRServiceDescription(description string) map[string]string {
+	var parsedDescription gcrServiceDescription
+	if err := json.Unmarshal([]byte(description), &parsedDescription); err != nil {
+		log.Errorf("Could not parse GCR service description: %s", err.Error())
+		return nil
+	}
+
+	template := parsedDescription.Spec.Template
+	settings := map[string]string{
+		"concurrency":          strconv.Itoa(template.Spec.ContainerConcurrency),
+		"timeoutSeconds":       strconv.Itoa(template.Spec.TimeoutSeconds),
+		"executionEnvironment": template.Metadata.Annotations["run.googleapis.com/execution-environment"],
+		"cpuThrottling":        template.Metadata.Annotations["run.googleapis.com/cpu-throttling"],
+		"startupCPUBoost":      template.Metadata.Annotations["run.googleapis.com/startup-cpu-boost"],
+		"minInstances":         template.Metadata.Annotations["autoscaling.knative.dev/minScale"],
+		"maxInstances":         template.Metadata.Annotations["autoscaling.knative.dev/maxScale"],
+	}
+	if len(template.Spec.Containers) > 0 {
+		settings["cpu"] = template.Spec.Containers[0].Resources.Limits["cpu"]
+		settings["memory"] = template.Spec.Containers[0].Resources.Limits["memory"]
 	}
+	return settings
 }
 
 func DeployCloudflareWorkers(subex *SubExperiment, index int, randomTag string, path string) {
 	log.Infof("Deploying Cloudflare Workers...")
 	for i := 0; i < subex.Parallelism; i++ {
 		name := fmt.Sprintf("%s-%s", randomTag, createName(subex, index, i))
-		providerFunctionNames["cloudflare"] = append(providerFunctionNames["cloudflare"], name) // Used for function removal
+		providerDeployedServices["cloudflare"] = append(providerDeployedServices["cloudflare"], deployedService{Name: name}) // Used for function removal
 
 		cloudFlareDeployCommand := exec.Command("wrangler", "deploy", fmt.Sprintf("%s/%s/%s", path, subex.Function, subex.Handler), "--name", name, "--compatibility-date", time.Now().Format("2006-01-02"))
 		deployMessage := util.RunCommandAndLog(cloudFlareDeployCommand)
@@ -0,0 +1,60 @@
+package setup
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// ServiceTimeCalibration is the busy-spin rate measured for the functions of a target by probing them.
+type ServiceTimeCalibration struct {
+	BusySpinIncrementsPerMillisecond float64   `json:"BusySpinIncrementsPerMillisecond"`
+	CalibratedAt                     time.Time `json:"CalibratedAt"`
+}
+
+// ServiceTimeCalibrationCache persists the calibrations across runs, keyed by the CalibrationTarget of the
+// sub-experiments. It is safe for concurrent use.
+type ServiceTimeCalibrationCache struct {
+	path         string
+	mux          sync.Mutex
+	calibrations map[string]ServiceTimeCalibration
+}
+
+// LoadServiceTimeCalibrationCache reads the calibration cache at the given path, empty if the file does not exist yet.
+func LoadServiceTimeCalibrationCache(path string) (*ServiceTimeCalibrationCache, error) {
+	cache := &ServiceTimeCalibrationCache{path: path, calibrations: make(map[string]ServiceTimeCalibration)}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &cache.calibrations); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// Get returns the calibration of the target, if cached.
+func (c *ServiceTimeCalibrationCache) Get(target string) (ServiceTimeCalibration, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	calibration, ok := c.calibrations[target]
+	return calibration, ok
+}
+
+// Set caches the calibration of the target and writes the cache to its file.
+func (c *ServiceTimeCalibrationCache) Set(target string, calibration ServiceTimeCalibration) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.calibrations[target] = calibration
+
+	content, err := json.MarshalIndent(c.calibrations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, content, 0644)
+}
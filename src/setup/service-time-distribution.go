@@ -0,0 +1,166 @@
+package setup
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConstantServiceTime gives every request the same service time
+	ConstantServiceTime = "constant"
+	// ExponentialServiceTime samples exponentially distributed service times
+	ExponentialServiceTime = "exponential"
+	// LognormalServiceTime samples log-normally distributed service times
+	LognormalServiceTime = "lognormal"
+	// BimodalServiceTime gives requests a short service time, or a long one with the given probability
+	BimodalServiceTime = "bimodal"
+	// EmpiricalServiceTime samples the service times listed in a file
+	EmpiricalServiceTime = "empirical"
+)
+
+// ServiceTimeDistribution is the distribution of the service times of the requests of a sub-experiment, in
+// milliseconds, replacing the DesiredServiceTimes cycled per burst.
+type ServiceTimeDistribution struct {
+	Type string `json:"Type"`
+	// MeanMilliseconds is the service time of constant distributions and the mean of exponential and lognormal ones
+	MeanMilliseconds float64 `json:"MeanMilliseconds"`
+	// StdDevMilliseconds is the standard deviation of lognormal distributions
+	StdDevMilliseconds float64 `json:"StdDevMilliseconds"`
+	// ShortMilliseconds and LongMilliseconds are the service times of bimodal distributions, the long one being
+	// sampled with LongProbability
+	ShortMilliseconds float64 `json:"ShortMilliseconds"`
+	LongMilliseconds  float64 `json:"LongMilliseconds"`
+	LongProbability   float64 `json:"LongProbability"`
+	// File lists the service times of empirical distributions, one per line, lines starting with # being ignored
+	File string `json:"File"`
+	// Seed makes the sampled service times identical across runs
+	Seed int64 `json:"Seed"`
+}
+
+// Validate ensures the distribution is known and its parameters are consistent.
+func (d *ServiceTimeDistribution) Validate() error {
+	switch d.Type {
+	case ConstantServiceTime, ExponentialServiceTime:
+		if d.MeanMilliseconds < 0 {
+			return fmt.Errorf("%s service time distribution has a negative MeanMilliseconds", d.Type)
+		}
+	case LognormalServiceTime:
+		if d.MeanMilliseconds <= 0 || d.StdDevMilliseconds < 0 {
+			return fmt.Errorf("lognormal service time distribution needs a positive MeanMilliseconds and a non-negative StdDevMilliseconds")
+		}
+	case BimodalServiceTime:
+		if d.ShortMilliseconds < 0 || d.LongMilliseconds < 0 || d.LongProbability < 0 || d.LongProbability > 1 {
+			return fmt.Errorf("bimodal service time distribution needs non-negative service times and a LongProbability between 0 and 1")
+		}
+	case EmpiricalServiceTime:
+		if d.File == "" {
+			return fmt.Errorf("empirical service time distribution needs a File")
+		}
+	default:
+		return fmt.Errorf("unknown service time distribution %q", d.Type)
+	}
+	return nil
+}
+
+// Label summarizes the distribution in the titles of the sub-experiment outputs, e.g., exponential50ms.
+func (d *ServiceTimeDistribution) Label() string {
+	switch d.Type {
+	case LognormalServiceTime:
+		return fmt.Sprintf("lognormal%vms-sd%vms", d.MeanMilliseconds, d.StdDevMilliseconds)
+	case BimodalServiceTime:
+		return fmt.Sprintf("bimodal%vms-%vms-p%v", d.ShortMilliseconds, d.LongMilliseconds, d.LongProbability)
+	case EmpiricalServiceTime:
+		return "empirical-" + strings.TrimSuffix(filepath.Base(d.File), filepath.Ext(d.File))
+	default:
+		return fmt.Sprintf("%s%vms", d.Type, d.MeanMilliseconds)
+	}
+}
+
+// ServiceTimeSampler samples service times from a distribution. It is not safe for concurrent use.
+type ServiceTimeSampler struct {
+	distribution ServiceTimeDistribution
+	random       *rand.Rand
+	// empiricalSamples are the service times read from the file of empirical distributions
+	empiricalSamples []float64
+	// lognormalMu and lognormalSigma parametrize the normal distribution of the logarithm of lognormal service times
+	lognormalMu    float64
+	lognormalSigma float64
+}
+
+// NewSampler returns a sampler of the distribution, reading the service times of empirical distributions.
+func (d *ServiceTimeDistribution) NewSampler() (*ServiceTimeSampler, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	sampler := &ServiceTimeSampler{distribution: *d, random: rand.New(rand.NewSource(d.Seed))}
+	switch d.Type {
+	case LognormalServiceTime:
+		// The mean m and standard deviation s of the distribution give sigma^2 = ln(1 + s^2/m^2) and mu = ln(m) - sigma^2/2
+		variance := math.Log(1 + (d.StdDevMilliseconds*d.StdDevMilliseconds)/(d.MeanMilliseconds*d.MeanMilliseconds))
+		sampler.lognormalSigma = math.Sqrt(variance)
+		sampler.lognormalMu = math.Log(d.MeanMilliseconds) - variance/2
+	case EmpiricalServiceTime:
+		samples, err := readServiceTimes(d.File)
+		if err != nil {
+			return nil, err
+		}
+		sampler.empiricalSamples = samples
+	}
+	return sampler, nil
+}
+
+// Sample returns the next service time, in milliseconds.
+func (s *ServiceTimeSampler) Sample() float64 {
+	switch s.distribution.Type {
+	case ExponentialServiceTime:
+		return s.random.ExpFloat64() * s.distribution.MeanMilliseconds
+	case LognormalServiceTime:
+		return math.Exp(s.lognormalMu + s.lognormalSigma*s.random.NormFloat64())
+	case BimodalServiceTime:
+		if s.random.Float64() < s.distribution.LongProbability {
+			return s.distribution.LongMilliseconds
+		}
+		return s.distribution.ShortMilliseconds
+	case EmpiricalServiceTime:
+		return s.empiricalSamples[s.random.Intn(len(s.empiricalSamples))]
+	default:
+		return s.distribution.MeanMilliseconds
+	}
+}
+
+// readServiceTimes reads the non-negative service times listed in the file, one per line
+func readServiceTimes(path string) ([]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var samples []float64
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample, err := strconv.ParseFloat(line, 64)
+		if err != nil || sample < 0 {
+			return nil, fmt.Errorf("line %d of %s is not a non-negative service time in milliseconds: %q", lineNumber, path, line)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("%s lists no service times", path)
+	}
+	return samples, nil
+}
@@ -0,0 +1,62 @@
+package setup
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"stellar/util"
+	"time"
+)
+
+const (
+	// ClientServiceTime converts service times into busy-spin increments timed on the host running the client
+	ClientServiceTime = "client"
+	// ProbeServiceTime converts service times into busy-spin increments calibrated by probing every deployed function
+	ProbeServiceTime = "probe"
+	// WallServiceTime makes the functions spin for the service times on their wall clock
+	WallServiceTime = "wall"
+	// CPUServiceTime makes the functions spin for the service times on their CPU clock
+	CPUServiceTime = "cpu"
+)
+
+// validateServiceTimeMode ensures the functions of the sub-experiment can be given service times in its mode
+func validateServiceTimeMode(subExperiment SubExperiment) {
+	switch subExperiment.ServiceTimeMode {
+	case ClientServiceTime:
+	case ProbeServiceTime, WallServiceTime, CPUServiceTime:
+		if subExperiment.Provider == "vhive" {
+			// The gRPC functions of vHive only take busy-spin increments and do not report their service time
+			log.Fatalf("Sub-experiment %q: ServiceTimeMode %q is not supported by vhive.", subExperiment.Title, subExperiment.ServiceTimeMode)
+		}
+		if subExperiment.ServiceTimeMode == CPUServiceTime && (subExperiment.Provider == "fermyon" || subExperiment.Provider == "spin") {
+			// The WebAssembly sandbox of Spin functions has no CPU clock to spin on
+			log.Fatalf("Sub-experiment %q: ServiceTimeMode %q is not supported by %s.", subExperiment.Title, subExperiment.ServiceTimeMode, subExperiment.Provider)
+		}
+	default:
+		log.Fatalf("Sub-experiment %q has an unknown ServiceTimeMode %q.", subExperiment.Title, subExperiment.ServiceTimeMode)
+	}
+}
+
+// TargetSideServiceTime returns whether the functions of the sub-experiment spin for the service times themselves
+func (s *SubExperiment) TargetSideServiceTime() bool {
+	return s.ServiceTimeMode == WallServiceTime || s.ServiceTimeMode == CPUServiceTime
+}
+
+// DesiredServiceTimeMilliseconds returns the desired service time of the bursts of the given refresh period, the
+// DesiredServiceTimes being cycled as for the busy-spin increments
+func (s *SubExperiment) DesiredServiceTimeMilliseconds(deltaIndex int) float64 {
+	if len(s.DesiredServiceTimes) == 0 {
+		return 0
+	}
+	serviceTime := s.DesiredServiceTimes[util.IntegerMin(deltaIndex, len(s.DesiredServiceTimes)-1)]
+	duration, err := time.ParseDuration(serviceTime)
+	if err != nil {
+		log.Fatalf("Could not parse desired function run duration %s from configuration file.", serviceTime)
+	}
+	return float64(duration.Microseconds()) / 1000
+}
+
+// CalibrationTarget identifies the platform the busy-spin increments of the sub-experiment are calibrated for, e.g.,
+// aws/us-west-1/hellopy/python3.12/128MB/x86_64
+func (s *SubExperiment) CalibrationTarget() string {
+	return fmt.Sprintf("%s/%s/%s/%s/%dMB/%s", s.Provider, s.Region, s.Function, s.Runtime, s.FunctionMemoryMB, s.Architecture)
+}
@@ -0,0 +1,187 @@
+package setup
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"stellar/setup/deployment/packaging"
+	"stellar/util"
+	"strconv"
+	"time"
+)
+
+const (
+	spinManifestFileName  = "spin.toml"
+	spinListenHost        = "127.0.0.1"
+	spinFirstListenPort   = 3000
+	spinReadinessTimeout  = time.Minute
+	spinReadinessInterval = 500 * time.Millisecond
+)
+
+// spinFunctionModules are the paths of the WebAssembly modules built for each function, relative to its application
+var spinFunctionModules = map[string]string{
+	"hellorust": "target/wasm32-wasip1/release/hellorust.wasm",
+}
+
+var (
+	spinApplicationNameRegex = regexp.MustCompile(`(?m)^name = ".*"$`)
+	fermyonEndpointRegex     = regexp.MustCompile(`https://[a-z0-9-]+\.fermyon\.app`)
+	spinProcesses            []*exec.Cmd
+	spinNextListenPort       = spinFirstListenPort
+)
+
+// ProvisionFunctionsSpin builds the functions of the sub-experiments to WASI components and deploys them, one
+// application per parallelism, to Fermyon Cloud (provider fermyon) or to local Spin runtimes (provider spin).
+func ProvisionFunctionsSpin(config *Configuration, appsDirPath string) {
+	for index := range config.SubExperiments {
+		subExperiment := &config.SubExperiments[index]
+		if subExperiment.DataTransferChainLength > 1 || subExperiment.StorageTransfer {
+			log.Fatalf("[sub-experiment %d] Data transfers are not supported on Spin.", subExperiment.ID)
+		}
+
+		appDir := BuildSpinApplication(subExperiment, index, appsDirPath)
+		randomTag := util.GenerateRandLowercaseLetters(5)
+		for parallelism := 0; parallelism < subExperiment.Parallelism; parallelism++ {
+			var endpointID string
+			switch config.Provider {
+			case "fermyon":
+				endpointID = deployFermyonApplication(subExperiment, appDir, fmt.Sprintf("%s-%s", randomTag, createName(subExperiment, index, parallelism)))
+			case "spin":
+				endpointID = startSpinApplication(subExperiment, appDir)
+			default:
+				log.Fatalf("Provider %s does not run Spin applications", config.Provider)
+			}
+			subExperiment.Endpoints = append(subExperiment.Endpoints, EndpointInfo{ID: endpointID, Region: subExperiment.Region})
+			subExperiment.AddRoute("")
+		}
+	}
+}
+
+// BuildSpinApplication copies the application of the sub-experiment function to its own directory and builds it.
+// When FunctionImageSizeMB is set, the module is padded to that size by embedding a filler file (see FillerProfile)
+// sized after a first build without filler.
+func BuildSpinApplication(subExperiment *SubExperiment, index int, appsDirPath string) string {
+	modulePath, ok := spinFunctionModules[subExperiment.Function]
+	if !ok {
+		log.Fatalf("[sub-experiment %d] Function %s is not available for Spin.", subExperiment.ID, subExperiment.Function)
+	}
+
+	appDir := filepath.Join(appsDirPath, fmt.Sprintf("sub-experiment-%d", index))
+	if err := os.RemoveAll(appDir); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not clean Spin application directory %s: %s", subExperiment.ID, appDir, err.Error())
+	}
+	util.RunCommandAndLog(exec.Command("cp", "-r", filepath.Join(appsDirPath, subExperiment.Function), appDir))
+
+	fillerFilePath := filepath.Join(appDir, "filler.file")
+	packaging.GenerateFillerFile(subExperiment.ID, fillerFilePath, 0)
+	buildSpinApplication(appDir)
+
+	if subExperiment.FunctionImageSizeMB > 0 {
+		moduleInfo, err := os.Stat(filepath.Join(appDir, modulePath))
+		if err != nil {
+			log.Fatalf("[sub-experiment %d] Could not find the built WebAssembly module: %s", subExperiment.ID, err.Error())
+		}
+		fillerFileSize := packaging.CalculateFillerFileSizeInBytes(moduleInfo.Size(), util.MebibyteToBytes(subExperiment.FunctionImageSizeMB))
+		fillerOptions := subExperiment.FillerOptions()
+		fillerOptions.Files = 1 // the module embeds a single filler file
+		packaging.GenerateFillerFiles(subExperiment.ID, fillerFilePath, fillerFileSize, fillerOptions)
+		buildSpinApplication(appDir)
+	}
+	return appDir
+}
+
+func buildSpinApplication(appDir string) {
+	util.RunCommandAndLog(exec.Command("spin", "build", "--from", filepath.Join(appDir, spinManifestFileName)))
+}
+
+// deployFermyonApplication deploys the built application to Fermyon Cloud under the given name
+func deployFermyonApplication(subExperiment *SubExperiment, appDir string, name string) string {
+	manifest, err := os.ReadFile(filepath.Join(appDir, spinManifestFileName))
+	if err != nil {
+		log.Fatalf("[sub-experiment %d] Could not read Spin manifest: %s", subExperiment.ID, err.Error())
+	}
+	// Fermyon Cloud names applications after their manifest, so each parallel copy gets its own
+	manifestPath := filepath.Join(appDir, fmt.Sprintf("spin-%s.toml", name))
+	renamedManifest := SetSpinApplicationName(string(manifest), name)
+	if err := os.WriteFile(manifestPath, []byte(renamedManifest), 0644); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not write Spin manifest: %s", subExperiment.ID, err.Error())
+	}
+
+	log.Infof("[sub-experiment %d] Deploying Spin application %s to Fermyon Cloud...", subExperiment.ID, name)
+	providerDeployedServices["fermyon"] = append(providerDeployedServices["fermyon"], deployedService{Name: name}) // Used for function removal
+	deployMessage := util.RunCommandAndLog(exec.Command("spin", "deploy", "--from", manifestPath))
+	return GetFermyonEndpointID(deployMessage)
+}
+
+// startSpinApplication runs the built application in a local Spin runtime and waits for it to accept connections
+func startSpinApplication(subExperiment *SubExperiment, appDir string) string {
+	address := net.JoinHostPort(spinListenHost, strconv.Itoa(spinNextListenPort))
+	spinNextListenPort++
+
+	log.Infof("[sub-experiment %d] Starting Spin application at %s...", subExperiment.ID, address)
+	spinUpCommand := exec.Command("spin", "up", "--from", filepath.Join(appDir, spinManifestFileName), "--listen", address)
+	if err := spinUpCommand.Start(); err != nil {
+		log.Fatalf("[sub-experiment %d] Could not start Spin: %s", subExperiment.ID, err.Error())
+	}
+	spinProcesses = append(spinProcesses, spinUpCommand)
+
+	deadline := time.Now().Add(spinReadinessTimeout)
+	for {
+		connection, err := net.DialTimeout("tcp", address, spinReadinessInterval)
+		if err == nil {
+			connection.Close()
+			return address
+		}
+		if time.Now().After(deadline) {
+			log.Fatalf("[sub-experiment %d] Spin application at %s was not ready after %v.", subExperiment.ID, address, spinReadinessTimeout)
+		}
+		time.Sleep(spinReadinessInterval)
+	}
+}
+
+// SetSpinApplicationName replaces the name of the application in the given Spin manifest
+func SetSpinApplicationName(manifest string, name string) string {
+	replaced := false
+	return spinApplicationNameRegex.ReplaceAllStringFunc(manifest, func(line string) string {
+		if replaced {
+			return line
+		}
+		replaced = true
+		return fmt.Sprintf("name = %q", name)
+	})
+}
+
+// GetFermyonEndpointID finds the Fermyon Cloud endpoint ID from the deployment message
+func GetFermyonEndpointID(message string) string {
+	endpoint := fermyonEndpointRegex.FindString(message)
+	if endpoint == "" {
+		log.Fatalf("Could not find the Fermyon Cloud URL in the deployment message: %s", message)
+	}
+	return endpoint[len("https://"):]
+}
+
+// RemoveSpinAllApplications removes the applications deployed to Fermyon Cloud and stops the local Spin runtimes
+func RemoveSpinAllApplications() []string {
+	var removeMessages []string
+	for _, application := range providerDeployedServices["fermyon"] {
+		log.Infof("Deleting Fermyon Cloud application %s...", application.Name)
+		removeMessages = append(removeMessages, util.RunCommandAndLog(exec.Command("spin", "cloud", "apps", "delete", application.Name)))
+	}
+	providerDeployedServices["fermyon"] = nil
+
+	for _, process := range spinProcesses {
+		if err := process.Process.Kill(); err != nil {
+			log.Errorf("Could not stop Spin runtime (pid %d): %s", process.Process.Pid, err.Error())
+			continue
+		}
+		_ = process.Wait()
+		removeMessages = append(removeMessages, fmt.Sprintf("Spin runtime (pid %d) stopped.", process.Process.Pid))
+	}
+	spinProcesses = nil
+	spinNextListenPort = spinFirstListenPort
+	return removeMessages
+}
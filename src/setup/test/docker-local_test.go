@@ -0,0 +1,80 @@
+package setup
+
+import (
+	"archive/zip"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"stellar/setup/deployment/connection/docker"
+	"testing"
+	"time"
+)
+
+func TestDockerLocalLambdaFunctionConfig(t *testing.T) {
+	subExperiment := &setup.SubExperiment{
+		Handler:            "main.lambda_handler",
+		Runtime:            "python3.9",
+		FunctionMemoryMB:   256,
+		FunctionCPU:        0.5,
+		MaxInstances:       4,
+		IdleTimeoutSeconds: 30,
+	}
+
+	functionConfig := setup.DockerLocalLambdaFunctionConfig(subExperiment, "/tmp/sub-experiment-0")
+	require.True(t, functionConfig.Lambda)
+	require.Equal(t, 30*time.Second, functionConfig.IdleTimeout)
+	require.Equal(t, 4, functionConfig.MaxInstances)
+	require.Equal(t, docker.ContainerSpec{
+		Image:         "public.ecr.aws/lambda/python:3.9",
+		Cmd:           []string{"main.lambda_handler"},
+		Binds:         []string{"/tmp/sub-experiment-0:/var/task:ro"},
+		ContainerPort: docker.LambdaPort,
+		MemoryMB:      256,
+		CPU:           0.5,
+	}, functionConfig.Container)
+}
+
+func TestDockerLocalHTTPFunctionConfig(t *testing.T) {
+	subExperiment := &setup.SubExperiment{MinInstances: 1}
+
+	functionConfig := setup.DockerLocalHTTPFunctionConfig(subExperiment, "stellar-hellopy:abcde-0")
+	require.False(t, functionConfig.Lambda)
+	require.Equal(t, 5*time.Minute, functionConfig.IdleTimeout, "idle instances should be stopped after 5 minutes by default")
+	require.Equal(t, 1, functionConfig.MinInstances)
+	require.Equal(t, "stellar-hellopy:abcde-0", functionConfig.Container.Image)
+	require.Equal(t, []string{"PORT=8080"}, functionConfig.Container.Env)
+	require.Equal(t, 8080, functionConfig.Container.ContainerPort)
+}
+
+func writeTestZIP(t *testing.T, files map[string]string) string {
+	zipPath := filepath.Join(t.TempDir(), "artifact.zip")
+	zipFile, err := os.Create(zipPath)
+	require.NoError(t, err)
+	writer := zip.NewWriter(zipFile)
+	for name, content := range files {
+		fileWriter, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = fileWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, zipFile.Close())
+	return zipPath
+}
+
+func TestUnzipArtifact(t *testing.T) {
+	zipPath := writeTestZIP(t, map[string]string{"main.py": "print('hello')", "lib/filler.file": "filler"})
+	destination := filepath.Join(t.TempDir(), "sub-experiment-0")
+
+	require.NoError(t, setup.UnzipArtifact(zipPath, destination))
+	content, err := os.ReadFile(filepath.Join(destination, "main.py"))
+	require.NoError(t, err)
+	require.Equal(t, "print('hello')", string(content))
+	content, err = os.ReadFile(filepath.Join(destination, "lib", "filler.file"))
+	require.NoError(t, err)
+	require.Equal(t, "filler", string(content))
+
+	zipPath = writeTestZIP(t, map[string]string{"../escaped.py": "print('escaped')"})
+	require.Error(t, setup.UnzipArtifact(zipPath, destination))
+}
@@ -0,0 +1,60 @@
+package setup
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"testing"
+)
+
+func TestExtractConfigurationFillerSeed(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "filler-seed.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"SubExperiments": [
+		{"Title": "first", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10},
+		{"Title": "second", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10},
+		{"Title": "zero", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10, "FillerSeed": 0},
+		{"Title": "explicit", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10, "FillerSeed": 42}
+	]}`), 0644))
+
+	config := setup.ExtractConfiguration(configPath)
+
+	require.Equal(t, int64(0), config.SubExperiments[0].FillerOptions().Seed)
+	require.Equal(t, int64(1), config.SubExperiments[1].FillerOptions().Seed)
+	require.Equal(t, int64(0), config.SubExperiments[2].FillerOptions().Seed)
+	require.Equal(t, int64(42), config.SubExperiments[3].FillerOptions().Seed)
+	require.Equal(t, config.SubExperiments, setup.ExtractConfiguration(configPath).SubExperiments, "the same configuration should give the same filler")
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"FillerSeed": 1000, "SubExperiments": [
+		{"Title": "first", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10},
+		{"Title": "second", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10}
+	]}`), 0644))
+	config = setup.ExtractConfiguration(configPath)
+	require.Equal(t, int64(1000), config.SubExperiments[0].FillerOptions().Seed)
+	require.Equal(t, int64(1001), config.SubExperiments[1].FillerOptions().Seed)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"RandomFillerSeed": true, "SubExperiments": [
+		{"Title": "first", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10},
+		{"Title": "explicit", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10, "FillerSeed": 42}
+	]}`), 0644))
+	config = setup.ExtractConfiguration(configPath)
+	require.NotZero(t, config.SubExperiments[0].FillerOptions().Seed)
+	require.Equal(t, int64(42), config.SubExperiments[1].FillerOptions().Seed)
+}
+
+func TestExtractConfigurationProviderDefaultMemory(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "memory.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"SubExperiments": [
+		{"Title": "lambda", "Provider": "aws", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10},
+		{"Title": "cloud-run", "Provider": "gcr", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10},
+		{"Title": "cloud-run-sized", "Provider": "gcr", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10, "FunctionMemoryMB": 256},
+		{"Title": "knative", "Provider": "vhive", "Bursts": 1, "BurstSizes": [1], "IATSeconds": 10}
+	]}`), 0644))
+
+	config := setup.ExtractConfiguration(configPath)
+
+	require.Equal(t, int64(128), config.SubExperiments[0].FunctionMemoryMB)
+	require.NotContains(t, setup.GCRDeployArguments(&config.SubExperiments[1], "svc", "docker.io/user/img", "us-west1"), "--memory")
+	require.Contains(t, setup.GCRDeployArguments(&config.SubExperiments[2], "svc", "docker.io/user/img", "us-west1"), "256Mi")
+	require.Equal(t, int64(0), config.SubExperiments[3].FunctionMemoryMB, "Knative Services should keep the memory of the cluster defaults")
+}
@@ -0,0 +1,28 @@
+package setup
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"testing"
+)
+
+func TestExtractConfigurationForceCold(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "force-cold.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"SubExperiments": [
+		{"Title": "forced", "Provider": "gcr", "Bursts": 3, "BurstSizes": [5], "IATSeconds": 10, "ForceCold": true},
+		{"Title": "idle", "Bursts": 3, "BurstSizes": [5], "IATSeconds": 600}
+	]}`), 0644))
+
+	config := setup.ExtractConfiguration(configPath)
+
+	require.True(t, config.SubExperiments[0].ForceCold)
+	require.False(t, config.SubExperiments[1].ForceCold)
+}
+
+func TestGCRForceColdArguments(t *testing.T) {
+	require.Equal(t,
+		[]string{"run", "services", "update", "svc", "--quiet", "--region", "us-west1", "--update-env-vars", "STELLAR_FORCE_COLD=1700000000"},
+		setup.GCRForceColdArguments("svc", "us-west1", "1700000000"))
+}
@@ -0,0 +1,66 @@
+package setup
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"stellar/setup"
+	"stellar/setup/deployment/connection/knative"
+	"strings"
+	"testing"
+)
+
+func TestProvisionFunctionsKnative(t *testing.T) {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{knative.ServiceResource: "ServiceList"})
+	client.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		service := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		_ = unstructured.SetNestedField(service.Object, "http://"+service.GetName()+".default.192.168.1.240.nip.io", "status", "url")
+		_ = unstructured.SetNestedSlice(service.Object, []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		}, "status", "conditions")
+		return false, nil, nil
+	})
+	setup.KnativeDeployer = knative.NewDeployer(client, "default")
+	defer func() { setup.KnativeDeployer = nil }()
+
+	config := &setup.Configuration{
+		Provider: "vhive",
+		SubExperiments: []setup.SubExperiment{
+			{ID: 0, Title: "2chain,minio", Provider: "vhive", Function: "producer-consumer", Parallelism: 2,
+				DataTransferChainLength: 2, FunctionMemoryMB: 256, MinInstances: 1},
+		},
+	}
+	setup.ProvisionFunctionsKnative(config)
+
+	endpoints := config.SubExperiments[0].Endpoints
+	require.Len(t, endpoints, 2)
+	require.Len(t, config.SubExperiments[0].Routes, 2)
+	for _, endpoint := range endpoints {
+		require.True(t, strings.HasSuffix(endpoint.ID, "-0.default.192.168.1.240.nip.io:80"))
+		require.Len(t, endpoint.DataTransferChainIDs, 1)
+		require.True(t, strings.HasSuffix(endpoint.DataTransferChainIDs[0], "-1.default.192.168.1.240.nip.io:80"))
+	}
+
+	services, err := client.Resource(knative.ServiceResource).Namespace("default").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, services.Items, 4)
+	annotations, _, _ := unstructured.NestedStringMap(services.Items[0].Object, "spec", "template", "metadata", "annotations")
+	require.Equal(t, "1", annotations["autoscaling.knative.dev/minScale"])
+
+	setup.RemoveKnativeAllServices()
+
+	services, err = client.Resource(knative.ServiceResource).Namespace("default").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, services.Items)
+}
+
+func TestKnativeImage(t *testing.T) {
+	require.Equal(t, "vhiveease/stellar:prodcons", setup.KnativeImage(&setup.SubExperiment{Function: "producer-consumer"}))
+	require.Equal(t, "docker.io/user/custom:latest", setup.KnativeImage(&setup.SubExperiment{Function: "producer-consumer", ContainerImage: "docker.io/user/custom:latest"}))
+}
@@ -0,0 +1,66 @@
+package setup
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"stellar/setup"
+	"stellar/setup/deployment/connection/openfaas"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProvisionFunctionsOpenFaaS(t *testing.T) {
+	var mu sync.Mutex
+	functions := make(map[string]openfaas.Deployment)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPost:
+			var deployment openfaas.Deployment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deployment))
+			functions[deployment.Service] = deployment
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"availableReplicas": 1})
+		case http.MethodDelete:
+			var request map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			delete(functions, request["functionName"])
+		}
+	}))
+	defer server.Close()
+
+	openfaas.SetInstance(openfaas.NewClient(server.URL, "admin", "secret"))
+	defer openfaas.SetInstance(nil)
+
+	config := &setup.Configuration{
+		Provider: "openfaas",
+		SubExperiments: []setup.SubExperiment{
+			{ID: 0, Title: "hellopy", Provider: "openfaas", Function: "hellopy", Parallelism: 2, DataTransferChainLength: 1,
+				ContainerImage: "stellar/hellopy:latest", FunctionMemoryMB: 256, MinInstances: 1, MaxInstances: 4},
+		},
+	}
+	setup.ProvisionFunctionsOpenFaaS(config)
+
+	endpoints := config.SubExperiments[0].Endpoints
+	require.Len(t, endpoints, 2)
+	require.Len(t, config.SubExperiments[0].Routes, 2)
+	require.Len(t, functions, 2)
+	for _, endpoint := range endpoints {
+		require.True(t, strings.HasPrefix(endpoint.ID, server.URL+"/function/stellar-"))
+
+		deployment := functions[strings.TrimPrefix(endpoint.ID, server.URL+"/function/")]
+		require.Equal(t, "stellar/hellopy:latest", deployment.Image)
+		require.Equal(t, "256Mi", deployment.Limits.Memory)
+		require.Equal(t, "1", deployment.Labels["com.openfaas.scale.min"])
+		require.Equal(t, "4", deployment.Labels["com.openfaas.scale.max"])
+	}
+
+	setup.RemoveOpenFaaSAllFunctions()
+	require.Empty(t, functions)
+}
@@ -0,0 +1,65 @@
+package setup
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"stellar/setup"
+	"stellar/setup/deployment/connection/openwhisk"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProvisionFunctionsOpenWhisk(t *testing.T) {
+	var mu sync.Mutex
+	actions := make(map[string]map[string]interface{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode([]string{"guest"})
+		case http.MethodPut:
+			var action map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&action))
+			actions[path.Base(r.URL.Path)] = action
+		case http.MethodDelete:
+			delete(actions, path.Base(r.URL.Path))
+		}
+	}))
+	defer server.Close()
+
+	client, err := openwhisk.NewClient(server.URL, "", "user:password")
+	require.NoError(t, err)
+	openwhisk.SetInstance(client)
+	defer openwhisk.SetInstance(nil)
+
+	config := &setup.Configuration{
+		Provider: "openwhisk",
+		SubExperiments: []setup.SubExperiment{
+			{ID: 0, Title: "hellopy", Provider: "openwhisk", Function: "hellopy", Runtime: "python3.9", Parallelism: 2,
+				DataTransferChainLength: 1, FunctionMemoryMB: 256, TimeoutSeconds: 60, Concurrency: 2},
+		},
+	}
+	setup.ProvisionFunctionsOpenWhisk(config, "../deployment/raw-code/serverless/openwhisk/")
+
+	endpoints := config.SubExperiments[0].Endpoints
+	require.Len(t, endpoints, 2)
+	require.Len(t, config.SubExperiments[0].Routes, 2)
+	require.Len(t, actions, 2)
+	for _, endpoint := range endpoints {
+		require.True(t, strings.HasPrefix(endpoint.ID, server.URL+"/api/v1/web/guest/default/stellar-"))
+		require.True(t, strings.HasSuffix(endpoint.ID, ".json"))
+
+		action := actions[strings.TrimSuffix(path.Base(endpoint.ID), ".json")]
+		require.Equal(t, "python:default", action["exec"].(map[string]interface{})["kind"])
+		require.Contains(t, action["exec"].(map[string]interface{})["code"], "__OW_ACTIVATION_ID")
+		require.Equal(t, map[string]interface{}{"memory": 256.0, "timeout": 60000.0, "concurrency": 2.0}, action["limits"])
+	}
+
+	setup.RemoveOpenWhiskAllActions()
+	require.Empty(t, actions)
+}
@@ -0,0 +1,36 @@
+package setup
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"testing"
+)
+
+func TestExtractConfigurationPowerTuning(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "power-tuning.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"SubExperiments": [
+		{"Title": "power-tuning", "ExperimentType": "power-tuning", "MemorySizesMB": [512, 128, 1024], "RoundsPerMemorySize": 2,
+		 "BurstSizes": [5, 10], "LatencyTargetMs": 100}
+	]}`), 0644))
+
+	powerTuning := setup.ExtractConfiguration(configPath).SubExperiments[0]
+	require.Equal(t, int64(512), powerTuning.FunctionMemoryMB)
+	require.Equal(t, 12, powerTuning.Bursts)
+	require.Equal(t, []int{5}, powerTuning.BurstSizes)
+	require.Equal(t, 0., powerTuning.IATSeconds)
+
+	memoryMB, cold := powerTuning.PowerTuningBurst(0)
+	require.Equal(t, int64(512), memoryMB)
+	require.True(t, cold)
+	memoryMB, cold = powerTuning.PowerTuningBurst(3)
+	require.Equal(t, int64(512), memoryMB)
+	require.False(t, cold)
+	memoryMB, cold = powerTuning.PowerTuningBurst(4)
+	require.Equal(t, int64(128), memoryMB)
+	require.True(t, cold)
+	memoryMB, cold = powerTuning.PowerTuningBurst(11)
+	require.Equal(t, int64(1024), memoryMB)
+	require.False(t, cold)
+}
@@ -0,0 +1,30 @@
+package setup
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"testing"
+)
+
+func TestExtractConfigurationScaleOut(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "scale-out.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"SubExperiments": [
+		{"Title": "scale-out", "ExperimentType": "scale-out", "ArrivalRate": 250.5, "DurationSeconds": 9.5, "TargetInstances": 100},
+		{"Title": "bursts", "Bursts": 3, "BurstSizes": [1], "IATSeconds": 600}
+	]}`), 0644))
+
+	config := setup.ExtractConfiguration(configPath)
+
+	scaleOut := config.SubExperiments[0]
+	require.Equal(t, 10, scaleOut.Bursts)
+	require.Equal(t, []int{251}, scaleOut.BurstSizes)
+	require.Equal(t, 1., scaleOut.IATSeconds)
+	require.Equal(t, 2380, scaleOut.ScaleOutRequests())
+
+	bursts := config.SubExperiments[1]
+	require.Equal(t, setup.BurstsExperiment, bursts.ExperimentType)
+	require.Equal(t, 3, bursts.Bursts)
+	require.Equal(t, 600., bursts.IATSeconds)
+}
@@ -28,11 +28,75 @@ func TestCreateHeaderConfig(t *testing.T) {
 	}
 
 	actual := &setup.Serverless{}
-	actual.CreateHeaderConfig(config, "STeLLAR")
+	actual.CreateHeaderConfig(config, "STeLLAR", "")
 
 	require.Equal(t, expected, actual)
 }
 
+func TestCreateHeaderConfigRegion(t *testing.T) {
+	config := &setup.Configuration{
+		Provider: "aws",
+		Runtime:  "python3.9",
+	}
+
+	actual := &setup.Serverless{}
+	actual.CreateHeaderConfig(config, "STeLLAR", "eu-central-1")
+
+	require.Equal(t, "eu-central-1", actual.Provider.Region)
+}
+
+func TestServerlessConfigFileName(t *testing.T) {
+	require.Equal(t, "serverless.yml", setup.ServerlessConfigFileName("aws", ""))
+	require.Equal(t, "serverless.yml", setup.ServerlessConfigFileName("aws", "us-west-1"))
+	require.Equal(t, "serverless-eu-central-1.yml", setup.ServerlessConfigFileName("aws", "eu-central-1"))
+	require.Equal(t, "serverless-North-Europe.yml", setup.ServerlessConfigFileName("azure", "North Europe"))
+}
+
+func TestConfigurationRegions(t *testing.T) {
+	config := &setup.Configuration{
+		SubExperiments: []setup.SubExperiment{
+			{Region: "us-west-1"},
+			{Region: "eu-central-1"},
+			{Region: "us-west-1"},
+		},
+	}
+
+	require.Equal(t, []string{"us-west-1", "eu-central-1"}, config.Regions())
+}
+
+func TestConfigurationForProvider(t *testing.T) {
+	config := &setup.Configuration{
+		Provider: "aws",
+		Runtime:  "python3.9",
+		SubExperiments: []setup.SubExperiment{
+			{ID: 0, Provider: "aws"},
+			{ID: 1, Provider: "azure", Runtime: "python3.8", Parallelism: 1},
+			{ID: 2, Provider: "aws"},
+			{ID: 3, Provider: "gcr"},
+			{ID: 4, Provider: "gcr", Runtime: "go1.x"},
+		},
+	}
+
+	require.Equal(t, []string{"aws", "azure", "gcr"}, config.Providers())
+	require.Equal(t, "python3.9", config.ForProvider("aws").Runtime)
+	require.Equal(t, "go1.x", config.ForProvider("gcr").Runtime, "the runtime should come from the sub-experiments of the provider")
+
+	azureConfig := config.ForProvider("azure")
+	require.Equal(t, "azure", azureConfig.Provider)
+	require.Equal(t, "python3.8", azureConfig.Runtime)
+	require.Len(t, azureConfig.SubExperiments, 1)
+	require.Equal(t, 1, azureConfig.SubExperiments[0].ID)
+
+	azureConfig.SubExperiments[0].AssignEndpointIDs("azure-endpoint")
+	require.Nil(t, config.SubExperiments[1].Endpoints)
+
+	config.MergeSubExperiments(azureConfig)
+	require.Equal(t, []setup.EndpointInfo{{ID: "azure-endpoint"}}, config.SubExperiments[1].Endpoints)
+	require.Nil(t, config.SubExperiments[0].Endpoints)
+	require.Equal(t, "aws", config.Provider)
+	require.Equal(t, "python3.9", config.Runtime)
+}
+
 func TestAddFunctionConfigAWS(t *testing.T) {
 	expected := &setup.Serverless{
 		Package: setup.Package{Individually: true},
@@ -88,6 +152,172 @@ func TestAddFunctionConfigAWS(t *testing.T) {
 	require.Equal(t, []string{"abc12-test1-2-0", "abc12-test1-2-1"}, subEx.Routes)
 }
 
+func TestAddFunctionConfigAWSArchitecture(t *testing.T) {
+	actual := &setup.Serverless{Package: setup.Package{Individually: true}}
+
+	armSubEx := &setup.SubExperiment{Title: "arm", Parallelism: 1, Runtime: "go1.x", Handler: "bootstrap", PackagePattern: "bootstrap", Architecture: "arm64"}
+	actual.AddFunctionConfigAWS(armSubEx, 0, "abc12", "artifacts/hellogo-arm64/hellogo-arm64.zip")
+	x86SubEx := &setup.SubExperiment{Title: "x86", Parallelism: 1, Runtime: "go1.x", Handler: "bootstrap", PackagePattern: "bootstrap", Architecture: "x86_64"}
+	actual.AddFunctionConfigAWS(x86SubEx, 1, "abc12", "artifacts/hellogo/hellogo.zip")
+
+	require.Equal(t, "arm64", actual.Functions["abc12-arm-0-0"].Architecture)
+	require.Equal(t, "artifacts/hellogo-arm64/hellogo-arm64.zip", actual.Functions["abc12-arm-0-0"].Package.Artifact)
+	require.Equal(t, "", actual.Functions["abc12-x86-1-0"].Architecture)
+}
+
+func TestAddFunctionConfigAWSProvisionedConcurrency(t *testing.T) {
+	actual := &setup.Serverless{Package: setup.Package{Individually: true}}
+
+	subEx := &setup.SubExperiment{Title: "warm", Parallelism: 2, Runtime: "python3.9", Handler: "main.lambda_handler", PackagePattern: "main.py", ProvisionedConcurrency: 5}
+	actual.AddFunctionConfigAWS(subEx, 0, "abc12", "")
+
+	require.Equal(t, 5, actual.Functions["abc12-warm-0-0"].ProvisionedConcurrency)
+	require.Equal(t, 5, actual.Functions["abc12-warm-0-1"].ProvisionedConcurrency)
+}
+
+func TestAddFunctionConfigAWSInitWorkload(t *testing.T) {
+	actual := &setup.Serverless{Package: setup.Package{Individually: true}}
+
+	subEx := &setup.SubExperiment{Title: "init", Parallelism: 1, Runtime: "python3.12", Handler: "main.lambda_handler", PackagePattern: "main.py",
+		InitImportModules: 50, InitDependencyBundleMB: 2.5, InitMemoryMB: 128}
+	actual.AddFunctionConfigAWS(subEx, 0, "abc12", "")
+
+	require.Equal(t, map[string]string{
+		"STELLAR_INIT_IMPORT_MODULES":       "50",
+		"STELLAR_INIT_DEPENDENCY_BUNDLE_MB": "2.5",
+		"STELLAR_INIT_MEMORY_MB":            "128",
+	}, actual.Functions["abc12-init-0-0"].Environment)
+
+	subEx = &setup.SubExperiment{Title: "plain", Parallelism: 1, Runtime: "python3.12", Handler: "main.lambda_handler", PackagePattern: "main.py"}
+	actual.AddFunctionConfigAWS(subEx, 1, "abc12", "")
+	require.Nil(t, actual.Functions["abc12-plain-1-0"].Environment)
+}
+
+func TestAddFunctionConfigAWSWorkload(t *testing.T) {
+	actual := &setup.Serverless{Package: setup.Package{Individually: true}}
+
+	subEx := &setup.SubExperiment{Title: "disk", Parallelism: 1, Runtime: "python3.12", Handler: "main.lambda_handler", PackagePattern: "main.py",
+		InitMemoryMB: 64, Workload: setup.DiskWorkload, WorkloadBytes: 104857600, WorkloadFsync: true}
+	actual.AddFunctionConfigAWS(subEx, 0, "abc12", "")
+
+	require.Equal(t, map[string]string{
+		"STELLAR_INIT_MEMORY_MB": "64",
+		"STELLAR_WORKLOAD":       "disk",
+		"STELLAR_WORKLOAD_BYTES": "104857600",
+		"STELLAR_WORKLOAD_FSYNC": "true",
+	}, actual.Functions["abc12-disk-0-0"].Environment)
+
+	subEx = &setup.SubExperiment{Title: "fetch", Parallelism: 1, Runtime: "python3.12", Handler: "main.lambda_handler", PackagePattern: "main.py",
+		Workload: setup.FetchWorkload, WorkloadURL: "https://example.com/object"}
+	actual.AddFunctionConfigAWS(subEx, 1, "abc12", "")
+	require.Equal(t, map[string]string{
+		"STELLAR_WORKLOAD":     "fetch",
+		"STELLAR_WORKLOAD_URL": "https://example.com/object",
+	}, actual.Functions["abc12-fetch-1-0"].Environment)
+}
+
+func TestGCRDeployArguments(t *testing.T) {
+	subEx := &setup.SubExperiment{Title: "warm", Parallelism: 1}
+	require.Equal(t,
+		[]string{"run", "deploy", "svc", "--image", "docker.io/user/img", "--allow-unauthenticated", "--region", "us-west1"},
+		setup.GCRDeployArguments(subEx, "svc", "docker.io/user/img", "us-west1"))
+
+	subEx = &setup.SubExperiment{Title: "warm", Parallelism: 1, CPUBoostEnabled: true, MinInstances: 1, MaxInstances: 3}
+	require.Equal(t,
+		[]string{"run", "deploy", "svc", "--image", "docker.io/user/img", "--allow-unauthenticated", "--region", "us-west1",
+			"--cpu-boost", "--min-instances", "1", "--max-instances", "3"},
+		setup.GCRDeployArguments(subEx, "svc", "docker.io/user/img", "us-west1"))
+
+	subEx = &setup.SubExperiment{Title: "resources", Parallelism: 1, FunctionMemoryMB: 1024, FunctionCPU: 0.5, Concurrency: 1,
+		TimeoutSeconds: 60, ExecutionEnvironment: "gen2", CPUAlwaysAllocated: true}
+	require.Equal(t,
+		[]string{"run", "deploy", "svc", "--image", "docker.io/user/img", "--allow-unauthenticated", "--region", "us-west1",
+			"--memory", "1024Mi", "--cpu", "0.5", "--concurrency", "1", "--timeout", "60", "--execution-environment", "gen2",
+			"--no-cpu-throttling"},
+		setup.GCRDeployArguments(subEx, "svc", "docker.io/user/img", "us-west1"))
+
+	subEx = &setup.SubExperiment{Title: "init", Parallelism: 1, InitSleepMilliseconds: 200, InitReadFillerMB: 10}
+	require.Equal(t,
+		[]string{"run", "deploy", "svc", "--image", "docker.io/user/img", "--allow-unauthenticated", "--region", "us-west1",
+			"--set-env-vars", "STELLAR_INIT_READ_FILLER_MB=10,STELLAR_INIT_SLEEP_MS=200"},
+		setup.GCRDeployArguments(subEx, "svc", "docker.io/user/img", "us-west1"))
+}
+
+func TestGoogleDeployArguments(t *testing.T) {
+	source := setup.GoogleFunctionSource{Directory: "hellopy", EntryPoint: "hello_world", Runtime: "python39"}
+
+	subEx := &setup.SubExperiment{Title: "warm", Parallelism: 1, Runtime: "python3.11"}
+	require.Equal(t,
+		[]string{"functions", "deploy", "fn", "--region", "us-west2", "--runtime", "python311", "--source", "hellopy",
+			"--entry-point", "hello_world", "--trigger-http", "--allow-unauthenticated", "--quiet"},
+		setup.GoogleDeployArguments(subEx, "fn", source, "us-west2"))
+
+	subEx = &setup.SubExperiment{Title: "warm", Parallelism: 1, Runtime: "go1.x", ExecutionEnvironment: "gen2",
+		FunctionMemoryMB: 512, MinInstances: 1, MaxInstances: 2, TimeoutSeconds: 60}
+	require.Equal(t,
+		[]string{"functions", "deploy", "fn", "--region", "us-west2", "--runtime", "python39", "--source", "hellopy",
+			"--entry-point", "hello_world", "--trigger-http", "--allow-unauthenticated", "--quiet",
+			"--gen2", "--memory", "512MB", "--min-instances", "1", "--max-instances", "2", "--timeout", "60s"},
+		setup.GoogleDeployArguments(subEx, "fn", source, "us-west2"))
+
+	subEx = &setup.SubExperiment{Title: "gen1", Parallelism: 1, Runtime: "python39", ExecutionEnvironment: "gen1"}
+	require.Contains(t, setup.GoogleDeployArguments(subEx, "fn", source, "us-west2"), "--no-gen2")
+}
+
+func TestGoogleRuntime(t *testing.T) {
+	require.Equal(t, "python39", setup.GoogleRuntime("python3.9"))
+	require.Equal(t, "nodejs18", setup.GoogleRuntime("nodejs18.x"))
+	require.Equal(t, "go121", setup.GoogleRuntime("go1.x"))
+	require.Equal(t, "java11", setup.GoogleRuntime("java11"))
+	require.Equal(t, "go122", setup.GoogleRuntime("go122"))
+	require.Equal(t, "python312", setup.GoogleRuntime("python3.12"))
+	require.Equal(t, "nodejs22", setup.GoogleRuntime("nodejs22.x"))
+	require.Equal(t, "dotnet8", setup.GoogleRuntime("dotnet8"))
+}
+
+func TestParseGCRServiceDescription(t *testing.T) {
+	description := `{
+  "apiVersion": "serving.knative.dev/v1",
+  "kind": "Service",
+  "spec": {
+    "template": {
+      "metadata": {
+        "annotations": {
+          "autoscaling.knative.dev/maxScale": "3",
+          "run.googleapis.com/cpu-throttling": "false",
+          "run.googleapis.com/execution-environment": "gen2"
+        }
+      },
+      "spec": {
+        "containerConcurrency": 1,
+        "timeoutSeconds": 60,
+        "containers": [
+          {
+            "image": "docker.io/user/img",
+            "resources": {
+              "limits": {
+                "cpu": "1000m",
+                "memory": "1024Mi"
+              }
+            }
+          }
+        ]
+      }
+    }
+  }
+}`
+
+	settings := setup.ParseGCRServiceDescription(description)
+	require.Equal(t, "1000m", settings["cpu"])
+	require.Equal(t, "1024Mi", settings["memory"])
+	require.Equal(t, "1", settings["concurrency"])
+	require.Equal(t, "60", settings["timeoutSeconds"])
+	require.Equal(t, "gen2", settings["executionEnvironment"])
+	require.Equal(t, "false", settings["cpuThrottling"])
+	require.Equal(t, "3", settings["maxInstances"])
+	require.Equal(t, "", settings["minInstances"])
+}
+
 func TestAddFunctionConfigAzure(t *testing.T) {
 	expected := &setup.Serverless{
 		Functions: map[string]*setup.Function{
@@ -284,12 +514,32 @@ func TestGetGCREndpointID(t *testing.T) {
 	require.Equal(t, "test-function-nfjrndgaha-uw.a.run.app", actual)
 }
 
+func TestGetGoogleEndpointID(t *testing.T) {
+	gen1Description := `{"name": "projects/zinc-hour-315914/locations/us-west2/functions/hellopy-1", "httpsTrigger": {"url": "https://us-west2-zinc-hour-315914.cloudfunctions.net/hellopy-1"}}`
+	require.Equal(t, "us-west2-zinc-hour-315914.cloudfunctions.net/hellopy-1", setup.GetGoogleEndpointID(gen1Description))
+
+	gen2Description := `{"environment": "GEN_2", "serviceConfig": {"uri": "https://hellopy-1-nfjrndgaha-wl.a.run.app"}, "url": "https://us-west2-zinc-hour-315914.cloudfunctions.net/hellopy-1"}`
+	require.Equal(t, "us-west2-zinc-hour-315914.cloudfunctions.net/hellopy-1", setup.GetGoogleEndpointID(gen2Description))
+}
+
 func TestGetCloudflareEndpointID(t *testing.T) {
 	testMsg := "Published hellojs_wrangler0 (3.48 sec)\nhttps://hellonode.stellarbench.workers.dev\nCurrent Deployment ID: 26923084-4e66-4b4b-b876-cb85341b75f6"
 	actual := setup.GetCloudflareEndpointID(testMsg)
 	require.Equal(t, "hellonode.stellarbench.workers.dev", actual)
 }
 
+func TestGetFermyonEndpointID(t *testing.T) {
+	testMsg := "Uploading hellorust version 0.1.0 to Fermyon Cloud...\nDeploying...\nWaiting for application to become ready.......... ready\n\nView application:   https://abcde-hellorust-0-0-x1y2z3.fermyon.app/\nManage application: https://cloud.fermyon.com/app/abcde-hellorust-0-0\n"
+	actual := setup.GetFermyonEndpointID(testMsg)
+	require.Equal(t, "abcde-hellorust-0-0-x1y2z3.fermyon.app", actual)
+}
+
+func TestSetSpinApplicationName(t *testing.T) {
+	manifest := "spin_manifest_version = 2\n\n[application]\nname = \"hellorust\"\nversion = \"0.1.0\"\n\n[component.hellorust]\nname = \"other\"\n"
+	expected := "spin_manifest_version = 2\n\n[application]\nname = \"abcde-hellorust-0-1\"\nversion = \"0.1.0\"\n\n[component.hellorust]\nname = \"other\"\n"
+	require.Equal(t, expected, setup.SetSpinApplicationName(manifest, "abcde-hellorust-0-1"))
+}
+
 func TestGetAlibabaEndpointID(t *testing.T) {
 	testMsg := "Deploying API sls_http_my_service_dev_hello...\nDeployed API sls_http_my_service_dev_hello\nGET http://5cfeb440ed6d4ad69ae29d8408aa606e-ap-southeast-1.alicloudapi.com/foo -> my-service-dev.my-service-dev-hello\n"
 	actual := setup.GetAlibabaEndpointID(testMsg)
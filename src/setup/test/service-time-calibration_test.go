@@ -0,0 +1,57 @@
+package setup
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"testing"
+	"time"
+)
+
+func TestServiceTimeCalibrationCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service-time-calibration.json")
+	cache, err := setup.LoadServiceTimeCalibrationCache(path)
+	require.NoError(t, err)
+
+	target := "aws/us-west-1/hellopy/python3.12/128MB/x86_64"
+	_, ok := cache.Get(target)
+	require.False(t, ok)
+
+	calibration := setup.ServiceTimeCalibration{BusySpinIncrementsPerMillisecond: 21500, CalibratedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, cache.Set(target, calibration))
+
+	reloaded, err := setup.LoadServiceTimeCalibrationCache(path)
+	require.NoError(t, err)
+	cached, ok := reloaded.Get(target)
+	require.True(t, ok)
+	require.Equal(t, calibration.BusySpinIncrementsPerMillisecond, cached.BusySpinIncrementsPerMillisecond)
+	require.True(t, calibration.CalibratedAt.Equal(cached.CalibratedAt))
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+	_, err = setup.LoadServiceTimeCalibrationCache(path)
+	require.Error(t, err)
+}
+
+func TestServiceTimeModes(t *testing.T) {
+	experiment := setup.SubExperiment{
+		Provider:            "aws",
+		Region:              "us-west-1",
+		Function:            "hellopy",
+		Runtime:             "python3.12",
+		FunctionMemoryMB:    512,
+		Architecture:        "arm64",
+		DesiredServiceTimes: []string{"250us", "40ms"},
+		ServiceTimeMode:     setup.CPUServiceTime,
+	}
+	require.True(t, experiment.TargetSideServiceTime())
+	require.Equal(t, 0.25, experiment.DesiredServiceTimeMilliseconds(0))
+	require.Equal(t, 40.0, experiment.DesiredServiceTimeMilliseconds(1))
+	require.Equal(t, 40.0, experiment.DesiredServiceTimeMilliseconds(5))
+	require.Equal(t, "aws/us-west-1/hellopy/python3.12/512MB/arm64", experiment.CalibrationTarget())
+
+	experiment.ServiceTimeMode = setup.ProbeServiceTime
+	experiment.DesiredServiceTimes = nil
+	require.False(t, experiment.TargetSideServiceTime())
+	require.Zero(t, experiment.DesiredServiceTimeMilliseconds(0))
+}
@@ -0,0 +1,106 @@
+package setup
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"os"
+	"path/filepath"
+	"stellar/setup"
+	"testing"
+)
+
+func sampleServiceTimes(t *testing.T, distribution setup.ServiceTimeDistribution, count int) []float64 {
+	sampler, err := distribution.NewSampler()
+	require.NoError(t, err)
+
+	samples := make([]float64, count)
+	for i := range samples {
+		samples[i] = sampler.Sample()
+	}
+	return samples
+}
+
+func meanAndStdDev(samples []float64) (float64, float64) {
+	sum, squares := 0.0, 0.0
+	for _, sample := range samples {
+		sum += sample
+		squares += sample * sample
+	}
+	mean := sum / float64(len(samples))
+	return mean, math.Sqrt(squares/float64(len(samples)) - mean*mean)
+}
+
+func TestConstantServiceTimes(t *testing.T) {
+	samples := sampleServiceTimes(t, setup.ServiceTimeDistribution{Type: setup.ConstantServiceTime, MeanMilliseconds: 25}, 100)
+	for _, sample := range samples {
+		require.Equal(t, 25.0, sample)
+	}
+}
+
+func TestExponentialServiceTimes(t *testing.T) {
+	samples := sampleServiceTimes(t, setup.ServiceTimeDistribution{Type: setup.ExponentialServiceTime, MeanMilliseconds: 50, Seed: 7}, 50000)
+	mean, stdDev := meanAndStdDev(samples)
+	require.InEpsilon(t, 50, mean, 0.03)
+	require.InEpsilon(t, 50, stdDev, 0.05)
+}
+
+func TestLognormalServiceTimes(t *testing.T) {
+	samples := sampleServiceTimes(t, setup.ServiceTimeDistribution{Type: setup.LognormalServiceTime, MeanMilliseconds: 100, StdDevMilliseconds: 40, Seed: 7}, 50000)
+	mean, stdDev := meanAndStdDev(samples)
+	require.InEpsilon(t, 100, mean, 0.03)
+	require.InEpsilon(t, 40, stdDev, 0.05)
+	for _, sample := range samples {
+		require.Positive(t, sample)
+	}
+}
+
+func TestBimodalServiceTimes(t *testing.T) {
+	samples := sampleServiceTimes(t, setup.ServiceTimeDistribution{Type: setup.BimodalServiceTime, ShortMilliseconds: 10,
+		LongMilliseconds: 500, LongProbability: 0.2, Seed: 7}, 20000)
+
+	long := 0
+	for _, sample := range samples {
+		require.Contains(t, []float64{10, 500}, sample)
+		if sample == 500 {
+			long++
+		}
+	}
+	require.InDelta(t, 0.2, float64(long)/float64(len(samples)), 0.01)
+}
+
+func TestEmpiricalServiceTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.csv")
+	require.NoError(t, os.WriteFile(path, []byte("# service times of a trace\n12.5\n\n40\n7\n"), 0644))
+
+	distribution := setup.ServiceTimeDistribution{Type: setup.EmpiricalServiceTime, File: path, Seed: 7}
+	samples := sampleServiceTimes(t, distribution, 1000)
+	for _, sample := range samples {
+		require.Contains(t, []float64{12.5, 40, 7}, sample)
+	}
+	require.Equal(t, samples, sampleServiceTimes(t, distribution, 1000), "the same seed must give the same service times")
+	require.Equal(t, "empirical-trace", distribution.Label())
+
+	require.NoError(t, os.WriteFile(path, []byte("12.5\n-3\n"), 0644))
+	_, err := distribution.NewSampler()
+	require.Error(t, err)
+}
+
+func TestServiceTimeDistributionValidate(t *testing.T) {
+	require.Error(t, (&setup.ServiceTimeDistribution{Type: "uniform"}).Validate())
+	require.Error(t, (&setup.ServiceTimeDistribution{Type: setup.LognormalServiceTime, StdDevMilliseconds: 3}).Validate())
+	require.Error(t, (&setup.ServiceTimeDistribution{Type: setup.BimodalServiceTime, LongProbability: 1.5}).Validate())
+	require.Error(t, (&setup.ServiceTimeDistribution{Type: setup.EmpiricalServiceTime}).Validate())
+	require.NoError(t, (&setup.ServiceTimeDistribution{Type: setup.ExponentialServiceTime, MeanMilliseconds: 10}).Validate())
+}
+
+func TestSubExperimentServiceTimes(t *testing.T) {
+	subEx := setup.SubExperiment{DesiredServiceTimes: []string{"10ms", "20ms"}}
+	require.Equal(t, "10ms", subEx.ServiceTimeLabel())
+
+	subEx = setup.SubExperiment{
+		ServiceTimeDistribution:          &setup.ServiceTimeDistribution{Type: setup.ExponentialServiceTime, MeanMilliseconds: 50},
+		BusySpinIncrementsPerMillisecond: 1e6,
+	}
+	require.Equal(t, "exponential50ms", subEx.ServiceTimeLabel())
+	require.Equal(t, int64(12_500_000), subEx.BusySpinIncrement(12.5))
+}
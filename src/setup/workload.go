@@ -0,0 +1,97 @@
+package setup
+
+import (
+	log "github.com/sirupsen/logrus"
+	code_generation "stellar/setup/code-generation"
+	"strconv"
+)
+
+const (
+	// MemoryWorkload allocates, writes and reads a buffer of WorkloadBytes
+	MemoryWorkload = "memory"
+	// DiskWorkload writes WorkloadBytes to a file in /tmp, optionally syncs it to disk, then reads and deletes it
+	DiskWorkload = "disk"
+	// FetchWorkload downloads up to WorkloadBytes from WorkloadURL
+	FetchWorkload = "fetch"
+)
+
+// workloadFunctions are the hand-written functions of AWS running the init-time and per-request workloads
+var workloadFunctions = map[string]bool{"hellopy": true, "hellonode": true, "hellogo": true}
+
+// workloadLanguages are the languages of the templates running the per-request workload
+var workloadLanguages = map[string]bool{"go": true, "python": true, "nodejs": true}
+
+// runsWorkloads returns whether the functions of the sub-experiment read the init-time and per-request workloads from
+// their environment: the hellopy, hellonode and hellogo functions of AWS and the functions generated from a specification,
+// deployed to AWS or run from their ZIP artifacts by docker-local
+func runsWorkloads(subExperiment SubExperiment) bool {
+	switch subExperiment.Provider {
+	case "aws":
+	case "docker-local":
+		if subExperiment.PackageType != "Zip" {
+			return false
+		}
+	default:
+		return false
+	}
+	return workloadFunctions[subExperiment.Function] || code_generation.HasSpecification(subExperiment.Function)
+}
+
+// validateWorkload ensures the per-request workload of the sub-experiment is known and can reach its functions
+func validateWorkload(subExperiment SubExperiment) {
+	switch subExperiment.Workload {
+	case "":
+		return
+	case MemoryWorkload, DiskWorkload:
+		if subExperiment.WorkloadBytes <= 0 {
+			log.Fatalf("Sub-experiment %q: the %s workload needs a positive WorkloadBytes.", subExperiment.Title, subExperiment.Workload)
+		}
+	case FetchWorkload:
+		if subExperiment.WorkloadURL == "" || subExperiment.WorkloadBytes < 0 {
+			log.Fatalf("Sub-experiment %q: the fetch workload needs a WorkloadURL and a non-negative WorkloadBytes.", subExperiment.Title)
+		}
+	default:
+		log.Fatalf("Sub-experiment %q has an unknown Workload %q, expected memory, disk or fetch.", subExperiment.Title, subExperiment.Workload)
+	}
+
+	if !runsWorkloads(subExperiment) {
+		log.Fatalf("Sub-experiment %q: the Workload is only run by the hellopy, hellonode and hellogo functions and the functions generated from a specification, on aws or from ZIP artifacts on docker-local.",
+			subExperiment.Title)
+	}
+	if code_generation.HasSpecification(subExperiment.Function) {
+		if language, err := code_generation.Language(subExperiment.Runtime); err != nil || !workloadLanguages[language] {
+			log.Fatalf("Sub-experiment %q: the Workload is not run by the functions generated for runtime %s, expected a Go, Python or Node.js runtime.",
+				subExperiment.Title, subExperiment.Runtime)
+		}
+	}
+}
+
+// WorkloadEnvironment returns the environment variables through which the functions of the sub-experiment receive its
+// per-request workload, if any
+func (s *SubExperiment) WorkloadEnvironment() map[string]string {
+	environment := make(map[string]string)
+	if s.Workload == "" {
+		return environment
+	}
+	environment["STELLAR_WORKLOAD"] = s.Workload
+	if s.WorkloadBytes > 0 {
+		environment["STELLAR_WORKLOAD_BYTES"] = strconv.FormatInt(s.WorkloadBytes, 10)
+	}
+	if s.WorkloadFsync {
+		environment["STELLAR_WORKLOAD_FSYNC"] = "true"
+	}
+	if s.WorkloadURL != "" {
+		environment["STELLAR_WORKLOAD_URL"] = s.WorkloadURL
+	}
+	return environment
+}
+
+// FunctionEnvironment returns the environment variables of the functions of the sub-experiment, setting both its
+// init-time and per-request workloads
+func (s *SubExperiment) FunctionEnvironment() map[string]string {
+	environment := s.InitEnvironment()
+	for name, value := range s.WorkloadEnvironment() {
+		environment[name] = value
+	}
+	return environment
+}